@@ -1,18 +1,25 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"expvar"
+	"flag"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
-
-	"gorm.io/driver/postgres"
-	"gorm.io/gorm"
+	"os"
+	"time"
 
 	"recipe-api/internal/api"
+	"recipe-api/internal/cache"
 	"recipe-api/internal/config"
+	"recipe-api/internal/database"
 	"recipe-api/internal/logger"
 	"recipe-api/internal/middleware"
 	"recipe-api/internal/repository"
+	"recipe-api/internal/storage"
 )
 
 func init() {
@@ -20,7 +27,19 @@ func init() {
 }
 
 func main() {
-	cfg := config.Load()
+	// `recipe-api config [flags]` prints the effective configuration
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		printConfig(os.Args[2:])
+		return
+	}
+
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
 	log.Println("Starting server on port", cfg.Port)
 
 	appLogger := logger.NewAppLogger("[recipes]", cfg.Debug)
@@ -28,12 +47,14 @@ func main() {
 
 	gormLogger := logger.NewGormLogger()
 
-	dbConn, err := gorm.Open(
-		postgres.Open(cfg.DatabaseURL),
-		&gorm.Config{
-			Logger: gormLogger,
-		},
-	)
+	dbConn, err := database.Open(database.Options{
+		DSN:             cfg.DatabaseURL,
+		Driver:          cfg.DBDriver,
+		MaxOpenConns:    cfg.DBMaxOpenConns,
+		MaxIdleConns:    cfg.DBMaxIdleConns,
+		ConnMaxLifetime: cfg.DBConnMaxLifetime,
+		Logger:          gormLogger,
+	})
 	if err != nil {
 		appLogger.Fatal("Failed to connect to database: ", err)
 	}
@@ -47,13 +68,64 @@ func main() {
 
 	rateLimiter := middleware.NewRateLimiter()
 
+	store, err := storage.New(storage.Options{
+		Backend:     cfg.StorageBackend,
+		Path:        cfg.StoragePath,
+		S3Endpoint:  cfg.S3Endpoint,
+		S3Bucket:    cfg.S3Bucket,
+		S3Region:    cfg.S3Region,
+		S3AccessKey: cfg.S3AccessKey,
+		S3SecretKey: cfg.S3SecretKey,
+	})
+	if err != nil {
+		appLogger.Fatal("failed to open media storage:", err)
+	}
+
+	var responseCache *middleware.ResponseCache
+	if cfg.ResponseCacheEntries > 0 {
+		responseCache = middleware.NewResponseCache(cfg.ResponseCacheEntries, int64(cfg.ResponseCacheBytes), cfg.ResponseCacheTTL)
+		expvar.Publish("response_cache", expvar.Func(func() any { return responseCache.Stats() }))
+	}
+
+	recipeCache, err := cache.New(cache.Options{
+		Backend:    cfg.RecipeCache,
+		MaxEntries: cfg.RecipeCacheEntries,
+		MaxBytes:   int64(cfg.RecipeCacheBytes),
+		RedisURL:   cfg.RedisURL,
+		Prefix:     cfg.RedisPrefix,
+	})
+	if err != nil {
+		appLogger.Fatal("failed to open recipe cache:", err)
+	}
+	var recipeReads *cache.ReadThrough
+	if recipeCache != nil {
+		if redis, ok := recipeCache.(*cache.Redis); ok {
+			if err := redis.Ping(context.Background()); err != nil {
+				appLogger.Println("Recipe cache is unreachable, reading from the database:", err)
+			}
+		}
+		recipeReads = cache.NewReadThrough(recipeCache, cfg.RecipeCacheTTL)
+		recipeReads.OnError = func(err error) { appLogger.Println("Recipe cache error:", err) }
+		expvar.Publish("recipe_cache", expvar.Func(func() any { return recipeReads.Stats() }))
+	}
+
 	apiApp := &api.App{
 		Repo:        repoApp,
 		Logger:      appLogger,
 		RateLimiter: rateLimiter,
+		Storage:     store,
+		MaxImage:    int64(cfg.ImageMaxBytes),
+
+		EventRetention: cfg.EventRetention,
+
+		ResponseCache: responseCache,
+		RecipeCache:   recipeReads,
+
+		GraphQLMaxDepth:      cfg.GraphQLMaxDepth,
+		GraphQLMaxComplexity: cfg.GraphQLMaxComplexity,
 	}
 
-	// Get the underlying *sql.DB for connection pooling configuration
+	// Get the underlying *sql.DB to check the connection
 	sqlDB, err := repoApp.DB.DB()
 	if err != nil {
 		appLogger.Fatalf("failed to get generic database object: %v", err)
@@ -65,7 +137,42 @@ func main() {
 
 	appLogger.Println("Database connection is alive.")
 
-	router := api.NewRouter(apiApp, cfg.FrontendURL, appLogger)
+	if err := apiApp.ResumeCookTimers(); err != nil {
+		appLogger.Println("Failed to resume cook timers:", err)
+	}
+	go apiApp.RunWebhookWorker(context.Background())
+	go apiApp.RunEventPruner(context.Background())
+
+	corsPolicy := &middleware.CorsPolicy{
+		AllowedOrigins:   cfg.CorsAllowedOrigins,
+		AllowedHeaders:   cfg.CorsAllowedHeaders,
+		ExposedHeaders:   cfg.CorsExposedHeaders,
+		AllowCredentials: cfg.CorsAllowCredentials,
+		MaxAge:           time.Duration(cfg.CorsMaxAge) * time.Second,
+	}
+
+	router := api.NewRouter(apiApp, corsPolicy)
+
+	grpcListener, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+	if err != nil {
+		appLogger.Fatalf("failed to listen for gRPC: %v", err)
+	}
+	grpcServer := apiApp.NewGRPCServer()
+	appLogger.Println("gRPC listening on port", cfg.GRPCPort)
+	go func() {
+		appLogger.Fatal(grpcServer.Serve(grpcListener))
+	}()
+
+	// Runtime counters stay off the public router: expvar publishes the
+	// command line and memory stats
+	if cfg.AdminAddr != "" {
+		admin := http.NewServeMux()
+		admin.Handle("GET /debug/vars", expvar.Handler())
+		appLogger.Println("Admin endpoints listening on", cfg.AdminAddr)
+		go func() {
+			appLogger.Println("Admin listener stopped:", http.ListenAndServe(cfg.AdminAddr, admin))
+		}()
+	}
 
 	appLogger.Fatal(http.ListenAndServe(":"+cfg.Port, router))
 
@@ -73,3 +180,22 @@ func main() {
 
 	appLogger.Println("App initialized successfully.")
 }
+
+// Print the merged configuration with secrets redacted, then report any
+// validation errors
+func printConfig(args []string) {
+	cfg, err := config.Load(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if cfg == nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
+
+	cfg.Print(os.Stdout)
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid configuration:", err)
+		os.Exit(1)
+	}
+}
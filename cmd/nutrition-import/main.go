@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	"recipe-api/internal/config"
+	"recipe-api/internal/database"
+	"recipe-api/internal/logger"
+	"recipe-api/internal/nutrition"
+	"recipe-api/internal/repository"
+)
+
+// Offline importer for USDA-style nutrition CSVs:
+//
+//	nutrition-import -file foods.csv [-create] [-- config flags]
+//
+// Database settings come from the same config sources as the API.
+func main() {
+	fs := flag.NewFlagSet("nutrition-import", flag.ExitOnError)
+	file := fs.String("file", "", "CSV file to import")
+	create := fs.Bool("create", false, "create ingredients for foods that match none")
+	source := fs.String("source", "", "source recorded against the facts (default file name)")
+	fs.Parse(os.Args[1:])
+
+	if *file == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *source == "" {
+		*source = filepath.Base(*file)
+	}
+
+	cfg, err := config.Load(fs.Args())
+	if err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
+
+	appLogger := logger.NewAppLogger("[nutrition-import]", cfg.Debug)
+
+	db, err := database.Open(database.Options{
+		DSN:    cfg.DatabaseURL,
+		Driver: cfg.DBDriver,
+		Logger: logger.NewGormLogger(),
+	})
+	if err != nil {
+		appLogger.Fatal("Failed to connect to database: ", err)
+	}
+
+	repo := repository.NewApp(db)
+	if err := repo.AutoMigrate(); err != nil {
+		appLogger.Fatal("failed to run database migrations:", err)
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		appLogger.Fatal(err)
+	}
+	defer f.Close()
+
+	records, err := nutrition.ParseCSV(f)
+	if err != nil {
+		appLogger.Fatal("Failed to read CSV: ", err)
+	}
+
+	report, err := nutrition.Import(repo.DB, records, *source, *create)
+	if err != nil {
+		appLogger.Fatal("Import failed: ", err)
+	}
+
+	appLogger.Printf("Imported %d records: %d matched, %d created, %d unmatched",
+		len(records), report.Matched, report.Created, len(report.Unmatched))
+	for _, label := range report.Unmatched {
+		appLogger.Println("Unmatched:", label)
+	}
+}
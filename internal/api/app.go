@@ -2,12 +2,42 @@ package api
 
 import (
 	"log"
+	"recipe-api/internal/cache"
+	"recipe-api/internal/events"
 	"recipe-api/internal/middleware"
 	"recipe-api/internal/repository"
+	"recipe-api/internal/storage"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type App struct {
-	Repo        *repository.App
-	Logger      *log.Logger
-	RateLimiter *middleware.RateLimiter
+	Repo           *repository.App
+	Logger         *log.Logger
+	RateLimiter    *middleware.RateLimiter
+	ResponseCache  *middleware.ResponseCache // recipe reads, nil for none
+	RecipeCache    *cache.ReadThrough        // recipe lookups by ID and name, nil for none
+	Storage        storage.Store             // uploaded images
+	MaxImage       int64                     // largest image upload in bytes, 0 for the default
+	EventRetention time.Duration             // how long events are logged, 0 for ever
+
+	GraphQLMaxDepth      int // 0 for the default
+	GraphQLMaxComplexity int // 0 for the default
+
+	cookOnce sync.Once
+	cook     *cookHub // created on first use
+
+	busOnce sync.Once
+	bus     *events.Bus // created on first use
+
+	eventMu       sync.Mutex   // held by transactions that record events
+	eventMarkOnce sync.Once    // reads the mark from the log on first use
+	eventMark     atomic.Int64 // every event up to this ID has committed
+
+	schemaOnce sync.Once
+	schema     *graphqlSchema // created on first use
+
+	webhookOnce   sync.Once
+	webhookSignal chan struct{} // wakes the webhook worker
 }
@@ -0,0 +1,267 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"gorm.io/gorm"
+
+	"recipe-api/internal/models"
+	"recipe-api/internal/repository"
+)
+
+// Ingredient with how much it is used
+type ingredientEntry struct {
+	models.Ingredient
+	Usage catalogueUsage `json:"usage"`
+}
+
+func ingredientEntries(db *gorm.DB, ingredients []models.Ingredient) ([]ingredientEntry, error) {
+	ids := make([]int, len(ingredients))
+	for i, ingredient := range ingredients {
+		ids[i] = ingredient.IngredientID
+	}
+	usage, err := ingredientCatalogue.usage(db, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]ingredientEntry, len(ingredients))
+	for i, ingredient := range ingredients {
+		entries[i] = ingredientEntry{ingredient, usage[ingredient.IngredientID]}
+	}
+	return entries, nil
+}
+
+func (app *App) writeIngredientEntry(w http.ResponseWriter, ingredient models.Ingredient) {
+	entries, err := ingredientEntries(app.Repo.DB, []models.Ingredient{ingredient})
+	if err != nil {
+		http.Error(w, "Error counting ingredient usage.", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries[0])
+}
+
+// List ingredients with usage counts, filtered by ?q= and ?unused=true
+func (app *App) getAllIngredients(w http.ResponseWriter, r *http.Request) {
+	var ingredients []models.Ingredient
+	if result := ingredientCatalogue.search(app.Repo.DB, r).Find(&ingredients); result.Error != nil {
+		http.Error(w, "Error fetching ingredients.", http.StatusInternalServerError)
+		return
+	}
+
+	entries, err := ingredientEntries(app.Repo.DB, ingredients)
+	if err != nil {
+		http.Error(w, "Error counting ingredient usage.", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// Get an ingredient with its usage counts
+func (app *App) getIngredientByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := catalogueID(w, r)
+	if !ok {
+		return
+	}
+
+	var ingredient models.Ingredient
+	if result := app.Repo.DB.First(&ingredient, id); result.Error != nil {
+		http.Error(w, "Ingredient not found", http.StatusNotFound)
+		return
+	}
+	app.writeIngredientEntry(w, ingredient)
+}
+
+// Rename an ingredient or change its category. Renaming onto another
+// ingredient's label is refused; merge them instead.
+func (app *App) updateIngredientByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := catalogueID(w, r)
+	if !ok {
+		return
+	}
+
+	var data models.Ingredient
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	var ingredient models.Ingredient
+	if result := app.Repo.DB.First(&ingredient, id); result.Error != nil {
+		http.Error(w, "Ingredient not found", http.StatusNotFound)
+		return
+	}
+
+	if label := repository.CanonicalLabel(data.Label); label != "" {
+		var existing models.Ingredient
+		found, err := repository.FindByLabel(app.Repo.DB.Where("ingredient_id <> ?", id), &existing, label)
+		if err != nil {
+			http.Error(w, "Error checking ingredient label.", http.StatusInternalServerError)
+			return
+		}
+		if found {
+			http.Error(w, "Ingredient "+existing.Label+" already exists, merge them instead", http.StatusConflict)
+			return
+		}
+		ingredient.Label = label
+	}
+	if data.Category != nil {
+		ingredient.Category = data.Category
+		if *data.Category == "" {
+			ingredient.Category = nil
+		}
+	}
+
+	err := app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&ingredient).Select("Label", "Category").Updates(&ingredient).Error; err != nil {
+			return err
+		}
+		return ingredientCatalogue.touch(tx, id)
+	})
+	if err != nil {
+		app.Logger.Println("Transaction Failed:", err)
+		http.Error(w, "Failed to update ingredient", http.StatusInternalServerError)
+		return
+	}
+	app.forgetAllRecipes(r.Context())
+	app.writeIngredientEntry(w, ingredient)
+}
+
+// Merge duplicate ingredients into one, re-pointing everything that uses them
+func (app *App) mergeIngredients(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeMergeRequest(w, r)
+	if !ok {
+		return
+	}
+	from, err := ingredientCatalogue.checkMerge(app.Repo.DB, req)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Ingredient not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		// Keep the survivor's nutrition, or adopt the first merged one's
+		var survivorFacts int64
+		if err := tx.Model(&models.Nutrition{}).Where("ingredient_id = ?", req.Into).Count(&survivorFacts).Error; err != nil {
+			return err
+		}
+		if survivorFacts == 0 {
+			var facts models.Nutrition
+			result := tx.Where("ingredient_id IN ?", from).Order("ingredient_id ASC").Limit(1).Find(&facts)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected > 0 {
+				if err := tx.Model(&facts).Update("ingredient_id", req.Into).Error; err != nil {
+					return err
+				}
+			}
+		}
+		if err := tx.Where("ingredient_id IN ?", from).Delete(&models.Nutrition{}).Error; err != nil {
+			return err
+		}
+
+		if err := ingredientCatalogue.merge(tx, req.Into, from); err != nil {
+			return err
+		}
+
+		// Swaps that now replace an ingredient with itself are dropped
+		selfSwaps := tx.Model(&models.Substitution{}).Select("substitution_id").Where("ingredient_id = ?", req.Into)
+		err := tx.Where("ingredient_id = ? AND substitution_id IN (?)", req.Into, selfSwaps).
+			Delete(&models.SubstitutionReplacement{}).Error
+		if err != nil {
+			return err
+		}
+		emptySwaps := tx.Model(&models.SubstitutionReplacement{}).Select("substitution_id")
+		return tx.Where("ingredient_id = ? AND substitution_id NOT IN (?)", req.Into, emptySwaps).
+			Delete(&models.Substitution{}).Error
+	})
+	if err != nil {
+		app.Logger.Println("Transaction Failed:", err)
+		http.Error(w, "Failed to merge ingredients", http.StatusInternalServerError)
+		return
+	}
+	app.forgetAllRecipes(r.Context())
+
+	var survivor models.Ingredient
+	app.Repo.DB.First(&survivor, req.Into)
+	app.writeIngredientEntry(w, survivor)
+}
+
+// Delete an ingredient nothing uses
+func (app *App) deleteIngredientByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := catalogueID(w, r)
+	if !ok {
+		return
+	}
+
+	usage, err := ingredientCatalogue.usage(app.Repo.DB, []int{id})
+	if err != nil {
+		http.Error(w, "Error counting ingredient usage.", http.StatusInternalServerError)
+		return
+	}
+	if usage[id].Uses > 0 {
+		http.Error(w, "Ingredient is still in use", http.StatusConflict)
+		return
+	}
+
+	err = app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("ingredient_id = ?", id).Delete(&models.Nutrition{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.Ingredient{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Ingredient not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to delete ingredient", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Delete every ingredient nothing uses
+func (app *App) deleteUnusedIngredients(w http.ResponseWriter, r *http.Request) {
+	var deleted int64
+	err := app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		var ids []int
+		if err := ingredientCatalogue.unused(tx.Table("ingredients")).Pluck("ingredient_id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		if err := tx.Where("ingredient_id IN ?", ids).Delete(&models.Nutrition{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.Ingredient{}, ids)
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		app.Logger.Println("Transaction Failed:", err)
+		http.Error(w, "Failed to delete unused ingredients", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int64{"deleted": deleted})
+}
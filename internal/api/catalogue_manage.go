@@ -0,0 +1,169 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// Catalogue table and the tables whose rows point at it
+type catalogueTable struct {
+	table      string
+	key        string   // primary key, and the column name in referencing tables
+	references []string // tables with a key column
+}
+
+var ingredientCatalogue = catalogueTable{
+	table:      "ingredients",
+	key:        "ingredient_id",
+	references: []string{"recipe_ingredients", "pantry_items", "shopping_list_items", "substitutions", "substitution_replacements"},
+}
+
+var unitCatalogue = catalogueTable{
+	table:      "units",
+	key:        "unit_id",
+	references: []string{"recipe_ingredients", "pantry_items", "substitution_replacements"},
+}
+
+// How much a catalogue row is used
+type catalogueUsage struct {
+	Recipes int `json:"recipes"` // distinct recipes using it
+	Uses    int `json:"uses"`    // rows pointing at it across every table
+}
+
+// Body of a merge request
+type mergeRequest struct {
+	Into int   `json:"into"` // survivor
+	From []int `json:"from"` // rows merged into it and removed
+}
+
+// Usage of the given rows, or of every row when ids is nil
+func (cat catalogueTable) usage(db *gorm.DB, ids []int) (map[int]catalogueUsage, error) {
+	type count struct {
+		ID    int
+		Count int
+	}
+	counts := func(table, expression string) ([]count, error) {
+		query := db.Table(table).Select(cat.key + " AS id, " + expression + " AS count").
+			Where(cat.key + " IS NOT NULL").Group(cat.key)
+		if ids != nil {
+			query = query.Where(cat.key+" IN ?", ids)
+		}
+		var rows []count
+		return rows, query.Scan(&rows).Error
+	}
+
+	usage := make(map[int]catalogueUsage)
+	for _, table := range cat.references {
+		rows, err := counts(table, "COUNT(*)")
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			entry := usage[row.ID]
+			entry.Uses += row.Count
+			usage[row.ID] = entry
+		}
+	}
+
+	rows, err := counts("recipe_ingredients", "COUNT(DISTINCT recipe_id)")
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		entry := usage[row.ID]
+		entry.Recipes = row.Count
+		usage[row.ID] = entry
+	}
+	return usage, nil
+}
+
+// Restrict a query on the catalogue table to rows nothing points at
+func (cat catalogueTable) unused(db *gorm.DB) *gorm.DB {
+	for _, table := range cat.references {
+		db = db.Where(cat.table+"."+cat.key+" NOT IN (?)",
+			db.Session(&gorm.Session{NewDB: true}).Table(table).Select(cat.key).Where(cat.key+" IS NOT NULL"))
+	}
+	return db
+}
+
+// Query on the catalogue table filtered by ?q= (label contains) and ?unused=true
+func (cat catalogueTable) search(db *gorm.DB, r *http.Request) *gorm.DB {
+	query := db.Table(cat.table).Order("LOWER(label) ASC").Order(cat.key + " ASC")
+	if term := strings.TrimSpace(r.URL.Query().Get("q")); term != "" {
+		query = query.Where("LOWER(label) LIKE ?", "%"+strings.ToLower(term)+"%")
+	}
+	if unused, _ := strconv.ParseBool(r.URL.Query().Get("unused")); unused {
+		query = cat.unused(query)
+	}
+	return query
+}
+
+// Point every reference to the from rows at into, then delete the from rows
+func (cat catalogueTable) merge(tx *gorm.DB, into int, from []int) error {
+	for _, table := range cat.references {
+		if err := tx.Table(table).Where(cat.key+" IN ?", from).Update(cat.key, into).Error; err != nil {
+			return err
+		}
+	}
+	if err := cat.touch(tx, into); err != nil {
+		return err
+	}
+	return tx.Exec("DELETE FROM "+cat.table+" WHERE "+cat.key+" IN ?", from).Error
+}
+
+// Mark the recipes using a row as changed
+func (cat catalogueTable) touch(tx *gorm.DB, id int) error {
+	recipes := tx.Session(&gorm.Session{NewDB: true}).Table("recipe_ingredients").
+		Select("recipe_id").Where(cat.key+" = ?", id)
+	return touchRecipes(tx, recipes)
+}
+
+// Check a merge request, returning the rows to merge without the survivor
+func (cat catalogueTable) checkMerge(db *gorm.DB, req mergeRequest) ([]int, error) {
+	var from []int
+	for _, id := range req.From {
+		if id != req.Into && !slices.Contains(from, id) {
+			from = append(from, id)
+		}
+	}
+	if req.Into == 0 || len(from) == 0 {
+		return nil, errors.New("into and from are required")
+	}
+
+	var found int64
+	all := append([]int{req.Into}, from...)
+	if err := db.Table(cat.table).Where(cat.key+" IN ?", all).Count(&found).Error; err != nil {
+		return nil, err
+	}
+	if int(found) != len(all) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return from, nil
+}
+
+// ID from the route, writing a 400 when it isn't a number
+func catalogueID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// Decode a merge request body, writing a 400 when it's malformed
+func decodeMergeRequest(w http.ResponseWriter, r *http.Request) (mergeRequest, bool) {
+	var req mergeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return req, false
+	}
+	return req, true
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"recipe-api/internal/models"
+	"testing"
+)
+
+func findTestIngredients(t *testing.T, router http.Handler, query string) []ingredientEntry {
+	t.Helper()
+	var entries []ingredientEntry
+	w := serveJSON(t, router, http.MethodGet, "/ingredient/all?"+query, nil)
+	json.NewDecoder(w.Body).Decode(&entries)
+	return entries
+}
+
+func TestCatalogueCanonicalLabels(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+
+	for i, label := range []string{"Catalogue  Tomato ", "catalogue tomato"} {
+		postTestRecipe(t, testApp, models.Recipe{
+			Name:       fmt.Sprintf("Canonical %d", i),
+			Difficulty: 1,
+			UserID:     "cook",
+			Ingredients: []models.RecipeIngredient{
+				{Amount: ToPtr(float32(1)), Ingredient: createTestIngredient(label), Unit: createTestUnit(" KG")},
+			},
+		})
+	}
+
+	entries := findTestIngredients(t, router, "q=catalogue")
+	if len(entries) != 1 {
+		t.Fatalf("expected one ingredient for both spellings, got %+v", entries)
+	}
+	if entries[0].Label != "Catalogue Tomato" || entries[0].Usage.Recipes != 2 {
+		t.Fatalf("unexpected ingredient %+v", entries[0])
+	}
+}
+
+func TestCatalogueMergeIngredients(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+
+	recipe := func(name, ingredient string) {
+		postTestRecipe(t, testApp, models.Recipe{
+			Name: name, Difficulty: 1, UserID: "cook",
+			Ingredients: []models.RecipeIngredient{
+				{Amount: ToPtr(float32(1)), Ingredient: createTestIngredient(ingredient), Unit: createTestUnit("g")},
+			},
+		})
+	}
+	recipe("Soup", "Merge potato")
+	recipe("Mash", "Merge potatoe")
+	addTestPantryItem(t, router, "Merge potatoe", 500, "g", nil)
+
+	var survivor, typo int
+	for _, entry := range findTestIngredients(t, router, "q=merge") {
+		if entry.Label == "Merge potato" {
+			survivor = entry.IngredientID
+		} else {
+			typo = entry.IngredientID
+		}
+	}
+
+	// Renaming onto an existing label asks for a merge
+	w := serveJSON(t, router, http.MethodPut, fmt.Sprintf("/ingredient/id/%d", typo), models.Ingredient{Label: "MERGE POTATO"})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+	w = serveJSON(t, router, http.MethodDelete, fmt.Sprintf("/ingredient/id/%d", typo), nil)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected status %d deleting a used ingredient, got %d", http.StatusConflict, w.Code)
+	}
+
+	w = serveJSON(t, router, http.MethodPost, "/ingredient/merge", mergeRequest{Into: survivor, From: []int{typo}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var merged ingredientEntry
+	json.NewDecoder(w.Body).Decode(&merged)
+	if merged.Usage.Recipes != 2 || merged.Usage.Uses != 3 {
+		t.Fatalf("expected both recipes and the pantry item on the survivor, got %+v", merged.Usage)
+	}
+
+	w = serveJSON(t, router, http.MethodGet, fmt.Sprintf("/ingredient/id/%d", typo), nil)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected the merged ingredient to be gone, got %d", w.Code)
+	}
+	w = serveJSON(t, router, http.MethodPost, "/ingredient/merge", mergeRequest{Into: survivor, From: []int{typo}})
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d merging a missing ingredient, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestCatalogueUnusedAndUnits(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+
+	recipe := postTestRecipe(t, testApp, models.Recipe{
+		Name: "Unit test", Difficulty: 1, UserID: "cook",
+		Ingredients: []models.RecipeIngredient{
+			{Amount: ToPtr(float32(1)), Ingredient: createTestIngredient("Unused flour"), Unit: createTestUnit("grams")},
+			{Amount: ToPtr(float32(1)), Ingredient: createTestIngredient("Unused sugar"), Unit: createTestUnit("gramz")},
+		},
+	})
+
+	var units []unitEntry
+	w := serveJSON(t, router, http.MethodGet, "/unit/all?q=gram", nil)
+	json.NewDecoder(w.Body).Decode(&units)
+	if len(units) != 2 {
+		t.Fatalf("expected 2 units, got %+v", units)
+	}
+	w = serveJSON(t, router, http.MethodPost, "/unit/merge", mergeRequest{Into: units[0].UnitID, From: []int{units[1].UnitID}})
+	var unit unitEntry
+	json.NewDecoder(w.Body).Decode(&unit)
+	if unit.Label != "grams" || unit.Usage.Uses != 2 {
+		t.Fatalf("unexpected merged unit %+v", unit)
+	}
+	w = serveJSON(t, router, http.MethodPut, fmt.Sprintf("/unit/id/%d", unit.UnitID), models.Unit{Label: " gram "})
+	json.NewDecoder(w.Body).Decode(&unit)
+	if unit.Label != "gram" {
+		t.Fatalf("expected the unit to be renamed, got %+v", unit)
+	}
+
+	// Deleting the recipe leaves its ingredients unused
+	testApp.Repo.DB.Where("recipe_id = ?", recipe.RecipeID).Delete(&models.RecipeIngredient{})
+	if unused := findTestIngredients(t, router, "q=unused&unused=true"); len(unused) != 2 {
+		t.Fatalf("expected 2 unused ingredients, got %+v", unused)
+	}
+	w = serveJSON(t, router, http.MethodDelete, "/ingredient/unused", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if left := findTestIngredients(t, router, "q=unused"); len(left) != 0 {
+		t.Fatalf("expected unused ingredients to be deleted, got %+v", left)
+	}
+}
@@ -0,0 +1,201 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"gorm.io/gorm"
+
+	"recipe-api/internal/models"
+	"recipe-api/internal/repository"
+)
+
+// Unit with how much it is used
+type unitEntry struct {
+	models.Unit
+	Usage catalogueUsage `json:"usage"`
+}
+
+func unitEntries(db *gorm.DB, units []models.Unit) ([]unitEntry, error) {
+	ids := make([]int, len(units))
+	for i, unit := range units {
+		ids[i] = unit.UnitID
+	}
+	usage, err := unitCatalogue.usage(db, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]unitEntry, len(units))
+	for i, unit := range units {
+		entries[i] = unitEntry{unit, usage[unit.UnitID]}
+	}
+	return entries, nil
+}
+
+func (app *App) writeUnitEntry(w http.ResponseWriter, unit models.Unit) {
+	entries, err := unitEntries(app.Repo.DB, []models.Unit{unit})
+	if err != nil {
+		http.Error(w, "Error counting unit usage.", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries[0])
+}
+
+// List units with usage counts, filtered by ?q= and ?unused=true
+func (app *App) getAllUnits(w http.ResponseWriter, r *http.Request) {
+	var units []models.Unit
+	if result := unitCatalogue.search(app.Repo.DB, r).Find(&units); result.Error != nil {
+		http.Error(w, "Error fetching units.", http.StatusInternalServerError)
+		return
+	}
+
+	entries, err := unitEntries(app.Repo.DB, units)
+	if err != nil {
+		http.Error(w, "Error counting unit usage.", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// Get a unit with its usage counts
+func (app *App) getUnitByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := catalogueID(w, r)
+	if !ok {
+		return
+	}
+
+	var unit models.Unit
+	if result := app.Repo.DB.First(&unit, id); result.Error != nil {
+		http.Error(w, "Unit not found", http.StatusNotFound)
+		return
+	}
+	app.writeUnitEntry(w, unit)
+}
+
+// Rename a unit. Renaming onto another unit's label is refused; merge them instead.
+func (app *App) updateUnitByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := catalogueID(w, r)
+	if !ok {
+		return
+	}
+
+	var data models.Unit
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	label := repository.CanonicalLabel(data.Label)
+	if label == "" {
+		http.Error(w, "label is required", http.StatusBadRequest)
+		return
+	}
+
+	var unit models.Unit
+	if result := app.Repo.DB.First(&unit, id); result.Error != nil {
+		http.Error(w, "Unit not found", http.StatusNotFound)
+		return
+	}
+
+	var existing models.Unit
+	found, err := repository.FindByLabel(app.Repo.DB.Where("unit_id <> ?", id), &existing, label)
+	if err != nil {
+		http.Error(w, "Error checking unit label.", http.StatusInternalServerError)
+		return
+	}
+	if found {
+		http.Error(w, "Unit "+existing.Label+" already exists, merge them instead", http.StatusConflict)
+		return
+	}
+
+	err = app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&unit).Update("label", label).Error; err != nil {
+			return err
+		}
+		return unitCatalogue.touch(tx, id)
+	})
+	if err != nil {
+		app.Logger.Println("Transaction Failed:", err)
+		http.Error(w, "Failed to update unit", http.StatusInternalServerError)
+		return
+	}
+	app.forgetAllRecipes(r.Context())
+	app.writeUnitEntry(w, unit)
+}
+
+// Merge duplicate units into one, re-pointing everything that uses them
+func (app *App) mergeUnits(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeMergeRequest(w, r)
+	if !ok {
+		return
+	}
+	from, err := unitCatalogue.checkMerge(app.Repo.DB, req)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Unit not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		return unitCatalogue.merge(tx, req.Into, from)
+	})
+	if err != nil {
+		app.Logger.Println("Transaction Failed:", err)
+		http.Error(w, "Failed to merge units", http.StatusInternalServerError)
+		return
+	}
+	app.forgetAllRecipes(r.Context())
+
+	var survivor models.Unit
+	app.Repo.DB.First(&survivor, req.Into)
+	app.writeUnitEntry(w, survivor)
+}
+
+// Delete a unit nothing uses
+func (app *App) deleteUnitByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := catalogueID(w, r)
+	if !ok {
+		return
+	}
+
+	usage, err := unitCatalogue.usage(app.Repo.DB, []int{id})
+	if err != nil {
+		http.Error(w, "Error counting unit usage.", http.StatusInternalServerError)
+		return
+	}
+	if usage[id].Uses > 0 {
+		http.Error(w, "Unit is still in use", http.StatusConflict)
+		return
+	}
+
+	result := app.Repo.DB.Delete(&models.Unit{}, id)
+	if result.Error != nil {
+		http.Error(w, "Failed to delete unit", http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 0 {
+		http.Error(w, "Unit not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Delete every unit nothing uses
+func (app *App) deleteUnusedUnits(w http.ResponseWriter, r *http.Request) {
+	result := unitCatalogue.unused(app.Repo.DB.Table("units")).Delete(&models.Unit{})
+	if result.Error != nil {
+		app.Logger.Println("Unit error:", result.Error)
+		http.Error(w, "Failed to delete unused units", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int64{"deleted": result.RowsAffected})
+}
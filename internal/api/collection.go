@@ -0,0 +1,306 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+
+	"recipe-api/internal/models"
+)
+
+// Load a collection with its entries in order
+func findCollection(db *gorm.DB, query any, args ...any) (models.Collection, error) {
+	var collection models.Collection
+	result := db.Preload("Entries", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC").Order("collection_entry_id ASC")
+	}).Preload("Entries.Recipe").Where(query, args...).First(&collection)
+	return collection, result.Error
+}
+
+// Random token for sharing a collection by link
+func newShareToken() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
+
+// Set a collection's visibility, issuing a fresh share token for links so old
+// links stop working
+func setVisibility(collection *models.Collection, visibility string) error {
+	if visibility == "" {
+		visibility = models.VisibilityPrivate
+	}
+	if !slices.Contains(models.Visibilities, visibility) {
+		return fmt.Errorf("visibility must be private, public or link")
+	}
+	collection.Visibility = visibility
+	collection.ShareToken = nil
+	if visibility == models.VisibilityLink {
+		token, err := newShareToken()
+		if err != nil {
+			return err
+		}
+		collection.ShareToken = &token
+	}
+	return nil
+}
+
+// Check recipe IDs exist and appear once
+func checkCollectionRecipes(db *gorm.DB, recipeIDs []int) error {
+	for i, id := range recipeIDs {
+		if slices.Contains(recipeIDs[:i], id) {
+			return fmt.Errorf("recipe %d is listed twice", id)
+		}
+	}
+	if len(recipeIDs) == 0 {
+		return nil
+	}
+	var found int64
+	if err := db.Model(&models.Recipe{}).Where("recipe_id IN ?", recipeIDs).Count(&found).Error; err != nil {
+		return err
+	}
+	if int(found) != len(recipeIDs) {
+		return errors.New("recipe not found")
+	}
+	return nil
+}
+
+// Recipe IDs of a collection's entries in order
+func collectionRecipeIDs(collection models.Collection) []int {
+	ids := make([]int, len(collection.Entries))
+	for i, entry := range collection.Entries {
+		ids[i] = entry.RecipeID
+	}
+	return ids
+}
+
+// Make a collection's entries exactly recipeIDs, in that order
+func writeCollectionEntries(tx *gorm.DB, collectionID int, recipeIDs []int) error {
+	if err := tx.Where("collection_id = ? AND recipe_id NOT IN ?", collectionID, append([]int{0}, recipeIDs...)).
+		Delete(&models.CollectionEntry{}).Error; err != nil {
+		return err
+	}
+	for i, recipeID := range recipeIDs {
+		entry := models.CollectionEntry{CollectionID: collectionID, RecipeID: recipeID}
+		result := tx.Where(entry).Assign(models.CollectionEntry{Position: i + 1}).FirstOrCreate(&entry)
+		if result.Error != nil {
+			return result.Error
+		}
+	}
+	return nil
+}
+
+func collectionID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid collection ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// Collection the request may see: by share token, or by ID when public or
+// owned by ?userID=
+func (app *App) viewableCollection(w http.ResponseWriter, r *http.Request) (models.Collection, bool) {
+	var collection models.Collection
+	var err error
+	if token, ok := mux.Vars(r)["token"]; ok {
+		collection, err = findCollection(app.Repo.DB, "share_token = ? AND visibility = ?", token, models.VisibilityLink)
+	} else {
+		id, ok := collectionID(w, r)
+		if !ok {
+			return collection, false
+		}
+		collection, err = findCollection(app.Repo.DB, "collection_id = ?", id)
+		if err == nil && collection.Visibility != models.VisibilityPublic && collection.UserID != r.URL.Query().Get("userID") {
+			err = gorm.ErrRecordNotFound
+		}
+	}
+	if err != nil {
+		http.Error(w, "Collection not found", http.StatusNotFound)
+		return collection, false
+	}
+	return collection, true
+}
+
+// Collection the request may change: by ID and owned by ?userID=. Other
+// users' collections are not found, or forbidden when public.
+func (app *App) ownedCollection(w http.ResponseWriter, r *http.Request) (models.Collection, bool) {
+	id, ok := collectionID(w, r)
+	if !ok {
+		return models.Collection{}, false
+	}
+	collection, err := findCollection(app.Repo.DB, "collection_id = ?", id)
+	owned := err == nil && collection.UserID == r.URL.Query().Get("userID")
+	switch {
+	case owned:
+		return collection, true
+	case err == nil && collection.Visibility == models.VisibilityPublic:
+		http.Error(w, "Collection belongs to another user", http.StatusForbidden)
+	default:
+		http.Error(w, "Collection not found", http.StatusNotFound)
+	}
+	return models.Collection{}, false
+}
+
+// Create a collection, optionally with recipes in order
+func (app *App) addCollection(w http.ResponseWriter, r *http.Request) {
+	var data models.Collection
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if data.UserID == "" || data.Name == "" {
+		http.Error(w, "userID and name are required", http.StatusBadRequest)
+		return
+	}
+
+	collection := models.Collection{
+		UserID:      data.UserID,
+		Name:        data.Name,
+		Description: data.Description,
+	}
+	if err := setVisibility(&collection, data.Visibility); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	recipeIDs := collectionRecipeIDs(data)
+	if err := checkCollectionRecipes(app.Repo.DB, recipeIDs); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result := app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&collection).Error; err != nil {
+			return err
+		}
+		return writeCollectionEntries(tx, collection.CollectionID, recipeIDs)
+	})
+	if result != nil {
+		app.Logger.Println("Transaction Failed:", result)
+		http.Error(w, result.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	collection, _ = findCollection(app.Repo.DB, "collection_id = ?", collection.CollectionID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(collection)
+}
+
+// Get a collection by ID, or by share token
+func (app *App) getCollection(w http.ResponseWriter, r *http.Request) {
+	collection, ok := app.viewableCollection(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(collection)
+}
+
+// Get a user's collections: all of them for the user themselves (?userID=),
+// only the public ones for anyone else
+func (app *App) getCollectionsByUser(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["userID"]
+	query := app.Repo.DB.Where("user_id = ?", userID)
+	if r.URL.Query().Get("userID") != userID {
+		query = query.Where("visibility = ?", models.VisibilityPublic)
+	}
+	app.writeCollections(w, query)
+}
+
+// Get every public collection
+func (app *App) getPublicCollections(w http.ResponseWriter, r *http.Request) {
+	app.writeCollections(w, app.Repo.DB.Where("visibility = ?", models.VisibilityPublic))
+}
+
+// Write collections as a list. Share tokens are left out; the owner gets
+// them by ID.
+func (app *App) writeCollections(w http.ResponseWriter, query *gorm.DB) {
+	collections := []models.Collection{}
+	result := query.Preload("Entries", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC")
+	}).Order("created_at DESC").Find(&collections)
+	if result.Error != nil {
+		http.Error(w, "Error fetching collections.", http.StatusInternalServerError)
+		return
+	}
+	for i := range collections {
+		collections[i].ShareToken = nil
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(collections)
+}
+
+// Change a collection's name and description
+func (app *App) updateCollectionByID(w http.ResponseWriter, r *http.Request) {
+	var data models.Collection
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if data.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+
+	collection, ok := app.ownedCollection(w, r)
+	if !ok {
+		return
+	}
+	result := app.Repo.DB.Model(&models.Collection{}).Where("collection_id = ?", collection.CollectionID).Updates(map[string]any{
+		"name":        data.Name,
+		"description": data.Description,
+	})
+	if result.Error != nil {
+		http.Error(w, "Failed to update collection", http.StatusInternalServerError)
+		return
+	}
+
+	collection, _ = findCollection(app.Repo.DB, "collection_id = ?", collection.CollectionID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(collection)
+}
+
+// Delete a collection, leaving its recipes alone
+func (app *App) deleteCollectionByID(w http.ResponseWriter, r *http.Request) {
+	collection, ok := app.ownedCollection(w, r)
+	if !ok {
+		return
+	}
+	id := collection.CollectionID
+
+	err := app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("collection_id = ?", id).Delete(&models.CollectionEntry{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.Collection{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Collection not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to delete collection", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
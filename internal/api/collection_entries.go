@@ -0,0 +1,217 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+
+	"recipe-api/internal/models"
+	"recipe-api/internal/render"
+)
+
+// Collection with its recipes in full, for export
+type collectionExport struct {
+	models.Collection
+	Recipes []models.Recipe `json:"recipes"`
+}
+
+// Load a collection, write its new entries and return it reloaded
+func (app *App) changeCollectionEntries(w http.ResponseWriter, r *http.Request, change func(ids []int) ([]int, error)) {
+	collection, ok := app.ownedCollection(w, r)
+	if !ok {
+		return
+	}
+	id := collection.CollectionID
+
+	recipeIDs, err := change(collectionRecipeIDs(collection))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	result := app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		return writeCollectionEntries(tx, id, recipeIDs)
+	})
+	if result != nil {
+		app.Logger.Println("Transaction Failed:", result)
+		http.Error(w, result.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	collection, _ = findCollection(app.Repo.DB, "collection_id = ?", id)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(collection)
+}
+
+// Add a recipe to a collection at a 1-based position, at the end by default
+func (app *App) addCollectionRecipe(w http.ResponseWriter, r *http.Request) {
+	var data struct {
+		RecipeID int `json:"recipe_id"`
+		Position int `json:"position"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	app.changeCollectionEntries(w, r, func(ids []int) ([]int, error) {
+		if slices.Contains(ids, data.RecipeID) {
+			return nil, fmt.Errorf("recipe %d is already in the collection", data.RecipeID)
+		}
+		if err := checkCollectionRecipes(app.Repo.DB, []int{data.RecipeID}); err != nil {
+			return nil, err
+		}
+		index := len(ids)
+		if data.Position > 0 && data.Position <= len(ids) {
+			index = data.Position - 1
+		}
+		return slices.Insert(ids, index, data.RecipeID), nil
+	})
+}
+
+// Take a recipe out of a collection
+func (app *App) removeCollectionRecipe(w http.ResponseWriter, r *http.Request) {
+	recipeID, err := strconv.Atoi(mux.Vars(r)["recipeID"])
+	if err != nil {
+		http.Error(w, "invalid recipe ID", http.StatusBadRequest)
+		return
+	}
+
+	app.changeCollectionEntries(w, r, func(ids []int) ([]int, error) {
+		index := slices.Index(ids, recipeID)
+		if index < 0 {
+			return nil, fmt.Errorf("recipe %d is not in the collection", recipeID)
+		}
+		return slices.Delete(ids, index, index+1), nil
+	})
+}
+
+// Put a collection's recipes in a new order. Every recipe must be listed once.
+func (app *App) reorderCollection(w http.ResponseWriter, r *http.Request) {
+	var data struct {
+		RecipeIDs []int `json:"recipe_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	app.changeCollectionEntries(w, r, func(ids []int) ([]int, error) {
+		sorted, wanted := slices.Clone(ids), slices.Clone(data.RecipeIDs)
+		slices.Sort(sorted)
+		slices.Sort(wanted)
+		if !slices.Equal(sorted, wanted) {
+			return nil, fmt.Errorf("recipe_ids must list each recipe in the collection once")
+		}
+		return data.RecipeIDs, nil
+	})
+}
+
+// Make a collection private, public or shared by link. Sharing by link again
+// issues a new token.
+func (app *App) shareCollection(w http.ResponseWriter, r *http.Request) {
+	var data struct {
+		Visibility string `json:"visibility"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	collection, ok := app.ownedCollection(w, r)
+	if !ok {
+		return
+	}
+	if err := setVisibility(&collection, data.Visibility); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	result := app.Repo.DB.Model(&collection).Select("Visibility", "ShareToken").Updates(&collection)
+	if result.Error != nil {
+		http.Error(w, "Failed to share collection", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(collection)
+}
+
+// Export a collection with its recipes in full as JSON, Markdown or a PDF
+// cookbook (?format=)
+func (app *App) exportCollection(w http.ResponseWriter, r *http.Request) {
+	collection, ok := app.viewableCollection(w, r)
+	if !ok {
+		return
+	}
+
+	export := collectionExport{Collection: collection, Recipes: []models.Recipe{}}
+	recipeIDs := collectionRecipeIDs(collection)
+	if len(recipeIDs) > 0 {
+		var recipes []models.Recipe
+		if result := preloadRecipe(app.Repo.DB).Find(&recipes, recipeIDs); result.Error != nil {
+			http.Error(w, "Error fetching recipes.", http.StatusInternalServerError)
+			return
+		}
+		app.decorateRecipes(recipes)
+		for _, id := range recipeIDs {
+			index := slices.IndexFunc(recipes, func(recipe models.Recipe) bool { return recipe.RecipeID == id })
+			if index >= 0 {
+				export.Recipes = append(export.Recipes, recipes[index])
+			}
+		}
+	}
+	export.Entries = nil
+	export.ShareToken = nil
+
+	switch r.URL.Query().Get("format") {
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", exportFilename(collection.Name, "json")))
+		json.NewEncoder(w).Encode(export)
+	case "markdown", "md":
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", exportFilename(collection.Name, "md")))
+		renderCollectionMarkdown(w, export)
+	case "pdf":
+		book := render.Book{Title: collection.Name, Recipes: export.Recipes}
+		if collection.Description != nil {
+			book.Subtitle = *collection.Description
+		}
+		app.writeCookbook(w, r, book)
+	default:
+		http.Error(w, "format must be json, markdown or pdf", http.StatusBadRequest)
+	}
+}
+
+// File name for an export, e.g. "weeknight-dinners.md"
+func exportFilename(name, extension string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return "collection." + extension
+	}
+	return strings.Join(words, "-") + "." + extension
+}
+
+func renderCollectionMarkdown(w io.Writer, export collectionExport) {
+	fmt.Fprintf(w, "# %s\n", export.Name)
+	if export.Description != nil && *export.Description != "" {
+		fmt.Fprintf(w, "\n%s\n", *export.Description)
+	}
+	fmt.Fprintln(w)
+	for i, recipe := range export.Recipes {
+		fmt.Fprintf(w, "%d. %s\n", i+1, recipe.Name)
+	}
+	for _, recipe := range export.Recipes {
+		fmt.Fprint(w, "\n---\n\n")
+		render.RecipeMarkdown(w, recipe, "##")
+	}
+}
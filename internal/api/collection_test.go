@@ -0,0 +1,191 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"recipe-api/internal/models"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func decodeCollection(t *testing.T, router http.Handler, method, path string, payload any) models.Collection {
+	t.Helper()
+	w := serveJSON(t, router, method, path, payload)
+	if w.Code != http.StatusOK && w.Code != http.StatusCreated {
+		t.Fatalf("%s %s: unexpected status %d: %s", method, path, w.Code, w.Body.String())
+	}
+	var collection models.Collection
+	json.NewDecoder(w.Body).Decode(&collection)
+	return collection
+}
+
+func TestCollectionOrdering(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+
+	var ids []int
+	for _, name := range []string{"Lasagne", "Risotto", "Tiramisu"} {
+		ids = append(ids, postTestRecipe(t, testApp, models.Recipe{Name: name, Difficulty: 1, UserID: "cook"}).RecipeID)
+	}
+
+	collection := decodeCollection(t, router, http.MethodPost, "/collection/add", models.Collection{
+		UserID:  "cook",
+		Name:    "Sunday lunch",
+		Entries: []models.CollectionEntry{{RecipeID: ids[2]}, {RecipeID: ids[0]}},
+	})
+	base := fmt.Sprintf("/collection/id/%d", collection.CollectionID)
+	if fmt.Sprint(collectionRecipeIDs(collection)) != fmt.Sprint([]int{ids[2], ids[0]}) {
+		t.Fatalf("expected entries in the given order, got %v", collectionRecipeIDs(collection))
+	}
+
+	collection = decodeCollection(t, router, http.MethodPost, base+"/recipe?userID=cook", map[string]int{"recipe_id": ids[1], "position": 2})
+	if fmt.Sprint(collectionRecipeIDs(collection)) != fmt.Sprint([]int{ids[2], ids[1], ids[0]}) {
+		t.Fatalf("expected risotto second, got %v", collectionRecipeIDs(collection))
+	}
+
+	collection = decodeCollection(t, router, http.MethodPut, base+"/order?userID=cook", map[string][]int{"recipe_ids": ids})
+	if fmt.Sprint(collectionRecipeIDs(collection)) != fmt.Sprint(ids) {
+		t.Fatalf("expected the new order, got %v", collectionRecipeIDs(collection))
+	}
+	for i, entry := range collection.Entries {
+		if entry.Position != i+1 {
+			t.Fatalf("expected positions to be renumbered, got %+v", collection.Entries)
+		}
+	}
+
+	w := serveJSON(t, router, http.MethodPut, base+"/order?userID=cook", map[string][]int{"recipe_ids": ids[:2]})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d for a partial order, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	collection = decodeCollection(t, router, http.MethodDelete, fmt.Sprintf("%s/recipe/%d?userID=cook", base, ids[0]), nil)
+	if fmt.Sprint(collectionRecipeIDs(collection)) != fmt.Sprint(ids[1:]) {
+		t.Fatalf("expected lasagne to be removed, got %v", collectionRecipeIDs(collection))
+	}
+
+	// Deleting a recipe takes it out of collections
+	if w := serveJSON(t, router, http.MethodDelete, fmt.Sprintf("/recipe/id/%d", ids[1]), nil); w.Code != http.StatusNoContent {
+		t.Fatalf("expected recipe delete to succeed, got %d", w.Code)
+	}
+	collection = decodeCollection(t, router, http.MethodGet, base+"?userID=cook", nil)
+	if fmt.Sprint(collectionRecipeIDs(collection)) != fmt.Sprint(ids[2:]) {
+		t.Fatalf("expected only tiramisu to remain, got %v", collectionRecipeIDs(collection))
+	}
+}
+
+func TestCollectionSharingAndExport(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+
+	recipe := postTestRecipe(t, testApp, createTestRecipe(t, testApp, true))
+	description := "Festive favourites"
+	collection := decodeCollection(t, router, http.MethodPost, "/collection/add", models.Collection{
+		UserID:      "cook",
+		Name:        "Christmas!",
+		Description: &description,
+		Entries:     []models.CollectionEntry{{RecipeID: recipe.RecipeID}},
+	})
+	base := fmt.Sprintf("/collection/id/%d", collection.CollectionID)
+
+	// Private collections are only visible to their owner
+	if w := serveJSON(t, router, http.MethodGet, base, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("expected a private collection to be hidden, got %d", w.Code)
+	}
+
+	shared := decodeCollection(t, router, http.MethodPost, base+"/share?userID=cook", map[string]string{"visibility": "link"})
+	if shared.ShareToken == nil {
+		t.Fatal("expected a share token")
+	}
+	if w := serveJSON(t, router, http.MethodGet, "/collection/shared/"+*shared.ShareToken, nil); w.Code != http.StatusOK {
+		t.Fatalf("expected the shared link to work, got %d", w.Code)
+	}
+
+	decodeCollection(t, router, http.MethodPost, base+"/share?userID=cook", map[string]string{"visibility": "public"})
+	if w := serveJSON(t, router, http.MethodGet, "/collection/shared/"+*shared.ShareToken, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("expected the old link to stop working, got %d", w.Code)
+	}
+	var public []models.Collection
+	json.NewDecoder(serveJSON(t, router, http.MethodGet, "/collection/public", nil).Body).Decode(&public)
+	if len(public) != 1 || public[0].CollectionID != collection.CollectionID {
+		t.Fatalf("expected the collection to be listed publicly, got %+v", public)
+	}
+
+	w := serveJSON(t, router, http.MethodGet, base+"/export?format=markdown", nil)
+	if disposition := w.Header().Get("Content-Disposition"); !strings.Contains(disposition, `"christmas.md"`) {
+		t.Fatalf("unexpected content disposition %q", disposition)
+	}
+	markdown := w.Body.String()
+	for _, expected := range []string{"# Christmas!\n\nFestive favourites\n\n1. " + recipe.Name, "### Ingredients\n\n- 1 Cup Salt\n", "### Method\n\n1. boil it\n2. dry it\n"} {
+		if !strings.Contains(markdown, expected) {
+			t.Fatalf("expected %q in export:\n%s", expected, markdown)
+		}
+	}
+
+	var export collectionExport
+	json.NewDecoder(serveJSON(t, router, http.MethodGet, base+"/export", nil).Body).Decode(&export)
+	if len(export.Recipes) != 1 || len(export.Recipes[0].Ingredients) != 2 {
+		t.Fatalf("expected full recipes in the JSON export, got %+v", export.Recipes)
+	}
+}
+
+func TestCollectionOwnership(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+
+	recipe := postTestRecipe(t, testApp, models.Recipe{Name: "Gazpacho", Difficulty: 1, UserID: "cook"})
+	public := decodeCollection(t, router, http.MethodPost, "/collection/add", models.Collection{UserID: "cook", Name: "Summer", Visibility: "public"})
+	linked := decodeCollection(t, router, http.MethodPost, "/collection/add", models.Collection{UserID: "cook", Name: "Picnic", Visibility: "link"})
+	decodeCollection(t, router, http.MethodPost, "/collection/add", models.Collection{UserID: "cook", Name: "Secret"})
+
+	// Others only see public collections, and nobody gets share tokens from a listing
+	listed := func(path string) string {
+		var collections []models.Collection
+		json.NewDecoder(serveJSON(t, router, http.MethodGet, path, nil).Body).Decode(&collections)
+		var names []string
+		for _, collection := range collections {
+			if collection.ShareToken != nil {
+				t.Errorf("GET %s: expected no share token, got %+v", path, collection)
+			}
+			names = append(names, collection.Name)
+		}
+		slices.Sort(names)
+		return strings.Join(names, ",")
+	}
+	if names := listed("/collection/user/cook"); names != "Summer" {
+		t.Errorf("expected only the public collection for others, got %s", names)
+	}
+	if names := listed("/collection/user/cook?userID=cook"); names != "Picnic,Secret,Summer" {
+		t.Errorf("expected every collection for the owner, got %s", names)
+	}
+
+	// Only the owner changes a collection
+	for _, collection := range []models.Collection{public, linked} {
+		base := fmt.Sprintf("/collection/id/%d", collection.CollectionID)
+		expected := http.StatusNotFound
+		if collection.Visibility == models.VisibilityPublic {
+			expected = http.StatusForbidden
+		}
+		for _, request := range []struct {
+			method, path string
+			payload      any
+		}{
+			{http.MethodPut, base + "?userID=thief", map[string]string{"name": "Mine"}},
+			{http.MethodPost, base + "/share?userID=thief", map[string]string{"visibility": "public"}},
+			{http.MethodPost, base + "/recipe?userID=thief", map[string]int{"recipe_id": recipe.RecipeID}},
+			{http.MethodDelete, base, nil},
+		} {
+			if w := serveJSON(t, router, request.method, request.path, request.payload); w.Code != expected {
+				t.Errorf("%s %s: expected status %d, got %d", request.method, request.path, expected, w.Code)
+			}
+		}
+	}
+	renamed := decodeCollection(t, router, http.MethodPut, fmt.Sprintf("/collection/id/%d?userID=cook", public.CollectionID), map[string]string{"name": "Summer salads"})
+	if renamed.Name != "Summer salads" {
+		t.Fatalf("expected the owner to rename the collection, got %+v", renamed)
+	}
+	if w := serveJSON(t, router, http.MethodDelete, fmt.Sprintf("/collection/id/%d?userID=cook", linked.CollectionID), nil); w.Code != http.StatusNoContent {
+		t.Fatalf("expected the owner to delete the collection, got %d", w.Code)
+	}
+}
@@ -0,0 +1,259 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+
+	"recipe-api/internal/models"
+)
+
+// Load a cook session with its timers, newest first, and its current step
+func loadCookSession(db *gorm.DB, id int) (models.CookSession, error) {
+	var session models.CookSession
+	result := db.Preload("Timers", func(db *gorm.DB) *gorm.DB {
+		return db.Order("started_at DESC").Order("cook_timer_id DESC")
+	}).First(&session, id)
+	if result.Error != nil {
+		return session, result.Error
+	}
+	return session, attachCookStep(db, &session)
+}
+
+// Attach the current instruction and the number of steps
+func attachCookStep(db *gorm.DB, session *models.CookSession) error {
+	var steps []models.Instruction
+	if err := db.Where("recipe_id = ?", session.RecipeID).Order("step_number ASC").Find(&steps).Error; err != nil {
+		return err
+	}
+	session.TotalSteps = len(steps)
+	session.Step = nil
+	for i := range steps {
+		if steps[i].StepNumber == session.CurrentStep {
+			session.Step = &steps[i]
+		}
+	}
+	return nil
+}
+
+// Session named in the route, writing the error response when missing
+func (app *App) cookSessionFromRoute(w http.ResponseWriter, r *http.Request) (models.CookSession, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid cook session ID", http.StatusBadRequest)
+		return models.CookSession{}, false
+	}
+	session, err := loadCookSession(app.Repo.DB, id)
+	if err != nil {
+		http.Error(w, "Cook session not found", http.StatusNotFound)
+		return models.CookSession{}, false
+	}
+	return session, true
+}
+
+// Start cooking a recipe from its first step
+func (app *App) addCookSession(w http.ResponseWriter, r *http.Request) {
+	var data models.CookSession
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if data.UserID == "" {
+		http.Error(w, "userID is required", http.StatusBadRequest)
+		return
+	}
+
+	var recipe models.Recipe
+	if result := app.Repo.DB.First(&recipe, data.RecipeID); result.Error != nil {
+		http.Error(w, "Recipe not found", http.StatusNotFound)
+		return
+	}
+	var first models.Instruction
+	result := app.Repo.DB.Where("recipe_id = ?", recipe.RecipeID).Order("step_number ASC").First(&first)
+	if result.Error != nil {
+		http.Error(w, "recipe has no steps to cook", http.StatusBadRequest)
+		return
+	}
+
+	session := models.CookSession{RecipeID: recipe.RecipeID, UserID: data.UserID, CurrentStep: first.StepNumber}
+	if err := app.Repo.DB.Create(&session).Error; err != nil {
+		app.Logger.Println("Cook session error:", err)
+		http.Error(w, "Failed to start cook session", http.StatusInternalServerError)
+		return
+	}
+	session, _ = loadCookSession(app.Repo.DB, session.CookSessionID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(session)
+}
+
+// Get a cook session, e.g. to resume after a refresh
+func (app *App) getCookSession(w http.ResponseWriter, r *http.Request) {
+	session, ok := app.cookSessionFromRoute(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(session)
+}
+
+// Get a user's cook sessions, most recently used first
+func (app *App) getCookSessionsByUser(w http.ResponseWriter, r *http.Request) {
+	var sessions []models.CookSession
+	result := app.Repo.DB.Preload("Timers", "fired_at IS NULL AND cancelled_at IS NULL").
+		Where("user_id = ?", mux.Vars(r)["userID"]).
+		Order("updated_at DESC").Find(&sessions)
+	if result.Error != nil {
+		http.Error(w, "Error fetching cook sessions.", http.StatusInternalServerError)
+		return
+	}
+	for i := range sessions {
+		attachCookStep(app.Repo.DB, &sessions[i])
+	}
+	if sessions == nil {
+		sessions = []models.CookSession{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sessions)
+}
+
+// Returned when jumping to a step the recipe doesn't have
+var errNoSuchStep = errors.New("Step not found")
+
+// Move a session to another step, chosen by pick from the recipe's steps in order
+func (app *App) moveCookSession(w http.ResponseWriter, r *http.Request, pick func(steps []int, current int) (int, error)) {
+	session, ok := app.cookSessionFromRoute(w, r)
+	if !ok {
+		return
+	}
+
+	var steps []int
+	err := app.Repo.DB.Model(&models.Instruction{}).Where("recipe_id = ?", session.RecipeID).
+		Order("step_number ASC").Pluck("step_number", &steps).Error
+	if err != nil {
+		app.Logger.Println("Cook session error:", err)
+		http.Error(w, "Error fetching steps.", http.StatusInternalServerError)
+		return
+	}
+	step, err := pick(steps, session.CurrentStep)
+	if errors.Is(err, errNoSuchStep) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	result := app.Repo.DB.Model(&session).Update("current_step", step)
+	if result.Error != nil {
+		http.Error(w, "Failed to change step", http.StatusInternalServerError)
+		return
+	}
+	session, _ = loadCookSession(app.Repo.DB, session.CookSessionID)
+	app.cookHub().publish(session.CookSessionID, cookEvent{Type: cookEventStep, Session: &session})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(session)
+}
+
+// Advance to the next step
+func (app *App) nextCookStep(w http.ResponseWriter, r *http.Request) {
+	app.moveCookSession(w, r, func(steps []int, current int) (int, error) {
+		for _, step := range steps {
+			if step > current {
+				return step, nil
+			}
+		}
+		return 0, errors.New("already at the last step")
+	})
+}
+
+// Go back to the previous step
+func (app *App) previousCookStep(w http.ResponseWriter, r *http.Request) {
+	app.moveCookSession(w, r, func(steps []int, current int) (int, error) {
+		for i := len(steps) - 1; i >= 0; i-- {
+			if steps[i] < current {
+				return steps[i], nil
+			}
+		}
+		return 0, errors.New("already at the first step")
+	})
+}
+
+// Jump to a step by number
+func (app *App) setCookStep(w http.ResponseWriter, r *http.Request) {
+	var data struct {
+		StepNumber int `json:"stepNumber"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	app.moveCookSession(w, r, func(steps []int, current int) (int, error) {
+		for _, step := range steps {
+			if step == data.StepNumber {
+				return step, nil
+			}
+		}
+		return 0, errNoSuchStep
+	})
+}
+
+// Finish a cook session, stopping its timers
+func (app *App) deleteCookSession(w http.ResponseWriter, r *http.Request) {
+	session, ok := app.cookSessionFromRoute(w, r)
+	if !ok {
+		return
+	}
+
+	var ended []models.CookSession
+	err := app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		ended, err = deleteCookSessions(tx, "cook_session_id = ?", session.CookSessionID)
+		return err
+	})
+	if err != nil {
+		app.Logger.Println("Transaction Failed:", err)
+		http.Error(w, "Failed to delete cook session", http.StatusInternalServerError)
+		return
+	}
+	app.endCookSessions(ended)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Delete the cook sessions matching a condition, with their timers,
+// returning what was deleted for endCookSessions once the transaction commits
+func deleteCookSessions(tx *gorm.DB, query string, args ...any) ([]models.CookSession, error) {
+	var sessions []models.CookSession
+	if err := tx.Preload("Timers").Where(query, args...).Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, nil
+	}
+	ids := make([]int, len(sessions))
+	for i, session := range sessions {
+		ids[i] = session.CookSessionID
+	}
+	if err := tx.Where("cook_session_id IN ?", ids).Delete(&models.CookTimer{}).Error; err != nil {
+		return nil, err
+	}
+	return sessions, tx.Where("cook_session_id IN ?", ids).Delete(&models.CookSession{}).Error
+}
+
+// Stop the timers of deleted sessions and tell their listeners they finished
+func (app *App) endCookSessions(sessions []models.CookSession) {
+	for _, session := range sessions {
+		for _, timer := range session.Timers {
+			app.cookHub().stop(timer.CookTimerID)
+		}
+		app.cookHub().publish(session.CookSessionID, cookEvent{Type: cookEventFinished})
+	}
+}
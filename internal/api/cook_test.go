@@ -0,0 +1,201 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"recipe-api/internal/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func decodeCookSession(t *testing.T, router http.Handler, method, path string, payload any) models.CookSession {
+	t.Helper()
+	w := serveJSON(t, router, method, path, payload)
+	if w.Code != http.StatusOK && w.Code != http.StatusCreated {
+		t.Fatalf("%s %s: unexpected status %d: %s", method, path, w.Code, w.Body.String())
+	}
+	var session models.CookSession
+	json.NewDecoder(w.Body).Decode(&session)
+	return session
+}
+
+func TestCookSessionSteps(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+	recipe := postTestRecipe(t, testApp, createTestRecipe(t, testApp, true))
+
+	session := decodeCookSession(t, router, http.MethodPost, "/cook/add", map[string]any{"userID": "cook", "recipe_id": recipe.RecipeID})
+	base := fmt.Sprintf("/cook/id/%d", session.CookSessionID)
+	if session.CurrentStep != 1 || session.TotalSteps != 2 || session.Step == nil || session.Step.StepText != "boil it" {
+		t.Fatalf("expected to start on step 1 of 2, got %+v", session)
+	}
+
+	session = decodeCookSession(t, router, http.MethodPost, base+"/next", nil)
+	if session.CurrentStep != 2 {
+		t.Fatalf("expected step 2, got %d", session.CurrentStep)
+	}
+	if w := serveJSON(t, router, http.MethodPost, base+"/next", nil); w.Code != http.StatusConflict {
+		t.Fatalf("expected status %d past the last step, got %d", http.StatusConflict, w.Code)
+	}
+	session = decodeCookSession(t, router, http.MethodPost, base+"/previous", nil)
+	if session.CurrentStep != 1 {
+		t.Fatalf("expected step 1, got %d", session.CurrentStep)
+	}
+	if w := serveJSON(t, router, http.MethodPut, base+"/step", map[string]int{"stepNumber": 7}); w.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d for a missing step, got %d", http.StatusNotFound, w.Code)
+	}
+	decodeCookSession(t, router, http.MethodPut, base+"/step", map[string]int{"stepNumber": 2})
+
+	// State is persisted, so a fresh load resumes on the same step
+	session = decodeCookSession(t, router, http.MethodGet, base, nil)
+	if session.CurrentStep != 2 {
+		t.Fatalf("expected to resume on step 2, got %d", session.CurrentStep)
+	}
+	w := serveJSON(t, router, http.MethodGet, "/cook/user/cook", nil)
+	var sessions []models.CookSession
+	json.NewDecoder(w.Body).Decode(&sessions)
+	if len(sessions) != 1 || sessions[0].CurrentStep != 2 {
+		t.Fatalf("expected the session in the user's list, got %+v", sessions)
+	}
+
+	if w := serveJSON(t, router, http.MethodPost, base+"/timer", nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d for a step without a time, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	if w := serveJSON(t, router, http.MethodDelete, base, nil); w.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, w.Code)
+	}
+	if w := serveJSON(t, router, http.MethodGet, base, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("expected the session to be gone, got %d", w.Code)
+	}
+}
+
+// Read events from a stream until one of the wanted type arrives
+func nextCookEvent(t *testing.T, lines *bufio.Scanner, want string) cookEvent {
+	t.Helper()
+	event := ""
+	for lines.Scan() {
+		line := lines.Text()
+		if name, ok := strings.CutPrefix(line, "event: "); ok {
+			event = name
+		}
+		if data, ok := strings.CutPrefix(line, "data: "); ok && event == want {
+			var decoded cookEvent
+			if err := json.Unmarshal([]byte(data), &decoded); err != nil {
+				t.Fatalf("invalid event data %q: %v", data, err)
+			}
+			return decoded
+		}
+	}
+	t.Fatalf("stream ended waiting for %s: %v", want, lines.Err())
+	return cookEvent{}
+}
+
+func TestCookSessionEvents(t *testing.T) {
+	defer clearDatabase(testApp)
+	server := httptest.NewServer(testRouter())
+	defer server.Close()
+	router := testRouter()
+
+	recipe := postTestRecipe(t, testApp, models.Recipe{Name: "Egg", Difficulty: 1, Instructions: []models.Instruction{
+		{StepNumber: 1, StepText: "boil", Duration: ToPtr(6)},
+		{StepNumber: 2, StepText: "peel"},
+	}})
+	session := decodeCookSession(t, router, http.MethodPost, "/cook/add", map[string]any{"userID": "cook", "recipe_id": recipe.RecipeID})
+	base := fmt.Sprintf("/cook/id/%d", session.CookSessionID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+base+"/events", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %s", resp.Header.Get("Content-Type"))
+	}
+	lines := bufio.NewScanner(resp.Body)
+	if snapshot := nextCookEvent(t, lines, cookEventSession); snapshot.Session.CurrentStep != 1 {
+		t.Fatalf("expected a snapshot on step 1, got %+v", snapshot.Session)
+	}
+
+	// The step's own time is used by default
+	w := serveJSON(t, router, http.MethodPost, base+"/timer", nil)
+	var long models.CookTimer
+	json.NewDecoder(w.Body).Decode(&long)
+	if w.Code != http.StatusCreated || long.Seconds != 360 {
+		t.Fatalf("expected a 6 minute timer, got %d %+v", w.Code, long)
+	}
+	nextCookEvent(t, lines, cookEventTimerStarted)
+	serveJSON(t, router, http.MethodDelete, fmt.Sprintf("%s/timer/%d", base, long.CookTimerID), nil)
+	if cancelled := nextCookEvent(t, lines, cookEventTimerCancelled); cancelled.Timer.CookTimerID != long.CookTimerID {
+		t.Fatalf("expected the long timer to be cancelled, got %+v", cancelled.Timer)
+	}
+
+	serveJSON(t, router, http.MethodPost, base+"/next", nil)
+	if step := nextCookEvent(t, lines, cookEventStep); step.Session.CurrentStep != 2 {
+		t.Fatalf("expected a step change to 2, got %+v", step.Session)
+	}
+
+	w = serveJSON(t, router, http.MethodPost, base+"/timer", map[string]int{"stepNumber": 1, "seconds": 1})
+	var short models.CookTimer
+	json.NewDecoder(w.Body).Decode(&short)
+	expired := nextCookEvent(t, lines, cookEventTimerExpired)
+	if expired.Timer.CookTimerID != short.CookTimerID || expired.Timer.FiredAt == nil {
+		t.Fatalf("expected the short timer to expire, got %+v", expired.Timer)
+	}
+
+	// Timers are capped at a day
+	w = serveJSON(t, router, http.MethodPost, base+"/timer", map[string]int{"seconds": 86401})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected a timer over a day to be refused, got %d", w.Code)
+	}
+
+	serveJSON(t, router, http.MethodDelete, base, nil)
+	nextCookEvent(t, lines, cookEventFinished)
+}
+
+func TestCookSessionRecipeDeleted(t *testing.T) {
+	defer clearDatabase(testApp)
+	server := httptest.NewServer(testRouter())
+	defer server.Close()
+	router := testRouter()
+
+	recipe := postTestRecipe(t, testApp, models.Recipe{Name: "Stock", Difficulty: 1, Instructions: []models.Instruction{
+		{StepNumber: 1, StepText: "simmer", Duration: ToPtr(180)},
+	}})
+	session := decodeCookSession(t, router, http.MethodPost, "/cook/add", map[string]any{"userID": "cook", "recipe_id": recipe.RecipeID})
+	base := fmt.Sprintf("/cook/id/%d", session.CookSessionID)
+	var timer models.CookTimer
+	json.NewDecoder(serveJSON(t, router, http.MethodPost, base+"/timer", nil).Body).Decode(&timer)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+base+"/events", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	lines := bufio.NewScanner(resp.Body)
+	nextCookEvent(t, lines, cookEventSession)
+
+	// Deleting the recipe ends its sessions: listeners hear and timers stop
+	if err := testApp.removeRecipe(recipe.RecipeID); err != nil {
+		t.Fatal(err)
+	}
+	nextCookEvent(t, lines, cookEventFinished)
+	hub := testApp.cookHub()
+	hub.mu.Lock()
+	_, running := hub.timers[timer.CookTimerID]
+	hub.mu.Unlock()
+	if running {
+		t.Errorf("expected the session's timer to be stopped")
+	}
+}
@@ -0,0 +1,306 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+
+	"recipe-api/internal/models"
+)
+
+// Cook session event types
+const (
+	cookEventSession        = "session" // snapshot sent when a client connects
+	cookEventStep           = "step"
+	cookEventTimerStarted   = "timer_started"
+	cookEventTimerExpired   = "timer_expired"
+	cookEventTimerCancelled = "timer_cancelled"
+	cookEventFinished       = "finished"
+)
+
+// Longest timer a cook can start
+const maxCookTimer = 24 * time.Hour
+
+// Change to a cook session pushed to its listeners
+type cookEvent struct {
+	Type    string              `json:"type"`
+	Session *models.CookSession `json:"session,omitempty"`
+	Timer   *models.CookTimer   `json:"timer,omitempty"`
+}
+
+// Listeners and running timers of every cook session in this process
+type cookHub struct {
+	mu          sync.Mutex
+	subscribers map[int]map[chan cookEvent]bool // by session ID
+	timers      map[int]*time.Timer             // by timer ID
+}
+
+func (app *App) cookHub() *cookHub {
+	app.cookOnce.Do(func() {
+		app.cook = &cookHub{
+			subscribers: make(map[int]map[chan cookEvent]bool),
+			timers:      make(map[int]*time.Timer),
+		}
+	})
+	return app.cook
+}
+
+func (hub *cookHub) subscribe(sessionID int) chan cookEvent {
+	events := make(chan cookEvent, 16)
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	if hub.subscribers[sessionID] == nil {
+		hub.subscribers[sessionID] = make(map[chan cookEvent]bool)
+	}
+	hub.subscribers[sessionID][events] = true
+	return events
+}
+
+func (hub *cookHub) unsubscribe(sessionID int, events chan cookEvent) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	delete(hub.subscribers[sessionID], events)
+	if len(hub.subscribers[sessionID]) == 0 {
+		delete(hub.subscribers, sessionID)
+	}
+}
+
+// Send an event to a session's listeners, skipping any too slow to keep up
+func (hub *cookHub) publish(sessionID int, event cookEvent) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	for events := range hub.subscribers[sessionID] {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+// Run fire when a timer is due, replacing any schedule it already had
+func (hub *cookHub) schedule(timerID int, at time.Time, fire func()) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	if running, ok := hub.timers[timerID]; ok {
+		running.Stop()
+	}
+	hub.timers[timerID] = time.AfterFunc(time.Until(at), func() {
+		hub.mu.Lock()
+		delete(hub.timers, timerID)
+		hub.mu.Unlock()
+		fire()
+	})
+}
+
+func (hub *cookHub) stop(timerID int) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	if running, ok := hub.timers[timerID]; ok {
+		running.Stop()
+		delete(hub.timers, timerID)
+	}
+}
+
+// Schedule a persisted timer to fire in this process
+func (app *App) scheduleCookTimer(timer models.CookTimer) {
+	app.cookHub().schedule(timer.CookTimerID, timer.EndsAt, func() {
+		app.fireCookTimer(timer.CookTimerID)
+	})
+}
+
+// Mark a timer as run out and tell the session's listeners. Timers cancelled or
+// deleted in the meantime are ignored.
+func (app *App) fireCookTimer(timerID int) {
+	result := app.Repo.DB.Model(&models.CookTimer{}).
+		Where("cook_timer_id = ? AND fired_at IS NULL AND cancelled_at IS NULL", timerID).
+		Update("fired_at", time.Now())
+	if result.Error != nil {
+		app.Logger.Println("Cook timer error:", result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		return
+	}
+
+	var timer models.CookTimer
+	if err := app.Repo.DB.First(&timer, timerID).Error; err != nil {
+		return
+	}
+	app.cookHub().publish(timer.CookSessionID, cookEvent{Type: cookEventTimerExpired, Timer: &timer})
+}
+
+// Reschedule timers still running when the process last stopped. Ones that
+// ran out while it was down fire straight away.
+func (app *App) ResumeCookTimers() error {
+	var timers []models.CookTimer
+	if err := app.Repo.DB.Where("fired_at IS NULL AND cancelled_at IS NULL").Find(&timers).Error; err != nil {
+		return err
+	}
+	for _, timer := range timers {
+		app.scheduleCookTimer(timer)
+	}
+	return nil
+}
+
+// Start a timer in a cook session, for the current step by default.
+// The length comes from the step's stepTime unless "seconds" is given.
+func (app *App) startCookTimer(w http.ResponseWriter, r *http.Request) {
+	session, ok := app.cookSessionFromRoute(w, r)
+	if !ok {
+		return
+	}
+
+	var data struct {
+		StepNumber *int `json:"stepNumber"`
+		Seconds    *int `json:"seconds"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+			http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+			return
+		}
+	}
+	step := session.CurrentStep
+	if data.StepNumber != nil {
+		step = *data.StepNumber
+	}
+
+	var instruction models.Instruction
+	result := app.Repo.DB.Where("recipe_id = ? AND step_number = ?", session.RecipeID, step).First(&instruction)
+	if result.Error != nil {
+		http.Error(w, "Step not found", http.StatusNotFound)
+		return
+	}
+
+	seconds := 0
+	switch {
+	case data.Seconds != nil:
+		seconds = *data.Seconds
+	case instruction.Duration != nil:
+		seconds = *instruction.Duration * 60
+	default:
+		http.Error(w, "step has no stepTime, give seconds instead", http.StatusBadRequest)
+		return
+	}
+	if seconds <= 0 {
+		http.Error(w, "timer must be at least one second", http.StatusBadRequest)
+		return
+	}
+	if seconds > int(maxCookTimer/time.Second) {
+		http.Error(w, fmt.Sprintf("timer must be at most %d seconds", int(maxCookTimer/time.Second)), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	timer := models.CookTimer{
+		CookSessionID: session.CookSessionID,
+		StepNumber:    step,
+		Seconds:       seconds,
+		StartedAt:     now,
+		EndsAt:        now.Add(time.Duration(seconds) * time.Second),
+	}
+	if err := app.Repo.DB.Create(&timer).Error; err != nil {
+		app.Logger.Println("Cook timer error:", err)
+		http.Error(w, "Failed to start timer", http.StatusInternalServerError)
+		return
+	}
+	app.scheduleCookTimer(timer)
+	app.cookHub().publish(session.CookSessionID, cookEvent{Type: cookEventTimerStarted, Timer: &timer})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(timer)
+}
+
+// Stop a running timer
+func (app *App) cancelCookTimer(w http.ResponseWriter, r *http.Request) {
+	session, ok := app.cookSessionFromRoute(w, r)
+	if !ok {
+		return
+	}
+	timerID, err := strconv.Atoi(mux.Vars(r)["timerID"])
+	if err != nil {
+		http.Error(w, "invalid timer ID", http.StatusBadRequest)
+		return
+	}
+
+	var timer models.CookTimer
+	err = app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("cook_timer_id = ? AND cook_session_id = ?", timerID, session.CookSessionID).First(&timer)
+		if result.Error != nil {
+			return result.Error
+		}
+		if timer.FiredAt != nil || timer.CancelledAt != nil {
+			return errTimerStopped
+		}
+		now := time.Now()
+		timer.CancelledAt = &now
+		return tx.Model(&timer).Update("cancelled_at", now).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Timer not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, errTimerStopped) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		app.Logger.Println("Transaction Failed:", err)
+		http.Error(w, "Failed to cancel timer", http.StatusInternalServerError)
+		return
+	}
+	app.cookHub().stop(timer.CookTimerID)
+	app.cookHub().publish(session.CookSessionID, cookEvent{Type: cookEventTimerCancelled, Timer: &timer})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(timer)
+}
+
+var errTimerStopped = errors.New("timer has already stopped")
+
+// Stream a cook session's step changes and timers as Server-Sent Events.
+// The first event is the whole session so a reconnecting client can resume.
+func (app *App) streamCookSession(w http.ResponseWriter, r *http.Request) {
+	session, ok := app.cookSessionFromRoute(w, r)
+	if !ok {
+		return
+	}
+
+	hub := app.cookHub()
+	events := hub.subscribe(session.CookSessionID)
+	defer hub.unsubscribe(session.CookSessionID, events)
+
+	stream, err := startSSE(w)
+	if err != nil {
+		app.Logger.Println("Streaming unsupported:", err)
+		return
+	}
+	if err := stream.send("", cookEventSession, cookEvent{Type: cookEventSession, Session: &session}); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if stream.heartbeat() != nil {
+				return
+			}
+		case event := <-events:
+			if stream.send("", event.Type, event) != nil || event.Type == cookEventFinished {
+				return
+			}
+		}
+	}
+}
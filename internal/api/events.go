@@ -0,0 +1,336 @@
+package api
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"gorm.io/gorm"
+
+	"recipe-api/internal/events"
+	"recipe-api/internal/middleware"
+	"recipe-api/internal/models"
+)
+
+// Events replayed per query when a client resumes the feed
+const eventReplayBatch = 500
+
+func (app *App) eventBus() *events.Bus {
+	app.busOnce.Do(func() {
+		app.bus = events.NewBus()
+	})
+	return app.bus
+}
+
+// Persist a recipe event in the caller's transaction. Events carry the
+// recipe as stored, deleted ones included so the recipe can be restored.
+// Call before deleting so the recipe can still be read. Webhook deliveries
+// are queued in the same transaction.
+func recordRecipeEvent(tx *gorm.DB, eventType string, recipeID int) (models.Event, error) {
+	var recipe models.Recipe
+	if err := preloadRecipe(tx).First(&recipe, recipeID).Error; err != nil {
+		return models.Event{}, err
+	}
+
+	data, err := json.Marshal(recipe)
+	if err != nil {
+		return models.Event{}, err
+	}
+
+	event := models.Event{Type: eventType, RecipeID: recipe.RecipeID, UserID: recipe.UserID, Data: string(data)}
+	if err := tx.Create(&event).Error; err != nil {
+		return models.Event{}, err
+	}
+	return event, enqueueWebhooks(tx, event)
+}
+
+// Run a transaction that records an event, then publish the event. These
+// transactions take turns within the process, so events commit in ID order
+// and the committed mark never passes an event still in flight.
+func (app *App) eventTransaction(write func(tx *gorm.DB) (models.Event, error)) error {
+	app.eventMu.Lock()
+	defer app.eventMu.Unlock()
+
+	var event models.Event
+	err := app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		event, err = write(tx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	app.publishEvents(event)
+	return nil
+}
+
+// ID of the last event known to have committed, with every event before it
+func (app *App) committedEventID() int64 {
+	app.eventMarkOnce.Do(func() {
+		app.eventMu.Lock()
+		defer app.eventMu.Unlock()
+		var last *int64
+		if err := app.Repo.DB.Model(&models.Event{}).Select("MAX(event_id)").Scan(&last).Error; err != nil {
+			app.Logger.Println("Event log error:", err)
+		}
+		if last != nil && *last > app.eventMark.Load() {
+			app.eventMark.Store(*last)
+		}
+	})
+	return app.eventMark.Load()
+}
+
+// Hand committed events to live subscribers and wake the webhook worker
+// for the deliveries they queued. Called with eventMu held.
+func (app *App) publishEvents(committed ...models.Event) {
+	ids := make([]int, len(committed))
+	for i, event := range committed {
+		ids[i] = event.RecipeID
+		if event.EventID > app.eventMark.Load() {
+			app.eventMark.Store(event.EventID)
+		}
+	}
+	app.forgetRecipes(context.Background(), ids...)
+
+	app.eventBus().Publish(committed...)
+	app.wakeWebhookWorker()
+	// Writes over gRPC don't pass the HTTP router's invalidation
+	app.ResponseCache.Purge()
+}
+
+// Which events a feed client wants
+type eventFilter struct {
+	userID string   // only events for this user's recipes
+	types  []string // only these types, all when empty
+}
+
+func (filter eventFilter) apply(db *gorm.DB) *gorm.DB {
+	if filter.userID != "" {
+		db = db.Where("user_id = ?", filter.userID)
+	}
+	if len(filter.types) > 0 {
+		db = db.Where("type IN ?", filter.types)
+	}
+	return db
+}
+
+// Sink for feed events, SSE or WebSocket
+type eventSender interface {
+	sendEvent(event models.Event) error
+	heartbeat() error
+}
+
+func (stream *sseStream) sendEvent(event models.Event) error {
+	return stream.send(strconv.FormatInt(event.EventID, 10), event.Type, event)
+}
+
+type wsEventSender struct {
+	conn *websocket.Conn
+}
+
+func (sender wsEventSender) sendEvent(event models.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return sender.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+func (sender wsEventSender) heartbeat() error {
+	return sender.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(sseHeartbeat))
+}
+
+// Largest message read from a feed client, which has nothing to send
+const wsMaxMessage = 1 << 20
+
+// Upgrader for WebSocket feeds. Browsers always send an Origin, which must
+// be the server's own or one the CORS policy allows; clients outside a
+// browser send none.
+func eventUpgrader(cors *middleware.CorsPolicy) *websocket.Upgrader {
+	return &websocket.Upgrader{CheckOrigin: func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		if parsed, err := url.Parse(origin); err == nil && strings.EqualFold(parsed.Host, r.Host) {
+			return true
+		}
+		return cors.AllowsOrigin(origin)
+	}}
+}
+
+// Stream recipe changes over Server-Sent Events, or WebSocket when the
+// request asks to upgrade. ?userID= narrows the feed to one user's recipes and
+// ?type= to some event types. Clients resume with the Last-Event-ID header (or
+// ?last_event_id=), replaying what they missed from the event log.
+//
+// Everything sent is read from the log up to the committed mark; live events
+// only wake the stream. An event that commits after one with a higher ID is
+// therefore still sent, rather than skipped as already seen.
+func (app *App) streamEvents(w http.ResponseWriter, r *http.Request, upgrader *websocket.Upgrader) {
+	query := r.URL.Query()
+	filter := eventFilter{userID: query.Get("userID"), types: queryList(query, "type")}
+	for _, eventType := range filter.types {
+		if !slices.Contains(events.Types, eventType) {
+			http.Error(w, "unknown event type "+eventType, http.StatusBadRequest)
+			return
+		}
+	}
+
+	var lastID *int64
+	if raw := cmp.Or(r.Header.Get("Last-Event-ID"), query.Get("last_event_id")); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || id < 0 {
+			http.Error(w, "invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+		lastID = &id
+	}
+
+	// Subscribe before reading the mark so nothing committed in between is missed
+	bus := app.eventBus()
+	live := bus.Subscribe()
+	defer bus.Unsubscribe(live)
+	if lastID == nil {
+		mark := app.committedEventID()
+		lastID = &mark
+	}
+
+	ctx := r.Context()
+	var sender eventSender
+	if websocket.IsWebSocketUpgrade(r) {
+		// Refused upgrades, a disallowed origin included, are answered here
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetReadLimit(wsMaxMessage)
+
+		// Reads answer pings and notice the client going away
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		go func() {
+			defer cancel()
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+		sender = wsEventSender{conn}
+	} else {
+		stream, err := startSSE(w)
+		if err != nil {
+			app.Logger.Println("Streaming unsupported:", err)
+			return
+		}
+		sender = stream
+	}
+
+	if err := app.replayEvents(ctx, sender, filter, lastID); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			if sender.heartbeat() != nil {
+				return
+			}
+		case _, open := <-live:
+			if !open {
+				return // fell behind; the client resumes from the log
+			}
+			// One read covers every wake-up queued so far
+			for len(live) > 0 {
+				<-live
+			}
+			if app.replayEvents(ctx, sender, filter, lastID) != nil {
+				return
+			}
+		}
+	}
+}
+
+// Send logged events after lastID up to the committed mark, advancing lastID
+// as they go out
+func (app *App) replayEvents(ctx context.Context, sender eventSender, filter eventFilter, lastID *int64) error {
+	mark := app.committedEventID()
+	for {
+		var batch []models.Event
+		result := filter.apply(app.Repo.DB.WithContext(ctx)).Where("event_id > ? AND event_id <= ?", *lastID, mark).
+			Order("event_id ASC").Limit(eventReplayBatch).Find(&batch)
+		if result.Error != nil {
+			return result.Error
+		}
+		for _, event := range batch {
+			if err := sender.sendEvent(event); err != nil {
+				return err
+			}
+			*lastID = event.EventID
+		}
+		if len(batch) < eventReplayBatch {
+			// Nothing else up to the mark passes the filter
+			*lastID = max(*lastID, mark)
+			return nil
+		}
+	}
+}
+
+// How often old events are pruned
+var eventPruneInterval = time.Hour
+
+// Prune events older than EventRetention until the context is cancelled
+func (app *App) RunEventPruner(ctx context.Context) {
+	if app.EventRetention <= 0 {
+		return
+	}
+	ticker := time.NewTicker(eventPruneInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := app.pruneEvents(time.Now().Add(-app.EventRetention)); err != nil {
+			app.Logger.Println("Event pruning error:", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Delete events logged before cutoff with their webhook deliveries, keeping
+// those still waiting to be delivered. Returns how many were deleted.
+func (app *App) pruneEvents(cutoff time.Time) (int64, error) {
+	var pruned int64
+	err := app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		query := func() *gorm.DB { return tx.Session(&gorm.Session{NewDB: true}) }
+		pending := query().Model(&models.WebhookDelivery{}).Select("event_id").Where("status = ?", models.DeliveryPending)
+		old := query().Model(&models.Event{}).Select("event_id").Where("created_at < ? AND event_id NOT IN (?)", cutoff, pending)
+		deliveries := query().Model(&models.WebhookDelivery{}).Select("delivery_id").Where("event_id IN (?)", old)
+
+		if err := query().Where("delivery_id IN (?)", deliveries).Delete(&models.WebhookAttempt{}).Error; err != nil {
+			return err
+		}
+		if err := query().Where("event_id IN (?)", old).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		result := query().Where("created_at < ? AND event_id NOT IN (?)", cutoff, pending).Delete(&models.Event{})
+		pruned = result.RowsAffected
+		return result.Error
+	})
+	return pruned, err
+}
@@ -0,0 +1,234 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"recipe-api/internal/events"
+	"recipe-api/internal/middleware"
+	"recipe-api/internal/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Open the SSE feed and return a function reading the next event
+func openEventStream(t *testing.T, ctx context.Context, url, lastEventID string) func() (string, models.Event) {
+	t.Helper()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	lines := bufio.NewScanner(resp.Body)
+
+	return func() (string, models.Event) {
+		t.Helper()
+		id := ""
+		for lines.Scan() {
+			line := lines.Text()
+			if value, ok := strings.CutPrefix(line, "id: "); ok {
+				id = value
+			}
+			if data, ok := strings.CutPrefix(line, "data: "); ok {
+				var event models.Event
+				json.Unmarshal([]byte(data), &event)
+				return id, event
+			}
+		}
+		t.Fatalf("stream ended: %v", lines.Err())
+		return "", models.Event{}
+	}
+}
+
+func TestEventFeedSSE(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+	server := httptest.NewServer(router)
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	next := openEventStream(t, ctx, server.URL+"/events?userID=ann", "")
+
+	bob := postTestRecipe(t, testApp, models.Recipe{Name: "Bob's soup", Difficulty: 1, UserID: "bob"})
+	ann := postTestRecipe(t, testApp, models.Recipe{Name: "Ann's stew", Difficulty: 1, UserID: "ann"})
+	id, event := next()
+	if event.Type != events.RecipeCreated || event.RecipeID != ann.RecipeID || id != fmt.Sprint(event.EventID) {
+		t.Fatalf("expected only ann's recipe being created, got %s %+v", id, event)
+	}
+	var payload models.Recipe
+	json.Unmarshal([]byte(event.Data), &payload)
+	if payload.Name != "Ann's stew" || payload.Instructions != nil {
+		t.Fatalf("expected the stored recipe as the payload, got %s", event.Data)
+	}
+	created := event
+
+	serveJSON(t, router, http.MethodPut, fmt.Sprintf("/recipe/id/%d", ann.RecipeID), models.Recipe{Name: "Ann's big stew", Difficulty: 2, UserID: "ann"})
+	serveJSON(t, router, http.MethodDelete, fmt.Sprintf("/recipe/id/%d", bob.RecipeID), nil)
+	serveJSON(t, router, http.MethodDelete, fmt.Sprintf("/recipe/id/%d", ann.RecipeID), nil)
+	if _, event = next(); event.Type != events.RecipeUpdated || !strings.Contains(event.Data, "Ann's big stew") {
+		t.Fatalf("expected the update with the new name, got %+v", event)
+	}
+	if _, event = next(); event.Type != events.RecipeDeleted || event.RecipeID != ann.RecipeID {
+		t.Fatalf("expected ann's recipe being deleted, got %+v", event)
+	}
+
+	// Resuming after the first event replays the rest from the log
+	replay := openEventStream(t, ctx, server.URL+"/events", fmt.Sprint(created.EventID))
+	var types []string
+	for range 3 {
+		_, event := replay()
+		types = append(types, event.Type)
+	}
+	if fmt.Sprint(types) != "[recipe.updated recipe.deleted recipe.deleted]" {
+		t.Fatalf("unexpected replay %v", types)
+	}
+
+	if w := serveJSON(t, router, http.MethodGet, "/events?type=recipe.eaten", nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d for an unknown type, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestEventFeedWebSocket(t *testing.T) {
+	defer clearDatabase(testApp)
+	server := httptest.NewServer(testRouter())
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/events?type=recipe.deleted", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	recipe := postTestRecipe(t, testApp, createTestRecipe(t, testApp, true))
+	serveJSON(t, testRouter(), http.MethodDelete, fmt.Sprintf("/recipe/id/%d", recipe.RecipeID), nil)
+
+	_, message, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var event models.Event
+	json.Unmarshal(message, &event)
+	if event.Type != events.RecipeDeleted || event.RecipeID != recipe.RecipeID {
+		t.Fatalf("expected only the delete, got %s", message)
+	}
+}
+
+func TestEventFeedWebSocketOrigin(t *testing.T) {
+	server := httptest.NewServer(NewRouter(testApp, middleware.NewCorsPolicy("https://*.example.com")))
+	defer server.Close()
+	feed := "ws" + strings.TrimPrefix(server.URL, "http") + "/events"
+
+	for _, origin := range []string{"", server.URL, "https://cook.example.com"} {
+		conn, _, err := websocket.DefaultDialer.Dial(feed, http.Header{"Origin": {origin}})
+		if err != nil {
+			t.Fatalf("expected origin %q to be let in, got %v", origin, err)
+		}
+		conn.Close()
+	}
+	_, response, err := websocket.DefaultDialer.Dial(feed, http.Header{"Origin": {"https://evil.test"}})
+	if err == nil || response == nil || response.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected status %d for a foreign origin, got %v", http.StatusForbidden, err)
+	}
+}
+
+func TestEventFeedCommittedOrder(t *testing.T) {
+	defer clearDatabase(testApp)
+	server := httptest.NewServer(testRouter())
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	next := openEventStream(t, ctx, server.URL+"/events", "")
+
+	// An event logged but not yet published, as if still committing, is held
+	// back rather than skipped once a later event goes out
+	recipe := postTestRecipe(t, testApp, models.Recipe{Name: "Slow soup", Difficulty: 1})
+	pending := models.Event{Type: events.RecipeUpdated, RecipeID: recipe.RecipeID, Data: "{}"}
+	testApp.eventMu.Lock()
+	testApp.Repo.DB.Create(&pending)
+	testApp.eventMu.Unlock()
+	if _, event := next(); event.Type != events.RecipeCreated {
+		t.Fatalf("expected the create first, got %+v", event)
+	}
+
+	postTestRecipe(t, testApp, models.Recipe{Name: "Quick soup", Difficulty: 1})
+	if _, event := next(); event.EventID != pending.EventID {
+		t.Fatalf("expected the held event %d next, got %+v", pending.EventID, event)
+	}
+	if _, event := next(); event.Type != events.RecipeCreated || event.EventID <= pending.EventID {
+		t.Fatalf("expected the second create last, got %+v", event)
+	}
+}
+
+func TestRestoreRecipe(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+
+	recipe := postTestRecipe(t, testApp, createTestRecipe(t, testApp, true))
+	path := fmt.Sprintf("/v1/recipes/%d/restore", recipe.RecipeID)
+	if w := serveJSON(t, router, http.MethodPost, path, nil); w.Code != http.StatusConflict {
+		t.Fatalf("expected status %d for a recipe that exists, got %d", http.StatusConflict, w.Code)
+	}
+	if w := serveJSON(t, router, http.MethodPost, "/v1/recipes/999999/restore", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d for a recipe never deleted, got %d", http.StatusNotFound, w.Code)
+	}
+
+	serveJSON(t, router, http.MethodDelete, fmt.Sprintf("/recipe/id/%d", recipe.RecipeID), nil)
+	w := serveJSON(t, router, http.MethodPost, path, nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	restored, err := testApp.loadRecipe(context.Background(), recipe.RecipeID)
+	if err != nil || restored.Name != recipe.Name || len(restored.Ingredients) != len(recipe.Ingredients) ||
+		len(restored.Instructions) != len(recipe.Instructions) {
+		t.Fatalf("expected the recipe back under its ID, got %+v (%v)", restored, err)
+	}
+	var last models.Event
+	testApp.Repo.DB.Order("event_id DESC").First(&last)
+	if last.Type != events.RecipeRestored || last.RecipeID != recipe.RecipeID {
+		t.Fatalf("expected a restored event, got %+v", last)
+	}
+}
+
+func TestPruneEvents(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+
+	hook := addTestWebhook(t, router, models.Webhook{UserID: "cook", URL: "https://example.com/hook", Events: models.StringList{events.RecipeDeleted}})
+	recipe := postTestRecipe(t, testApp, models.Recipe{Name: "Soup", Difficulty: 1, UserID: "cook"})
+	serveJSON(t, router, http.MethodDelete, fmt.Sprintf("/recipe/id/%d", recipe.RecipeID), nil)
+	postTestRecipe(t, testApp, models.Recipe{Name: "Stew", Difficulty: 1, UserID: "cook"})
+
+	// Age the soup's events; its delete still has a pending delivery
+	testApp.Repo.DB.Model(&models.Event{}).Where("recipe_id = ?", recipe.RecipeID).
+		Update("created_at", time.Now().Add(-48*time.Hour))
+	pruned, err := testApp.pruneEvents(time.Now().Add(-24 * time.Hour))
+	if err != nil || pruned != 1 {
+		t.Fatalf("expected the old create pruned, got %d (%v)", pruned, err)
+	}
+
+	// Once delivered, the old delete goes with its delivery
+	testApp.Repo.DB.Model(&models.WebhookDelivery{}).Where("webhook_id = ?", hook.WebhookID).
+		Update("status", models.DeliverySucceeded)
+	if pruned, err = testApp.pruneEvents(time.Now().Add(-24 * time.Hour)); err != nil || pruned != 1 {
+		t.Fatalf("expected the delivered delete pruned, got %d (%v)", pruned, err)
+	}
+	var events, deliveries int64
+	testApp.Repo.DB.Model(&models.Event{}).Count(&events)
+	testApp.Repo.DB.Model(&models.WebhookDelivery{}).Count(&deliveries)
+	if events != 1 || deliveries != 0 {
+		t.Fatalf("expected only the recent event left, got %d events and %d deliveries", events, deliveries)
+	}
+}
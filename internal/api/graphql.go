@@ -0,0 +1,410 @@
+package api
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"gorm.io/gorm"
+
+	"recipe-api/internal/middleware"
+	"recipe-api/internal/models"
+)
+
+//go:embed schema.graphql
+var graphqlSDL string
+
+// Limits applied when the App leaves them unset
+const (
+	defaultGraphQLDepth      = 8
+	defaultGraphQLComplexity = 1000
+)
+
+// Most recipes or ingredients a list field returns
+const graphqlMaxLimit = 100
+
+// Size assumed for a list field without a limit when estimating cost
+const graphqlListSize = 10
+
+// Resolvers run at once, enough for the items of a full page to be loaded
+// in one batch
+const graphqlParallelism = 4 * graphqlMaxLimit
+
+// Largest request body read, checked before the query is parsed
+const graphqlMaxBody = 1 << 20
+
+// Run a GraphQL operation: POST {"query", "operationName", "variables"}, or
+// GET with the same as query parameters (variables as JSON) for queries only
+func (app *App) serveGraphQL(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Query         string         `json:"query"`
+		OperationName string         `json:"operationName"`
+		Variables     map[string]any `json:"variables"`
+	}
+	if r.Method == http.MethodGet {
+		query := r.URL.Query()
+		request.Query, request.OperationName = query.Get("query"), query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &request.Variables); err != nil {
+				http.Error(w, "variables must be a JSON object", http.StatusBadRequest)
+				return
+			}
+		}
+	} else {
+		r.Body = http.MaxBytesReader(w, r.Body, graphqlMaxBody)
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, fmt.Sprintf("request body must be at most %d bytes", graphqlMaxBody), http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+			return
+		}
+	}
+	if strings.TrimSpace(request.Query) == "" {
+		http.Error(w, "query is required", http.StatusBadRequest)
+		return
+	}
+
+	ctx := withGraphQLLoaders(r.Context(), app.Repo.DB)
+	result, mutation := app.runGraphQL(ctx, request.Query, request.OperationName, request.Variables, r.Method == http.MethodGet)
+	if !mutation {
+		// Only mutations change what cached reads show
+		middleware.KeepCache(r)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// Check an operation against the limits, then run it, reporting whether a
+// mutation ran
+func (app *App) runGraphQL(ctx context.Context, query, operationName string, variables map[string]any, queryOnly bool) (*graphql.Response, bool) {
+	schema := app.graphqlSchema()
+	// Syntax, types and depth
+	if errs := schema.exec.ValidateWithVariables(query, variables); len(errs) > 0 {
+		return &graphql.Response{Errors: errs}, false
+	}
+
+	doc, errs := gqlparser.LoadQuery(schema.types, query)
+	if len(errs) > 0 {
+		response := &graphql.Response{}
+		for _, err := range errs {
+			response.Errors = append(response.Errors, &gqlerrors.QueryError{Message: err.Message})
+		}
+		return response, false
+	}
+	// Without a match, Exec reports the missing operation
+	operation := doc.Operations.ForName(operationName)
+	if operation == nil {
+		return schema.exec.Exec(ctx, query, operationName, variables), false
+	}
+	if queryOnly && operation.Operation != ast.Query {
+		return graphqlFailure("Mutations are not allowed here; send them with POST."), false
+	}
+	limit := cmp.Or(app.GraphQLMaxComplexity, defaultGraphQLComplexity)
+	if complexity := selectionCost(operation.SelectionSet, variables); complexity > limit {
+		return graphqlFailure("Query complexity %d exceeds the limit of %d.", complexity, limit), false
+	}
+
+	return schema.exec.Exec(ctx, query, operationName, variables), operation.Operation == ast.Mutation
+}
+
+func graphqlFailure(format string, args ...any) *graphql.Response {
+	return &graphql.Response{Errors: []*gqlerrors.QueryError{gqlerrors.Errorf(format, args...)}}
+}
+
+// Estimated cost of a selection: each field costs 1, and list fields
+// multiply their children by their limit, or by graphqlListSize without one
+func selectionCost(selections ast.SelectionSet, variables map[string]any) int {
+	cost := 0
+	for _, selection := range selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			children := selectionCost(selection.SelectionSet, variables)
+			if selection.Definition != nil && selection.Definition.Type.Elem != nil {
+				size := graphqlListSize
+				switch limit := selection.ArgumentMap(variables)["limit"].(type) {
+				case int64:
+					size = cmp.Or(max(int(limit), 0), size)
+				case float64: // from JSON variables
+					size = cmp.Or(max(int(limit), 0), size)
+				}
+				children *= size
+			}
+			cost += 1 + children
+		case *ast.FragmentSpread:
+			cost += selectionCost(selection.Definition.SelectionSet, variables)
+		case *ast.InlineFragment:
+			cost += selectionCost(selection.SelectionSet, variables)
+		}
+	}
+	return cost
+}
+
+// The schema in SDL, for clients and code generators
+func (app *App) getGraphQLSchema(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprint(w, graphqlSDL)
+}
+
+// Schema parsed twice: by the executor, bound to the resolvers, and by the
+// parser that estimates an operation's cost before it runs
+type graphqlSchema struct {
+	exec  *graphql.Schema
+	types *ast.Schema
+}
+
+func (app *App) graphqlSchema() *graphqlSchema {
+	app.schemaOnce.Do(func() {
+		app.schema = &graphqlSchema{
+			exec: graphql.MustParseSchema(graphqlSDL, &graphqlResolver{app: app},
+				graphql.UseStringDescriptions(),
+				graphql.DisableIntrospection(),
+				graphql.MaxDepth(cmp.Or(app.GraphQLMaxDepth, defaultGraphQLDepth)),
+				graphql.MaxParallelism(graphqlParallelism),
+			),
+			types: gqlparser.MustLoadSchema(&ast.Source{Name: "schema.graphql", Input: graphqlSDL}),
+		}
+	})
+	return app.schema
+}
+
+// Numeric ID argument
+func idArg(id graphql.ID) (int, error) {
+	value, err := strconv.Atoi(string(id))
+	if err != nil {
+		return 0, fmt.Errorf("invalid id %q", id)
+	}
+	return value, nil
+}
+
+// limit and offset arguments of a list field
+func page(limit, offset int32) (int, int, error) {
+	if limit < 1 || limit > graphqlMaxLimit || offset < 0 {
+		return 0, 0, fmt.Errorf("limit must be between 1 and %d and offset not negative", graphqlMaxLimit)
+	}
+	return int(limit), int(offset), nil
+}
+
+// Recipe payload of the addRecipe and updateRecipe mutations
+type recipeInput struct {
+	Name         string
+	Difficulty   int32
+	Description  *string
+	Servings     *int32
+	UserID       string
+	Ingredients  *[]recipeIngredientInput
+	Instructions *[]instructionInput
+	Tags         *[]tagInput
+}
+
+type recipeIngredientInput struct {
+	Ingredient string
+	Amount     *float64
+	Unit       *string
+}
+
+type instructionInput struct {
+	StepNumber int32
+	StepText   string
+	StepTime   *int32
+	Phase      *string
+	Notes      *string
+}
+
+type tagInput struct {
+	Name string
+	Kind *string
+}
+
+// Recipe model from a RecipeInput, lists left nil when not given
+func recipeFromInput(input recipeInput) models.Recipe {
+	recipe := models.Recipe{
+		Name:        input.Name,
+		Difficulty:  int(input.Difficulty),
+		Description: input.Description,
+		Servings:    intPtr(input.Servings),
+		UserID:      input.UserID,
+	}
+	if input.Ingredients != nil {
+		recipe.Ingredients = []models.RecipeIngredient{}
+		for _, item := range *input.Ingredients {
+			ri := models.RecipeIngredient{Ingredient: &models.Ingredient{Label: item.Ingredient}}
+			if item.Amount != nil {
+				amount := float32(*item.Amount)
+				ri.Amount = &amount
+			}
+			if item.Unit != nil {
+				ri.Unit = &models.Unit{Label: *item.Unit}
+			}
+			recipe.Ingredients = append(recipe.Ingredients, ri)
+		}
+	}
+	if input.Instructions != nil {
+		recipe.Instructions = []models.Instruction{}
+		for _, step := range *input.Instructions {
+			recipe.Instructions = append(recipe.Instructions, models.Instruction{
+				StepNumber: int(step.StepNumber),
+				StepText:   step.StepText,
+				Duration:   intPtr(step.StepTime),
+				Phase:      step.Phase,
+				Notes:      step.Notes,
+			})
+		}
+	}
+	if input.Tags != nil {
+		recipe.Tags = []models.Tag{}
+		for _, tag := range *input.Tags {
+			kind := ""
+			if tag.Kind != nil {
+				kind = *tag.Kind
+			}
+			recipe.Tags = append(recipe.Tags, models.Tag{Name: tag.Name, Kind: kind})
+		}
+	}
+	return recipe
+}
+
+// Error a mutation reports for a failed write, logging storage failures
+func (app *App) mutationError(err error) error {
+	var invalid invalidRecipe
+	switch {
+	case errors.As(err, &invalid):
+		return err
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return errors.New("recipe not found")
+	}
+	app.Logger.Println("Transaction Failed:", err)
+	return err
+}
+
+// Root of the GraphQL resolvers, answering Query and Mutation fields
+type graphqlResolver struct {
+	app *App
+}
+
+func (root *graphqlResolver) Recipe(ctx context.Context, args struct{ ID graphql.ID }) (*recipeResolver, error) {
+	recipeID, err := idArg(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	recipe, err := loadersFrom(ctx).recipes.Load(ctx, recipeID)()
+	return newRecipeResolver(recipe), err
+}
+
+func (root *graphqlResolver) Recipes(ctx context.Context, args struct {
+	Search     *string
+	Difficulty *int32
+	UserID     *string
+	Limit      int32
+	Offset     int32
+}) ([]*recipeResolver, error) {
+	limit, offset, err := page(args.Limit, args.Offset)
+	if err != nil {
+		return nil, err
+	}
+	db := root.app.Repo.DB.WithContext(ctx)
+	if args.Search != nil && *args.Search != "" {
+		db = db.Where("LOWER(name) LIKE ?", "%"+strings.ToLower(*args.Search)+"%")
+	}
+	if args.Difficulty != nil {
+		db = db.Where("difficulty = ?", *args.Difficulty)
+	}
+	if args.UserID != nil {
+		db = db.Where("user_id = ?", *args.UserID)
+	}
+	var recipes []models.Recipe
+	err = db.Order("name ASC").Limit(limit).Offset(offset).Find(&recipes).Error
+	return resolveAll(recipes, newRecipeResolver), err
+}
+
+func (root *graphqlResolver) Ingredient(ctx context.Context, args struct{ ID graphql.ID }) (*ingredientResolver, error) {
+	ingredientID, err := idArg(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	ingredient, err := loadersFrom(ctx).ingredients.Load(ctx, ingredientID)()
+	return newIngredientResolver(ingredient), err
+}
+
+func (root *graphqlResolver) Ingredients(ctx context.Context, args struct {
+	Search *string
+	Limit  int32
+	Offset int32
+}) ([]*ingredientResolver, error) {
+	limit, offset, err := page(args.Limit, args.Offset)
+	if err != nil {
+		return nil, err
+	}
+	db := root.app.Repo.DB.WithContext(ctx)
+	if args.Search != nil && *args.Search != "" {
+		db = db.Where("LOWER(label) LIKE ?", "%"+strings.ToLower(*args.Search)+"%")
+	}
+	var ingredients []models.Ingredient
+	err = db.Order("label ASC").Limit(limit).Offset(offset).Find(&ingredients).Error
+	return resolveAll(ingredients, newIngredientResolver), err
+}
+
+func (root *graphqlResolver) Unit(ctx context.Context, args struct{ ID graphql.ID }) (*unitResolver, error) {
+	unitID, err := idArg(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	unit, err := loadersFrom(ctx).units.Load(ctx, unitID)()
+	return newUnitResolver(unit), err
+}
+
+func (root *graphqlResolver) Units(ctx context.Context) ([]*unitResolver, error) {
+	var units []models.Unit
+	err := root.app.Repo.DB.WithContext(ctx).Order("label ASC").Find(&units).Error
+	return resolveAll(units, newUnitResolver), err
+}
+
+func (root *graphqlResolver) AddRecipe(ctx context.Context, args struct{ Input recipeInput }) (*recipeResolver, error) {
+	created, err := root.app.createRecipe(recipeFromInput(args.Input))
+	if err != nil {
+		return nil, root.app.mutationError(err)
+	}
+	return newRecipeResolver(&created), nil
+}
+
+func (root *graphqlResolver) UpdateRecipe(ctx context.Context, args struct {
+	ID    graphql.ID
+	Input recipeInput
+}) (*recipeResolver, error) {
+	recipeID, err := idArg(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := root.app.saveRecipe(recipeID, recipeFromInput(args.Input)); err != nil {
+		return nil, root.app.mutationError(err)
+	}
+	// Read back as stored, rather than the payload
+	recipes := loadersFrom(ctx).recipes
+	recipes.Clear(ctx, recipeID)
+	recipe, err := recipes.Load(ctx, recipeID)()
+	return newRecipeResolver(recipe), err
+}
+
+func (root *graphqlResolver) DeleteRecipe(ctx context.Context, args struct{ ID graphql.ID }) (graphql.ID, error) {
+	recipeID, err := idArg(args.ID)
+	if err != nil {
+		return "", err
+	}
+	if err := root.app.removeRecipe(recipeID); err != nil {
+		return "", root.app.mutationError(err)
+	}
+	return args.ID, nil
+}
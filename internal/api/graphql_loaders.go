@@ -0,0 +1,134 @@
+package api
+
+import (
+	"context"
+	"slices"
+
+	"github.com/graph-gophers/dataloader/v7"
+	"gorm.io/gorm"
+
+	"recipe-api/internal/models"
+)
+
+// Per-request loaders behind the GraphQL resolvers, so a relation costs one
+// query per depth of the query rather than one per parent. Resolvers run
+// concurrently and each loader batches the keys asked for within its wait.
+type graphqlLoaders struct {
+	recipes           *dataloader.Loader[int, *models.Recipe]
+	recipeIngredients *dataloader.Loader[int, []models.RecipeIngredient] // by recipe
+	instructions      *dataloader.Loader[int, []models.Instruction]      // by recipe
+	tags              *dataloader.Loader[int, []models.Tag]              // by recipe
+	ingredients       *dataloader.Loader[int, *models.Ingredient]
+	units             *dataloader.Loader[int, *models.Unit]
+	ingredientRecipes *dataloader.Loader[int, []models.Recipe] // by ingredient
+}
+
+type graphqlLoadersKey struct{}
+
+func withGraphQLLoaders(ctx context.Context, db *gorm.DB) context.Context {
+	return context.WithValue(ctx, graphqlLoadersKey{}, newGraphQLLoaders(db))
+}
+
+func loadersFrom(ctx context.Context) *graphqlLoaders {
+	return ctx.Value(graphqlLoadersKey{}).(*graphqlLoaders)
+}
+
+func newGraphQLLoaders(db *gorm.DB) *graphqlLoaders {
+	return &graphqlLoaders{
+		recipes: batched(func(ctx context.Context, ids []int) (map[int]*models.Recipe, error) {
+			var rows []models.Recipe
+			err := db.WithContext(ctx).Where("recipe_id IN ?", ids).Find(&rows).Error
+			return byKey(rows, func(recipe models.Recipe) int { return recipe.RecipeID }), err
+		}),
+		recipeIngredients: batched(func(ctx context.Context, ids []int) (map[int][]models.RecipeIngredient, error) {
+			var rows []models.RecipeIngredient
+			err := db.WithContext(ctx).Where("recipe_id IN ?", ids).Order("ingredient_id ASC").Find(&rows).Error
+			return groupByKey(rows, func(ri models.RecipeIngredient) int { return ri.RecipeID }), err
+		}),
+		instructions: batched(func(ctx context.Context, ids []int) (map[int][]models.Instruction, error) {
+			var rows []models.Instruction
+			err := db.WithContext(ctx).Where("recipe_id IN ?", ids).Order("step_number ASC").Find(&rows).Error
+			return groupByKey(rows, func(instruction models.Instruction) int { return instruction.RecipeID }), err
+		}),
+		tags: batched(func(ctx context.Context, ids []int) (map[int][]models.Tag, error) {
+			var rows []struct {
+				models.Tag
+				RecipeID int
+			}
+			err := db.WithContext(ctx).Table("tags").Select("tags.*, recipe_tags.recipe_id").
+				Joins("JOIN recipe_tags ON recipe_tags.tag_id = tags.tag_id").
+				Where("recipe_tags.recipe_id IN ?", ids).Order("kind ASC").Order("name ASC").Scan(&rows).Error
+			tags := make(map[int][]models.Tag)
+			for _, row := range rows {
+				tags[row.RecipeID] = append(tags[row.RecipeID], row.Tag)
+			}
+			return tags, err
+		}),
+		ingredients: batched(func(ctx context.Context, ids []int) (map[int]*models.Ingredient, error) {
+			var rows []models.Ingredient
+			err := db.WithContext(ctx).Where("ingredient_id IN ?", ids).Find(&rows).Error
+			return byKey(rows, func(ingredient models.Ingredient) int { return ingredient.IngredientID }), err
+		}),
+		units: batched(func(ctx context.Context, ids []int) (map[int]*models.Unit, error) {
+			var rows []models.Unit
+			err := db.WithContext(ctx).Where("unit_id IN ?", ids).Find(&rows).Error
+			return byKey(rows, func(unit models.Unit) int { return unit.UnitID }), err
+		}),
+		ingredientRecipes: batched(func(ctx context.Context, ids []int) (map[int][]models.Recipe, error) {
+			var links []models.RecipeIngredient
+			err := db.WithContext(ctx).Distinct("recipe_id", "ingredient_id").Where("ingredient_id IN ?", ids).Find(&links).Error
+			if err != nil || len(links) == 0 {
+				return nil, err
+			}
+			recipeIDs := make([]int, len(links))
+			for i, link := range links {
+				recipeIDs[i] = link.RecipeID
+			}
+			var rows []models.Recipe
+			if err := db.WithContext(ctx).Where("recipe_id IN ?", recipeIDs).Order("name ASC").Find(&rows).Error; err != nil {
+				return nil, err
+			}
+
+			recipes := make(map[int][]models.Recipe)
+			for _, recipe := range rows {
+				for _, link := range links {
+					if link.RecipeID == recipe.RecipeID && !slices.ContainsFunc(recipes[link.IngredientID], func(r models.Recipe) bool { return r.RecipeID == recipe.RecipeID }) {
+						recipes[link.IngredientID] = append(recipes[link.IngredientID], recipe)
+					}
+				}
+			}
+			return recipes, nil
+		}),
+	}
+}
+
+// Loader over a fetch by IDs, where IDs missing from the map load as the
+// zero value
+func batched[V any](fetch func(ctx context.Context, ids []int) (map[int]V, error)) *dataloader.Loader[int, V] {
+	return dataloader.NewBatchedLoader(func(ctx context.Context, ids []int) []*dataloader.Result[V] {
+		values, err := fetch(ctx, ids)
+		results := make([]*dataloader.Result[V], len(ids))
+		for i, id := range ids {
+			results[i] = &dataloader.Result[V]{Data: values[id], Error: err}
+		}
+		return results
+	})
+}
+
+// Index rows by a key, pointing into the slice
+func byKey[T any](rows []T, key func(T) int) map[int]*T {
+	indexed := make(map[int]*T, len(rows))
+	for i := range rows {
+		indexed[key(rows[i])] = &rows[i]
+	}
+	return indexed
+}
+
+// Group rows by a key, keeping their order
+func groupByKey[T any](rows []T, key func(T) int) map[int][]T {
+	grouped := make(map[int][]T)
+	for _, row := range rows {
+		grouped[key(row)] = append(grouped[key(row)], row)
+	}
+	return grouped
+}
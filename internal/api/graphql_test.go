@@ -0,0 +1,241 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"recipe-api/internal/models"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+type graphqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func postGraphQL(t *testing.T, router http.Handler, query string, variables map[string]any, data any) graphqlResponse {
+	t.Helper()
+	w := serveJSON(t, router, http.MethodPost, "/graphql", map[string]any{"query": query, "variables": variables})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var response graphqlResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	if data != nil && len(response.Data) > 0 {
+		if err := json.Unmarshal(response.Data, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return response
+}
+
+// Count the SELECTs run while f runs, through Find or Scan
+func countQueries(t *testing.T, f func()) int64 {
+	t.Helper()
+	var count atomic.Int64
+	counter := func(*gorm.DB) { count.Add(1) }
+	queries, rows := testApp.Repo.DB.Callback().Query(), testApp.Repo.DB.Callback().Row()
+	if err := queries.After("gorm:query").Register("test:count", counter); err != nil {
+		t.Fatal(err)
+	}
+	defer queries.Remove("test:count")
+	if err := rows.After("gorm:row").Register("test:count", counter); err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Remove("test:count")
+	f()
+	return count.Load()
+}
+
+func TestGraphQLQueriesBatch(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+
+	for i := 1; i <= 4; i++ {
+		postTestRecipe(t, testApp, models.Recipe{Name: fmt.Sprintf("Soup %d", i), Difficulty: i, UserID: "cook",
+			Ingredients: []models.RecipeIngredient{
+				{Amount: ToPtr(float32(0.33)), Ingredient: &models.Ingredient{Label: "Leek"}, Unit: &models.Unit{Label: "bunch"}},
+				{Ingredient: &models.Ingredient{Label: fmt.Sprintf("Stock %d", i)}},
+			},
+			Instructions: []models.Instruction{{StepNumber: 2, StepText: "simmer"}, {StepNumber: 1, StepText: "chop"}},
+			Tags:         []models.Tag{{Name: "warming"}},
+		})
+	}
+
+	query := `{
+		recipes(search: "soup", limit: 3) {
+			name
+			ingredients { amount unit { label } ingredient { label recipes(limit: 2) { name } } }
+			instructions { stepNumber stepText }
+			tags { name kind }
+		}
+	}`
+	var data struct {
+		Recipes []struct {
+			Name        string
+			Ingredients []struct {
+				Amount     *float64
+				Unit       *struct{ Label string }
+				Ingredient struct {
+					Label   string
+					Recipes []struct{ Name string }
+				}
+			}
+			Instructions []struct {
+				StepNumber int
+				StepText   string
+			}
+			Tags []struct{ Name, Kind string }
+		}
+	}
+	var response graphqlResponse
+	queries := countQueries(t, func() {
+		response = postGraphQL(t, router, query, nil, &data)
+	})
+	if len(response.Errors) > 0 {
+		t.Fatalf("unexpected errors %+v", response.Errors)
+	}
+
+	// recipes, their ingredients, instructions and tags, then ingredients and
+	// units, then the ingredients' recipes (links and rows)
+	if queries != 8 {
+		t.Fatalf("expected 8 queries whatever the number of recipes, got %d", queries)
+	}
+	if len(data.Recipes) != 3 || data.Recipes[0].Name != "Soup 1" {
+		t.Fatalf("expected three soups by name, got %+v", data.Recipes)
+	}
+	first := data.Recipes[0]
+	if len(first.Ingredients) != 2 || *first.Ingredients[0].Amount != 0.33 || first.Ingredients[0].Unit.Label != "bunch" ||
+		first.Ingredients[1].Unit != nil || first.Ingredients[0].Ingredient.Label != "Leek" {
+		t.Fatalf("unexpected ingredients %+v", first.Ingredients)
+	}
+	if len(first.Ingredients[0].Ingredient.Recipes) != 2 || len(first.Ingredients[1].Ingredient.Recipes) != 1 {
+		t.Fatalf("expected the leek in two recipes within the limit and the stock in one, got %+v", first.Ingredients)
+	}
+	if first.Instructions[0].StepText != "chop" || len(first.Tags) != 1 || first.Tags[0].Kind != "tag" {
+		t.Fatalf("unexpected instructions or tags %+v", first)
+	}
+}
+
+func TestGraphQLMutations(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+
+	add := `mutation Add($input: RecipeInput!) {
+		addRecipe(input: $input) { id name ingredients { amount ingredient { label } unit { label } } instructions { stepText } }
+	}`
+	input := map[string]any{
+		"name": "Pancakes", "difficulty": 2, "userID": "cook",
+		"ingredients":  []any{map[string]any{"ingredient": "Spelt flour", "amount": 2, "unit": "scoop"}},
+		"instructions": []any{map[string]any{"stepNumber": 1, "stepText": "whisk", "phase": "prep"}},
+	}
+	var added struct {
+		AddRecipe struct {
+			ID          string
+			Name        string
+			Ingredients []struct {
+				Amount     float64
+				Ingredient struct{ Label string }
+				Unit       struct{ Label string }
+			}
+		}
+	}
+	if response := postGraphQL(t, router, add, map[string]any{"input": input}, &added); len(response.Errors) > 0 {
+		t.Fatalf("unexpected errors %+v", response.Errors)
+	}
+	recipe := added.AddRecipe
+	if recipe.Name != "Pancakes" || len(recipe.Ingredients) != 1 || recipe.Ingredients[0].Ingredient.Label != "Spelt flour" ||
+		recipe.Ingredients[0].Unit.Label != "scoop" || recipe.Ingredients[0].Amount != 2 {
+		t.Fatalf("unexpected recipe %+v", recipe)
+	}
+
+	// Validation errors come back as GraphQL errors
+	input["name"] = "Waffles"
+	input["instructions"] = []any{map[string]any{"stepNumber": 1, "stepText": "heat", "phase": "bake"}}
+	if response := postGraphQL(t, router, add, map[string]any{"input": input}, nil); len(response.Errors) != 1 ||
+		!strings.Contains(response.Errors[0].Message, "phase") {
+		t.Fatalf("expected the phase to be rejected, got %+v", response.Errors)
+	}
+
+	update := `mutation($id: ID!, $input: RecipeInput!) { updateRecipe(id: $id, input: $input) { name difficulty instructions { stepText } } }`
+	input["name"], input["difficulty"] = "Crepes", 3
+	input["instructions"] = []any{map[string]any{"stepNumber": 1, "stepText": "swirl"}}
+	var updated struct {
+		UpdateRecipe struct {
+			Name         string
+			Difficulty   int
+			Instructions []struct{ StepText string }
+		}
+	}
+	if response := postGraphQL(t, router, update, map[string]any{"id": recipe.ID, "input": input}, &updated); len(response.Errors) > 0 {
+		t.Fatalf("unexpected errors %+v", response.Errors)
+	}
+	if updated.UpdateRecipe.Name != "Crepes" || updated.UpdateRecipe.Difficulty != 3 ||
+		len(updated.UpdateRecipe.Instructions) != 1 || updated.UpdateRecipe.Instructions[0].StepText != "swirl" {
+		t.Fatalf("unexpected update %+v", updated.UpdateRecipe)
+	}
+
+	var deleted struct{ DeleteRecipe string }
+	postGraphQL(t, router, `mutation($id: ID!) { deleteRecipe(id: $id) }`, map[string]any{"id": recipe.ID}, &deleted)
+	if deleted.DeleteRecipe != recipe.ID {
+		t.Fatalf("expected recipe %s deleted, got %+v", recipe.ID, deleted)
+	}
+	var events int64
+	testApp.Repo.DB.Model(&models.Event{}).Count(&events)
+	if events != 3 {
+		t.Fatalf("expected mutations to log created, updated and deleted events, got %d", events)
+	}
+	response := postGraphQL(t, router, `mutation { deleteRecipe(id: 999) }`, nil, nil)
+	if len(response.Errors) != 1 || response.Errors[0].Message != "recipe not found" {
+		t.Fatalf("expected recipe not found, got %+v", response.Errors)
+	}
+}
+
+func TestGraphQLLimitsAndTransport(t *testing.T) {
+	router := testRouter()
+
+	deep := `{ recipes { ingredients { recipe { ingredients { recipe { ingredients { recipe { ingredients { ingredient { label } } } } } } } } } }`
+	response := postGraphQL(t, router, deep, nil, nil)
+	if len(response.Errors) != 1 || response.Errors[0].Message != `Field "ingredient" has depth 9 that exceeds max depth 8` {
+		t.Fatalf("expected the depth limit, got %+v", response.Errors)
+	}
+	wide := `{ recipes(limit: 100) { ingredients { ingredient { recipes(limit: 100) { name } } } } }`
+	response = postGraphQL(t, router, wide, nil, nil)
+	if len(response.Errors) != 1 || !strings.HasPrefix(response.Errors[0].Message, "Query complexity") {
+		t.Fatalf("expected the complexity limit, got %+v", response.Errors)
+	}
+	// Limits given as variables and fields spread from fragments count too
+	wide = `query($limit: Int) { recipes(limit: $limit) { ...Uses } } fragment Uses on Recipe { ingredients { ingredient { recipes(limit: $limit) { name } } } }`
+	response = postGraphQL(t, router, wide, map[string]any{"limit": 100}, nil)
+	if len(response.Errors) != 1 || response.Errors[0].Message != "Query complexity 102101 exceeds the limit of 1000." {
+		t.Fatalf("expected the complexity limit, got %+v", response.Errors)
+	}
+
+	w := serveJSON(t, router, http.MethodGet, "/graphql?query="+url.QueryEscape(`{ units { label } }`), nil)
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Body.String(), `{"data":{"units":[`) {
+		t.Fatalf("expected the units over GET, got %d: %s", w.Code, w.Body.String())
+	}
+	w = serveJSON(t, router, http.MethodGet, "/graphql?query="+url.QueryEscape(`mutation { deleteRecipe(id: 1) }`), nil)
+	if !strings.Contains(w.Body.String(), "Mutations are not allowed here") {
+		t.Fatalf("expected mutations refused over GET, got %s", w.Body.String())
+	}
+	if w := serveJSON(t, router, http.MethodPost, "/graphql", map[string]any{}); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d without a query, got %d", http.StatusBadRequest, w.Code)
+	}
+	huge := map[string]any{"query": "{ units { label } }" + strings.Repeat(" ", graphqlMaxBody)}
+	if w := serveJSON(t, router, http.MethodPost, "/graphql", huge); w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected status %d for an oversized body, got %d", http.StatusRequestEntityTooLarge, w.Code)
+	}
+
+	w = serveJSON(t, router, http.MethodGet, "/graphql/schema", nil)
+	if !strings.Contains(w.Body.String(), "addRecipe(input: RecipeInput!): Recipe!") {
+		t.Fatalf("expected the SDL to list addRecipe, got %s", w.Body.String())
+	}
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/graph-gophers/graphql-go"
+
+	"recipe-api/internal/models"
+)
+
+// Resolvers for the GraphQL object types, one per model. Relations go
+// through the request's loaders.
+
+// Resolver for each row, in order
+func resolveAll[T, R any](rows []T, resolver func(*T) *R) []*R {
+	resolved := make([]*R, len(rows))
+	for i := range rows {
+		resolved[i] = resolver(&rows[i])
+	}
+	return resolved
+}
+
+func modelID(id int) graphql.ID {
+	return graphql.ID(strconv.Itoa(id))
+}
+
+type recipeResolver struct {
+	recipe *models.Recipe
+}
+
+// Resolver for a recipe, nil for none
+func newRecipeResolver(recipe *models.Recipe) *recipeResolver {
+	if recipe == nil {
+		return nil
+	}
+	return &recipeResolver{recipe: recipe}
+}
+
+func (r *recipeResolver) ID() graphql.ID         { return modelID(r.recipe.RecipeID) }
+func (r *recipeResolver) Name() string           { return r.recipe.Name }
+func (r *recipeResolver) Description() *string   { return r.recipe.Description }
+func (r *recipeResolver) Difficulty() int32      { return int32(r.recipe.Difficulty) }
+func (r *recipeResolver) Servings() *int32       { return int32Ptr(r.recipe.Servings) }
+func (r *recipeResolver) UserID() string         { return r.recipe.UserID }
+func (r *recipeResolver) RatingAverage() float64 { return r.recipe.RatingAverage }
+func (r *recipeResolver) RatingCount() int32     { return int32(r.recipe.RatingCount) }
+
+func (r *recipeResolver) Ingredients(ctx context.Context) ([]*recipeIngredientResolver, error) {
+	ingredients, err := loadersFrom(ctx).recipeIngredients.Load(ctx, r.recipe.RecipeID)()
+	return resolveAll(ingredients, newRecipeIngredientResolver), err
+}
+
+func (r *recipeResolver) Instructions(ctx context.Context) ([]*instructionResolver, error) {
+	instructions, err := loadersFrom(ctx).instructions.Load(ctx, r.recipe.RecipeID)()
+	return resolveAll(instructions, newInstructionResolver), err
+}
+
+func (r *recipeResolver) Tags(ctx context.Context) ([]*tagResolver, error) {
+	tags, err := loadersFrom(ctx).tags.Load(ctx, r.recipe.RecipeID)()
+	return resolveAll(tags, newTagResolver), err
+}
+
+type recipeIngredientResolver struct {
+	ri *models.RecipeIngredient
+}
+
+func newRecipeIngredientResolver(ri *models.RecipeIngredient) *recipeIngredientResolver {
+	return &recipeIngredientResolver{ri: ri}
+}
+
+func (r *recipeIngredientResolver) ID() graphql.ID { return modelID(r.ri.RecipeIngredientID) }
+
+func (r *recipeIngredientResolver) Amount() *float64 {
+	if r.ri.Amount == nil {
+		return nil
+	}
+	// Shortest decimal for the stored float32, e.g. 0.33 rather than 0.330000013
+	amount, _ := strconv.ParseFloat(strconv.FormatFloat(float64(*r.ri.Amount), 'f', -1, 32), 64)
+	return &amount
+}
+
+func (r *recipeIngredientResolver) Ingredient(ctx context.Context) (*ingredientResolver, error) {
+	ingredient, err := loadersFrom(ctx).ingredients.Load(ctx, r.ri.IngredientID)()
+	return newIngredientResolver(ingredient), err
+}
+
+func (r *recipeIngredientResolver) Unit(ctx context.Context) (*unitResolver, error) {
+	if r.ri.UnitID == nil {
+		return nil, nil
+	}
+	unit, err := loadersFrom(ctx).units.Load(ctx, *r.ri.UnitID)()
+	return newUnitResolver(unit), err
+}
+
+func (r *recipeIngredientResolver) Recipe(ctx context.Context) (*recipeResolver, error) {
+	recipe, err := loadersFrom(ctx).recipes.Load(ctx, r.ri.RecipeID)()
+	return newRecipeResolver(recipe), err
+}
+
+type ingredientResolver struct {
+	ingredient *models.Ingredient
+}
+
+// Resolver for an ingredient, nil for none
+func newIngredientResolver(ingredient *models.Ingredient) *ingredientResolver {
+	if ingredient == nil {
+		return nil
+	}
+	return &ingredientResolver{ingredient: ingredient}
+}
+
+func (r *ingredientResolver) ID() graphql.ID    { return modelID(r.ingredient.IngredientID) }
+func (r *ingredientResolver) Label() string     { return r.ingredient.Label }
+func (r *ingredientResolver) Category() *string { return r.ingredient.Category }
+func (r *ingredientResolver) Animal() *string   { return r.ingredient.Animal }
+func (r *ingredientResolver) DietChecked() bool { return r.ingredient.DietChecked }
+func (r *ingredientResolver) Allergens() []string {
+	return append([]string{}, r.ingredient.Allergens...)
+}
+
+func (r *ingredientResolver) Recipes(ctx context.Context, args struct{ Limit int32 }) ([]*recipeResolver, error) {
+	limit, _, err := page(args.Limit, 0)
+	if err != nil {
+		return nil, err
+	}
+	recipes, err := loadersFrom(ctx).ingredientRecipes.Load(ctx, r.ingredient.IngredientID)()
+	return resolveAll(recipes[:min(limit, len(recipes))], newRecipeResolver), err
+}
+
+type unitResolver struct {
+	unit *models.Unit
+}
+
+// Resolver for a unit, nil for none
+func newUnitResolver(unit *models.Unit) *unitResolver {
+	if unit == nil {
+		return nil
+	}
+	return &unitResolver{unit: unit}
+}
+
+func (r *unitResolver) ID() graphql.ID { return modelID(r.unit.UnitID) }
+func (r *unitResolver) Label() string  { return r.unit.Label }
+
+type instructionResolver struct {
+	instruction *models.Instruction
+}
+
+func newInstructionResolver(instruction *models.Instruction) *instructionResolver {
+	return &instructionResolver{instruction: instruction}
+}
+
+func (r *instructionResolver) ID() graphql.ID    { return modelID(r.instruction.InstructionID) }
+func (r *instructionResolver) StepNumber() int32 { return int32(r.instruction.StepNumber) }
+func (r *instructionResolver) StepText() string  { return r.instruction.StepText }
+func (r *instructionResolver) StepTime() *int32  { return int32Ptr(r.instruction.Duration) }
+func (r *instructionResolver) Phase() *string    { return r.instruction.Phase }
+func (r *instructionResolver) Notes() *string    { return r.instruction.Notes }
+
+type tagResolver struct {
+	tag *models.Tag
+}
+
+func newTagResolver(tag *models.Tag) *tagResolver {
+	return &tagResolver{tag: tag}
+}
+
+func (r *tagResolver) ID() graphql.ID { return modelID(r.tag.TagID) }
+func (r *tagResolver) Name() string   { return r.tag.Name }
+func (r *tagResolver) Kind() string   { return r.tag.Kind }
@@ -0,0 +1,256 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+
+	"recipe-api/internal/models"
+	"recipe-api/internal/recipepb"
+)
+
+// Recipes sent per database page while streaming a listing
+const grpcListPage = 100
+
+// gRPC server for the recipe service, with health checking and reflection
+func (app *App) NewGRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	server := grpc.NewServer(opts...)
+	recipepb.RegisterRecipeServiceServer(server, &recipeService{app: app})
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus(recipepb.RecipeService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	reflection.Register(server)
+	return server
+}
+
+type recipeService struct {
+	recipepb.UnimplementedRecipeServiceServer
+	app *App
+}
+
+func (s *recipeService) GetRecipe(ctx context.Context, req *recipepb.GetRecipeRequest) (*recipepb.Recipe, error) {
+	recipe, err := s.app.loadRecipe(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, s.grpcError(err)
+	}
+	s.app.decorateRecipe(&recipe)
+	return recipeToProto(recipe), nil
+}
+
+func (s *recipeService) ListRecipes(req *recipepb.ListRecipesRequest, stream grpc.ServerStreamingServer[recipepb.Recipe]) error {
+	filter, err := filterFromProto(req.GetFilter())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// Page through the listing so large catalogues aren't held in memory
+	ctx := stream.Context()
+	for offset := 0; ; offset += grpcListPage {
+		var recipes []models.Recipe
+		query := filter.order(filter.apply(preloadRecipe(s.app.Repo.DB.WithContext(ctx))), "").Order("recipes.recipe_id ASC")
+		if err := query.Limit(grpcListPage).Offset(offset).Find(&recipes).Error; err != nil {
+			return s.grpcError(err)
+		}
+		s.app.decorateRecipes(recipes)
+		for _, recipe := range recipes {
+			if err := stream.Send(recipeToProto(recipe)); err != nil {
+				return err
+			}
+		}
+		if len(recipes) < grpcListPage {
+			return nil
+		}
+	}
+}
+
+func (s *recipeService) CreateRecipe(ctx context.Context, req *recipepb.CreateRecipeRequest) (*recipepb.Recipe, error) {
+	created, err := s.app.createRecipe(recipeFromProto(req.GetRecipe()))
+	if err != nil {
+		return nil, s.grpcError(err)
+	}
+	return s.GetRecipe(ctx, &recipepb.GetRecipeRequest{Id: int32(created.RecipeID)})
+}
+
+func (s *recipeService) UpdateRecipe(ctx context.Context, req *recipepb.UpdateRecipeRequest) (*recipepb.Recipe, error) {
+	if _, err := s.app.saveRecipe(int(req.GetId()), recipeFromProto(req.GetRecipe())); err != nil {
+		return nil, s.grpcError(err)
+	}
+	return s.GetRecipe(ctx, &recipepb.GetRecipeRequest{Id: req.GetId()})
+}
+
+func (s *recipeService) DeleteRecipe(ctx context.Context, req *recipepb.DeleteRecipeRequest) (*recipepb.DeleteRecipeResponse, error) {
+	if err := s.app.removeRecipe(int(req.GetId())); err != nil {
+		return nil, s.grpcError(err)
+	}
+	return &recipepb.DeleteRecipeResponse{}, nil
+}
+
+func (s *recipeService) RandomRecipe(ctx context.Context, req *recipepb.RandomRecipeRequest) (*recipepb.Recipe, error) {
+	filter, err := filterFromProto(req.GetFilter())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	var recipe models.Recipe
+	query := filter.apply(randomRecipeQuery(s.app.Repo.DB.WithContext(ctx), int(req.GetMaxDifficulty()), nil))
+	if err := query.First(&recipe).Error; err != nil {
+		return nil, s.grpcError(err)
+	}
+	s.app.decorateRecipe(&recipe)
+	return recipeToProto(recipe), nil
+}
+
+func (s *recipeService) SearchRecipes(ctx context.Context, req *recipepb.SearchRecipesRequest) (*recipepb.SearchRecipesResponse, error) {
+	filter, err := filterFromProto(req.GetFilter())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	var recipes []models.Recipe
+	if err := searchRecipeQuery(s.app.Repo.DB.WithContext(ctx), filter, req.GetQuery()).Find(&recipes).Error; err != nil {
+		return nil, s.grpcError(err)
+	}
+	s.app.decorateRecipes(recipes)
+
+	response := &recipepb.SearchRecipesResponse{}
+	for _, recipe := range recipes {
+		response.Recipes = append(response.Recipes, recipeToProto(recipe))
+	}
+	return response, nil
+}
+
+// Status for an error from the shared recipe logic
+func (s *recipeService) grpcError(err error) error {
+	var invalid invalidRecipe
+	switch {
+	case errors.As(err, &invalid):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return status.Error(codes.NotFound, "recipe not found")
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	}
+	s.app.Logger.Println("gRPC error:", err)
+	return status.Error(codes.Internal, err.Error())
+}
+
+// Listing filter from its message, checked like the HTTP query parameters
+func filterFromProto(filter *recipepb.RecipeFilter) (recipeFilter, error) {
+	query := url.Values{
+		"diet":             filter.GetDiets(),
+		"exclude_allergen": filter.GetExcludeAllergens(),
+		"tag":              filter.GetTags(),
+	}
+	if filter.GetMatchAnyTag() {
+		query.Set("tag_mode", "or")
+	}
+	if filter != nil && filter.MaxTotalTime != nil {
+		query.Set("max_total_time", strconv.Itoa(int(filter.GetMaxTotalTime())))
+	}
+	query.Set("sort", filter.GetSort())
+	return parseRecipeFilter(query)
+}
+
+func recipeToProto(recipe models.Recipe) *recipepb.Recipe {
+	message := &recipepb.Recipe{
+		Id:            int32(recipe.RecipeID),
+		Name:          recipe.Name,
+		Description:   recipe.Description,
+		Difficulty:    int32(recipe.Difficulty),
+		Servings:      int32Ptr(recipe.Servings),
+		UserId:        recipe.UserID,
+		RatingAverage: recipe.RatingAverage,
+		RatingCount:   int32(recipe.RatingCount),
+		Diets:         recipe.Diets,
+		Allergens:     recipe.Allergens,
+	}
+	for _, ri := range recipe.Ingredients {
+		item := &recipepb.RecipeIngredient{Id: int32(ri.RecipeIngredientID), Amount: ri.Amount}
+		if ri.Ingredient != nil {
+			item.Ingredient = &recipepb.Ingredient{
+				Id:        int32(ri.Ingredient.IngredientID),
+				Label:     ri.Ingredient.Label,
+				Category:  ri.Ingredient.Category,
+				Allergens: ri.Ingredient.Allergens,
+				Animal:    ri.Ingredient.Animal,
+			}
+		}
+		if ri.Unit != nil {
+			item.Unit = &recipepb.Unit{Id: int32(ri.Unit.UnitID), Label: ri.Unit.Label}
+		}
+		message.Ingredients = append(message.Ingredients, item)
+	}
+	for _, instruction := range recipe.Instructions {
+		message.Instructions = append(message.Instructions, &recipepb.Instruction{
+			Id:         int32(instruction.InstructionID),
+			StepNumber: int32(instruction.StepNumber),
+			StepText:   instruction.StepText,
+			StepTime:   int32Ptr(instruction.Duration),
+			Phase:      instruction.Phase,
+			Notes:      instruction.Notes,
+		})
+	}
+	return message
+}
+
+// Recipe to write from its message; ingredients and units go by label
+func recipeFromProto(message *recipepb.Recipe) models.Recipe {
+	recipe := models.Recipe{
+		Name:        message.GetName(),
+		Description: message.Description,
+		Difficulty:  int(message.GetDifficulty()),
+		Servings:    intPtr(message.Servings),
+		UserID:      message.GetUserId(),
+	}
+	for _, item := range message.GetIngredients() {
+		ri := models.RecipeIngredient{Amount: item.Amount}
+		if item.Ingredient != nil {
+			ri.Ingredient = &models.Ingredient{
+				Label:     item.Ingredient.GetLabel(),
+				Category:  item.Ingredient.Category,
+				Allergens: item.Ingredient.GetAllergens(),
+				Animal:    item.Ingredient.Animal,
+			}
+		}
+		if item.Unit != nil {
+			ri.Unit = &models.Unit{Label: item.Unit.GetLabel()}
+		}
+		recipe.Ingredients = append(recipe.Ingredients, ri)
+	}
+	for _, step := range message.GetInstructions() {
+		recipe.Instructions = append(recipe.Instructions, models.Instruction{
+			StepNumber: int(step.GetStepNumber()),
+			StepText:   step.GetStepText(),
+			Duration:   intPtr(step.StepTime),
+			Phase:      step.Phase,
+			Notes:      step.Notes,
+		})
+	}
+	return recipe
+}
+
+func int32Ptr(value *int) *int32 {
+	if value == nil {
+		return nil
+	}
+	converted := int32(*value)
+	return &converted
+}
+
+func intPtr(value *int32) *int {
+	if value == nil {
+		return nil
+	}
+	converted := int(*value)
+	return &converted
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"recipe-api/internal/models"
+	"recipe-api/internal/recipepb"
+)
+
+// Client connected to the test app's gRPC server over an in-memory listener
+func grpcTestConn(t *testing.T) *grpc.ClientConn {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := testApp.NewGRPCServer()
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestGRPCRecipeService(t *testing.T) {
+	defer clearDatabase(testApp)
+	ctx := context.Background()
+	client := recipepb.NewRecipeServiceClient(grpcTestConn(t))
+
+	created, err := client.CreateRecipe(ctx, &recipepb.CreateRecipeRequest{Recipe: &recipepb.Recipe{
+		Name:       "Rye porridge",
+		Difficulty: 1,
+		UserId:     "ann",
+		Ingredients: []*recipepb.RecipeIngredient{{
+			Amount:     ToPtr(float32(80)),
+			Ingredient: &recipepb.Ingredient{Label: "Rye flakes", Allergens: []string{"gluten"}},
+			Unit:       &recipepb.Unit{Label: "gram"},
+		}},
+		Instructions: []*recipepb.Instruction{{StepNumber: 1, StepText: "simmer", StepTime: ToPtr(int32(10))}},
+	}})
+	if err != nil {
+		t.Fatalf("CreateRecipe: %v", err)
+	}
+	if created.Id == 0 || len(created.Ingredients) != 1 || created.Ingredients[0].Unit.GetLabel() != "gram" {
+		t.Fatalf("unexpected created recipe %v", created)
+	}
+	postTestRecipe(t, testApp, models.Recipe{Name: "Rye bread", Difficulty: 2})
+
+	got, err := client.GetRecipe(ctx, &recipepb.GetRecipeRequest{Id: created.Id})
+	if err != nil || got.Name != "Rye porridge" || got.Instructions[0].GetStepTime() != 10 {
+		t.Fatalf("GetRecipe: unexpected %v, %v", got, err)
+	}
+
+	stream, err := client.ListRecipes(ctx, &recipepb.ListRecipesRequest{})
+	if err != nil {
+		t.Fatalf("ListRecipes: %v", err)
+	}
+	var listed []string
+	for {
+		recipe, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("ListRecipes: %v", err)
+		}
+		listed = append(listed, recipe.Name)
+	}
+	if len(listed) != 2 {
+		t.Fatalf("expected both recipes streamed, got %v", listed)
+	}
+
+	search, err := client.SearchRecipes(ctx, &recipepb.SearchRecipesRequest{
+		Query:  "rye",
+		Filter: &recipepb.RecipeFilter{ExcludeAllergens: []string{"gluten"}},
+	})
+	if err != nil || len(search.Recipes) != 1 || search.Recipes[0].Name != "Rye bread" {
+		t.Fatalf("SearchRecipes: unexpected %v, %v", search, err)
+	}
+
+	random, err := client.RandomRecipe(ctx, &recipepb.RandomRecipeRequest{
+		MaxDifficulty: 5,
+		Filter:        &recipepb.RecipeFilter{MaxTotalTime: ToPtr(int32(15))},
+	})
+	if err != nil || random.Id != created.Id {
+		t.Fatalf("RandomRecipe: unexpected %v, %v", random, err)
+	}
+
+	got.Name = "Oat porridge"
+	updated, err := client.UpdateRecipe(ctx, &recipepb.UpdateRecipeRequest{Id: created.Id, Recipe: got})
+	if err != nil || updated.Name != "Oat porridge" {
+		t.Fatalf("UpdateRecipe: unexpected %v, %v", updated, err)
+	}
+
+	if _, err := client.DeleteRecipe(ctx, &recipepb.DeleteRecipeRequest{Id: created.Id}); err != nil {
+		t.Fatalf("DeleteRecipe: %v", err)
+	}
+	if _, err := client.GetRecipe(ctx, &recipepb.GetRecipeRequest{Id: created.Id}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound after delete, got %v", err)
+	}
+}
+
+func TestGRPCErrors(t *testing.T) {
+	defer clearDatabase(testApp)
+	ctx := context.Background()
+	client := recipepb.NewRecipeServiceClient(grpcTestConn(t))
+
+	tests := []struct {
+		name string
+		call func() error
+		code codes.Code
+	}{
+		{"invalid recipe", func() error {
+			_, err := client.CreateRecipe(ctx, &recipepb.CreateRecipeRequest{Recipe: &recipepb.Recipe{
+				Name:         "Stew",
+				Instructions: []*recipepb.Instruction{{StepNumber: 1, StepText: "stew", Phase: ToPtr("simmering")}},
+			}})
+			return err
+		}, codes.InvalidArgument},
+		{"unknown diet", func() error {
+			_, err := client.SearchRecipes(ctx, &recipepb.SearchRecipesRequest{Filter: &recipepb.RecipeFilter{Diets: []string{"carnivore"}}})
+			return err
+		}, codes.InvalidArgument},
+		{"missing update", func() error {
+			_, err := client.UpdateRecipe(ctx, &recipepb.UpdateRecipeRequest{Id: 999, Recipe: &recipepb.Recipe{Name: "Ghost", Difficulty: 1}})
+			return err
+		}, codes.NotFound},
+		{"missing delete", func() error {
+			_, err := client.DeleteRecipe(ctx, &recipepb.DeleteRecipeRequest{Id: 999})
+			return err
+		}, codes.NotFound},
+		{"no random match", func() error {
+			_, err := client.RandomRecipe(ctx, &recipepb.RandomRecipeRequest{MaxDifficulty: 5})
+			return err
+		}, codes.NotFound},
+	}
+	for _, test := range tests {
+		if code := status.Code(test.call()); code != test.code {
+			t.Errorf("%s: expected %s, got %s", test.name, test.code, code)
+		}
+	}
+}
+
+func TestGRPCHealthAndReflection(t *testing.T) {
+	conn := grpcTestConn(t)
+	health := healthpb.NewHealthClient(conn)
+	for _, service := range []string{"", "recipe.v1.RecipeService"} {
+		response, err := health.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		if err != nil || response.Status != healthpb.HealthCheckResponse_SERVING {
+			t.Fatalf("health %q: unexpected %v, %v", service, response, err)
+		}
+	}
+
+	services := testApp.NewGRPCServer().GetServiceInfo()
+	for _, name := range []string{"recipe.v1.RecipeService", "grpc.health.v1.Health", "grpc.reflection.v1.ServerReflection"} {
+		if _, ok := services[name]; !ok {
+			t.Errorf("expected %s to be registered, got %v", name, services)
+		}
+	}
+}
@@ -0,0 +1,349 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+
+	"recipe-api/internal/media"
+	"recipe-api/internal/models"
+)
+
+// Upload limit when App.MaxImage is unset
+const defaultMaxImage = 5 << 20
+
+// Stored images never change under a URL, so clients may keep them
+const imageCacheControl = "public, max-age=31536000, immutable"
+
+func (app *App) maxImage() int64 {
+	if app.MaxImage > 0 {
+		return app.MaxImage
+	}
+	return defaultMaxImage
+}
+
+// Storage key of one size of an image
+func imageObject(image models.Image, size string) string {
+	if size == media.Original {
+		return image.Key + "/" + size + media.Ext(image.ContentType)
+	}
+	return image.Key + "/" + size + media.Ext(media.ThumbnailType(image.ContentType))
+}
+
+// Every stored size of an image, the original first
+func imageSizes(image models.Image) []string {
+	return append([]string{media.Original}, image.Thumbnails...)
+}
+
+func withImageURLs(image models.Image) *models.Image {
+	image.URLs = make(map[string]string)
+	for _, size := range imageSizes(image) {
+		image.URLs[size] = fmt.Sprintf("/image/id/%d/%s", image.ImageID, size)
+	}
+	return &image
+}
+
+// Attach hero images and step photos to recipes
+func attachImages(db *gorm.DB, recipes []models.Recipe) error {
+	if len(recipes) == 0 {
+		return nil
+	}
+	ids := make([]int, len(recipes))
+	for i, recipe := range recipes {
+		ids[i] = recipe.RecipeID
+	}
+	var images []models.Image
+	if err := db.Where("recipe_id IN ?", ids).Find(&images).Error; err != nil {
+		return err
+	}
+
+	for _, image := range images {
+		for i := range recipes {
+			if recipes[i].RecipeID != image.RecipeID {
+				continue
+			}
+			if image.StepNumber == nil {
+				recipes[i].Image = withImageURLs(image)
+				continue
+			}
+			for j := range recipes[i].Instructions {
+				if recipes[i].Instructions[j].StepNumber == *image.StepNumber {
+					recipes[i].Instructions[j].Photo = withImageURLs(image)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Every image belonging to a recipe
+func recipeImages(db *gorm.DB, recipeID int) ([]models.Image, error) {
+	var images []models.Image
+	return images, db.Where("recipe_id = ?", recipeID).Find(&images).Error
+}
+
+// Image in one slot of a recipe: the hero image, or a step's photo
+func slotImages(db *gorm.DB, recipeID int, step *int) ([]models.Image, error) {
+	query := db.Where("recipe_id = ?", recipeID)
+	if step == nil {
+		query = query.Where("step_number IS NULL")
+	} else {
+		query = query.Where("step_number = ?", *step)
+	}
+	var images []models.Image
+	return images, query.Find(&images).Error
+}
+
+// Remove every stored size of images whose rows are gone. Failures only leave
+// orphaned files behind, so they are logged rather than returned.
+func (app *App) removeImageFiles(images []models.Image) {
+	if app.Storage == nil {
+		return
+	}
+	for _, image := range images {
+		for _, size := range imageSizes(image) {
+			if err := app.Storage.Delete(context.Background(), imageObject(image, size)); err != nil {
+				app.Logger.Println("Image cleanup failed:", err)
+			}
+		}
+	}
+}
+
+// Recipe ID and optional step number from the route, checking both exist
+func (app *App) imageSlot(w http.ResponseWriter, r *http.Request) (int, *int, bool) {
+	vars := mux.Vars(r)
+	recipeID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "invalid recipe ID", http.StatusBadRequest)
+		return 0, nil, false
+	}
+	var recipe models.Recipe
+	if result := app.Repo.DB.First(&recipe, recipeID); result.Error != nil {
+		http.Error(w, "Recipe not found", http.StatusNotFound)
+		return 0, nil, false
+	}
+
+	raw, ok := vars["step"]
+	if !ok {
+		return recipeID, nil, true
+	}
+	step, err := strconv.Atoi(raw)
+	if err != nil {
+		http.Error(w, "invalid step number", http.StatusBadRequest)
+		return 0, nil, false
+	}
+	var instruction models.Instruction
+	if result := app.Repo.DB.Where("recipe_id = ? AND step_number = ?", recipeID, step).First(&instruction); result.Error != nil {
+		http.Error(w, "Step not found", http.StatusNotFound)
+		return 0, nil, false
+	}
+	return recipeID, &step, true
+}
+
+// Read the "image" part of a multipart upload, enforcing the size limit
+func (app *App) readImageUpload(w http.ResponseWriter, r *http.Request) ([]byte, int, error) {
+	limit := app.maxImage()
+	r.Body = http.MaxBytesReader(w, r.Body, limit+1<<20) // room for the multipart framing
+	if err := r.ParseMultipartForm(limit); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, http.StatusRequestEntityTooLarge, fmt.Errorf("image must be at most %d bytes", limit)
+		}
+		return nil, http.StatusBadRequest, errors.New("expected a multipart form with an image")
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, _, err := r.FormFile("image")
+	if err != nil {
+		return nil, http.StatusBadRequest, errors.New("image is required")
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, limit+1))
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if int64(len(data)) > limit {
+		return nil, http.StatusRequestEntityTooLarge, fmt.Errorf("image must be at most %d bytes", limit)
+	}
+	return data, 0, nil
+}
+
+// Upload a recipe's hero image, or a step's photo when the route has a step,
+// replacing any earlier one
+func (app *App) uploadImage(w http.ResponseWriter, r *http.Request) {
+	if app.Storage == nil {
+		http.Error(w, "Image storage is not configured", http.StatusServiceUnavailable)
+		return
+	}
+	recipeID, step, ok := app.imageSlot(w, r)
+	if !ok {
+		return
+	}
+
+	data, status, err := app.readImageUpload(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	processed, err := media.Process(data)
+	if errors.Is(err, media.ErrUnsupported) {
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	token, err := newShareToken()
+	if err != nil {
+		http.Error(w, "Failed to save image", http.StatusInternalServerError)
+		return
+	}
+	image := models.Image{
+		RecipeID:    recipeID,
+		StepNumber:  step,
+		Key:         fmt.Sprintf("recipes/%d/%s", recipeID, token),
+		ContentType: processed.ContentType,
+		Width:       processed.Width,
+		Height:      processed.Height,
+		Size:        int64(len(data)),
+	}
+	for _, size := range media.Sizes {
+		if _, ok := processed.Thumbnails[size.Name]; ok {
+			image.Thumbnails = append(image.Thumbnails, size.Name)
+		}
+	}
+
+	// Files go in first so a saved row always has its files
+	err = app.Storage.Put(r.Context(), imageObject(image, media.Original), processed.Original, processed.ContentType)
+	for _, size := range image.Thumbnails {
+		if err == nil {
+			err = app.Storage.Put(r.Context(), imageObject(image, size), processed.Thumbnails[size], processed.ThumbType)
+		}
+	}
+	if err != nil {
+		app.Logger.Println("Image upload failed:", err)
+		app.removeImageFiles([]models.Image{image})
+		http.Error(w, "Failed to store image", http.StatusInternalServerError)
+		return
+	}
+
+	var replaced []models.Image
+	err = app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if replaced, err = slotImages(tx, recipeID, step); err != nil {
+			return err
+		}
+		for _, old := range replaced {
+			if err := tx.Delete(&old).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(&image).Error; err != nil {
+			return err
+		}
+		return touchRecipes(tx, []int{recipeID})
+	})
+	if err != nil {
+		app.Logger.Println("Transaction Failed:", err)
+		app.removeImageFiles([]models.Image{image})
+		http.Error(w, "Failed to save image", http.StatusInternalServerError)
+		return
+	}
+	app.removeImageFiles(replaced)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(withImageURLs(image))
+}
+
+// Remove a recipe's hero image, or a step's photo when the route has a step
+func (app *App) deleteImage(w http.ResponseWriter, r *http.Request) {
+	recipeID, step, ok := app.imageSlot(w, r)
+	if !ok {
+		return
+	}
+
+	var images []models.Image
+	err := app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if images, err = slotImages(tx, recipeID, step); err != nil {
+			return err
+		}
+		if len(images) == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.Delete(&images).Error; err != nil {
+			return err
+		}
+		return touchRecipes(tx, []int{recipeID})
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Image not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.Logger.Println("Transaction Failed:", err)
+		http.Error(w, "Failed to delete image", http.StatusInternalServerError)
+		return
+	}
+	app.removeImageFiles(images)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Serve one size of an image. Every upload gets a new ID, so responses are
+// cacheable indefinitely; ETags let clients revalidate cheaply anyway.
+func (app *App) getImage(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "invalid image ID", http.StatusBadRequest)
+		return
+	}
+	var image models.Image
+	if result := app.Repo.DB.First(&image, id); result.Error != nil {
+		http.Error(w, "Image not found", http.StatusNotFound)
+		return
+	}
+	size := vars["size"]
+	if !slices.Contains(imageSizes(image), size) {
+		http.Error(w, "Image size not found", http.StatusNotFound)
+		return
+	}
+	if app.Storage == nil {
+		http.Error(w, "Image storage is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	data, info, err := app.Storage.Get(r.Context(), imageObject(image, size))
+	if err != nil {
+		app.Logger.Println("Image read failed:", err)
+		http.Error(w, "Image unavailable", http.StatusNotFound)
+		return
+	}
+
+	contentType := image.ContentType
+	if size != media.Original {
+		contentType = media.ThumbnailType(image.ContentType)
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", imageCacheControl)
+	w.Header().Set("ETag", fmt.Sprintf(`"%d-%s"`, image.ImageID, size))
+	modified := info.ModTime
+	if modified.IsZero() {
+		modified = image.CreatedAt
+	}
+	http.ServeContent(w, r, "", modified, bytes.NewReader(data))
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"recipe-api/internal/media"
+	"recipe-api/internal/models"
+	"testing"
+)
+
+// Send a file as the "image" part of a multipart PUT
+func uploadTestImage(t *testing.T, router http.Handler, path string, data []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("image", "photo.png")
+	part.Write(data)
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPut, path, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func testImagePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestRecipeImages(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+	recipe := postTestRecipe(t, testApp, createTestRecipe(t, testApp, true))
+	base := fmt.Sprintf("/recipe/id/%d", recipe.RecipeID)
+
+	w := uploadTestImage(t, router, base+"/image", testImagePNG(t, 600, 400))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var hero models.Image
+	json.NewDecoder(w.Body).Decode(&hero)
+	if hero.Width != 600 || hero.URLs["small"] == "" || hero.URLs["medium"] == "" || hero.URLs["large"] != "" {
+		t.Fatalf("expected small and medium thumbnails only, got %+v", hero)
+	}
+
+	w = serveJSON(t, router, http.MethodGet, hero.URLs["small"], nil)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/png" || w.Header().Get("Cache-Control") != imageCacheControl {
+		t.Fatalf("unexpected thumbnail response %d %v", w.Code, w.Header())
+	}
+	thumb, err := png.DecodeConfig(w.Body)
+	if err != nil || thumb.Width != 160 {
+		t.Fatalf("expected a 160px thumbnail, got %+v %v", thumb, err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, hero.URLs["original"], nil)
+	req.Header.Set("If-None-Match", fmt.Sprintf(`"%d-original"`, hero.ImageID))
+	cached := httptest.NewRecorder()
+	router.ServeHTTP(cached, req)
+	if cached.Code != http.StatusNotModified {
+		t.Fatalf("expected status %d for a matching ETag, got %d", http.StatusNotModified, cached.Code)
+	}
+
+	w = uploadTestImage(t, router, base+"/step/2/image", testImagePNG(t, 100, 100))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d for a step photo, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	if w = uploadTestImage(t, router, base+"/step/9/image", testImagePNG(t, 100, 100)); w.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d for a missing step, got %d", http.StatusNotFound, w.Code)
+	}
+
+	w = serveJSON(t, router, http.MethodGet, base, nil)
+	var got models.Recipe
+	json.NewDecoder(w.Body).Decode(&got)
+	if got.Image == nil || got.Image.ImageID != hero.ImageID {
+		t.Fatalf("expected the hero image on the recipe, got %+v", got.Image)
+	}
+	if got.Instructions[1].Photo == nil || got.Instructions[0].Photo != nil {
+		t.Fatalf("expected a photo on step 2 only, got %+v", got.Instructions)
+	}
+
+	// Replacing the hero image removes the old files
+	uploadTestImage(t, router, base+"/image", testImagePNG(t, 300, 300))
+	if w = serveJSON(t, router, http.MethodGet, hero.URLs["original"], nil); w.Code != http.StatusNotFound {
+		t.Fatalf("expected the replaced image to be gone, got %d", w.Code)
+	}
+	if _, _, err := testApp.Storage.Get(req.Context(), imageObject(hero, media.Original)); err == nil {
+		t.Fatal("expected the replaced image's file to be deleted")
+	}
+
+	// Deleting the recipe purges the rest
+	var images []models.Image
+	testApp.Repo.DB.Find(&images)
+	serveJSON(t, router, http.MethodDelete, base, nil)
+	for _, image := range images {
+		for _, size := range imageSizes(image) {
+			if _, _, err := testApp.Storage.Get(req.Context(), imageObject(image, size)); err == nil {
+				t.Fatalf("expected %s to be deleted with the recipe", imageObject(image, size))
+			}
+		}
+	}
+}
+
+func TestRecipeImageValidation(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+	recipe := postTestRecipe(t, testApp, createTestRecipe(t, testApp, true))
+	path := fmt.Sprintf("/recipe/id/%d/image", recipe.RecipeID)
+
+	if w := uploadTestImage(t, router, path, []byte("%PDF-1.4 not an image")); w.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("expected status %d for a PDF, got %d", http.StatusUnsupportedMediaType, w.Code)
+	}
+
+	limit := testApp.MaxImage
+	testApp.MaxImage = 100
+	defer func() { testApp.MaxImage = limit }()
+	if w := uploadTestImage(t, router, path, testImagePNG(t, 200, 200)); w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected status %d for an oversized image, got %d", http.StatusRequestEntityTooLarge, w.Code)
+	}
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"expvar"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Unversioned recipe routes are deprecated in favour of /v1 and go away at
+// the sunset date
+var (
+	legacyDeprecated = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	legacySunset     = time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC)
+)
+
+// Requests per legacy route, e.g. "GET /recipe/id/{id}", published on the admin listener
+var legacyRouteHits = expvar.NewMap("legacy_route_hits")
+
+// Mark a legacy route deprecated, point at its successor (with {id} filled
+// in from the request) and count its use
+func legacyRoute(successor string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		route := r.URL.Path
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+		legacyRouteHits.Add(r.Method+" "+route, 1)
+
+		header := w.Header()
+		header.Set("Deprecation", fmt.Sprintf("@%d", legacyDeprecated.Unix()))
+		header.Set("Sunset", legacySunset.Format(http.TimeFormat))
+		if successor != "" {
+			link := strings.ReplaceAll(successor, "{id}", mux.Vars(r)["id"])
+			header.Add("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", link))
+		}
+		next(w, r)
+	}
+}
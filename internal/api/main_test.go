@@ -1,13 +1,16 @@
 package api
 
 import (
+	"net/http"
 	"os"
+	"recipe-api/internal/cache"
+	"recipe-api/internal/database"
 	"recipe-api/internal/logger"
-	"recipe-api/internal/models"
+	"recipe-api/internal/middleware"
+	"recipe-api/internal/storage"
 	"testing"
+	"time"
 
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
 	gormlogger "gorm.io/gorm/logger"
 )
 
@@ -15,27 +18,50 @@ var testApp *App
 
 func TestMain(m *testing.M) {
 
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+	// Run against SQLite by default, or any DSN given in TEST_DATABASE_URL
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		dsn = "sqlite://:memory:"
+	}
+
+	db, err := database.Open(database.Options{
+		DSN:    dsn,
 		Logger: gormlogger.Default.LogMode(gormlogger.Silent),
 	})
 	if err != nil {
 		os.Exit(1)
 	}
 
-	err = db.AutoMigrate(
-		&models.Recipe{},
-		&models.Ingredient{},
-		&models.Unit{},
-		&models.RecipeIngredient{},
-		&models.Instruction{},
-	)
-
 	repo := createRepository(db)
+	if err := repo.AutoMigrate(); err != nil {
+		os.Exit(1)
+	}
+
+	uploads, err := os.MkdirTemp("", "recipe-api-uploads")
+	if err != nil {
+		os.Exit(1)
+	}
+	store, err := storage.NewLocal(uploads)
+	if err != nil {
+		os.Exit(1)
+	}
 
 	testApp = &App{
-		Repo:   repo,
-		Logger: logger.NewAppLogger("TEST", true),
+		Repo:          repo,
+		Logger:        logger.NewAppLogger("TEST", true),
+		Storage:       store,
+		RecipeCache:   cache.NewReadThrough(cache.NewMemory(100, 0), time.Minute),
+		ResponseCache: middleware.NewResponseCache(100, 0, time.Minute),
 	}
+	clearDatabase(testApp)
+
 	exitCode := m.Run()
+	os.RemoveAll(uploads)
 	os.Exit(exitCode)
 }
+
+// Router with every route and middleware as the server has them, without
+// rate limits
+func testRouter() http.Handler {
+	return NewRouter(testApp, &middleware.CorsPolicy{})
+}
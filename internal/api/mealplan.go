@@ -0,0 +1,408 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+
+	"recipe-api/internal/models"
+)
+
+// Monday 00:00 UTC of the week containing t
+func weekStart(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	offset := (int(day.Weekday()) + 6) % 7 // days since Monday
+	return day.AddDate(0, 0, -offset)
+}
+
+// Normalise a plan's dates and check its entries fall inside the week
+func validateMealPlan(plan *models.MealPlan) error {
+	if plan.UserID == "" {
+		return errors.New("userID is required")
+	}
+	if plan.WeekStart.IsZero() {
+		plan.WeekStart = time.Now()
+	}
+	plan.WeekStart = weekStart(plan.WeekStart)
+	weekEnd := plan.WeekStart.AddDate(0, 0, 7)
+
+	for i := range plan.Entries {
+		entry := &plan.Entries[i]
+		entry.Date = time.Date(entry.Date.Year(), entry.Date.Month(), entry.Date.Day(), 0, 0, 0, 0, time.UTC)
+		if entry.Date.Before(plan.WeekStart) || !entry.Date.Before(weekEnd) {
+			return fmt.Errorf("entry date %s is outside the planned week", entry.Date.Format(time.DateOnly))
+		}
+		if !slices.Contains(models.MealSlots, entry.Slot) {
+			return fmt.Errorf("unknown meal slot %q", entry.Slot)
+		}
+		if entry.RecipeID == 0 {
+			return errors.New("every entry needs a recipe_id")
+		}
+		if entry.Servings < 1 {
+			entry.Servings = 1
+		}
+	}
+	return nil
+}
+
+// First recipe a plan's entries use that doesn't exist, or 0 when they all do
+func missingMealPlanRecipe(db *gorm.DB, entries []models.MealPlanEntry) (int, error) {
+	ids := make([]int, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.RecipeID
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	var found []int
+	if err := db.Model(&models.Recipe{}).Where("recipe_id IN ?", ids).Pluck("recipe_id", &found).Error; err != nil {
+		return 0, err
+	}
+	for _, id := range ids {
+		if !slices.Contains(found, id) {
+			return id, nil
+		}
+	}
+	return 0, nil
+}
+
+// Check a plan and the recipes it uses, answering 400 or 500 when it fails
+func (app *App) checkMealPlan(w http.ResponseWriter, plan *models.MealPlan) bool {
+	if err := validateMealPlan(plan); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	missing, err := missingMealPlanRecipe(app.Repo.DB, plan.Entries)
+	if err != nil {
+		app.Logger.Println("Meal plan error:", err)
+		http.Error(w, "Error checking recipes.", http.StatusInternalServerError)
+		return false
+	}
+	if missing != 0 {
+		http.Error(w, fmt.Sprintf("Recipe with id %d not found", missing), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// Insert a plan's entries, returning them with IDs set
+func createMealPlanEntries(tx *gorm.DB, planID int, entries []models.MealPlanEntry) ([]models.MealPlanEntry, error) {
+	created := make([]models.MealPlanEntry, 0, len(entries))
+	for _, entry := range entries {
+		entry.MealPlanEntryID = 0
+		entry.MealPlanID = planID
+		entry.Recipe = nil
+		result := tx.Create(&entry)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		created = append(created, entry)
+	}
+	return created, nil
+}
+
+// Load a plan with its entries in date order
+func findMealPlan(db *gorm.DB, id int) (models.MealPlan, error) {
+	var plan models.MealPlan
+	result := db.Preload("Entries", func(db *gorm.DB) *gorm.DB {
+		return db.Order("date ASC").Order("meal_plan_entry_id ASC")
+	}).Preload("Entries.Recipe").First(&plan, id)
+	return plan, result.Error
+}
+
+func mealPlanID(r *http.Request) (int, error) {
+	return strconv.Atoi(mux.Vars(r)["id"])
+}
+
+// Create a new meal plan
+func (app *App) addMealPlan(w http.ResponseWriter, r *http.Request) {
+	var data models.MealPlan
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if !app.checkMealPlan(w, &data) {
+		return
+	}
+
+	plan := models.MealPlan{
+		UserID:    data.UserID,
+		Name:      data.Name,
+		WeekStart: data.WeekStart,
+	}
+	result := app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&plan).Error; err != nil {
+			return err
+		}
+		entries, err := createMealPlanEntries(tx, plan.MealPlanID, data.Entries)
+		plan.Entries = entries
+		return err
+	})
+	if result != nil {
+		app.Logger.Println("Transaction Failed:", result)
+		http.Error(w, "Failed to save meal plan", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(plan)
+}
+
+// Get a single meal plan with its recipes
+func (app *App) getMealPlanByID(w http.ResponseWriter, r *http.Request) {
+	id, err := mealPlanID(r)
+	if err != nil {
+		http.Error(w, "invalid meal plan ID", http.StatusBadRequest)
+		return
+	}
+
+	plan, err := findMealPlan(app.Repo.DB, id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Meal plan with id %d not found", id), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(plan)
+}
+
+// Get every meal plan owned by a user, newest week first
+func (app *App) getMealPlansByUser(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["userID"]
+
+	plans := []models.MealPlan{}
+	result := app.Repo.DB.Preload("Entries", func(db *gorm.DB) *gorm.DB {
+		return db.Order("date ASC").Order("meal_plan_entry_id ASC")
+	}).Where("user_id = ?", userID).Order("week_start DESC").Find(&plans)
+	if result.Error != nil {
+		http.Error(w, "Error fetching meal plans.", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(plans)
+}
+
+// Replace a meal plan's details and entries
+func (app *App) updateMealPlanByID(w http.ResponseWriter, r *http.Request) {
+	id, err := mealPlanID(r)
+	if err != nil {
+		http.Error(w, "invalid meal plan ID", http.StatusBadRequest)
+		return
+	}
+
+	var data models.MealPlan
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	var plan models.MealPlan
+	if result := app.Repo.DB.First(&plan, id); result.Error != nil {
+		http.Error(w, "Meal plan not found", http.StatusNotFound)
+		return
+	}
+	data.UserID = plan.UserID
+	if !app.checkMealPlan(w, &data) {
+		return
+	}
+
+	result := app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&plan).Updates(map[string]any{
+			"name":       data.Name,
+			"week_start": data.WeekStart,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if err := tx.Where("meal_plan_id = ?", id).Delete(&models.MealPlanEntry{}).Error; err != nil {
+			return err
+		}
+		_, err := createMealPlanEntries(tx, id, data.Entries)
+		return err
+	})
+	if result != nil {
+		app.Logger.Println("Transaction Failed:", result)
+		http.Error(w, "Failed to save meal plan", http.StatusInternalServerError)
+		return
+	}
+
+	plan, _ = findMealPlan(app.Repo.DB, id)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(plan)
+}
+
+// Delete a meal plan and its entries
+func (app *App) deleteMealPlanByID(w http.ResponseWriter, r *http.Request) {
+	id, err := mealPlanID(r)
+	if err != nil {
+		http.Error(w, "invalid meal plan ID", http.StatusBadRequest)
+		return
+	}
+
+	err = app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("meal_plan_id = ?", id).Delete(&models.MealPlanEntry{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.MealPlan{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Meal plan not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to delete meal plan", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Copy a plan into the following week
+func (app *App) copyMealPlanToNextWeek(w http.ResponseWriter, r *http.Request) {
+	id, err := mealPlanID(r)
+	if err != nil {
+		http.Error(w, "invalid meal plan ID", http.StatusBadRequest)
+		return
+	}
+
+	source, err := findMealPlan(app.Repo.DB, id)
+	if err != nil {
+		http.Error(w, "Meal plan not found", http.StatusNotFound)
+		return
+	}
+
+	plan := models.MealPlan{
+		UserID:    source.UserID,
+		Name:      source.Name,
+		WeekStart: source.WeekStart.AddDate(0, 0, 7),
+	}
+	entries := make([]models.MealPlanEntry, len(source.Entries))
+	for i, entry := range source.Entries {
+		entry.Date = entry.Date.AddDate(0, 0, 7)
+		entries[i] = entry
+	}
+
+	result := app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&plan).Error; err != nil {
+			return err
+		}
+		created, err := createMealPlanEntries(tx, plan.MealPlanID, entries)
+		plan.Entries = created
+		return err
+	})
+	if result != nil {
+		app.Logger.Println("Transaction Failed:", result)
+		http.Error(w, "Failed to save meal plan", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(plan)
+}
+
+// Options for auto-filling the empty slots of a plan
+type autoFillRequest struct {
+	Slots      []string `json:"slots"`      // defaults to every slot
+	Difficulty int      `json:"difficulty"` // maximum difficulty, 0 for any
+	Servings   int      `json:"servings"`
+}
+
+// Fill every empty day/slot of a plan with random recipes, never repeating a
+// recipe already in the plan. Slots stay empty once the candidates run out.
+func (app *App) autoFillMealPlan(w http.ResponseWriter, r *http.Request) {
+	id, err := mealPlanID(r)
+	if err != nil {
+		http.Error(w, "invalid meal plan ID", http.StatusBadRequest)
+		return
+	}
+
+	var options autoFillRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&options); err != nil {
+			http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+			return
+		}
+	}
+	if len(options.Slots) == 0 {
+		options.Slots = models.MealSlots
+	}
+	for _, slot := range options.Slots {
+		if !slices.Contains(models.MealSlots, slot) {
+			http.Error(w, fmt.Sprintf("unknown meal slot %q", slot), http.StatusBadRequest)
+			return
+		}
+	}
+	if options.Servings < 1 {
+		options.Servings = 1
+	}
+
+	plan, err := findMealPlan(app.Repo.DB, id)
+	if err != nil {
+		http.Error(w, "Meal plan not found", http.StatusNotFound)
+		return
+	}
+
+	filled := make(map[string]bool)
+	var used []int
+	for _, entry := range plan.Entries {
+		filled[entry.Date.Format(time.DateOnly)+entry.Slot] = true
+		used = append(used, entry.RecipeID)
+	}
+
+	result := app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		for day := 0; day < 7; day++ {
+			date := plan.WeekStart.AddDate(0, 0, day)
+			for _, slot := range options.Slots {
+				if filled[date.Format(time.DateOnly)+slot] {
+					continue
+				}
+
+				var recipe models.Recipe
+				result := randomRecipeQuery(tx, options.Difficulty, used).First(&recipe)
+				if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+					return nil
+				}
+				if result.Error != nil {
+					return result.Error
+				}
+
+				entry := models.MealPlanEntry{
+					MealPlanID: plan.MealPlanID,
+					Date:       date,
+					Slot:       slot,
+					RecipeID:   recipe.RecipeID,
+					Servings:   options.Servings,
+				}
+				if err := tx.Create(&entry).Error; err != nil {
+					return err
+				}
+				used = append(used, recipe.RecipeID)
+			}
+		}
+		return nil
+	})
+	if result != nil {
+		app.Logger.Println("Transaction Failed:", result)
+		http.Error(w, "Failed to save meal plan", http.StatusInternalServerError)
+		return
+	}
+
+	plan, _ = findMealPlan(app.Repo.DB, id)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(plan)
+}
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"recipe-api/internal/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func serveJSON(t *testing.T, router http.Handler, method, path string, payload any) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var body bytes.Buffer
+	if payload != nil {
+		if err := json.NewEncoder(&body).Encode(payload); err != nil {
+			t.Fatalf("failed to marshal payload: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, &body)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestMealPlanCreateAndCopy(t *testing.T) {
+	defer clearDatabase(testApp)
+	recipe := postTestRecipe(t, testApp, createTestRecipe(t, testApp, true))
+	router := testRouter()
+
+	// Wednesday, normalised back to Monday
+	week := time.Date(2026, 10, 21, 15, 0, 0, 0, time.UTC)
+	plan := models.MealPlan{
+		UserID:    "cook",
+		Name:      "Busy week",
+		WeekStart: week,
+		Entries: []models.MealPlanEntry{
+			{Date: week, Slot: models.SlotDinner, RecipeID: recipe.RecipeID, Servings: 4},
+		},
+	}
+
+	w := serveJSON(t, router, http.MethodPost, "/mealplan/add", plan)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	var created models.MealPlan
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !created.WeekStart.Equal(time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected week to start on Monday, got %v", created.WeekStart)
+	}
+	if len(created.Entries) != 1 || created.Entries[0].MealPlanEntryID == 0 {
+		t.Fatalf("expected 1 stored entry, got %+v", created.Entries)
+	}
+
+	w = serveJSON(t, router, http.MethodPost, fmt.Sprintf("/mealplan/id/%d/copy", created.MealPlanID), nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+
+	var copied models.MealPlan
+	json.NewDecoder(w.Body).Decode(&copied)
+	if copied.MealPlanID == created.MealPlanID {
+		t.Fatal("expected a new plan to be created")
+	}
+	if !copied.WeekStart.Equal(created.WeekStart.AddDate(0, 0, 7)) {
+		t.Fatalf("expected copy to start a week later, got %v", copied.WeekStart)
+	}
+	if len(copied.Entries) != 1 || !copied.Entries[0].Date.Equal(created.Entries[0].Date.AddDate(0, 0, 7)) {
+		t.Fatalf("expected copied entry a week later, got %+v", copied.Entries)
+	}
+
+	w = serveJSON(t, router, http.MethodGet, "/mealplan/user/cook", nil)
+	var plans []models.MealPlan
+	json.NewDecoder(w.Body).Decode(&plans)
+	if len(plans) != 2 {
+		t.Fatalf("expected 2 plans for user, got %d", len(plans))
+	}
+}
+
+func TestMealPlanRejectsDatesOutsideWeek(t *testing.T) {
+	defer clearDatabase(testApp)
+	recipe := postTestRecipe(t, testApp, createTestRecipe(t, testApp, true))
+
+	week := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	plan := models.MealPlan{
+		UserID:    "cook",
+		WeekStart: week,
+		Entries: []models.MealPlanEntry{
+			{Date: week.AddDate(0, 0, 7), Slot: models.SlotLunch, RecipeID: recipe.RecipeID},
+		},
+	}
+
+	w := serveJSON(t, testRouter(), http.MethodPost, "/mealplan/add", plan)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestMealPlanRejectsUnknownRecipes(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+	recipe := postTestRecipe(t, testApp, createTestRecipe(t, testApp, true))
+
+	week := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	plan := models.MealPlan{
+		UserID:    "cook",
+		WeekStart: week,
+		Entries: []models.MealPlanEntry{
+			{Date: week, Slot: models.SlotLunch, RecipeID: recipe.RecipeID},
+			{Date: week, Slot: models.SlotDinner, RecipeID: 999999},
+		},
+	}
+	w := serveJSON(t, router, http.MethodPost, "/mealplan/add", plan)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "999999") {
+		t.Fatalf("expected status %d naming the recipe, got %d: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+
+	plan.Entries = plan.Entries[:1]
+	var created models.MealPlan
+	w = serveJSON(t, router, http.MethodPost, "/mealplan/add", plan)
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil || w.Code != http.StatusCreated {
+		t.Fatalf("expected the plan created, got %d: %v", w.Code, err)
+	}
+	plan.Entries = append(plan.Entries, models.MealPlanEntry{Date: week, Slot: models.SlotDinner, RecipeID: 999999})
+	w = serveJSON(t, router, http.MethodPut, fmt.Sprintf("/mealplan/id/%d", created.MealPlanID), plan)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d for an update, got %d: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+}
+
+func TestMealPlanAutoFill(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+
+	// Three easy recipes and one too hard to be picked
+	easy := make(map[int]bool)
+	for i := 0; i < 3; i++ {
+		recipe := createTestRecipe(t, testApp, true)
+		recipe.Difficulty = 2
+		easy[postTestRecipe(t, testApp, recipe).RecipeID] = true
+	}
+	hard := createTestRecipe(t, testApp, true)
+	hard.Difficulty = 9
+	postTestRecipe(t, testApp, hard)
+
+	w := serveJSON(t, router, http.MethodPost, "/mealplan/add", models.MealPlan{UserID: "cook"})
+	var plan models.MealPlan
+	json.NewDecoder(w.Body).Decode(&plan)
+
+	options := autoFillRequest{Slots: []string{models.SlotDinner}, Difficulty: 3, Servings: 2}
+	w = serveJSON(t, router, http.MethodPost, fmt.Sprintf("/mealplan/id/%d/autofill", plan.MealPlanID), options)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var filled models.MealPlan
+	json.NewDecoder(w.Body).Decode(&filled)
+
+	// Only three candidates, so only three days can be filled without repeats
+	if len(filled.Entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(filled.Entries))
+	}
+	seen := make(map[int]bool)
+	for _, entry := range filled.Entries {
+		if !easy[entry.RecipeID] {
+			t.Errorf("recipe %d does not match the difficulty filter", entry.RecipeID)
+		}
+		if seen[entry.RecipeID] {
+			t.Errorf("recipe %d was repeated", entry.RecipeID)
+		}
+		seen[entry.RecipeID] = true
+		if entry.Slot != models.SlotDinner || entry.Servings != 2 {
+			t.Errorf("unexpected entry %+v", entry)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+
+	"recipe-api/internal/models"
+	"recipe-api/internal/repository"
+)
+
+// Load a user's pantry with ingredient and unit details, soonest expiry first
+func loadPantry(db *gorm.DB, userID string) ([]models.PantryItem, error) {
+	var items []models.PantryItem
+	result := db.Preload("Ingredient").Preload("Unit").
+		Where("user_id = ?", userID).
+		Order("CASE WHEN expires_at IS NULL THEN 1 ELSE 0 END, expires_at ASC, pantry_item_id ASC").
+		Find(&items)
+	return items, result.Error
+}
+
+// Resolve the ingredient and unit labels sent with a pantry item
+func resolvePantryItem(tx *gorm.DB, item *models.PantryItem) error {
+	if item.Ingredient != nil {
+		ingredient, err := repository.FirstOrCreateIngredient(tx, item.Ingredient)
+		if err != nil {
+			return err
+		}
+		item.Ingredient = &ingredient
+		item.IngredientID = ingredient.IngredientID
+	}
+	if item.IngredientID == 0 {
+		return errors.New("an ingredient is required")
+	}
+
+	if item.Unit != nil {
+		unit, err := repository.FirstOrCreateUnit(tx, item.Unit)
+		if err != nil {
+			return err
+		}
+		item.Unit = &unit
+		item.UnitID = &unit.UnitID
+	}
+	return nil
+}
+
+// Add an ingredient to a user's pantry
+func (app *App) addPantryItem(w http.ResponseWriter, r *http.Request) {
+	var data models.PantryItem
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if data.UserID == "" {
+		http.Error(w, "userID is required", http.StatusBadRequest)
+		return
+	}
+	if data.Quantity < 0 {
+		http.Error(w, "quantity must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	item := data
+	item.PantryItemID = 0
+
+	result := app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		if err := resolvePantryItem(tx, &item); err != nil {
+			return err
+		}
+		return tx.Omit("Ingredient", "Unit").Create(&item).Error
+	})
+	if result != nil {
+		app.Logger.Println("Transaction Failed:", result)
+		http.Error(w, result.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(item)
+}
+
+// Get a user's pantry
+func (app *App) getPantryByUser(w http.ResponseWriter, r *http.Request) {
+	items, err := loadPantry(app.Repo.DB, mux.Vars(r)["userID"])
+	if err != nil {
+		http.Error(w, "Error fetching pantry.", http.StatusInternalServerError)
+		return
+	}
+	if items == nil {
+		items = []models.PantryItem{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
+
+// Change the quantity, unit or expiry of a pantry item
+func (app *App) updatePantryItemByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid pantry item ID", http.StatusBadRequest)
+		return
+	}
+
+	var data models.PantryItem
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if data.Quantity < 0 {
+		http.Error(w, "quantity must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	var item models.PantryItem
+	if result := app.Repo.DB.First(&item, id); result.Error != nil {
+		http.Error(w, "Pantry item not found", http.StatusNotFound)
+		return
+	}
+
+	result := app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		if data.IngredientID == 0 {
+			data.IngredientID = item.IngredientID
+		}
+		if err := resolvePantryItem(tx, &data); err != nil {
+			return err
+		}
+		return tx.Model(&item).Updates(map[string]any{
+			"ingredient_id": data.IngredientID,
+			"quantity":      data.Quantity,
+			"unit_id":       data.UnitID,
+			"expires_at":    data.ExpiresAt,
+		}).Error
+	})
+	if result != nil {
+		app.Logger.Println("Transaction Failed:", result)
+		http.Error(w, result.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	app.Repo.DB.Preload("Ingredient").Preload("Unit").First(&item, id)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+// Remove an item from the pantry
+func (app *App) deletePantryItemByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid pantry item ID", http.StatusBadRequest)
+		return
+	}
+
+	result := app.Repo.DB.Delete(&models.PantryItem{}, id)
+	if result.Error != nil {
+		http.Error(w, "Failed to delete pantry item", http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 0 {
+		http.Error(w, "Pantry item not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
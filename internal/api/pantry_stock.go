@@ -0,0 +1,376 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"recipe-api/internal/measure"
+	"recipe-api/internal/models"
+)
+
+// Tolerance for comparing quantities after unit conversion
+const quantityEpsilon = 1e-6
+
+// Ingredient a recipe needs that the pantry can't cover
+type missingIngredient struct {
+	IngredientID int      `json:"ingredient_id"`
+	Label        string   `json:"label"`
+	Needed       *float64 `json:"needed,omitempty"` // nil when the recipe gives no amount
+	Available    float64  `json:"available"`
+	Unit         string   `json:"unit,omitempty"`
+}
+
+// Whether a recipe can be cooked from stock
+type recipeAvailability struct {
+	Recipe   models.Recipe       `json:"recipe"`
+	Cookable bool                `json:"cookable"`
+	Missing  []missingIngredient `json:"missing,omitempty"`
+}
+
+// Recipe that uses up expiring stock
+type expiringRecipe struct {
+	Recipe   models.Recipe `json:"recipe"`
+	Uses     []string      `json:"uses"` // labels of the expiring ingredients it needs
+	Cookable bool          `json:"cookable"`
+}
+
+// Pantry items grouped by ingredient, each in the order they should be used up
+type pantryStock map[int][]*models.PantryItem
+
+func newPantryStock(items []models.PantryItem) pantryStock {
+	stock := make(pantryStock)
+	for i := range items {
+		stock[items[i].IngredientID] = append(stock[items[i].IngredientID], &items[i])
+	}
+	return stock
+}
+
+func pantryUnit(item *models.PantryItem) string {
+	if item.Unit != nil {
+		return item.Unit.Label
+	}
+	return ""
+}
+
+// Quantity of an ingredient held in the given unit, and whether any is held at all
+func (stock pantryStock) available(ingredientID int, unit string) (float64, bool) {
+	total := 0.0
+	held := false
+	for _, item := range stock[ingredientID] {
+		held = held || item.Quantity > quantityEpsilon
+		if amount, ok := measure.Convert(item.Quantity, pantryUnit(item), unit); ok {
+			total += amount
+		}
+	}
+	return total, held
+}
+
+// Amount of one ingredient a recipe needs in one unit
+type pantryNeed struct {
+	IngredientID int
+	Label        string
+	Unit         string
+	Amount       *float64 // nil when no line gives an amount
+}
+
+type pantryNeedKey struct {
+	ingredientID int
+	unit         string
+}
+
+// What a recipe, scaled by factor, needs from the pantry. Lines for the same
+// ingredient in the same unit are summed, so a recipe that uses flour twice
+// needs both amounts.
+func recipeNeeds(recipe models.Recipe, factor float64) []pantryNeed {
+	var needs []pantryNeed
+	index := make(map[pantryNeedKey]int)
+	for _, ri := range recipe.Ingredients {
+		unit := ""
+		if ri.Unit != nil {
+			unit = ri.Unit.Label
+		}
+		key := pantryNeedKey{ri.IngredientID, unit}
+		i, ok := index[key]
+		if !ok {
+			i = len(needs)
+			index[key] = i
+			need := pantryNeed{IngredientID: ri.IngredientID, Unit: unit}
+			if ri.Ingredient != nil {
+				need.Label = ri.Ingredient.Label
+			}
+			needs = append(needs, need)
+		}
+		if ri.Amount != nil {
+			amount := float64(*ri.Amount) * factor
+			if needs[i].Amount != nil {
+				amount += *needs[i].Amount
+			}
+			needs[i].Amount = &amount
+		}
+	}
+	return needs
+}
+
+// Ingredients of a recipe, scaled by factor, that the stock can't cover.
+// Ingredients without an amount only need to be held in some quantity.
+func (stock pantryStock) missing(recipe models.Recipe, factor float64) []missingIngredient {
+	var missing []missingIngredient
+	for _, need := range recipeNeeds(recipe, factor) {
+		available, held := stock.available(need.IngredientID, need.Unit)
+		enough := held
+		if need.Amount != nil {
+			enough = available+quantityEpsilon >= *need.Amount
+		}
+		if enough {
+			continue
+		}
+		missing = append(missing, missingIngredient{
+			IngredientID: need.IngredientID,
+			Label:        need.Label,
+			Needed:       need.Amount,
+			Available:    available,
+			Unit:         need.Unit,
+		})
+	}
+	return missing
+}
+
+// Take an amount of an ingredient out of stock, soonest expiry first,
+// returning the items that changed and how much of the amount couldn't be
+// found
+func (stock pantryStock) consume(ingredientID int, amount float64, unit string) ([]*models.PantryItem, float64) {
+	var changed []*models.PantryItem
+	for _, item := range stock[ingredientID] {
+		if amount <= quantityEpsilon {
+			break
+		}
+		held, ok := measure.Convert(item.Quantity, pantryUnit(item), unit)
+		if !ok || held <= 0 {
+			continue
+		}
+
+		used := min(held, amount)
+		remaining, _ := measure.Convert(held-used, unit, pantryUnit(item))
+		item.Quantity = remaining
+		amount -= used
+		changed = append(changed, item)
+	}
+	return changed, max(amount, 0)
+}
+
+// Every recipe marked with whether it can be cooked from a user's pantry
+func (app *App) getCookableRecipes(w http.ResponseWriter, r *http.Request) {
+	items, err := loadPantry(app.Repo.DB, mux.Vars(r)["userID"])
+	if err != nil {
+		http.Error(w, "Error fetching pantry.", http.StatusInternalServerError)
+		return
+	}
+	stock := newPantryStock(items)
+
+	var recipes []models.Recipe
+	if result := preloadRecipe(app.Repo.DB).Find(&recipes); result.Error != nil {
+		http.Error(w, "Error fetching recipes.", http.StatusInternalServerError)
+		return
+	}
+
+	onlyCookable, _ := strconv.ParseBool(r.URL.Query().Get("only"))
+	availability := []recipeAvailability{}
+	for _, recipe := range recipes {
+		missing := stock.missing(recipe, 1)
+		if onlyCookable && len(missing) > 0 {
+			continue
+		}
+		availability = append(availability, recipeAvailability{
+			Recipe:   recipe,
+			Cookable: len(missing) == 0,
+			Missing:  missing,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(availability)
+}
+
+// Returned from the cook transaction when stock runs short
+type insufficientStockError struct {
+	missing []missingIngredient
+}
+
+func (err *insufficientStockError) Error() string {
+	return "not enough ingredients in the pantry"
+}
+
+// Cook a recipe, taking its ingredients out of the pantry in one transaction.
+// Optional ?servings= scales the amounts used.
+func (app *App) cookRecipeFromPantry(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userID"]
+	recipeID, err := strconv.Atoi(vars["recipeID"])
+	if err != nil {
+		http.Error(w, "invalid recipe ID", http.StatusBadRequest)
+		return
+	}
+	servings, _ := strconv.Atoi(r.URL.Query().Get("servings"))
+
+	var recipe models.Recipe
+	if result := preloadRecipe(app.Repo.DB).First(&recipe, recipeID); result.Error != nil {
+		http.Error(w, "Recipe not found", http.StatusNotFound)
+		return
+	}
+	factor := servingsFactor(recipe, servings)
+
+	err = app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the stock so concurrent cooks queue rather than both spending it
+		items, err := loadPantry(tx.Clauses(clause.Locking{Strength: "UPDATE"}), userID)
+		if err != nil {
+			return err
+		}
+		stock := newPantryStock(items)
+
+		if missing := stock.missing(recipe, factor); len(missing) > 0 {
+			return &insufficientStockError{missing}
+		}
+
+		// Needs in different units draw on the same items, so one can still
+		// come up short once the others are taken
+		changed := make(map[int]*models.PantryItem)
+		for _, need := range recipeNeeds(recipe, factor) {
+			if need.Amount == nil {
+				continue
+			}
+			items, short := stock.consume(need.IngredientID, *need.Amount, need.Unit)
+			if short > quantityEpsilon {
+				return &insufficientStockError{[]missingIngredient{{
+					IngredientID: need.IngredientID,
+					Label:        need.Label,
+					Needed:       need.Amount,
+					Available:    *need.Amount - short,
+					Unit:         need.Unit,
+				}}}
+			}
+			for _, item := range items {
+				changed[item.PantryItemID] = item
+			}
+		}
+
+		for _, item := range changed {
+			var result *gorm.DB
+			if item.Quantity <= quantityEpsilon {
+				result = tx.Delete(&models.PantryItem{}, item.PantryItemID)
+			} else {
+				result = tx.Model(&models.PantryItem{}).Where("pantry_item_id = ?", item.PantryItemID).
+					Update("quantity", item.Quantity)
+			}
+			if result.Error != nil {
+				return result.Error
+			}
+		}
+		return nil
+	})
+
+	var insufficient *insufficientStockError
+	if errors.As(err, &insufficient) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(recipeAvailability{Recipe: recipe, Missing: insufficient.missing})
+		return
+	}
+	if err != nil {
+		app.Logger.Println("Transaction Failed:", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	items, _ := loadPantry(app.Repo.DB, userID)
+	if items == nil {
+		items = []models.PantryItem{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
+
+// Pantry items expiring within ?days= (default 3), with the recipes that use
+// the most of them first
+func (app *App) getExpiringPantry(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["userID"]
+	days := 3
+	if value := r.URL.Query().Get("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			http.Error(w, "invalid days", http.StatusBadRequest)
+			return
+		}
+		days = parsed
+	}
+
+	items, err := loadPantry(app.Repo.DB, userID)
+	if err != nil {
+		http.Error(w, "Error fetching pantry.", http.StatusInternalServerError)
+		return
+	}
+
+	cutoff := time.Now().AddDate(0, 0, days)
+	expiring := []models.PantryItem{}
+	expiringIDs := make(map[int]bool)
+	for _, item := range items {
+		if item.ExpiresAt != nil && item.ExpiresAt.Before(cutoff) && item.Quantity > quantityEpsilon {
+			expiring = append(expiring, item)
+			expiringIDs[item.IngredientID] = true
+		}
+	}
+
+	suggestions := []expiringRecipe{}
+	if len(expiringIDs) > 0 {
+		ids := make([]int, 0, len(expiringIDs))
+		for id := range expiringIDs {
+			ids = append(ids, id)
+		}
+
+		var recipeIDs []int
+		result := app.Repo.DB.Model(&models.RecipeIngredient{}).
+			Select("recipe_id").
+			Where("ingredient_id IN ?", ids).
+			Group("recipe_id").
+			Order("COUNT(DISTINCT ingredient_id) DESC, recipe_id ASC").
+			Pluck("recipe_id", &recipeIDs)
+		if result.Error != nil {
+			http.Error(w, "Error fetching recipes.", http.StatusInternalServerError)
+			return
+		}
+
+		var recipes []models.Recipe
+		preloadRecipe(app.Repo.DB).Where("recipe_id IN ?", recipeIDs).Find(&recipes)
+		byID := make(map[int]models.Recipe, len(recipes))
+		for _, recipe := range recipes {
+			byID[recipe.RecipeID] = recipe
+		}
+
+		stock := newPantryStock(items)
+		for _, id := range recipeIDs {
+			recipe, ok := byID[id]
+			if !ok {
+				continue
+			}
+			suggestion := expiringRecipe{Recipe: recipe, Uses: []string{}, Cookable: len(stock.missing(recipe, 1)) == 0}
+			for _, ri := range recipe.Ingredients {
+				if expiringIDs[ri.IngredientID] && ri.Ingredient != nil {
+					suggestion.Uses = append(suggestion.Uses, ri.Ingredient.Label)
+				}
+			}
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"items":   expiring,
+		"recipes": suggestions,
+	})
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"recipe-api/internal/models"
+	"testing"
+	"time"
+)
+
+func addTestPantryItem(t *testing.T, router http.Handler, label string, quantity float64, unit string, expires *time.Time) {
+	t.Helper()
+	item := models.PantryItem{
+		UserID:     "cook",
+		Quantity:   quantity,
+		ExpiresAt:  expires,
+		Ingredient: createTestIngredient(label),
+		Unit:       createTestUnit(unit),
+	}
+	w := serveJSON(t, router, http.MethodPost, "/pantry/add", item)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+}
+
+func TestPantryCookable(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+
+	// Needs 1 cup of salt and 4 cups of water
+	recipe := postTestRecipe(t, testApp, createTestRecipe(t, testApp, true))
+
+	addTestPantryItem(t, router, "Salt", 250, "ml", nil)
+	addTestPantryItem(t, router, "Water", 0.75, "l", nil)
+
+	w := serveJSON(t, router, http.MethodGet, "/pantry/user/cook/cookable", nil)
+	var availability []recipeAvailability
+	json.NewDecoder(w.Body).Decode(&availability)
+
+	if len(availability) != 1 || availability[0].Recipe.RecipeID != recipe.RecipeID {
+		t.Fatalf("expected availability for the recipe, got %+v", availability)
+	}
+	if availability[0].Cookable {
+		t.Fatal("expected 0.75 l of water to fall short of 4 cups")
+	}
+	if len(availability[0].Missing) != 1 || availability[0].Missing[0].Label != "Water" {
+		t.Fatalf("expected water to be missing, got %+v", availability[0].Missing)
+	}
+	if available := availability[0].Missing[0].Available; available != 3 {
+		t.Fatalf("expected 3 cups available after conversion, got %v", available)
+	}
+
+	w = serveJSON(t, router, http.MethodGet, "/pantry/user/cook/cookable?only=true", nil)
+	json.NewDecoder(w.Body).Decode(&availability)
+	if len(availability) != 0 {
+		t.Fatalf("expected no cookable recipes, got %d", len(availability))
+	}
+}
+
+func TestPantryCookDecrementsStock(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+	recipe := postTestRecipe(t, testApp, createTestRecipe(t, testApp, true))
+
+	soon := time.Now().AddDate(0, 0, 1)
+	later := time.Now().AddDate(0, 0, 30)
+	addTestPantryItem(t, router, "Salt", 1, "Cup", nil)
+	addTestPantryItem(t, router, "Water", 3, "Cup", &later)
+	addTestPantryItem(t, router, "Water", 500, "ml", &soon) // 2 cups, used first
+
+	w := serveJSON(t, router, http.MethodPost, fmt.Sprintf("/pantry/user/cook/cook/%d", recipe.RecipeID), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var items []models.PantryItem
+	json.NewDecoder(w.Body).Decode(&items)
+
+	// Salt and the expiring water are used up, leaving 1 cup of the later water
+	if len(items) != 1 {
+		t.Fatalf("expected 1 remaining item, got %+v", items)
+	}
+	if items[0].Ingredient.Label != "Water" || items[0].Quantity != 1 {
+		t.Fatalf("expected 1 cup of water left, got %v %s", items[0].Quantity, items[0].Ingredient.Label)
+	}
+
+	// Cooking again fails without touching stock
+	w = serveJSON(t, router, http.MethodPost, fmt.Sprintf("/pantry/user/cook/cook/%d", recipe.RecipeID), nil)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+	w = serveJSON(t, router, http.MethodGet, "/pantry/user/cook", nil)
+	json.NewDecoder(w.Body).Decode(&items)
+	if len(items) != 1 || items[0].Quantity != 1 {
+		t.Fatalf("expected stock to be unchanged, got %+v", items)
+	}
+}
+
+func TestPantryExpiring(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+	recipe := postTestRecipe(t, testApp, createTestRecipe(t, testApp, true))
+
+	tomorrow := time.Now().AddDate(0, 0, 1)
+	nextMonth := time.Now().AddDate(0, 1, 0)
+	addTestPantryItem(t, router, "Water", 10, "Cup", &tomorrow)
+	addTestPantryItem(t, router, "Salt", 10, "Cup", &nextMonth)
+
+	w := serveJSON(t, router, http.MethodGet, "/pantry/user/cook/expiring?days=3", nil)
+	var response struct {
+		Items   []models.PantryItem `json:"items"`
+		Recipes []expiringRecipe    `json:"recipes"`
+	}
+	json.NewDecoder(w.Body).Decode(&response)
+
+	if len(response.Items) != 1 || response.Items[0].Ingredient.Label != "Water" {
+		t.Fatalf("expected only water to be expiring, got %+v", response.Items)
+	}
+	if len(response.Recipes) != 1 || response.Recipes[0].Recipe.RecipeID != recipe.RecipeID {
+		t.Fatalf("expected the recipe to be suggested, got %+v", response.Recipes)
+	}
+	if !response.Recipes[0].Cookable || len(response.Recipes[0].Uses) != 1 || response.Recipes[0].Uses[0] != "Water" {
+		t.Fatalf("unexpected suggestion %+v", response.Recipes[0])
+	}
+}
+
+func TestPantryRepeatedIngredient(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+
+	// Water twice in cups, and once more in ml
+	line := func(amount float32, unit string) models.RecipeIngredient {
+		return models.RecipeIngredient{Amount: ToPtr(amount), Ingredient: createTestIngredient("Water"), Unit: createTestUnit(unit)}
+	}
+	recipe := postTestRecipe(t, testApp, models.Recipe{
+		Name:         "Porridge",
+		Difficulty:   1,
+		Ingredients:  []models.RecipeIngredient{line(1, "Cup"), line(1, "Cup"), line(250, "ml")},
+		Instructions: []models.Instruction{{StepNumber: 1, StepText: "simmer"}},
+	})
+	cook := fmt.Sprintf("/pantry/user/cook/cook/%d", recipe.RecipeID)
+
+	// Each line alone fits in 1.5 cups, but not both cup lines together
+	addTestPantryItem(t, router, "Water", 1.5, "Cup", nil)
+	w := serveJSON(t, router, http.MethodGet, "/pantry/user/cook/cookable", nil)
+	var availability []recipeAvailability
+	json.NewDecoder(w.Body).Decode(&availability)
+	if len(availability) != 1 || len(availability[0].Missing) != 1 || *availability[0].Missing[0].Needed != 2 {
+		t.Fatalf("expected 2 cups of water missing, got %+v", availability)
+	}
+	if w := serveJSON(t, router, http.MethodPost, cook, nil); w.Code != http.StatusConflict {
+		t.Fatalf("expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+
+	// 2.5 cups covers the cup lines and the ml line on its own, but not all three
+	addTestPantryItem(t, router, "Water", 1, "Cup", nil)
+	if w := serveJSON(t, router, http.MethodPost, cook, nil); w.Code != http.StatusConflict {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusConflict, w.Code, w.Body.String())
+	}
+	var items []models.PantryItem
+	json.NewDecoder(serveJSON(t, router, http.MethodGet, "/pantry/user/cook", nil).Body).Decode(&items)
+	if len(items) != 2 || items[0].Quantity+items[1].Quantity != 2.5 {
+		t.Fatalf("expected stock to be unchanged, got %+v", items)
+	}
+
+	addTestPantryItem(t, router, "Water", 1, "Cup", nil)
+	if w := serveJSON(t, router, http.MethodPost, cook, nil); w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	json.NewDecoder(serveJSON(t, router, http.MethodGet, "/pantry/user/cook", nil).Body).Decode(&items)
+	if len(items) != 1 || items[0].Quantity != 0.5 {
+		t.Fatalf("expected half a cup left, got %+v", items)
+	}
+}
@@ -0,0 +1,292 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"recipe-api/internal/models"
+)
+
+// Page of a recipe's reviews
+type reviewPage struct {
+	Reviews []models.Rating `json:"reviews"`
+	Page    int             `json:"page"`
+	PerPage int             `json:"perPage"`
+	Total   int64           `json:"total"`
+}
+
+// Rating with the recipe's new aggregate
+type ratingResponse struct {
+	Rating        models.Rating `json:"rating"`
+	RatingAverage float64       `json:"ratingAverage"`
+	RatingCount   int           `json:"ratingCount"`
+}
+
+// Recompute a recipe's average rating and count from its ratings. The
+// recipe row is locked first, so concurrent ratings recompute one after
+// another and each sees the ratings committed before it.
+func refreshRating(tx *gorm.DB, recipeID int) (models.Recipe, error) {
+	var locked models.Recipe
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("recipe_id").First(&locked, recipeID).Error
+	if err != nil {
+		return models.Recipe{}, err
+	}
+
+	var aggregate struct {
+		Average float64
+		Count   int
+	}
+	err = tx.Model(&models.Rating{}).Select("COALESCE(AVG(stars), 0) AS average, COUNT(*) AS count").
+		Where("recipe_id = ?", recipeID).Scan(&aggregate).Error
+	if err != nil {
+		return models.Recipe{}, err
+	}
+
+	recipe := models.Recipe{
+		RecipeID:      recipeID,
+		RatingAverage: math.Round(aggregate.Average*100) / 100,
+		RatingCount:   aggregate.Count,
+	}
+	err = tx.Model(&recipe).Select("RatingAverage", "RatingCount").Updates(&recipe).Error
+	return recipe, err
+}
+
+func recipeIDFromRoute(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid recipe ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// Rate a recipe, replacing the user's earlier rating of it
+func (app *App) rateRecipe(w http.ResponseWriter, r *http.Request) {
+	recipeID, ok := recipeIDFromRoute(w, r)
+	if !ok {
+		return
+	}
+
+	var data models.Rating
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if data.UserID == "" {
+		http.Error(w, "userID is required", http.StatusBadRequest)
+		return
+	}
+	if data.Stars < 1 || data.Stars > 5 {
+		http.Error(w, "stars must be between 1 and 5", http.StatusBadRequest)
+		return
+	}
+
+	var recipe models.Recipe
+	if result := app.Repo.DB.First(&recipe, recipeID); result.Error != nil {
+		http.Error(w, "Recipe not found", http.StatusNotFound)
+		return
+	}
+
+	response := ratingResponse{Rating: models.Rating{
+		RecipeID: recipeID,
+		UserID:   data.UserID,
+		Stars:    data.Stars,
+		Review:   data.Review,
+	}}
+	err := app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "recipe_id"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"stars", "review", "updated_at"}),
+		}).Create(&response.Rating)
+		if result.Error != nil {
+			return result.Error
+		}
+		if err := tx.Where("recipe_id = ? AND user_id = ?", recipeID, data.UserID).First(&response.Rating).Error; err != nil {
+			return err
+		}
+
+		recipe, err := refreshRating(tx, recipeID)
+		response.RatingAverage, response.RatingCount = recipe.RatingAverage, recipe.RatingCount
+		return err
+	})
+	if err != nil {
+		app.Logger.Println("Transaction Failed:", err)
+		http.Error(w, "Failed to save rating", http.StatusInternalServerError)
+		return
+	}
+	app.forgetRecipes(r.Context(), recipeID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Remove a user's rating of a recipe
+func (app *App) deleteRecipeRating(w http.ResponseWriter, r *http.Request) {
+	recipeID, ok := recipeIDFromRoute(w, r)
+	if !ok {
+		return
+	}
+	userID := mux.Vars(r)["userID"]
+
+	err := app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("recipe_id = ? AND user_id = ?", recipeID, userID).Delete(&models.Rating{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		_, err := refreshRating(tx, recipeID)
+		return err
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Rating not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.Logger.Println("Transaction Failed:", err)
+		http.Error(w, "Failed to delete rating", http.StatusInternalServerError)
+		return
+	}
+	app.forgetRecipes(r.Context(), recipeID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Page through a recipe's ratings and reviews, newest first.
+// ?page= starts at 1, ?per_page= defaults to 20 and is capped at 100.
+func (app *App) getRecipeReviews(w http.ResponseWriter, r *http.Request) {
+	recipeID, ok := recipeIDFromRoute(w, r)
+	if !ok {
+		return
+	}
+	page, perPage := 1, 20
+	if value := r.URL.Query().Get("page"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			http.Error(w, "invalid page", http.StatusBadRequest)
+			return
+		}
+		page = parsed
+	}
+	if value := r.URL.Query().Get("per_page"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			http.Error(w, "invalid per_page", http.StatusBadRequest)
+			return
+		}
+		perPage = min(parsed, 100)
+	}
+
+	response := reviewPage{Reviews: []models.Rating{}, Page: page, PerPage: perPage}
+	query := app.Repo.DB.Model(&models.Rating{}).Where("recipe_id = ?", recipeID)
+	if err := query.Count(&response.Total).Error; err != nil {
+		http.Error(w, "Error fetching reviews.", http.StatusInternalServerError)
+		return
+	}
+	result := query.Order("updated_at DESC").Order("rating_id DESC").
+		Offset((page - 1) * perPage).Limit(perPage).Find(&response.Reviews)
+	if result.Error != nil {
+		http.Error(w, "Error fetching reviews.", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Mark a recipe as a user's favourite
+func (app *App) addFavourite(w http.ResponseWriter, r *http.Request) {
+	var data models.Favourite
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if data.UserID == "" {
+		http.Error(w, "userID is required", http.StatusBadRequest)
+		return
+	}
+
+	var recipe models.Recipe
+	if result := app.Repo.DB.First(&recipe, data.RecipeID); result.Error != nil {
+		http.Error(w, "Recipe not found", http.StatusNotFound)
+		return
+	}
+
+	favourite := models.Favourite{RecipeID: data.RecipeID, UserID: data.UserID}
+	result := app.Repo.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&favourite)
+	if result.Error != nil {
+		http.Error(w, "Failed to save favourite", http.StatusInternalServerError)
+		return
+	}
+	status := http.StatusCreated
+	if result.RowsAffected == 0 {
+		status = http.StatusOK // already a favourite
+		app.Repo.DB.Where("recipe_id = ? AND user_id = ?", data.RecipeID, data.UserID).First(&favourite)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(favourite)
+}
+
+// Unmark a favourite
+func (app *App) deleteFavourite(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	result := app.Repo.DB.Where("user_id = ? AND recipe_id = ?", vars["userID"], vars["recipeID"]).Delete(&models.Favourite{})
+	if result.Error != nil {
+		http.Error(w, "Failed to delete favourite", http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 0 {
+		http.Error(w, "Favourite not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Get a user's favourite recipes, most recently added first
+func (app *App) getFavouritesByUser(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["userID"]
+
+	var favourites []models.Favourite
+	result := app.Repo.DB.Where("user_id = ?", userID).Order("created_at DESC").Order("favourite_id DESC").Find(&favourites)
+	if result.Error != nil {
+		http.Error(w, "Error fetching favourites.", http.StatusInternalServerError)
+		return
+	}
+
+	recipes := []models.Recipe{}
+	if len(favourites) > 0 {
+		ids := make([]int, len(favourites))
+		for i, favourite := range favourites {
+			ids[i] = favourite.RecipeID
+		}
+		var found []models.Recipe
+		if result := preloadRecipe(app.Repo.DB).Find(&found, ids); result.Error != nil {
+			http.Error(w, "Error fetching recipes.", http.StatusInternalServerError)
+			return
+		}
+		byID := make(map[int]models.Recipe, len(found))
+		for _, recipe := range found {
+			byID[recipe.RecipeID] = recipe
+		}
+		for _, id := range ids {
+			if recipe, ok := byID[id]; ok {
+				recipes = append(recipes, recipe)
+			}
+		}
+		app.decorateRecipes(recipes)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(recipes)
+}
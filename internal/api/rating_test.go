@@ -0,0 +1,123 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"recipe-api/internal/models"
+	"testing"
+)
+
+func rate(t *testing.T, router http.Handler, recipeID int, userID string, stars int) ratingResponse {
+	t.Helper()
+	path := fmt.Sprintf("/recipe/id/%d/rating", recipeID)
+	w := serveJSON(t, router, http.MethodPut, path, map[string]any{"userID": userID, "stars": stars, "review": "review by " + userID})
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT %s: expected status %d, got %d: %s", path, http.StatusOK, w.Code, w.Body.String())
+	}
+	var response ratingResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	return response
+}
+
+func TestRatingAggregate(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+	recipe := postTestRecipe(t, testApp, models.Recipe{Name: "Soup", Difficulty: 1})
+
+	rate(t, router, recipe.RecipeID, "ann", 5)
+	rate(t, router, recipe.RecipeID, "bob", 2)
+	response := rate(t, router, recipe.RecipeID, "cat", 4)
+	if response.RatingAverage != 3.67 || response.RatingCount != 3 {
+		t.Fatalf("expected 3.67 from 3 ratings, got %v from %d", response.RatingAverage, response.RatingCount)
+	}
+
+	// A second rating from the same user replaces the first
+	response = rate(t, router, recipe.RecipeID, "bob", 5)
+	if response.RatingAverage != 4.67 || response.RatingCount != 3 {
+		t.Fatalf("expected 4.67 from 3 ratings after re-rating, got %v from %d", response.RatingAverage, response.RatingCount)
+	}
+	if response.Rating.Stars != 5 || response.Rating.Review == nil {
+		t.Fatalf("expected the updated rating back, got %+v", response.Rating)
+	}
+
+	w := serveJSON(t, router, http.MethodPut, fmt.Sprintf("/recipe/id/%d/rating", recipe.RecipeID), map[string]any{"userID": "dan", "stars": 6})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d for 6 stars, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	w = serveJSON(t, router, http.MethodDelete, fmt.Sprintf("/recipe/id/%d/rating/ann", recipe.RecipeID), nil)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, w.Code)
+	}
+	var stored models.Recipe
+	testApp.Repo.DB.First(&stored, recipe.RecipeID)
+	if stored.RatingAverage != 4.5 || stored.RatingCount != 2 {
+		t.Fatalf("expected 4.5 from 2 ratings after a delete, got %v from %d", stored.RatingAverage, stored.RatingCount)
+	}
+
+	w = serveJSON(t, router, http.MethodGet, fmt.Sprintf("/recipe/id/%d/reviews?per_page=1&page=2", recipe.RecipeID), nil)
+	var page reviewPage
+	json.NewDecoder(w.Body).Decode(&page)
+	if page.Total != 2 || len(page.Reviews) != 1 || page.Reviews[0].UserID != "cat" {
+		t.Fatalf("expected cat's review on the second page of 2, got %+v", page)
+	}
+
+	// Deleting the recipe deletes its ratings
+	serveJSON(t, router, http.MethodDelete, fmt.Sprintf("/recipe/id/%d", recipe.RecipeID), nil)
+	var count int64
+	testApp.Repo.DB.Model(&models.Rating{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("expected ratings to be deleted with the recipe, %d remain", count)
+	}
+}
+
+func TestSortByRating(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+
+	ok := postTestRecipe(t, testApp, models.Recipe{Name: "Alright", Difficulty: 1})
+	best := postTestRecipe(t, testApp, models.Recipe{Name: "Best", Difficulty: 1})
+	postTestRecipe(t, testApp, models.Recipe{Name: "Unrated", Difficulty: 1})
+	rate(t, router, ok.RecipeID, "ann", 3)
+	rate(t, router, best.RecipeID, "ann", 5)
+
+	w := serveJSON(t, router, http.MethodGet, "/recipe/all?sort=rating", nil)
+	var recipes []models.Recipe
+	json.NewDecoder(w.Body).Decode(&recipes)
+	names := make([]string, len(recipes))
+	for i, recipe := range recipes {
+		names[i] = recipe.Name
+	}
+	if fmt.Sprint(names) != "[Best Alright Unrated]" {
+		t.Fatalf("expected highest rated first, got %v", names)
+	}
+}
+
+func TestFavourites(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+	soup := postTestRecipe(t, testApp, models.Recipe{Name: "Soup", Difficulty: 1})
+	stew := postTestRecipe(t, testApp, models.Recipe{Name: "Stew", Difficulty: 1})
+
+	for _, want := range []struct {
+		recipeID, status int
+	}{{soup.RecipeID, http.StatusCreated}, {stew.RecipeID, http.StatusCreated}, {soup.RecipeID, http.StatusOK}, {9999, http.StatusNotFound}} {
+		w := serveJSON(t, router, http.MethodPost, "/favourite/add", map[string]any{"userID": "ann", "recipe_id": want.recipeID})
+		if w.Code != want.status {
+			t.Fatalf("favouriting %d: expected status %d, got %d", want.recipeID, want.status, w.Code)
+		}
+	}
+
+	if names := recipeNames(t, router, "/favourite/user/ann"); names != "Soup,Stew" {
+		t.Fatalf("expected both favourites once, got %s", names)
+	}
+
+	w := serveJSON(t, router, http.MethodDelete, fmt.Sprintf("/favourite/user/ann/recipe/%d", soup.RecipeID), nil)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, w.Code)
+	}
+	if names := recipeNames(t, router, "/favourite/user/ann"); names != "Stew" {
+		t.Fatalf("expected only Stew left, got %s", names)
+	}
+}
@@ -2,13 +2,38 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 
 	"gorm.io/gorm"
 
+	"recipe-api/internal/diet"
+	"recipe-api/internal/events"
 	"recipe-api/internal/models"
+	"recipe-api/internal/repository"
+	"recipe-api/internal/timing"
 )
 
+// Request data the recipe rules reject, as opposed to a storage failure
+type invalidRecipe struct{ error }
+
+// Check a recipe payload before writing it
+func checkRecipe(db *gorm.DB, data *models.Recipe) error {
+	overrides, err := diet.CheckOverrides(data.DietOverrides)
+	if err != nil {
+		return invalidRecipe{err}
+	}
+	data.DietOverrides = overrides
+	if err := timing.CheckInstructions(data.Instructions); err != nil {
+		return invalidRecipe{err}
+	}
+	if err := validateTags(db, data.Tags); err != nil {
+		return invalidRecipe{err}
+	}
+	return nil
+}
+
 // Add new recipe
 func (app *App) addRecipe(w http.ResponseWriter, r *http.Request) {
 	var data models.Recipe
@@ -18,20 +43,56 @@ func (app *App) addRecipe(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	recipe, err := app.createRecipe(data)
+	var invalid invalidRecipe
+	if errors.As(err, &invalid) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		app.Logger.Println("Transaction Failed:", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", fmt.Sprintf("/v1/recipes/%d", recipe.RecipeID))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(recipe)
+}
+
+// Write a new recipe with its instructions, ingredients and tags, and
+// publish the created event
+func (app *App) createRecipe(data models.Recipe) (models.Recipe, error) {
+	return app.insertRecipe(data, 0, events.RecipeCreated)
+}
+
+// Write a recipe under the given ID, or a new one for 0, and publish an
+// event of the given type
+func (app *App) insertRecipe(data models.Recipe, id int, eventType string) (models.Recipe, error) {
+	if err := checkRecipe(app.Repo.DB, &data); err != nil {
+		return models.Recipe{}, err
+	}
+
 	// Rebuild structs
 	var recipe = models.Recipe{}
-	result := app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+	result := app.eventTransaction(func(tx *gorm.DB) (models.Event, error) {
 		// Recipe
 		recipe = models.Recipe{
-			Name:       data.Name,
-			Difficulty: data.Difficulty,
-			UserID:     data.UserID,
+			RecipeID:    id,
+			Name:        data.Name,
+			Difficulty:  data.Difficulty,
+			Description: data.Description,
+			Servings:    data.Servings,
+			UserID:      data.UserID,
+
+			DietOverrides: data.DietOverrides,
 		}
 
 		result := tx.Create(&recipe) // Check if exists
 		if result.Error != nil {
 			app.Logger.Println("Recipe error:", result.Error)
-			return result.Error
+			return models.Event{}, result.Error
 		}
 
 		// Insert instructions
@@ -41,13 +102,14 @@ func (app *App) addRecipe(w http.ResponseWriter, r *http.Request) {
 				StepNumber: data.Instructions[i].StepNumber,
 				StepText:   data.Instructions[i].StepText,
 				Duration:   data.Instructions[i].Duration,
+				Phase:      data.Instructions[i].Phase,
 				Notes:      data.Instructions[i].Notes,
 			}
 
 			result := tx.Create(&instruction)
 			if result.Error != nil {
 				app.Logger.Println("Instruction error:", result.Error)
-				return result.Error
+				return models.Event{}, result.Error
 			}
 			recipe.Instructions = append(recipe.Instructions, instruction) // For return created object
 		}
@@ -61,26 +123,20 @@ func (app *App) addRecipe(w http.ResponseWriter, r *http.Request) {
 			}
 			// Create the Ingredient
 			if data.Ingredients[i].Ingredient != nil {
-				var ingredient = models.Ingredient{
-					Label: data.Ingredients[i].Ingredient.Label,
-				}
-				result := tx.FirstOrCreate(&ingredient, models.Ingredient{Label: ingredient.Label}) // Check if exists
-				if result.Error != nil {
-					app.Logger.Println("Ingredient error:", result.Error)
-					return result.Error
+				ingredient, err := repository.FirstOrCreateIngredient(tx, data.Ingredients[i].Ingredient) // Check if exists
+				if err != nil {
+					app.Logger.Println("Ingredient error:", err)
+					return models.Event{}, err
 				}
 				// Set IngredientID in linker
 				ri.IngredientID = ingredient.IngredientID
 			}
 			// Create Unit
 			if data.Ingredients[i].Unit != nil {
-				var unit = models.Unit{
-					Label: data.Ingredients[i].Unit.Label,
-				}
-				result := tx.FirstOrCreate(&unit, models.Unit{Label: unit.Label}) // Check if exists
-				if result.Error != nil {
-					app.Logger.Println("Unit error:", result.Error)
-					return result.Error
+				unit, err := repository.FirstOrCreateUnit(tx, data.Ingredients[i].Unit) // Check if exists
+				if err != nil {
+					app.Logger.Println("Unit error:", err)
+					return models.Event{}, err
 				}
 				// Set UnitID in linker
 				ri.UnitID = &unit.UnitID
@@ -89,19 +145,30 @@ func (app *App) addRecipe(w http.ResponseWriter, r *http.Request) {
 			result := tx.Create(&ri)
 			if result.Error != nil {
 				app.Logger.Println("RecipeIngredient error:", result.Error)
-				return result.Error
+				return models.Event{}, result.Error
 			}
 			recipe.Ingredients = append(recipe.Ingredients, ri)
 		}
-		return nil
+
+		// Attach tags
+		if len(data.Tags) > 0 {
+			tags, err := resolveTags(tx, data.Tags)
+			if err != nil {
+				app.Logger.Println("Tag error:", err)
+				return models.Event{}, err
+			}
+			if err := tx.Model(&recipe).Association("Tags").Replace(tags); err != nil {
+				app.Logger.Println("Tag error:", err)
+				return models.Event{}, err
+			}
+		}
+
+		// Log the change for the event feed
+		return recordRecipeEvent(tx, eventType, recipe.RecipeID)
 	})
 
 	if result != nil {
-		app.Logger.Println("Transaction Failed:", result)
-		http.Error(w, result.Error(), http.StatusInternalServerError)
-		return
+		return models.Recipe{}, result
 	}
-
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(recipe)
+	return recipe, nil
 }
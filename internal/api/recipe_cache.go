@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	"recipe-api/internal/models"
+)
+
+// Cache keys. Recipes are stored as loaded, before decorateRecipe, so the
+// computed fields stay current. Names map to IDs and are checked on use,
+// so renames and deletes only need the ID entry dropped.
+func recipeIDKey(id int) string {
+	return "recipe:id:" + strconv.Itoa(id)
+}
+
+func recipeNameKey(name string) string {
+	return "recipe:name:" + name
+}
+
+// Load a recipe with its ingredients, instructions and tags through the
+// recipe cache. Missing recipes return gorm.ErrRecordNotFound.
+func (app *App) loadRecipe(ctx context.Context, id int) (models.Recipe, error) {
+	var recipe models.Recipe
+	data, err := app.RecipeCache.Fetch(ctx, recipeIDKey(id), func(ctx context.Context) ([]byte, error) {
+		if err := preloadRecipe(app.Repo.DB.WithContext(ctx)).First(&recipe, id).Error; err != nil {
+			return nil, err
+		}
+		return json.Marshal(recipe)
+	})
+	if err != nil {
+		return models.Recipe{}, err
+	}
+	recipe = models.Recipe{}
+	return recipe, json.Unmarshal(data, &recipe)
+}
+
+// Load a recipe by its name through the recipe cache
+func (app *App) loadRecipeByName(ctx context.Context, name string) (models.Recipe, error) {
+	data, err := app.RecipeCache.Fetch(ctx, recipeNameKey(name), func(ctx context.Context) ([]byte, error) {
+		var recipe models.Recipe
+		if err := app.Repo.DB.WithContext(ctx).Select("recipe_id").First(&recipe, "name = ?", name).Error; err != nil {
+			return nil, err
+		}
+		return strconv.AppendInt(nil, int64(recipe.RecipeID), 10), nil
+	})
+	if err != nil {
+		return models.Recipe{}, err
+	}
+	id, err := strconv.Atoi(string(data))
+	if err != nil {
+		return models.Recipe{}, err
+	}
+
+	recipe, err := app.loadRecipe(ctx, id)
+	if err == nil && recipe.Name == name {
+		return recipe, nil
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.Recipe{}, err
+	}
+
+	// Renamed or deleted since the name was cached; ask the database
+	app.RecipeCache.Delete(ctx, recipeNameKey(name))
+	var fresh models.Recipe
+	if err := app.Repo.DB.WithContext(ctx).Select("recipe_id").First(&fresh, "name = ?", name).Error; err != nil {
+		return models.Recipe{}, err
+	}
+	return app.loadRecipe(ctx, fresh.RecipeID)
+}
+
+// Move UpdatedAt on for recipes showing shared data that changed, so their
+// Last-Modified does too. ids is a list or a subquery of recipe IDs.
+func touchRecipes(db *gorm.DB, ids any) error {
+	return db.Model(&models.Recipe{}).Where("recipe_id IN (?)", ids).UpdateColumn("updated_at", time.Now()).Error
+}
+
+// Drop cached recipes after they change
+func (app *App) forgetRecipes(ctx context.Context, ids ...int) {
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = recipeIDKey(id)
+	}
+	app.RecipeCache.Delete(ctx, keys...)
+}
+
+// Drop every cached recipe, after a change to ingredients, units or tags
+// that many recipes share
+func (app *App) forgetAllRecipes(ctx context.Context) {
+	app.RecipeCache.Clear(ctx)
+}
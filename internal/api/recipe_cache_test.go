@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"recipe-api/internal/models"
+)
+
+// Fetch a recipe, failing unless it is found
+func getCachedRecipe(t *testing.T, router http.Handler, path string) models.Recipe {
+	t.Helper()
+	w := serveJSON(t, router, http.MethodGet, path, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET %s: expected status %d, got %d: %s", path, http.StatusOK, w.Code, w.Body.String())
+	}
+	var recipe models.Recipe
+	if err := json.NewDecoder(w.Body).Decode(&recipe); err != nil {
+		t.Fatalf("GET %s: %v", path, err)
+	}
+	return recipe
+}
+
+func TestRecipeCache(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+
+	created := postTestRecipe(t, testApp, models.Recipe{
+		Name:       "Flapjacks",
+		Difficulty: 1,
+		Ingredients: []models.RecipeIngredient{
+			{Amount: ToPtr(float32(2)), Ingredient: &models.Ingredient{Label: "Rolled oats", Allergens: models.StringList{"gluten"}}, Unit: createTestUnit("Mug")},
+		},
+		Instructions: []models.Instruction{{StepNumber: 1, StepText: "bake"}},
+	})
+	byID := fmt.Sprintf("/recipe/id/%d", created.RecipeID)
+
+	before := testApp.RecipeCache.Stats()
+	first := getCachedRecipe(t, router, byID)
+	second := getCachedRecipe(t, router, byID)
+	byName := getCachedRecipe(t, router, "/recipe/name/Flapjacks")
+	loads := testApp.RecipeCache.Stats().Loads - before.Loads
+	if loads != 2 {
+		t.Errorf("expected one load for the recipe and one for its name, got %d", loads)
+	}
+	if first.Name != "Flapjacks" || second.Name != first.Name || byName.RecipeID != created.RecipeID {
+		t.Fatalf("unexpected cached recipes %+v %+v %+v", first, second, byName)
+	}
+	if second.Diets == nil || len(second.Ingredients) != 1 || second.Ingredients[0].Ingredient.Label != "Rolled oats" {
+		t.Errorf("expected a cached recipe to be loaded and decorated in full, got %+v", second)
+	}
+
+	// Updates and renames show straight away
+	first.Name, first.Difficulty = "Oat bars", 2
+	if w := serveJSON(t, router, http.MethodPut, byID, first); w.Code != http.StatusOK {
+		t.Fatalf("update: %d %s", w.Code, w.Body.String())
+	}
+	if recipe := getCachedRecipe(t, router, byID); recipe.Name != "Oat bars" || recipe.Difficulty != 2 {
+		t.Errorf("expected the update after a write, got %+v", recipe)
+	}
+	if w := serveJSON(t, router, http.MethodGet, "/recipe/name/Flapjacks", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected the old name to be gone, got %d", w.Code)
+	}
+	getCachedRecipe(t, router, "/recipe/name/"+url.PathEscape("Oat bars"))
+
+	// Ratings and catalogue renames change what a cached recipe holds
+	if w := serveJSON(t, router, http.MethodPut, byID+"/rating", map[string]any{"userID": "bea", "stars": 4}); w.Code != http.StatusOK {
+		t.Fatalf("rate: %d %s", w.Code, w.Body.String())
+	}
+	if recipe := getCachedRecipe(t, router, byID); recipe.RatingCount != 1 || recipe.RatingAverage != 4 {
+		t.Errorf("expected the new rating, got %d at %v", recipe.RatingCount, recipe.RatingAverage)
+	}
+	ingredientPath := fmt.Sprintf("/ingredient/id/%d", first.Ingredients[0].IngredientID)
+	if w := serveJSON(t, router, http.MethodPut, ingredientPath, map[string]any{"label": "Jumbo oats"}); w.Code != http.StatusOK {
+		t.Fatalf("rename ingredient: %d %s", w.Code, w.Body.String())
+	}
+	if recipe := getCachedRecipe(t, router, byID); recipe.Ingredients[0].Ingredient.Label != "Jumbo oats" {
+		t.Errorf("expected the renamed ingredient, got %q", recipe.Ingredients[0].Ingredient.Label)
+	}
+
+	// Deleted recipes are gone by ID and name
+	if w := serveJSON(t, router, http.MethodDelete, byID, nil); w.Code != http.StatusNoContent {
+		t.Fatalf("delete: %d %s", w.Code, w.Body.String())
+	}
+	for _, path := range []string{byID, "/recipe/name/" + url.PathEscape("Oat bars")} {
+		if w := serveJSON(t, router, http.MethodGet, path, nil); w.Code != http.StatusNotFound {
+			t.Errorf("GET %s: expected %d after delete, got %d", path, http.StatusNotFound, w.Code)
+		}
+	}
+}
+
+func TestRecipeLastModified(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+
+	created := postTestRecipe(t, testApp, models.Recipe{
+		Name:        "Porridge",
+		Difficulty:  1,
+		Ingredients: []models.RecipeIngredient{{Amount: ToPtr(float32(1)), Ingredient: createTestIngredient("Porridge oats")}},
+	})
+	byID := fmt.Sprintf("/recipe/id/%d", created.RecipeID)
+	lastModified := func() time.Time {
+		t.Helper()
+		w := serveJSON(t, router, http.MethodGet, byID, nil)
+		modified, err := http.ParseTime(w.Header().Get("Last-Modified"))
+		if err != nil {
+			t.Fatalf("GET %s: bad Last-Modified %q", byID, w.Header().Get("Last-Modified"))
+		}
+		return modified
+	}
+
+	old := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	age := func() {
+		testApp.Repo.DB.Model(&models.Recipe{}).Where("recipe_id = ?", created.RecipeID).UpdateColumn("updated_at", old)
+		testApp.forgetRecipes(context.Background(), created.RecipeID)
+	}
+	age()
+	if modified := lastModified(); !modified.Equal(old) {
+		t.Fatalf("expected Last-Modified %v from the recipe, got %v", old, modified)
+	}
+
+	// Edits and changes to the ingredients it shows move it on
+	recipe := getCachedRecipe(t, router, byID)
+	recipe.Difficulty = 2
+	if w := serveJSON(t, router, http.MethodPut, byID, recipe); w.Code != http.StatusOK {
+		t.Fatalf("update: %d %s", w.Code, w.Body.String())
+	}
+	if modified := lastModified(); !modified.After(old) {
+		t.Errorf("expected an update to move Last-Modified on, got %v", modified)
+	}
+	age()
+	ingredientPath := fmt.Sprintf("/ingredient/id/%d", recipe.Ingredients[0].IngredientID)
+	if w := serveJSON(t, router, http.MethodPut, ingredientPath, map[string]any{"label": "Rolled porridge oats"}); w.Code != http.StatusOK {
+		t.Fatalf("rename ingredient: %d %s", w.Code, w.Body.String())
+	}
+	if modified := lastModified(); !modified.After(old) {
+		t.Errorf("expected an ingredient rename to move Last-Modified on, got %v", modified)
+	}
+}
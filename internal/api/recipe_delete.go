@@ -1,11 +1,16 @@
 package api
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"recipe-api/internal/events"
 	"recipe-api/internal/models"
 	"strconv"
 
 	"github.com/gorilla/mux"
+	"gorm.io/gorm"
 )
 
 // Delete recipe using id
@@ -23,17 +28,17 @@ func (app *App) deleteRecipeByID(w http.ResponseWriter, r *http.Request) {
 	result := app.Repo.DB.First(&check, id)
 	if result.Error != nil {
 		app.Logger.Println("Recipe not found")
+		http.Error(w, "Recipe not found", http.StatusNotFound)
 		return
 	}
 
-	result = app.Repo.DB.Delete(&models.Recipe{}, id)
-	if result.Error != nil {
-		http.Error(w, "Failed to delete recipe by ID", http.StatusInternalServerError)
+	err = app.removeRecipe(check.RecipeID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Table unaffacted", http.StatusNotFound)
 		return
 	}
-
-	if result.RowsAffected == 0 {
-		http.Error(w, "Table unaffacted", http.StatusNotFound)
+	if err != nil {
+		http.Error(w, "Failed to delete recipe by ID", http.StatusInternalServerError)
 		return
 	}
 
@@ -47,23 +52,166 @@ func (app *App) deleteRecipeByName(w http.ResponseWriter, r *http.Request) {
 	recipeName := vars["name"]
 
 	var check models.Recipe
-	result := app.Repo.DB.First(&check, check.Name)
+	result := app.Repo.DB.First(&check, "name = ?", recipeName)
 	if result.Error != nil {
 		app.Logger.Println("Recipe not found")
+		http.Error(w, "Recipe not found", http.StatusNotFound)
 		return
 	}
 
-	result = app.Repo.DB.Where("name = ?", recipeName).Delete(&models.Recipe{})
-	if result.Error != nil {
+	err := app.removeRecipe(check.RecipeID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Table unaffacted", http.StatusNotFound)
+		return
+	}
+	if err != nil {
 		http.Error(w, "Failed to delete recipe by name", http.StatusInternalServerError)
 		return
 	}
 
+	w.WriteHeader(http.StatusNoContent)
+	app.Logger.Printf("Recipe '%s' deleted successfully", recipeName)
+}
+
+// Delete a recipe with everything hanging off it, then its image files, and
+// publish the deleted event
+func (app *App) removeRecipe(recipeID int) error {
+	images, _ := recipeImages(app.Repo.DB, recipeID)
+	var sessions []models.CookSession
+	err := app.eventTransaction(func(tx *gorm.DB) (models.Event, error) {
+		deleted, err := recordRecipeEvent(tx, events.RecipeDeleted, recipeID)
+		if err != nil {
+			return deleted, err
+		}
+		sessions, err = deleteRecipeTree(tx, recipeID)
+		return deleted, err
+	})
+	if err != nil {
+		return err
+	}
+
+	app.endCookSessions(sessions)
+	app.removeImageFiles(images)
+	return nil
+}
+
+// Delete a recipe with its child rows so foreign keys hold on every dialect,
+// returning the cook sessions that went with it
+func deleteRecipeTree(tx *gorm.DB, recipeID int) ([]models.CookSession, error) {
+	result := tx.Where("recipe_id = ?", recipeID).Delete(&models.MealPlanEntry{})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	sessions, err := deleteCookSessions(tx, "recipe_id = ?", recipeID)
+	if err != nil {
+		return nil, err
+	}
+
+	result = tx.Where("recipe_id = ?", recipeID).Delete(&models.Rating{})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	result = tx.Where("recipe_id = ?", recipeID).Delete(&models.Favourite{})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	result = tx.Where("recipe_id = ?", recipeID).Delete(&models.CollectionEntry{})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	result = tx.Where("recipe_id = ?", recipeID).Delete(&models.RecipeIngredient{})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	result = tx.Where("recipe_id = ?", recipeID).Delete(&models.Instruction{})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	result = tx.Where("recipe_id = ?", recipeID).Delete(&models.Image{})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	result = tx.Exec("DELETE FROM recipe_tags WHERE recipe_id = ?", recipeID)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	result = tx.Delete(&models.Recipe{}, recipeID)
+	if result.Error != nil {
+		return nil, result.Error
+	}
 	if result.RowsAffected == 0 {
-		http.Error(w, "Table unaffacted", http.StatusNotFound)
+		return nil, gorm.ErrRecordNotFound
+	}
+	return sessions, nil
+}
+
+// Bring a deleted recipe back under its old ID from the last deleted event
+// in the log, and publish the restored event. Ratings, favourites, images
+// and collection entries went with it and stay gone.
+func (app *App) restoreRecipeByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid recipe ID", http.StatusBadRequest)
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
-	app.Logger.Printf("Recipe '%s' deleted successfully", recipeName)
+	var existing int64
+	if err := app.Repo.DB.Model(&models.Recipe{}).Where("recipe_id = ?", id).Count(&existing).Error; err != nil {
+		http.Error(w, "Error fetching recipe.", http.StatusInternalServerError)
+		return
+	}
+	if existing > 0 {
+		http.Error(w, "Recipe has not been deleted", http.StatusConflict)
+		return
+	}
+
+	var deleted models.Event
+	result := app.Repo.DB.Where("recipe_id = ? AND type = ?", id, events.RecipeDeleted).
+		Order("event_id DESC").Limit(1).Find(&deleted)
+	if result.Error != nil {
+		http.Error(w, "Error fetching events.", http.StatusInternalServerError)
+		return
+	}
+	// Deletes older than the event retention, or logged with only an ID and
+	// name, can't be undone
+	var fields map[string]json.RawMessage
+	var data models.Recipe
+	if result.RowsAffected == 0 || json.Unmarshal([]byte(deleted.Data), &fields) != nil || fields["difficulty"] == nil ||
+		json.Unmarshal([]byte(deleted.Data), &data) != nil {
+		http.Error(w, "No restorable delete of this recipe in the event log", http.StatusNotFound)
+		return
+	}
+
+	var taken int64
+	app.Repo.DB.Model(&models.Recipe{}).Where("name = ?", data.Name).Count(&taken)
+	if taken > 0 {
+		http.Error(w, fmt.Sprintf("a recipe named %q already exists", data.Name), http.StatusConflict)
+		return
+	}
+
+	recipe, err := app.insertRecipe(data, id, events.RecipeRestored)
+	var invalid invalidRecipe
+	if errors.As(err, &invalid) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		app.Logger.Println("Transaction Failed:", err)
+		http.Error(w, "Failed to restore recipe", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", fmt.Sprintf("/v1/recipes/%d", recipe.RecipeID))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(recipe)
+	app.Logger.Printf("Recipe '%d' restored successfully", id)
 }
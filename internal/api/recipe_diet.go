@@ -0,0 +1,103 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+
+	"recipe-api/internal/diet"
+	"recipe-api/internal/models"
+)
+
+// Subquery of recipe IDs using an ingredient that is one of the animal
+// products, contains one of the allergens or is unchecked, and so might
+func recipesUsing(db *gorm.DB, animals, allergens []string) *gorm.DB {
+	conditions := []string{"ingredients.diet_checked = ?"}
+	args := []any{false}
+	if len(animals) > 0 {
+		conditions = append(conditions, "ingredients.animal IN ?")
+		args = append(args, animals)
+	}
+	for _, allergen := range allergens {
+		conditions = append(conditions, "COALESCE(ingredients.allergens, '') LIKE ?")
+		args = append(args, "%,"+allergen+",%")
+	}
+
+	return db.Session(&gorm.Session{NewDB: true}).Table("recipe_ingredients").
+		Select("recipe_ingredients.recipe_id").
+		Joins("JOIN ingredients ON ingredients.ingredient_id = recipe_ingredients.ingredient_id").
+		Where(strings.Join(conditions, " OR "), args...)
+}
+
+// Condition matching recipes that suit a diet: flagged on manually, or not
+// flagged off and without any ingredient the diet rules out
+func dietCondition(db *gorm.DB, d diet.Diet) *gorm.DB {
+	overrides := "COALESCE(recipes.diet_overrides, '')"
+	return db.Session(&gorm.Session{NewDB: true}).
+		Where(overrides+" LIKE ?", "%,+"+d.Name+",%").
+		Or(db.Session(&gorm.Session{NewDB: true}).
+			Where(overrides+" NOT LIKE ?", "%,-"+d.Name+",%").
+			Where("recipes.recipe_id NOT IN (?)", recipesUsing(db, d.Animals, d.Allergens)))
+}
+
+// Set the allergens and animal product of an ingredient, marking it checked
+func (app *App) updateIngredientDiet(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid ingredient ID", http.StatusBadRequest)
+		return
+	}
+
+	var data models.Ingredient
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if err := diet.CheckIngredient(&data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var ingredient models.Ingredient
+	if result := app.Repo.DB.First(&ingredient, id); result.Error != nil {
+		http.Error(w, "Ingredient not found", http.StatusNotFound)
+		return
+	}
+
+	ingredient.Allergens = data.Allergens
+	ingredient.Animal = data.Animal
+	ingredient.DietChecked = true
+	err = app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&ingredient).Select("Allergens", "Animal", "DietChecked").Updates(&ingredient).Error; err != nil {
+			return err
+		}
+		return ingredientCatalogue.touch(tx, ingredient.IngredientID)
+	})
+	if err != nil {
+		app.Logger.Println("Transaction Failed:", err)
+		http.Error(w, "Failed to update ingredient", http.StatusInternalServerError)
+		return
+	}
+	app.forgetAllRecipes(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ingredient)
+}
+
+// Diets and allergens the filters accept
+func getDietVocabulary(w http.ResponseWriter, r *http.Request) {
+	diets := make([]string, len(diet.Diets))
+	for i, d := range diet.Diets {
+		diets[i] = d.Name
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string][]string{
+		"diets":     diets,
+		"allergens": diet.Allergens,
+		"animals":   diet.Animals,
+	})
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"recipe-api/internal/media"
+	"recipe-api/internal/models"
+	"recipe-api/internal/render"
+)
+
+// Most recipes one PDF export prints, and how many are read at a time
+const (
+	maxExportRecipes = 500
+	exportBatchSize  = 50
+)
+
+// Export recipes as a PDF cookbook. ?id= picks recipes in order, otherwise
+// the listing filters apply; ?title= names the book and ?images=false leaves
+// out the pictures. Recipes are read and printed a batch at a time.
+func (app *App) exportRecipesPDF(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	title := query.Get("title")
+	if title == "" {
+		title = "Cookbook"
+	}
+	book := render.Book{Title: title}
+
+	if ids := queryList(query, "id"); len(ids) > 0 {
+		if len(ids) > maxExportRecipes {
+			http.Error(w, fmt.Sprintf("at most %d recipes can be exported at once", maxExportRecipes), http.StatusBadRequest)
+			return
+		}
+		recipeIDs := make([]int, len(ids))
+		for i, id := range ids {
+			recipeID, err := strconv.Atoi(id)
+			if err != nil {
+				http.Error(w, "invalid recipe ID", http.StatusBadRequest)
+				return
+			}
+			recipeIDs[i] = recipeID
+		}
+		var found []int
+		if err := app.Repo.DB.Model(&models.Recipe{}).Where("recipe_id IN ?", recipeIDs).Pluck("recipe_id", &found).Error; err != nil {
+			http.Error(w, "Error fetching recipes.", http.StatusInternalServerError)
+			return
+		}
+		for _, id := range recipeIDs {
+			if !slices.Contains(found, id) {
+				http.Error(w, fmt.Sprintf("Recipe with id %d not found", id), http.StatusNotFound)
+				return
+			}
+		}
+
+		book.Count = len(recipeIDs)
+		book.Batches = func(add func([]models.Recipe) error) error {
+			for chunk := range slices.Chunk(recipeIDs, exportBatchSize) {
+				var found []models.Recipe
+				if err := preloadRecipe(app.Repo.DB).Find(&found, chunk).Error; err != nil {
+					return err
+				}
+				recipes := make([]models.Recipe, 0, len(chunk))
+				for _, id := range chunk {
+					index := slices.IndexFunc(found, func(recipe models.Recipe) bool { return recipe.RecipeID == id })
+					if index >= 0 {
+						recipes = append(recipes, found[index])
+					}
+				}
+				app.decorateRecipes(recipes)
+				if err := add(recipes); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	} else {
+		filter, err := parseRecipeFilter(query)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var count int64
+		if err := filter.apply(app.Repo.DB.Model(&models.Recipe{})).Count(&count).Error; err != nil {
+			http.Error(w, "Error fetching recipes.", http.StatusInternalServerError)
+			return
+		}
+		if count > maxExportRecipes {
+			http.Error(w, fmt.Sprintf("%d recipes match, at most %d can be exported at once; narrow the filters", count, maxExportRecipes), http.StatusBadRequest)
+			return
+		}
+
+		// Paged by offset so the listing's order holds; FindInBatches pages by
+		// ID and would lose it
+		book.Count = int(count)
+		book.Batches = func(add func([]models.Recipe) error) error {
+			for offset := 0; offset < book.Count; offset += exportBatchSize {
+				var recipes []models.Recipe
+				page := filter.order(filter.apply(preloadRecipe(app.Repo.DB)), "").Order("recipes.recipe_id ASC")
+				if err := page.Offset(offset).Limit(exportBatchSize).Find(&recipes).Error; err != nil {
+					return err
+				}
+				if len(recipes) == 0 {
+					return nil
+				}
+				app.decorateRecipes(recipes)
+				if err := add(recipes); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	if book.Count == 0 {
+		http.Error(w, "No recipes to export", http.StatusNotFound)
+		return
+	}
+	app.writeCookbook(w, r, book)
+}
+
+// Stream a cookbook as a PDF download
+func (app *App) writeCookbook(w http.ResponseWriter, r *http.Request, book render.Book) {
+	var images render.ImageLoader
+	if r.URL.Query().Get("images") != "false" {
+		images = app.printImages(r.Context())
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", exportFilename(book.Title, "pdf")))
+	if err := render.Cookbook(w, book, images); err != nil {
+		// The response has started, so all that's left is to log it
+		app.Logger.Println("PDF export failed:", err)
+	}
+}
+
+// Images for print: the large thumbnail when there is one, else the original
+func (app *App) printImages(ctx context.Context) render.ImageLoader {
+	if app.Storage == nil {
+		return nil
+	}
+	return func(image models.Image) ([]byte, error) {
+		size := media.Original
+		if slices.Contains(image.Thumbnails, "large") {
+			size = "large"
+		}
+		data, _, err := app.Storage.Get(ctx, imageObject(image, size))
+		if err != nil {
+			app.Logger.Println("Image read failed:", err)
+		}
+		return data, err
+	}
+}
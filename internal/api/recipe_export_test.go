@@ -0,0 +1,73 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"recipe-api/internal/models"
+)
+
+func TestExportRecipesPDF(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+
+	stew := postTestRecipe(t, testApp, models.Recipe{Name: "Irish stew", Difficulty: 2, UserID: "cook",
+		Instructions: []models.Instruction{{StepNumber: 1, StepText: "Simmer", Duration: ToPtr(90)}}})
+	bread := postTestRecipe(t, testApp, models.Recipe{Name: "Wheaten bread", Difficulty: 1, UserID: "cook"})
+	w := uploadTestImage(t, router, fmt.Sprintf("/recipe/id/%d/image", bread.RecipeID), testImagePNG(t, 1200, 600))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("upload failed: %d %s", w.Code, w.Body.String())
+	}
+
+	w = serveJSON(t, router, http.MethodGet, fmt.Sprintf("/v1/recipes/export?id=%d,%d&title=Supper", bread.RecipeID, stew.RecipeID), nil)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/pdf" {
+		t.Fatalf("expected a PDF, got %d %s: %s", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+	}
+	if disposition := w.Header().Get("Content-Disposition"); disposition != `attachment; filename="supper.pdf"` {
+		t.Errorf("unexpected Content-Disposition %s", disposition)
+	}
+	doc := w.Body.Bytes()
+	// Cover, contents and a page per recipe, with the bread's picture
+	if !bytes.HasPrefix(doc, []byte("%PDF-")) || !bytes.Contains(doc, []byte("/Count 4 >>")) {
+		t.Fatalf("expected a four page PDF")
+	}
+	if bytes.Count(doc, []byte("/Subtype /Image")) != 1 {
+		t.Errorf("expected the bread's image embedded")
+	}
+
+	w = serveJSON(t, router, http.MethodGet, "/v1/recipes/export?images=false", nil)
+	if w.Code != http.StatusOK || bytes.Contains(w.Body.Bytes(), []byte("/Subtype /Image")) {
+		t.Errorf("expected a PDF without images, got %d", w.Code)
+	}
+
+	collection := decodeCollection(t, router, http.MethodPost, "/collection/add", models.Collection{
+		UserID: "cook", Name: "Comfort food", Entries: []models.CollectionEntry{{RecipeID: stew.RecipeID}},
+	})
+	w = serveJSON(t, router, http.MethodGet, fmt.Sprintf("/collection/id/%d/export?format=pdf&userID=cook", collection.CollectionID), nil)
+	if w.Code != http.StatusOK || !bytes.Contains(w.Body.Bytes(), []byte("/Count 3 >>")) {
+		t.Errorf("expected the collection as a three page PDF, got %d: %.200s", w.Code, w.Body.String())
+	}
+	if disposition := w.Header().Get("Content-Disposition"); disposition != `attachment; filename="comfort-food.pdf"` {
+		t.Errorf("unexpected Content-Disposition %s", disposition)
+	}
+
+	tooMany := "/v1/recipes/export?id=" + strings.Repeat(fmt.Sprintf("%d,", stew.RecipeID), maxExportRecipes) + "1"
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/v1/recipes/export?id=999", http.StatusNotFound},
+		{tooMany, http.StatusBadRequest},
+		{"/v1/recipes/export?id=soup", http.StatusBadRequest},
+		{"/v1/recipes/export?max_total_time=1", http.StatusNotFound},
+		{"/v1/recipes/export?diet=carnivore", http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		if w := serveJSON(t, router, http.MethodGet, test.path, nil); w.Code != test.status {
+			t.Errorf("GET %s: expected status %d, got %d", test.path, test.status, w.Code)
+		}
+	}
+}
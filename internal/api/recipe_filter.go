@@ -0,0 +1,188 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"recipe-api/internal/diet"
+	"recipe-api/internal/models"
+	"recipe-api/internal/repository"
+)
+
+// Filters shared by the recipe listing, search and random endpoints
+type recipeFilter struct {
+	diets            []diet.Diet  // recipes must suit every diet
+	excludeAllergens []string     // recipes must contain none of these
+	tags             []models.Tag // name with an optional kind
+	anyTag           bool         // recipes need one of the tags rather than all
+	maxTotalTime     *int         // minutes, recipes without step times never match
+	sort             string       // listing order, see order
+}
+
+// Comma separated and repeated values of a query parameter
+func queryList(query url.Values, key string) []string {
+	var values []string
+	for _, value := range query[key] {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				values = append(values, part)
+			}
+		}
+	}
+	return values
+}
+
+// Read ?diet=, ?exclude_allergen=, ?tag=, ?tag_mode=, ?max_total_time= and ?sort=
+// from a request's query.
+// Tags are given as name or kind:name.
+func parseRecipeFilter(query url.Values) (recipeFilter, error) {
+	var filter recipeFilter
+	for _, name := range queryList(query, "diet") {
+		d, ok := diet.Lookup(name)
+		if !ok {
+			return filter, fmt.Errorf("unknown diet %q", name)
+		}
+		filter.diets = append(filter.diets, d)
+	}
+	for _, name := range queryList(query, "exclude_allergen") {
+		allergen := diet.Normalise(name)
+		if !diet.IsAllergen(allergen) {
+			return filter, fmt.Errorf("unknown allergen %q", name)
+		}
+		filter.excludeAllergens = append(filter.excludeAllergens, allergen)
+	}
+	for _, value := range queryList(query, "tag") {
+		tag := models.Tag{Name: value}
+		if kind, name, found := strings.Cut(value, ":"); found {
+			tag = models.Tag{Kind: kind, Name: name}
+		}
+		tag.Name = strings.ToLower(repository.CanonicalLabel(tag.Name))
+		tag.Kind = strings.ToLower(strings.TrimSpace(tag.Kind))
+		if tag.Kind != "" && !slices.Contains(models.TagKinds, tag.Kind) {
+			return filter, fmt.Errorf("unknown tag kind %q", tag.Kind)
+		}
+		filter.tags = append(filter.tags, tag)
+	}
+	switch mode := strings.ToLower(query.Get("tag_mode")); mode {
+	case "", "and", "all":
+	case "or", "any":
+		filter.anyTag = true
+	default:
+		return filter, fmt.Errorf("unknown tag_mode %q", mode)
+	}
+	if value := query.Get("max_total_time"); value != "" {
+		minutes, err := strconv.Atoi(value)
+		if err != nil || minutes < 0 {
+			return filter, fmt.Errorf("max_total_time must be a number of minutes")
+		}
+		filter.maxTotalTime = &minutes
+	}
+	filter.sort = strings.ToLower(query.Get("sort"))
+	if filter.sort != "" && filter.sort != "name" && filter.sort != "rating" {
+		return filter, fmt.Errorf("sort must be name or rating")
+	}
+	return filter, nil
+}
+
+// Restrict a recipe query to the filter
+func (filter recipeFilter) apply(db *gorm.DB) *gorm.DB {
+	for _, d := range filter.diets {
+		db = db.Where(dietCondition(db, d))
+	}
+	if len(filter.excludeAllergens) > 0 {
+		db = db.Where("recipes.recipe_id NOT IN (?)", recipesUsing(db, nil, filter.excludeAllergens))
+	}
+	if len(filter.tags) > 0 {
+		if filter.anyTag {
+			db = db.Where("recipes.recipe_id IN (?)", recipesTagged(db, filter.tags...))
+		} else {
+			for _, tag := range filter.tags {
+				db = db.Where("recipes.recipe_id IN (?)", recipesTagged(db, tag))
+			}
+		}
+	}
+	if filter.maxTotalTime != nil {
+		// SUM is NULL when no step has a time, so untimed recipes drop out
+		db = db.Where("(SELECT SUM(instructions.duration) FROM instructions WHERE instructions.recipe_id = recipes.recipe_id) <= ?",
+			*filter.maxTotalTime)
+	}
+	return db
+}
+
+// Order a recipe listing by ?sort=, or by fallback when none was asked for.
+// Kept out of apply so random picks stay random.
+func (filter recipeFilter) order(db *gorm.DB, fallback string) *gorm.DB {
+	sort := filter.sort
+	if sort == "" {
+		sort = fallback
+	}
+	switch sort {
+	case "name":
+		return db.Order("recipes.name ASC")
+	case "rating":
+		return db.Order("recipes.rating_average DESC").Order("recipes.rating_count DESC").Order("recipes.name ASC")
+	}
+	return db
+}
+
+// Subquery of recipe IDs carrying any of the tags
+func recipesTagged(db *gorm.DB, tags ...models.Tag) *gorm.DB {
+	var conditions []string
+	var args []any
+	for _, tag := range tags {
+		if tag.Kind == "" {
+			conditions = append(conditions, "tags.name = ?")
+			args = append(args, tag.Name)
+		} else {
+			conditions = append(conditions, "(tags.kind = ? AND tags.name = ?)")
+			args = append(args, tag.Kind, tag.Name)
+		}
+	}
+
+	return db.Session(&gorm.Session{NewDB: true}).Table("recipe_tags").
+		Select("recipe_tags.recipe_id").
+		Joins("JOIN tags ON tags.tag_id = recipe_tags.tag_id").
+		Where(strings.Join(conditions, " OR "), args...)
+}
+
+// Recipes whose name, description or ingredient labels contain the term,
+// narrowed by the filter and in its order, by name by default
+func searchRecipeQuery(db *gorm.DB, filter recipeFilter, term string) *gorm.DB {
+	query := filter.apply(preloadRecipe(db))
+	if term = strings.TrimSpace(term); term != "" {
+		pattern := "%" + strings.ToLower(term) + "%"
+		byIngredient := db.Table("recipe_ingredients").
+			Select("recipe_ingredients.recipe_id").
+			Joins("JOIN ingredients ON ingredients.ingredient_id = recipe_ingredients.ingredient_id").
+			Where("LOWER(ingredients.label) LIKE ?", pattern)
+		query = query.Where("LOWER(recipes.name) LIKE ? OR LOWER(COALESCE(recipes.description, '')) LIKE ? OR recipes.recipe_id IN (?)",
+			pattern, pattern, byIngredient)
+	}
+	return filter.order(query, "name")
+}
+
+// Find recipes whose name, description or ingredients match ?q=, narrowed by
+// the listing filters
+func (app *App) searchRecipes(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseRecipeFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	recipes := []models.Recipe{}
+	if result := searchRecipeQuery(app.Repo.DB, filter, r.URL.Query().Get("q")).Find(&recipes); result.Error != nil {
+		app.Logger.Println("Search error:", result.Error)
+		http.Error(w, "Error searching recipes.", http.StatusInternalServerError)
+		return
+	}
+	app.decorateRecipes(recipes)
+
+	app.writeRecipes(w, r, recipes)
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"recipe-api/internal/models"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// Post a recipe made of the given ingredients, one of each
+func postDietRecipe(t *testing.T, name string, overrides models.DietOverrides, ingredients ...*models.Ingredient) models.Recipe {
+	t.Helper()
+	recipe := models.Recipe{Name: name, Difficulty: 2, UserID: "cook", DietOverrides: overrides}
+	for _, ingredient := range ingredients {
+		recipe.Ingredients = append(recipe.Ingredients, models.RecipeIngredient{
+			Amount:     ToPtr(float32(1)),
+			Ingredient: ingredient,
+		})
+	}
+	return postTestRecipe(t, testApp, recipe)
+}
+
+func recipeNames(t *testing.T, router http.Handler, path string) string {
+	t.Helper()
+	w := serveJSON(t, router, http.MethodGet, path, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET %s: expected status %d, got %d: %s", path, http.StatusOK, w.Code, w.Body.String())
+	}
+	var recipes []models.Recipe
+	json.NewDecoder(w.Body).Decode(&recipes)
+
+	names := make([]string, len(recipes))
+	for i, recipe := range recipes {
+		names[i] = recipe.Name
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func TestRecipeDietFilters(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+
+	egg := "egg"
+	postDietRecipe(t, "Salad", nil, &models.Ingredient{Label: "Lettuce", DietChecked: true})
+	postDietRecipe(t, "Bread", nil, &models.Ingredient{Label: "Flour", Allergens: models.StringList{"gluten"}})
+	postDietRecipe(t, "Omelette", nil, &models.Ingredient{Label: "Egg", Animal: &egg, Allergens: models.StringList{"egg"}})
+	postDietRecipe(t, "Pancakes", models.DietOverrides{"vegan": true},
+		&models.Ingredient{Label: "Flour"}, &models.Ingredient{Label: "Egg"})
+	postDietRecipe(t, "Curry", nil, &models.Ingredient{Label: "Curry paste"})
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/recipe/all", "Bread,Curry,Omelette,Pancakes,Salad"},
+		{"/recipe/all?diet=vegan", "Bread,Pancakes,Salad"},
+		{"/recipe/all?diet=vegan&exclude_allergen=gluten", "Salad"},
+		{"/recipe/all?diet=vegetarian,gluten-free", "Omelette,Salad"},
+		{"/recipe/all?exclude_allergen=egg&exclude_allergen=gluten", "Salad"},
+		{"/recipe/search?q=FLOUR", "Bread,Pancakes"},
+		{"/recipe/search?q=egg&diet=vegan", "Pancakes"},
+		{"/recipe/search?diet=egg-free", "Bread,Salad"},
+	}
+	for _, test := range tests {
+		if got := recipeNames(t, router, test.path); got != test.expected {
+			t.Errorf("GET %s: expected %s, got %s", test.path, test.expected, got)
+		}
+	}
+
+	var recipe models.Recipe
+	w := serveJSON(t, router, http.MethodGet, "/recipe/random/3?diet=vegan&exclude_allergen=gluten,egg", nil)
+	json.NewDecoder(w.Body).Decode(&recipe)
+	if recipe.Name != "Salad" || strings.Join(recipe.Diets, ",") != "vegan,vegetarian,pescatarian,gluten-free,dairy-free,egg-free,nut-free" {
+		t.Fatalf("expected the salad to suit every diet, got %s %v", recipe.Name, recipe.Diets)
+	}
+
+	// Nothing is known about curry paste, so the curry claims no diet
+	w = serveJSON(t, router, http.MethodGet, "/recipe/search?q=curry", nil)
+	var curry []models.Recipe
+	json.NewDecoder(w.Body).Decode(&curry)
+	if len(curry) != 1 || len(curry[0].Diets) != 0 || strings.Join(curry[0].Unverified, ",") != "Curry paste" {
+		t.Fatalf("expected the curry unverified and without diets, got %+v", curry)
+	}
+
+	w = serveJSON(t, router, http.MethodGet, "/recipe/random?diet=carnivore", nil)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d for an unknown diet, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	// Tagging lettuce with an allergen moves the salad out of the filter
+	var lettuce models.Ingredient
+	testApp.Repo.DB.First(&lettuce, "label = ?", "Lettuce")
+	w = serveJSON(t, router, http.MethodPut, fmt.Sprintf("/ingredient/id/%d/diet", lettuce.IngredientID),
+		models.Ingredient{Allergens: models.StringList{"Celery"}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if got := recipeNames(t, router, "/recipe/all?exclude_allergen=celery"); got != "Bread,Omelette,Pancakes" {
+		t.Fatalf("expected the salad to be excluded, got %s", got)
+	}
+}
+
+func TestRecipeTimingFilter(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+
+	bread := postTestRecipe(t, testApp, models.Recipe{Name: "Bread", Difficulty: 3, Instructions: []models.Instruction{
+		{StepNumber: 1, StepText: "knead", Duration: ToPtr(10), Phase: ToPtr("Prep")},
+		{StepNumber: 2, StepText: "prove", Duration: ToPtr(90), Phase: ToPtr("rest")},
+		{StepNumber: 3, StepText: "bake", Duration: ToPtr(35)},
+	}})
+	postTestRecipe(t, testApp, models.Recipe{Name: "Toast", Difficulty: 1, Instructions: []models.Instruction{
+		{StepNumber: 1, StepText: "toast", Duration: ToPtr(3)},
+	}})
+	postTestRecipe(t, testApp, models.Recipe{Name: "Untimed", Difficulty: 1, Instructions: []models.Instruction{
+		{StepNumber: 1, StepText: "assemble"},
+	}})
+
+	w := serveJSON(t, router, http.MethodGet, "/recipe/search?q=bread", nil)
+	var recipes []models.Recipe
+	json.NewDecoder(w.Body).Decode(&recipes)
+	if len(recipes) != 1 || recipes[0].RecipeID != bread.RecipeID || recipes[0].Timing == nil {
+		t.Fatalf("expected bread with its timing, got %+v", recipes)
+	}
+	timing := recipes[0].Timing
+	if timing.TotalMinutes != 135 || timing.ActiveMinutes != 45 || timing.TotalTime != "PT2H15M" || timing.PrepTime != "PT10M" {
+		t.Fatalf("unexpected timing %+v", timing)
+	}
+	if *recipes[0].Instructions[0].Phase != "prep" {
+		t.Fatalf("expected the phase to be normalised, got %s", *recipes[0].Instructions[0].Phase)
+	}
+
+	if names := recipeNames(t, router, "/recipe/all?max_total_time=60"); names != "Toast" {
+		t.Fatalf("expected only Toast within an hour, got %s", names)
+	}
+	if names := recipeNames(t, router, "/recipe/all?max_total_time=135"); names != "Bread,Toast" {
+		t.Fatalf("expected timed recipes within the limit, got %s", names)
+	}
+
+	w = serveJSON(t, router, http.MethodGet, "/recipe/all?max_total_time=soon", nil)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
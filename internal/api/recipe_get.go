@@ -1,7 +1,7 @@
 package api
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -9,14 +9,15 @@ import (
 	"github.com/gorilla/mux"
 	"gorm.io/gorm"
 
+	"recipe-api/internal/diet"
 	"recipe-api/internal/models"
+	"recipe-api/internal/render"
+	"recipe-api/internal/timing"
 )
 
-// Get all recipes
-func (app *App) getAllRecipes(w http.ResponseWriter, r *http.Request) {
-	var recipes []models.Recipe
-
-	result := app.Repo.DB.Preload("Ingredients", func(db *gorm.DB) *gorm.DB {
+// Load a recipe's ingredients, units and ordered instructions
+func preloadRecipe(db *gorm.DB) *gorm.DB {
+	return db.Preload("Ingredients", func(db *gorm.DB) *gorm.DB {
 		return db.Order("ingredient_id ASC")
 	}).
 		Preload("Ingredients.Ingredient"). // load Ingredient details
@@ -24,15 +25,51 @@ func (app *App) getAllRecipes(w http.ResponseWriter, r *http.Request) {
 		Preload("Instructions", func(db *gorm.DB) *gorm.DB {
 			return db.Order("step_number ASC")
 		}).
-		Find(&recipes)
+		Preload("Tags", func(db *gorm.DB) *gorm.DB {
+			return db.Order("kind ASC").Order("name ASC")
+		})
+}
+
+// Fill in the computed fields of recipes read from the database
+func (app *App) decorateRecipes(recipes []models.Recipe) {
+	for i := range recipes {
+		recipes[i].Diets, recipes[i].Allergens = diet.Derive(recipes[i])
+		recipes[i].Unverified = diet.Unverified(recipes[i])
+		recipes[i].Timing = timing.Compute(recipes[i].Instructions)
+	}
+	if err := attachNutrition(app.Repo.DB, recipes); err != nil {
+		app.Logger.Println("Nutrition error:", err)
+	}
+	if err := attachImages(app.Repo.DB, recipes); err != nil {
+		app.Logger.Println("Image error:", err)
+	}
+}
+
+func (app *App) decorateRecipe(recipe *models.Recipe) {
+	recipes := []models.Recipe{*recipe}
+	app.decorateRecipes(recipes)
+	*recipe = recipes[0]
+}
+
+// Get all recipes, optionally narrowed by the filters in parseRecipeFilter
+func (app *App) getAllRecipes(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseRecipeFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var recipes []models.Recipe
+
+	result := filter.order(filter.apply(preloadRecipe(app.Repo.DB)), "").Find(&recipes)
 
 	if result.Error != nil {
 		http.Error(w, "Error fetching recipes.", http.StatusNotFound)
 		return
 	}
+	app.decorateRecipes(recipes)
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(recipes)
+	app.writeRecipes(w, r, recipes)
 }
 
 // Find a recipe using the ID
@@ -45,51 +82,70 @@ func (app *App) getRecipeByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var recipe models.Recipe
-
-	result := app.Repo.DB.Preload("Ingredients", func(db *gorm.DB) *gorm.DB {
-		return db.Order("ingredient_id ASC")
-	}).Preload("Ingredients.Ingredient"). // load Ingredient details
-						Preload("Ingredients.Unit"). // load Unit details
-						Preload("Instructions", func(db *gorm.DB) *gorm.DB {
-			return db.Order("step_number ASC")
-		}).First(&recipe, id)
-	if result.Error != nil {
+	recipe, err := app.loadRecipe(r.Context(), id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
 		http.Error(w, fmt.Sprintf("Recipe with id %s not found", recipeID), http.StatusNotFound)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(recipe)
+	if err != nil {
+		app.Logger.Println("Recipe error:", err)
+		http.Error(w, "Error fetching recipe.", http.StatusInternalServerError)
+		return
+	}
+	app.decorateRecipe(&recipe)
+	setLastModified(w, recipe)
+	app.writeRecipe(w, r, recipe)
 }
 
 // Find a recipe using its name
 func (app *App) getRecipeByName(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	recipeName := vars["name"]
-	var recipe models.Recipe
 
-	result := app.Repo.DB.Preload("Ingredients", func(db *gorm.DB) *gorm.DB {
-		return db.Order("recipe_id ASC")
-	}).Preload("Ingredients.Ingredient"). // load Ingredient details
-						Preload("Ingredients.Unit"). // load Unit details
-						Preload("Instructions", func(db *gorm.DB) *gorm.DB {
-			return db.Order("step_number ASC")
-		}).First(&recipe, "name = ?", recipeName)
-	if result.Error != nil {
+	recipe, err := app.loadRecipeByName(r.Context(), recipeName)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
 		http.Error(w, fmt.Sprintf("Recipe %s not found", recipeName), http.StatusNotFound)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(recipe)
+	if err != nil {
+		app.Logger.Println("Recipe error:", err)
+		http.Error(w, "Error fetching recipe.", http.StatusInternalServerError)
+		return
+	}
+	app.decorateRecipe(&recipe)
+	setLastModified(w, recipe)
+	app.writeRecipe(w, r, recipe)
 }
 
-func (app *App) getNumberOfRecipes(w http.ResponseWriter, r *http.Request) {
-	var recipes []models.Recipe
-	result := app.Repo.DB.Find(&recipes)
-	if result.Error != nil {
-		app.Logger.Println("DB error:", result.Error)
+// Date the recipe last changed, for conditional requests
+func setLastModified(w http.ResponseWriter, recipe models.Recipe) {
+	if !recipe.UpdatedAt.IsZero() {
+		w.Header().Set("Last-Modified", recipe.UpdatedAt.UTC().Format(http.TimeFormat))
+	}
+}
+
+// Write a recipe in the format the request asks for
+func (app *App) writeRecipe(w http.ResponseWriter, r *http.Request, recipe models.Recipe) {
+	w.Header().Add("Vary", "Accept")
+	format, err := render.Negotiate(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotAcceptable)
+		return
+	}
+	if err := render.Recipe(w, format, recipe); err != nil {
+		app.Logger.Println("Render error:", err)
+	}
+}
+
+// Write a list of recipes in the format the request asks for
+func (app *App) writeRecipes(w http.ResponseWriter, r *http.Request, recipes []models.Recipe) {
+	w.Header().Add("Vary", "Accept")
+	format, err := render.Negotiate(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotAcceptable)
+		return
+	}
+	if err := render.Recipes(w, format, recipes); err != nil {
+		app.Logger.Println("Render error:", err)
 	}
-	app.Logger.Printf("Found %d recipes", len(recipes))
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(len(recipes))
 }
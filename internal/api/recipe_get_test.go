@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"recipe-api/internal/models"
+	"strings"
 	"testing"
 
 	"github.com/gorilla/mux"
@@ -167,3 +168,54 @@ func TestGetByName(t *testing.T) {
 	// 	t.Fatalf("Expected Method %q, got %q", created.Method, returned.Method)
 	// }
 }
+
+func TestRecipeContentNegotiation(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := mux.NewRouter()
+	router.HandleFunc("/recipe/all", testApp.getAllRecipes).Methods("GET")
+	router.HandleFunc("/recipe/id/{id}", testApp.getRecipeByID).Methods("GET")
+
+	recipe := postTestRecipe(t, testApp, models.Recipe{
+		Name:         "Colcannon",
+		Difficulty:   2,
+		Ingredients:  []models.RecipeIngredient{{Amount: ToPtr(float32(3)), Ingredient: createTestIngredient("Kale leaves")}},
+		Instructions: []models.Instruction{{StepNumber: 1, StepText: "Mash", Duration: ToPtr(5)}},
+	})
+	path := fmt.Sprintf("/recipe/id/%d", recipe.RecipeID)
+
+	tests := []struct {
+		path, accept, contentType, contains string
+		status                              int
+	}{
+		{path, "", "application/json", `"name":"Colcannon"`, http.StatusOK},
+		{path, "application/yaml", "application/yaml; charset=utf-8", "name: Colcannon\n", http.StatusOK},
+		{path, "text/markdown", "text/markdown; charset=utf-8", "## Ingredients\n\n- 3 Kale leaves\n", http.StatusOK},
+		{path, "text/html", "text/html; charset=utf-8", "<li>Mash <span class=\"time\">(5 min)</span>", http.StatusOK},
+		{path + "?format=markdown", "text/html", "text/markdown; charset=utf-8", "1. Mash\n", http.StatusOK},
+		{"/recipe/all?format=yaml", "", "application/yaml; charset=utf-8", "- id: ", http.StatusOK},
+		{path, "image/png", "", "", http.StatusNotAcceptable},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, test.path, nil)
+		req.Header.Set("Accept", test.accept)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != test.status {
+			t.Errorf("%s as %q: expected status %d, got %d", test.path, test.accept, test.status, w.Code)
+			continue
+		}
+		if w.Header().Get("Vary") != "Accept" {
+			t.Errorf("%s as %q: expected Vary: Accept", test.path, test.accept)
+		}
+		if test.status != http.StatusOK {
+			continue
+		}
+		if got := w.Header().Get("Content-Type"); got != test.contentType {
+			t.Errorf("%s as %q: expected %s, got %s", test.path, test.accept, test.contentType, got)
+		}
+		if !strings.Contains(w.Body.String(), test.contains) {
+			t.Errorf("%s as %q: expected body to contain %q, got:\n%s", test.path, test.accept, test.contains, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"recipe-api/internal/models"
+	"recipe-api/internal/nutrition"
+)
+
+// Compute nutrition for recipes with their ingredients preloaded, loading
+// every ingredient's facts in one query
+func attachNutrition(db *gorm.DB, recipes []models.Recipe) error {
+	var ids []int
+	for _, recipe := range recipes {
+		for _, ri := range recipe.Ingredients {
+			ids = append(ids, ri.IngredientID)
+		}
+	}
+
+	facts := make(map[int]models.Nutrition)
+	if len(ids) > 0 {
+		var rows []models.Nutrition
+		if err := db.Where("ingredient_id IN ?", ids).Find(&rows).Error; err != nil {
+			return err
+		}
+		for _, row := range rows {
+			facts[row.IngredientID] = row
+		}
+	}
+
+	for i := range recipes {
+		recipes[i].Nutrition = nutrition.Compute(recipes[i], facts)
+	}
+	return nil
+}
+
+// Get an ingredient's nutrition facts
+func (app *App) getIngredientNutrition(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid ingredient ID", http.StatusBadRequest)
+		return
+	}
+
+	var facts models.Nutrition
+	if result := app.Repo.DB.First(&facts, "ingredient_id = ?", id); result.Error != nil {
+		http.Error(w, "No nutrition data for ingredient", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(facts)
+}
+
+// Set an ingredient's nutrition facts, replacing any existing ones
+func (app *App) updateIngredientNutrition(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid ingredient ID", http.StatusBadRequest)
+		return
+	}
+
+	var facts models.Nutrition
+	if err := json.NewDecoder(r.Body).Decode(&facts); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if facts.Basis == "" {
+		facts.Basis = models.NutritionPer100g
+	}
+	if facts.Basis != models.NutritionPer100g && facts.Basis != models.NutritionPerUnit {
+		http.Error(w, "basis must be 100g or unit", http.StatusBadRequest)
+		return
+	}
+
+	var ingredient models.Ingredient
+	if result := app.Repo.DB.First(&ingredient, id); result.Error != nil {
+		http.Error(w, "Ingredient not found", http.StatusNotFound)
+		return
+	}
+
+	// Recipes show computed nutrition, so their Last-Modified moves too
+	facts.IngredientID = id
+	err = app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&facts).Error; err != nil {
+			return err
+		}
+		return ingredientCatalogue.touch(tx, id)
+	})
+	if err != nil {
+		app.Logger.Println("Transaction Failed:", err)
+		http.Error(w, "Failed to save nutrition", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(facts)
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"recipe-api/internal/models"
+	"testing"
+	"time"
+)
+
+func TestRecipeNutrition(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+
+	recipe := createTestRecipe(t, testApp, true)
+	recipe.Servings = ToPtr(2)
+	recipe = postTestRecipe(t, testApp, recipe)
+
+	var salt, water models.Ingredient
+	testApp.Repo.DB.First(&salt, "label = ?", "Salt")
+	testApp.Repo.DB.First(&water, "label = ?", "Water")
+
+	// 1 cup of salt at 2 g/ml weighs 500 g
+	old := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	testApp.Repo.DB.Model(&models.Recipe{}).Where("recipe_id = ?", recipe.RecipeID).UpdateColumn("updated_at", old)
+	facts := models.Nutrition{Density: ToPtr(2.0), Facts: models.NutritionFacts{Sodium: 38000}}
+	w := serveJSON(t, router, http.MethodPut, fmt.Sprintf("/ingredient/id/%d/nutrition", salt.IngredientID), facts)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var touched models.Recipe
+	testApp.Repo.DB.First(&touched, recipe.RecipeID)
+	if !touched.UpdatedAt.After(old) {
+		t.Errorf("expected new nutrition to move the recipe's UpdatedAt on, got %v", touched.UpdatedAt)
+	}
+
+	w = serveJSON(t, router, http.MethodGet, fmt.Sprintf("/ingredient/id/%d/nutrition", water.IngredientID), nil)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d for water, got %d", http.StatusNotFound, w.Code)
+	}
+
+	w = serveJSON(t, router, http.MethodGet, fmt.Sprintf("/recipe/id/%d", recipe.RecipeID), nil)
+	var got models.Recipe
+	json.NewDecoder(w.Body).Decode(&got)
+
+	if got.Nutrition == nil {
+		t.Fatal("expected nutrition on the recipe")
+	}
+	if got.Nutrition.Total.Sodium != 190000 || got.Nutrition.PerServing.Sodium != 95000 {
+		t.Errorf("unexpected sodium %+v", got.Nutrition)
+	}
+	if got.Nutrition.Complete || len(got.Nutrition.Unresolved) != 1 || got.Nutrition.Unresolved[0].Label != "Water" {
+		t.Errorf("expected water to be unresolved, got %+v", got.Nutrition.Unresolved)
+	}
+
+	w = serveJSON(t, router, http.MethodPut, fmt.Sprintf("/ingredient/id/%d/nutrition", salt.IngredientID), models.Nutrition{Basis: "cup"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d for an unknown basis, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -1,41 +1,69 @@
 package api
 
 import (
-	"encoding/json"
 	"net/http"
 	"recipe-api/internal/models"
+	"strconv"
 
 	"github.com/gorilla/mux"
 	"gorm.io/gorm"
 )
 
+// Random recipe selection shared by the random endpoints and meal plan auto-fill.
+// maxDifficulty of 0 means any difficulty; excludeIDs are never picked.
+func randomRecipeQuery(db *gorm.DB, maxDifficulty int, excludeIDs []int) *gorm.DB {
+	query := preloadRecipe(db)
+	if maxDifficulty > 0 {
+		query = query.Where("difficulty <= ?", maxDifficulty)
+	}
+	if len(excludeIDs) > 0 {
+		query = query.Where("recipe_id NOT IN ?", excludeIDs)
+	}
+	return query.Order("RANDOM()")
+}
+
 func (app *App) selectRandomRecipe(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseRecipeFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	var recipe models.Recipe
 
-	result := app.Repo.DB.Preload("Ingredients", func(db *gorm.DB) *gorm.DB {
-		return db.Order("ingredient_id ASC")
-	}).Preload("Ingredients.Ingredient"). // load Ingredient details
-						Preload("Ingredients.Unit"). // load Unit details
-						Preload("Instructions", func(db *gorm.DB) *gorm.DB {
-			return db.Order("step_number ASC")
-		}).Order("RANDOM()").First(&recipe)
+	result := filter.apply(randomRecipeQuery(app.Repo.DB, 0, nil)).First(&recipe)
 	if result.Error != nil {
 		http.Error(w, "Random recipe not retrieved", http.StatusNotFound)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(recipe)
+	app.decorateRecipe(&recipe)
+	// A new pick every time
+	w.Header().Set("Cache-Control", "no-store")
+	app.writeRecipe(w, r, recipe)
 }
 
 func (app *App) filterRandomRecipe(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
+	difficulty, err := strconv.Atoi(vars["difficulty"])
+	if err != nil || difficulty < 1 {
+		http.Error(w, "invalid difficulty", http.StatusBadRequest)
+		return
+	}
+	filter, err := parseRecipeFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	var recipe models.Recipe
-	result := app.Repo.DB.Where("difficulty <= ?", vars["difficulty"]).Order("RANDOM()").First(&recipe)
+	result := filter.apply(randomRecipeQuery(app.Repo.DB, difficulty, nil)).First(&recipe)
 	if result.Error != nil {
 		http.Error(w, "No recipe found", http.StatusNotFound)
 		return
 	}
+	app.decorateRecipe(&recipe)
+	// A new pick every time
+	w.Header().Set("Cache-Control", "no-store")
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(recipe)
+	app.writeRecipe(w, r, recipe)
 }
@@ -0,0 +1,222 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"recipe-api/internal/diet"
+	"recipe-api/internal/models"
+)
+
+// Recipe with substitutions applied, not saved
+type substitutedRecipe struct {
+	Recipe     models.Recipe                 `json:"recipe"`
+	Applied    []models.Substitution         `json:"applied"`
+	Unresolved []models.UnresolvedIngredient `json:"unresolved,omitempty"` // ingredients asked for without a usable swap
+}
+
+// Whether a substitution can be used for a diet: made for it, or with
+// replacements the diet allows
+func substitutionSuits(sub models.Substitution, d *diet.Diet) bool {
+	if d == nil || (sub.Diet != nil && *sub.Diet == d.Name) {
+		return true
+	}
+	for _, replacement := range sub.Replacements {
+		if replacement.Ingredient != nil && !d.Allows(*replacement.Ingredient) {
+			return false
+		}
+	}
+	return true
+}
+
+// Pick the substitution to use from an ingredient's candidates: swaps made for
+// the requested diet first, then general ones, then those for other diets
+func chooseSubstitution(candidates []models.Substitution, d *diet.Diet) (models.Substitution, bool) {
+	rank := func(sub models.Substitution) int {
+		switch {
+		case sub.Diet == nil:
+			return 1
+		case d != nil && *sub.Diet == d.Name:
+			return 0
+		default:
+			return 2
+		}
+	}
+
+	best := -1
+	for i, sub := range candidates {
+		if substitutionSuits(sub, d) && (best < 0 || rank(sub) < rank(candidates[best])) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return models.Substitution{}, false
+	}
+	return candidates[best], true
+}
+
+// Replace a recipe ingredient with a substitution's replacements, scaling
+// amounts by their ratios
+func applySubstitution(ri models.RecipeIngredient, sub models.Substitution) []models.RecipeIngredient {
+	lines := make([]models.RecipeIngredient, 0, len(sub.Replacements))
+	for _, replacement := range sub.Replacements {
+		line := models.RecipeIngredient{
+			RecipeID:     ri.RecipeID,
+			IngredientID: replacement.IngredientID,
+			Ingredient:   replacement.Ingredient,
+			UnitID:       ri.UnitID,
+			Unit:         ri.Unit,
+		}
+		if replacement.UnitID != nil {
+			line.UnitID = replacement.UnitID
+			line.Unit = replacement.Unit
+		}
+		if ri.Amount != nil {
+			amount := float32(float64(*ri.Amount) * replacement.Ratio)
+			line.Amount = &amount
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Variant of a recipe with ingredients swapped out. ?ingredient= picks the
+// ingredients to replace, ?substitution= picks specific swaps, and ?diet=
+// prefers swaps for that diet, replacing everything it rules out when no
+// ingredients are given.
+func (app *App) substituteRecipe(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid recipe ID", http.StatusBadRequest)
+		return
+	}
+	query := r.URL.Query()
+
+	var d *diet.Diet
+	if name := query.Get("diet"); name != "" {
+		found, ok := diet.Lookup(name)
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown diet %q", name), http.StatusBadRequest)
+			return
+		}
+		d = &found
+	}
+	parseIDs := func(key string) ([]int, error) {
+		var ids []int
+		for _, value := range queryList(query, key) {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s ID %q", key, value)
+			}
+			ids = append(ids, parsed)
+		}
+		return ids, nil
+	}
+	ingredientIDs, err := parseIDs("ingredient")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	substitutionIDs, err := parseIDs("substitution")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if d == nil && len(ingredientIDs) == 0 && len(substitutionIDs) == 0 {
+		http.Error(w, "ingredient, substitution or diet is required", http.StatusBadRequest)
+		return
+	}
+
+	var recipe models.Recipe
+	if result := preloadRecipe(app.Repo.DB).First(&recipe, id); result.Error != nil {
+		http.Error(w, fmt.Sprintf("Recipe with id %d not found", id), http.StatusNotFound)
+		return
+	}
+
+	// Chosen swaps by the ingredient they replace
+	chosen := make(map[int]models.Substitution)
+	if len(substitutionIDs) > 0 {
+		var subs []models.Substitution
+		if result := preloadSubstitution(app.Repo.DB).Find(&subs, substitutionIDs); result.Error != nil {
+			http.Error(w, "Error fetching substitutions.", http.StatusInternalServerError)
+			return
+		}
+		if len(subs) != len(substitutionIDs) {
+			http.Error(w, "Substitution not found", http.StatusNotFound)
+			return
+		}
+		for _, sub := range subs {
+			chosen[sub.IngredientID] = sub
+		}
+	}
+
+	// Ingredients to swap that no explicit substitution covers
+	wanted := make(map[int]bool)
+	for _, ingredientID := range ingredientIDs {
+		wanted[ingredientID] = true
+	}
+	if d != nil && len(ingredientIDs) == 0 && len(substitutionIDs) == 0 {
+		for _, ri := range recipe.Ingredients {
+			if ri.Ingredient != nil && d.Excludes(*ri.Ingredient) {
+				wanted[ri.IngredientID] = true
+			}
+		}
+	}
+	var lookup []int
+	for ingredientID := range wanted {
+		if _, ok := chosen[ingredientID]; !ok {
+			lookup = append(lookup, ingredientID)
+		}
+	}
+	if len(lookup) > 0 {
+		var candidates []models.Substitution
+		result := preloadSubstitution(app.Repo.DB).Where("ingredient_id IN ?", lookup).
+			Order("substitution_id ASC").Find(&candidates)
+		if result.Error != nil {
+			http.Error(w, "Error fetching substitutions.", http.StatusInternalServerError)
+			return
+		}
+		byIngredient := make(map[int][]models.Substitution)
+		for _, sub := range candidates {
+			byIngredient[sub.IngredientID] = append(byIngredient[sub.IngredientID], sub)
+		}
+		for _, ingredientID := range lookup {
+			if sub, ok := chooseSubstitution(byIngredient[ingredientID], d); ok {
+				chosen[ingredientID] = sub
+			}
+		}
+	}
+
+	response := substitutedRecipe{Applied: []models.Substitution{}}
+	variant := recipe
+	variant.Ingredients = nil
+	for _, ri := range recipe.Ingredients {
+		sub, ok := chosen[ri.IngredientID]
+		if !ok {
+			if wanted[ri.IngredientID] {
+				label := ""
+				if ri.Ingredient != nil {
+					label = ri.Ingredient.Label
+				}
+				response.Unresolved = append(response.Unresolved, models.UnresolvedIngredient{
+					IngredientID: ri.IngredientID,
+					Label:        label,
+					Reason:       "no substitution",
+				})
+			}
+			variant.Ingredients = append(variant.Ingredients, ri)
+			continue
+		}
+		variant.Ingredients = append(variant.Ingredients, applySubstitution(ri, sub)...)
+		response.Applied = append(response.Applied, sub)
+	}
+
+	app.decorateRecipe(&variant)
+	response.Recipe = variant
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
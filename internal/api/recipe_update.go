@@ -3,82 +3,124 @@ package api
 import (
 	"encoding/json"
 	"net/http"
+	"recipe-api/internal/events"
 	"recipe-api/internal/models"
+	"recipe-api/internal/repository"
 	"strconv"
 
 	"github.com/gorilla/mux"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func (app *App) updateRecipeByID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	recipeID := vars["id"]
 	var recipe models.Recipe
-	json.NewDecoder(r.Body).Decode(&recipe)
+	if err := json.NewDecoder(r.Body).Decode(&recipe); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
 
 	id, err := strconv.Atoi(recipeID)
 	if err != nil {
 		http.Error(w, "invalid recipe ID", http.StatusBadRequest)
 		return
 	}
-	result := app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+
+	app.writeSavedRecipe(w, id, recipe)
+}
+
+// Replace a recipe's fields, ingredients, instructions and tags (when
+// given), and publish the updated event. Zero fields are left alone unless
+// named in columns, which then limits the update to them.
+func (app *App) saveRecipe(id int, recipe models.Recipe, columns ...string) (models.Recipe, error) {
+	if err := checkRecipe(app.Repo.DB, &recipe); err != nil {
+		return recipe, err
+	}
+	result := app.eventTransaction(func(tx *gorm.DB) (models.Event, error) {
 
 		var check models.Recipe
 		result := tx.First(&check, id)
 		if result.Error != nil {
 			app.Logger.Println("Recipe not found")
-			return result.Error
+			return models.Event{}, result.Error
 		}
 
-		// Update Recipe object
-		result = tx.Model(&models.Recipe{}).Where("recipe_id = ?", recipeID).Updates(recipe)
+		// Update Recipe object, children are rebuilt below and ratings are maintained separately
+		update := tx.Model(&models.Recipe{}).Where("recipe_id = ?", id)
+		if len(columns) > 0 {
+			update = update.Select(append(columns, "UpdatedAt"))
+		}
+		result = update.Omit(clause.Associations, "RatingAverage", "RatingCount").Updates(recipe)
 		if result.Error != nil {
-			app.Logger.Println(w, "Failed to edit recipe by id", http.StatusInternalServerError)
-			return result.Error
+			app.Logger.Println("Failed to edit recipe by id")
+			return models.Event{}, result.Error
 		}
 
 		// Delete child linker
 		result = tx.Where("recipe_id = ?", id).Delete(&models.RecipeIngredient{})
 		if result.Error != nil {
-			app.Logger.Println(w, "Failed to edit child linker object", http.StatusInternalServerError)
-			return result.Error
+			app.Logger.Println("Failed to edit child linker object")
+			return models.Event{}, result.Error
 		}
 
 		// Delete Instructions object
 		result = tx.Where("recipe_id = ?", id).Delete(&models.Instruction{})
 		if result.Error != nil {
-			app.Logger.Println(w, "Failed to delete child instructions", http.StatusInternalServerError)
-			return result.Error
+			app.Logger.Println("Failed to delete child instructions")
+			return models.Event{}, result.Error
 		}
 
 		// Rebuild Ingredient objects to ensure existance (including units)
 		for i := range recipe.Ingredients {
 			recipe.Ingredients[i].RecipeID = id
 
-			result = tx.Where("label = ?", recipe.Ingredients[i].Unit.Label).FirstOrCreate(recipe.Ingredients[i].Unit)
-			if result.Error != nil {
-				app.Logger.Println(w, "Failed to rebuild Unit objects")
-				return result.Error
+			// Rebuild Linker object
+			linker := models.RecipeIngredient{
+				RecipeID: id,
+				Amount:   recipe.Ingredients[i].Amount,
 			}
 
-			result = tx.Where("label = ?", recipe.Ingredients[i].Ingredient.Label).FirstOrCreate(recipe.Ingredients[i].Ingredient)
-			if result.Error != nil {
-				app.Logger.Println(w, "Failed to rebuild ingredient objects")
-				return result.Error
+			if recipe.Ingredients[i].Unit != nil {
+				unit, err := repository.FirstOrCreateUnit(tx, recipe.Ingredients[i].Unit)
+				if err != nil {
+					app.Logger.Println("Failed to rebuild Unit objects")
+					return models.Event{}, err
+				}
+				recipe.Ingredients[i].Unit = &unit
+				linker.UnitID = &unit.UnitID
 			}
 
-			// Rebuild Linker object
-			linker := models.RecipeIngredient{
-				RecipeID:     id,
-				IngredientID: recipe.Ingredients[i].Ingredient.IngredientID,
-				UnitID:       &recipe.Ingredients[i].Unit.UnitID,
-				Amount:       recipe.Ingredients[i].Amount,
+			if recipe.Ingredients[i].Ingredient != nil {
+				ingredient, err := repository.FirstOrCreateIngredient(tx, recipe.Ingredients[i].Ingredient)
+				if err != nil {
+					app.Logger.Println("Failed to rebuild ingredient objects")
+					return models.Event{}, err
+				}
+				recipe.Ingredients[i].Ingredient = &ingredient
+				linker.IngredientID = ingredient.IngredientID
 			}
+
 			result = tx.Create(&linker)
 			if result.Error != nil {
-				app.Logger.Println(w, "Recipe Ingredient linking failed for %s", recipe.Ingredients[i].Ingredient.Label)
-				return result.Error
+				app.Logger.Println("Recipe Ingredient linking failed for", linker.IngredientID)
+				return models.Event{}, result.Error
+			}
+		}
+
+		// Replace tags when the payload has them
+		if recipe.Tags != nil {
+			tags, err := resolveTags(tx, recipe.Tags)
+			if err != nil {
+				app.Logger.Println("Failed to resolve tags:", err)
+				return models.Event{}, err
+			}
+			if err := tx.Model(&models.Recipe{RecipeID: id}).Association("Tags").Replace(tags); err != nil {
+				app.Logger.Println("Failed to replace tags:", err)
+				return models.Event{}, err
 			}
+			recipe.Tags = tags
 		}
 
 		// Rebuild Instruction objects
@@ -86,19 +128,18 @@ func (app *App) updateRecipeByID(w http.ResponseWriter, r *http.Request) {
 			recipe.Instructions[i].RecipeID = id
 			result = tx.Create(&recipe.Instructions[i])
 			if result.Error != nil {
-				app.Logger.Println(w, "Failed to rebuild ingredient objects")
+				app.Logger.Println("Failed to rebuild ingredient objects")
 			}
 		}
-		return nil
+
+		// Log the change for the event feed
+		return recordRecipeEvent(tx, events.RecipeUpdated, id)
 	})
 
 	if result != nil {
-		app.Logger.Println("Transaction Failed:", result)
-		http.Error(w, result.Error(), http.StatusInternalServerError)
+		return recipe, result
 	}
-
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(recipe)
+	return recipe, nil
 }
 
 func (app *App) updateRecipeByName(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+
+	"recipe-api/internal/models"
+)
+
+// Count recipes, optionally narrowed by the filters in parseRecipeFilter
+func (app *App) countRecipes(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseRecipeFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var count int64
+	if result := filter.apply(app.Repo.DB.Model(&models.Recipe{})).Count(&count); result.Error != nil {
+		app.Logger.Println("DB error:", result.Error)
+		http.Error(w, "Error counting recipes.", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int64{"count": count})
+}
+
+// Recipe columns a PATCH can set, by JSON key
+var patchColumns = map[string]string{
+	"name":          "Name",
+	"difficulty":    "Difficulty",
+	"description":   "Description",
+	"servings":      "Servings",
+	"userID":        "UserID",
+	"dietOverrides": "DietOverrides",
+}
+
+// Change some of a recipe's fields. Fields missing from the body keep their
+// value, and those given are written even when null or zero; ingredients,
+// instructions and tags are replaced when given.
+func (app *App) patchRecipe(w http.ResponseWriter, r *http.Request) {
+	recipeID := mux.Vars(r)["id"]
+	id, err := strconv.Atoi(recipeID)
+	if err != nil {
+		http.Error(w, "invalid recipe ID", http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	var recipe models.Recipe
+	if result := preloadRecipe(app.Repo.DB).First(&recipe, id); result.Error != nil {
+		http.Error(w, fmt.Sprintf("Recipe with id %s not found", recipeID), http.StatusNotFound)
+		return
+	}
+
+	// Decoding into a loaded slice would merge into its old elements
+	if _, ok := fields["ingredients"]; ok {
+		recipe.Ingredients = nil
+	}
+	if _, ok := fields["instructions"]; ok {
+		recipe.Instructions = nil
+	}
+	if _, ok := fields["tags"]; ok {
+		recipe.Tags = nil
+	}
+	if err := json.Unmarshal(body, &recipe); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if recipe.Tags == nil {
+		recipe.Tags = []models.Tag{}
+	}
+
+	// Only the fields given are written, so they can be cleared
+	columns := []string{}
+	for key := range fields {
+		if column, ok := patchColumns[key]; ok {
+			columns = append(columns, column)
+		}
+	}
+	app.writeSavedRecipe(w, id, recipe, columns...)
+}
+
+// Save a recipe and answer with it, or with the matching error status
+func (app *App) writeSavedRecipe(w http.ResponseWriter, id int, recipe models.Recipe, columns ...string) {
+	recipe, err := app.saveRecipe(id, recipe, columns...)
+	var invalid invalidRecipe
+	if errors.As(err, &invalid) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, fmt.Sprintf("Recipe with id %d not found", id), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.Logger.Println("Transaction Failed:", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(recipe)
+}
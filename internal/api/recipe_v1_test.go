@@ -0,0 +1,156 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"recipe-api/internal/models"
+)
+
+func TestV1Recipes(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+
+	w := serveJSON(t, router, http.MethodPost, "/v1/recipes", models.Recipe{
+		Name:       "Soda bread",
+		Difficulty: 2,
+		UserID:     "ann",
+		Ingredients: []models.RecipeIngredient{
+			{Amount: ToPtr(float32(2)), Ingredient: createTestIngredient("Soured cream"), Unit: createTestUnit("jug")},
+		},
+		Instructions: []models.Instruction{{StepNumber: 1, StepText: "mix"}},
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var created models.Recipe
+	json.NewDecoder(w.Body).Decode(&created)
+	path := fmt.Sprintf("/v1/recipes/%d", created.RecipeID)
+	if location := w.Header().Get("Location"); location != path {
+		t.Fatalf("expected Location %s, got %q", path, location)
+	}
+	postTestRecipe(t, testApp, models.Recipe{Name: "Scones", Difficulty: 3})
+
+	w = serveJSON(t, router, http.MethodGet, "/v1/recipes/count?max_total_time=30", nil)
+	if w.Code != http.StatusOK || w.Body.String() != "{\"count\":0}\n" {
+		t.Fatalf("expected no timed recipes, got %d: %s", w.Code, w.Body.String())
+	}
+	w = serveJSON(t, router, http.MethodGet, "/v1/recipes/count", nil)
+	if w.Body.String() != "{\"count\":2}\n" {
+		t.Fatalf("expected two recipes, got %s", w.Body.String())
+	}
+
+	// Patching the name keeps the ingredients and instructions
+	w = serveJSON(t, router, http.MethodPatch, path, map[string]any{"name": "Brown soda bread", "difficulty": 3})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var patched models.Recipe
+	w = serveJSON(t, router, http.MethodGet, path, nil)
+	json.NewDecoder(w.Body).Decode(&patched)
+	if patched.Name != "Brown soda bread" || patched.Difficulty != 3 || patched.UserID != "ann" ||
+		len(patched.Ingredients) != 1 || patched.Ingredients[0].Ingredient.Label != "Soured cream" || len(patched.Instructions) != 1 {
+		t.Fatalf("unexpected patched recipe %+v", patched)
+	}
+
+	// Given lists replace the old ones
+	w = serveJSON(t, router, http.MethodPatch, path, map[string]any{
+		"instructions": []models.Instruction{{StepNumber: 1, StepText: "mix"}, {StepNumber: 2, StepText: "bake"}},
+	})
+	json.NewDecoder(w.Body).Decode(&patched)
+	if len(patched.Instructions) != 2 || patched.Instructions[1].StepText != "bake" || patched.Name != "Brown soda bread" {
+		t.Fatalf("expected the instructions replaced, got %+v", patched)
+	}
+
+	// Fields given as null or zero are cleared
+	serveJSON(t, router, http.MethodPatch, path, map[string]any{"description": "Crusty", "servings": 4})
+	serveJSON(t, router, http.MethodPatch, path, map[string]any{"description": nil, "difficulty": 0})
+	patched = models.Recipe{}
+	json.NewDecoder(serveJSON(t, router, http.MethodGet, path, nil).Body).Decode(&patched)
+	if patched.Description != nil || patched.Difficulty != 0 || patched.Servings == nil || *patched.Servings != 4 {
+		t.Fatalf("expected the description and difficulty cleared and servings kept, got %+v", patched)
+	}
+
+	// Reads are cached until the next write
+	serveJSON(t, router, http.MethodGet, path, nil)
+	if w = serveJSON(t, router, http.MethodGet, path, nil); w.Header().Get("X-Cache") != "HIT" {
+		t.Errorf("expected a repeated read to hit the response cache, got %q", w.Header().Get("X-Cache"))
+	}
+	serveJSON(t, router, http.MethodPatch, path, map[string]any{"difficulty": 4})
+	w = serveJSON(t, router, http.MethodGet, path, nil)
+	json.NewDecoder(w.Body).Decode(&patched)
+	if w.Header().Get("X-Cache") != "MISS" || patched.Difficulty != 4 {
+		t.Errorf("expected a write to empty the response cache, got %q with difficulty %d", w.Header().Get("X-Cache"), patched.Difficulty)
+	}
+	// POSTs that only read keep it
+	generate := map[string]any{"recipes": []map[string]int{{"recipe_id": created.RecipeID}}}
+	if w = serveJSON(t, router, http.MethodPost, "/shoppinglist/generate", generate); w.Code != http.StatusOK {
+		t.Fatalf("expected status %d generating a list, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	postGraphQL(t, router, `{ recipes { name } }`, nil, nil)
+	if w = serveJSON(t, router, http.MethodGet, path, nil); w.Header().Get("X-Cache") != "HIT" {
+		t.Errorf("expected a generated list and a GraphQL query to keep the response cache, got %q", w.Header().Get("X-Cache"))
+	}
+	mutation := `mutation($id: ID!) { deleteRecipe(id: $id) }`
+	postGraphQL(t, router, mutation, map[string]any{"id": fmt.Sprint(created.RecipeID + 1000)}, nil)
+	if w = serveJSON(t, router, http.MethodGet, path, nil); w.Header().Get("X-Cache") != "MISS" {
+		t.Errorf("expected a GraphQL mutation to empty the response cache, got %q", w.Header().Get("X-Cache"))
+	}
+
+	tests := []struct {
+		method, path string
+		payload      any
+		status       int
+	}{
+		{http.MethodPatch, "/v1/recipes/999", map[string]any{"name": "Ghost"}, http.StatusNotFound},
+		{http.MethodPut, "/v1/recipes/999", models.Recipe{Name: "Ghost", Difficulty: 1}, http.StatusNotFound},
+		{http.MethodPatch, path, map[string]any{"instructions": []map[string]any{{"stepNumber": 1, "phase": "simmer"}}}, http.StatusBadRequest},
+		{http.MethodGet, "/v1/recipes/count?diet=carnivore", nil, http.StatusBadRequest},
+		{http.MethodDelete, path, nil, http.StatusNoContent},
+		{http.MethodGet, path, nil, http.StatusNotFound},
+	}
+	for _, test := range tests {
+		w := serveJSON(t, router, test.method, test.path, test.payload)
+		if w.Code != test.status {
+			t.Errorf("%s %s: expected status %d, got %d: %s", test.method, test.path, test.status, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestLegacyRouteHeaders(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+	recipe := postTestRecipe(t, testApp, models.Recipe{Name: "Farls", Difficulty: 1})
+
+	counter := func() int64 {
+		if hits, ok := legacyRouteHits.Get("GET /recipe/id/{id}").(interface{ Value() int64 }); ok {
+			return hits.Value()
+		}
+		return 0
+	}
+	before := counter()
+
+	w := serveJSON(t, router, http.MethodGet, fmt.Sprintf("/recipe/id/%d", recipe.RecipeID), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if got := w.Header().Get("Deprecation"); got != fmt.Sprintf("@%d", legacyDeprecated.Unix()) {
+		t.Errorf("unexpected Deprecation header %q", got)
+	}
+	if got := w.Header().Get("Sunset"); got != "Fri, 30 Apr 2027 00:00:00 GMT" {
+		t.Errorf("unexpected Sunset header %q", got)
+	}
+	if got, want := w.Header().Get("Link"), fmt.Sprintf("</v1/recipes/%d>; rel=\"successor-version\"", recipe.RecipeID); got != want {
+		t.Errorf("expected Link %s, got %s", want, got)
+	}
+	if counter() != before+1 {
+		t.Errorf("expected the legacy route counted once, got %d after %d", counter(), before)
+	}
+
+	w = serveJSON(t, router, http.MethodGet, fmt.Sprintf("/v1/recipes/%d", recipe.RecipeID), nil)
+	if w.Header().Get("Deprecation") != "" {
+		t.Errorf("expected no Deprecation header on /v1")
+	}
+}
@@ -9,53 +9,187 @@ import (
 	"recipe-api/internal/middleware"
 )
 
-func NewRouter(app *App, frontendURL string, appLogger *log.Logger) http.Handler {
+func NewRouter(app *App, cors *middleware.CorsPolicy) http.Handler {
 
 	router := mux.NewRouter()
 
+	// Recipe reads are kept in the response cache until the next write
+	cached := func(handler http.HandlerFunc) http.HandlerFunc {
+		return app.ResponseCache.Cached(handler).ServeHTTP
+	}
+
 	// Default Landing Page
 	router.HandleFunc("/", displayLanding)
 
-	// Recipe endpoints
-	// Add new Recipe
-	router.HandleFunc("/recipe/add", app.addRecipe).Methods("POST")
-
-	// Get all Recipes
-	router.HandleFunc("/recipe/all", app.getAllRecipes).Methods("GET")
-
-	// Get Recipes by value
-	router.HandleFunc("/recipe/id/{id}", app.getRecipeByID).Methods("GET")
-	router.HandleFunc("/recipe/name/{name}", app.getRecipeByName).Methods("GET")
-
-	// Get Number of Recipes
-	router.HandleFunc("/recipe/name/{name}", app.getNumberOfRecipes).Methods("GET")
-
-	// Update Recipes
-	router.HandleFunc("/recipe/id/{id}", app.updateRecipeByID).Methods("PUT")
-	router.HandleFunc("/recipe/name/{name}", app.updateRecipeByName).Methods("PUT")
-
-	// Delete Recipes
-	router.HandleFunc("/recipe/id/{id}", app.deleteRecipeByID).Methods("DELETE")
-	router.HandleFunc("/recipe/name/{name}", app.deleteRecipeByName).Methods("DELETE")
+	// Recipes
+	router.HandleFunc("/v1/recipes", cached(app.getAllRecipes)).Methods("GET")
+	router.HandleFunc("/v1/recipes", app.addRecipe).Methods("POST")
+	router.HandleFunc("/v1/recipes/count", cached(app.countRecipes)).Methods("GET")
+	router.HandleFunc("/v1/recipes/export", app.exportRecipesPDF).Methods("GET")
+	router.HandleFunc("/v1/recipes/{id:[0-9]+}", cached(app.getRecipeByID)).Methods("GET")
+	router.HandleFunc("/v1/recipes/{id:[0-9]+}", app.updateRecipeByID).Methods("PUT")
+	router.HandleFunc("/v1/recipes/{id:[0-9]+}", app.patchRecipe).Methods("PATCH")
+	router.HandleFunc("/v1/recipes/{id:[0-9]+}", app.deleteRecipeByID).Methods("DELETE")
+	router.HandleFunc("/v1/recipes/{id:[0-9]+}/restore", app.restoreRecipeByID).Methods("POST")
+
+	// Legacy recipe endpoints, deprecated in favour of /v1
+	router.HandleFunc("/recipe/add", legacyRoute("/v1/recipes", app.addRecipe)).Methods("POST")
+	router.HandleFunc("/recipe/all", legacyRoute("/v1/recipes", cached(app.getAllRecipes))).Methods("GET")
+	router.HandleFunc("/recipe/id/{id}", legacyRoute("/v1/recipes/{id}", cached(app.getRecipeByID))).Methods("GET")
+	router.HandleFunc("/recipe/id/{id}", legacyRoute("/v1/recipes/{id}", app.updateRecipeByID)).Methods("PUT")
+	router.HandleFunc("/recipe/id/{id}", legacyRoute("/v1/recipes/{id}", app.deleteRecipeByID)).Methods("DELETE")
+	router.HandleFunc("/recipe/name/{name}", legacyRoute("", cached(app.getRecipeByName))).Methods("GET")
+	router.HandleFunc("/recipe/name/{name}", legacyRoute("", app.updateRecipeByName)).Methods("PUT")
+	router.HandleFunc("/recipe/name/{name}", legacyRoute("", app.deleteRecipeByName)).Methods("DELETE")
+
+	// Recipe with ingredients swapped
+	router.HandleFunc("/recipe/id/{id}/substitute", app.substituteRecipe).Methods("GET")
+
+	// Images
+	router.HandleFunc("/recipe/id/{id}/image", app.uploadImage).Methods("PUT")
+	router.HandleFunc("/recipe/id/{id}/image", app.deleteImage).Methods("DELETE")
+	router.HandleFunc("/recipe/id/{id}/step/{step}/image", app.uploadImage).Methods("PUT")
+	router.HandleFunc("/recipe/id/{id}/step/{step}/image", app.deleteImage).Methods("DELETE")
+	router.HandleFunc("/image/id/{id}/{size}", app.getImage).Methods("GET")
+
+	// Change feed, SSE or WebSocket
+	upgrader := eventUpgrader(cors)
+	router.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+		app.streamEvents(w, r, upgrader)
+	}).Methods("GET")
+
+	// GraphQL
+	router.HandleFunc("/graphql", app.serveGraphQL).Methods("GET", "POST")
+	router.HandleFunc("/graphql/schema", app.getGraphQLSchema).Methods("GET")
+
+	// Cook mode
+	router.HandleFunc("/cook/add", app.addCookSession).Methods("POST")
+	router.HandleFunc("/cook/user/{userID}", app.getCookSessionsByUser).Methods("GET")
+	router.HandleFunc("/cook/id/{id}", app.getCookSession).Methods("GET")
+	router.HandleFunc("/cook/id/{id}", app.deleteCookSession).Methods("DELETE")
+	router.HandleFunc("/cook/id/{id}/next", app.nextCookStep).Methods("POST")
+	router.HandleFunc("/cook/id/{id}/previous", app.previousCookStep).Methods("POST")
+	router.HandleFunc("/cook/id/{id}/step", app.setCookStep).Methods("PUT")
+	router.HandleFunc("/cook/id/{id}/timer", app.startCookTimer).Methods("POST")
+	router.HandleFunc("/cook/id/{id}/timer/{timerID}", app.cancelCookTimer).Methods("DELETE")
+	router.HandleFunc("/cook/id/{id}/events", app.streamCookSession).Methods("GET")
+
+	// Webhooks
+	router.HandleFunc("/webhook/add", app.addWebhook).Methods("POST")
+	router.HandleFunc("/webhook/user/{userID}", app.getWebhooksByUser).Methods("GET")
+	router.HandleFunc("/webhook/id/{id}", app.getWebhook).Methods("GET")
+	router.HandleFunc("/webhook/id/{id}", app.updateWebhook).Methods("PUT")
+	router.HandleFunc("/webhook/id/{id}", app.deleteWebhook).Methods("DELETE")
+	router.HandleFunc("/webhook/id/{id}/deliveries", app.getWebhookDeliveries).Methods("GET")
+	router.HandleFunc("/webhook/id/{id}/replay", app.replayFailedDeliveries).Methods("POST")
+	router.HandleFunc("/webhook/delivery/{id}/replay", app.replayDelivery).Methods("POST")
+
+	// Ratings and reviews
+	router.HandleFunc("/recipe/id/{id}/rating", app.rateRecipe).Methods("PUT")
+	router.HandleFunc("/recipe/id/{id}/rating/{userID}", app.deleteRecipeRating).Methods("DELETE")
+	router.HandleFunc("/recipe/id/{id}/reviews", app.getRecipeReviews).Methods("GET")
+
+	// Search Recipes
+	router.HandleFunc("/recipe/search", cached(app.searchRecipes)).Methods("GET")
 
 	//Filtered Recipes
 	router.HandleFunc("/recipe/random", app.selectRandomRecipe).Methods("GET")
 	router.HandleFunc("/recipe/random/{difficulty}", app.filterRandomRecipe).Methods("GET")
 
+	// Tags
+	router.HandleFunc("/tag/add", app.addTag).Methods("POST")
+	router.HandleFunc("/tag/all", app.getAllTags).Methods("GET")
+	router.HandleFunc("/tag/facets", cached(app.getTagFacets)).Methods("GET")
+	router.HandleFunc("/tag/id/{id}", app.updateTagByID).Methods("PUT")
+	router.HandleFunc("/tag/id/{id}", app.deleteTagByID).Methods("DELETE")
+
+	// Ingredient and unit catalogue
+	router.HandleFunc("/ingredient/all", app.getAllIngredients).Methods("GET")
+	router.HandleFunc("/ingredient/merge", app.mergeIngredients).Methods("POST")
+	router.HandleFunc("/ingredient/unused", app.deleteUnusedIngredients).Methods("DELETE")
+	router.HandleFunc("/ingredient/id/{id}", app.getIngredientByID).Methods("GET")
+	router.HandleFunc("/ingredient/id/{id}", app.updateIngredientByID).Methods("PUT")
+	router.HandleFunc("/ingredient/id/{id}", app.deleteIngredientByID).Methods("DELETE")
+	router.HandleFunc("/unit/all", app.getAllUnits).Methods("GET")
+	router.HandleFunc("/unit/merge", app.mergeUnits).Methods("POST")
+	router.HandleFunc("/unit/unused", app.deleteUnusedUnits).Methods("DELETE")
+	router.HandleFunc("/unit/id/{id}", app.getUnitByID).Methods("GET")
+	router.HandleFunc("/unit/id/{id}", app.updateUnitByID).Methods("PUT")
+	router.HandleFunc("/unit/id/{id}", app.deleteUnitByID).Methods("DELETE")
+
+	// Ingredient nutrition
+	router.HandleFunc("/ingredient/id/{id}/nutrition", app.getIngredientNutrition).Methods("GET")
+	router.HandleFunc("/ingredient/id/{id}/nutrition", app.updateIngredientNutrition).Methods("PUT")
+
+	// Diets and allergens
+	router.HandleFunc("/diet", getDietVocabulary).Methods("GET")
+	router.HandleFunc("/ingredient/id/{id}/diet", app.updateIngredientDiet).Methods("PUT")
+
+	// Substitutions
+	router.HandleFunc("/substitution/add", app.addSubstitution).Methods("POST")
+	router.HandleFunc("/substitution/id/{id}", app.getSubstitutionByID).Methods("GET")
+	router.HandleFunc("/substitution/id/{id}", app.updateSubstitutionByID).Methods("PUT")
+	router.HandleFunc("/substitution/id/{id}", app.deleteSubstitutionByID).Methods("DELETE")
+	router.HandleFunc("/ingredient/id/{id}/substitutions", app.getIngredientSubstitutions).Methods("GET")
+
+	// Favourites
+	router.HandleFunc("/favourite/add", app.addFavourite).Methods("POST")
+	router.HandleFunc("/favourite/user/{userID}", app.getFavouritesByUser).Methods("GET")
+	router.HandleFunc("/favourite/user/{userID}/recipe/{recipeID}", app.deleteFavourite).Methods("DELETE")
+
+	// Collections
+	router.HandleFunc("/collection/add", app.addCollection).Methods("POST")
+	router.HandleFunc("/collection/public", app.getPublicCollections).Methods("GET")
+	router.HandleFunc("/collection/user/{userID}", app.getCollectionsByUser).Methods("GET")
+	router.HandleFunc("/collection/shared/{token}", app.getCollection).Methods("GET")
+	router.HandleFunc("/collection/shared/{token}/export", app.exportCollection).Methods("GET")
+	router.HandleFunc("/collection/id/{id}", app.getCollection).Methods("GET")
+	router.HandleFunc("/collection/id/{id}", app.updateCollectionByID).Methods("PUT")
+	router.HandleFunc("/collection/id/{id}", app.deleteCollectionByID).Methods("DELETE")
+	router.HandleFunc("/collection/id/{id}/recipe", app.addCollectionRecipe).Methods("POST")
+	router.HandleFunc("/collection/id/{id}/recipe/{recipeID}", app.removeCollectionRecipe).Methods("DELETE")
+	router.HandleFunc("/collection/id/{id}/order", app.reorderCollection).Methods("PUT")
+	router.HandleFunc("/collection/id/{id}/share", app.shareCollection).Methods("POST")
+	router.HandleFunc("/collection/id/{id}/export", app.exportCollection).Methods("GET")
+
+	// Meal plans
+	router.HandleFunc("/mealplan/add", app.addMealPlan).Methods("POST")
+	router.HandleFunc("/mealplan/user/{userID}", app.getMealPlansByUser).Methods("GET")
+	router.HandleFunc("/mealplan/id/{id}", app.getMealPlanByID).Methods("GET")
+	router.HandleFunc("/mealplan/id/{id}", app.updateMealPlanByID).Methods("PUT")
+	router.HandleFunc("/mealplan/id/{id}", app.deleteMealPlanByID).Methods("DELETE")
+	router.HandleFunc("/mealplan/id/{id}/copy", app.copyMealPlanToNextWeek).Methods("POST")
+	router.HandleFunc("/mealplan/id/{id}/autofill", app.autoFillMealPlan).Methods("POST")
+
+	// Shopping lists
+	router.Handle("/shoppinglist/generate", middleware.ReadOnly(http.HandlerFunc(app.generateShoppingList))).Methods("POST")
+	router.HandleFunc("/shoppinglist/add", app.addShoppingList).Methods("POST")
+	router.HandleFunc("/shoppinglist/user/{userID}", app.getShoppingListsByUser).Methods("GET")
+	router.HandleFunc("/shoppinglist/id/{id}", app.getShoppingListByID).Methods("GET")
+	router.HandleFunc("/shoppinglist/id/{id}", app.deleteShoppingListByID).Methods("DELETE")
+	router.HandleFunc("/shoppinglist/item/{id}", app.checkShoppingListItem).Methods("PUT")
+
+	// Pantry
+	router.HandleFunc("/pantry/add", app.addPantryItem).Methods("POST")
+	router.HandleFunc("/pantry/user/{userID}", app.getPantryByUser).Methods("GET")
+	router.HandleFunc("/pantry/user/{userID}/cookable", app.getCookableRecipes).Methods("GET")
+	router.HandleFunc("/pantry/user/{userID}/expiring", app.getExpiringPantry).Methods("GET")
+	router.HandleFunc("/pantry/user/{userID}/cook/{recipeID}", app.cookRecipeFromPantry).Methods("POST")
+	router.HandleFunc("/pantry/id/{id}", app.updatePantryItemByID).Methods("PUT")
+	router.HandleFunc("/pantry/id/{id}", app.deletePantryItemByID).Methods("DELETE")
+
 	// Enable Rate Limiting
 	router.Use(app.RateLimiter.RateLimitMiddleware)
 
+	// Drop cached reads after writes, and set caching headers on the rest
+	router.Use(app.ResponseCache.Invalidate)
+
 	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		log.Printf("404 Not Found: %s %s", r.Method, r.URL.Path)
 		http.NotFound(w, r)
 	})
 
-	// Wrap CORS
-	handler := middleware.CorsMiddleware(router, frontendURL)
-
-	http.Handle("/", router)
-
-	appLogger.Fatal(http.ListenAndServe(":8080", handler))
-
-	return router
+	// Wrap CORS around the whole router so preflights never hit the rate limiter,
+	// and compress whatever comes out
+	return middleware.Compress(cors.CorsMiddleware(router))
 }
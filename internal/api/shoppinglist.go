@@ -0,0 +1,327 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"recipe-api/internal/measure"
+	"recipe-api/internal/models"
+	"recipe-api/internal/repository"
+)
+
+// Category used for ingredients without an aisle
+const uncategorised = "Other"
+
+// Recipes to shop for, given directly or through a meal plan
+type shoppingListRequest struct {
+	UserID     string `json:"userID"`
+	Name       string `json:"name"`
+	MealPlanID int    `json:"mealPlan_id"`
+	Recipes    []struct {
+		RecipeID int `json:"recipe_id"`
+		Servings int `json:"servings"` // defaults to the recipe's own servings
+	} `json:"recipes"`
+}
+
+// Items sharing an aisle
+type shoppingListGroup struct {
+	Category string                    `json:"category"`
+	Items    []models.ShoppingListItem `json:"items"`
+}
+
+// Shopping list as returned to clients, grouped by aisle
+type shoppingListResponse struct {
+	ShoppingListID int                 `json:"id,omitempty"`
+	UserID         string              `json:"userID,omitempty"`
+	Name           string              `json:"name,omitempty"`
+	Groups         []shoppingListGroup `json:"groups"`
+}
+
+// Recipe and how much to scale its amounts by
+type scaledRecipe struct {
+	recipe models.Recipe
+	factor float64
+}
+
+// How much to scale a recipe's amounts to make the given servings.
+// Recipes without servings, or a request without any, are not scaled.
+func servingsFactor(recipe models.Recipe, servings int) float64 {
+	if servings > 0 && recipe.Servings != nil && *recipe.Servings > 0 {
+		return float64(servings) / float64(*recipe.Servings)
+	}
+	return 1
+}
+
+// Load the requested recipes with their scaling factors
+func (app *App) shoppingListRecipes(req shoppingListRequest) ([]scaledRecipe, error) {
+	type wanted struct{ recipeID, servings int }
+	var wants []wanted
+
+	for _, item := range req.Recipes {
+		wants = append(wants, wanted{item.RecipeID, item.Servings})
+	}
+	if req.MealPlanID != 0 {
+		plan, err := findMealPlan(app.Repo.DB, req.MealPlanID)
+		if err != nil {
+			return nil, fmt.Errorf("meal plan %d not found", req.MealPlanID)
+		}
+		for _, entry := range plan.Entries {
+			wants = append(wants, wanted{entry.RecipeID, entry.Servings})
+		}
+	}
+	if len(wants) == 0 {
+		return nil, errors.New("no recipes requested")
+	}
+
+	scaled := make([]scaledRecipe, 0, len(wants))
+	for _, want := range wants {
+		var recipe models.Recipe
+		result := preloadRecipe(app.Repo.DB).First(&recipe, want.recipeID)
+		if result.Error != nil {
+			return nil, fmt.Errorf("recipe %d not found", want.recipeID)
+		}
+
+		scaled = append(scaled, scaledRecipe{recipe, servingsFactor(recipe, want.servings)})
+	}
+	return scaled, nil
+}
+
+// Merge recipe ingredients by ingredient, summing amounts whose units match or
+// convert, and keeping separate lines for units that don't
+func buildShoppingList(recipes []scaledRecipe) []models.ShoppingListItem {
+	var items []models.ShoppingListItem
+	lines := make(map[string][]int) // shoppingLineKey -> indexes into items
+
+	for _, scaled := range recipes {
+		for _, ri := range scaled.recipe.Ingredients {
+			item := models.ShoppingListItem{IngredientID: ri.IngredientID, Category: uncategorised}
+			if ri.Ingredient != nil {
+				item.Label = ri.Ingredient.Label
+				if ri.Ingredient.Category != nil && *ri.Ingredient.Category != "" {
+					item.Category = *ri.Ingredient.Category
+				}
+			}
+			if ri.Unit != nil {
+				item.Unit = ri.Unit.Label
+			}
+			if ri.Amount != nil {
+				amount := float64(*ri.Amount) * scaled.factor
+				item.Amount = &amount
+			}
+
+			key := shoppingLineKey(item)
+			merged := false
+			for _, index := range lines[key] {
+				if mergeShoppingItem(&items[index], item) {
+					merged = true
+					break
+				}
+			}
+			if !merged {
+				lines[key] = append(lines[key], len(items))
+				items = append(items, item)
+			}
+		}
+	}
+
+	for i := range items {
+		if items[i].Amount != nil {
+			rounded := math.Round(*items[i].Amount*100) / 100
+			items[i].Amount = &rounded
+		}
+	}
+	return items
+}
+
+// Key of the lines an item may merge into: its ingredient, or for items
+// without one their label ignoring case and spacing
+func shoppingLineKey(item models.ShoppingListItem) string {
+	if item.IngredientID != 0 {
+		return "id:" + strconv.Itoa(item.IngredientID)
+	}
+	return "label:" + strings.ToLower(repository.CanonicalLabel(item.Label))
+}
+
+// Add item into existing when their units are compatible
+func mergeShoppingItem(existing *models.ShoppingListItem, item models.ShoppingListItem) bool {
+	// Unmeasured amounts ("salt to taste") only merge with each other
+	if existing.Amount == nil || item.Amount == nil {
+		return existing.Amount == nil && item.Amount == nil && measure.Same(existing.Unit, item.Unit)
+	}
+
+	amount, ok := measure.Convert(*item.Amount, item.Unit, existing.Unit)
+	if !ok {
+		return false
+	}
+	total := *existing.Amount + amount
+	existing.Amount = &total
+	return true
+}
+
+// Group items by category, alphabetically with uncategorised items last
+func groupShoppingList(items []models.ShoppingListItem) []shoppingListGroup {
+	byCategory := make(map[string][]models.ShoppingListItem)
+	for _, item := range items {
+		category := item.Category
+		if category == "" {
+			category = uncategorised
+		}
+		byCategory[category] = append(byCategory[category], item)
+	}
+
+	groups := make([]shoppingListGroup, 0, len(byCategory))
+	for category, categoryItems := range byCategory {
+		sort.SliceStable(categoryItems, func(i, j int) bool {
+			return strings.ToLower(categoryItems[i].Label) < strings.ToLower(categoryItems[j].Label)
+		})
+		groups = append(groups, shoppingListGroup{Category: category, Items: categoryItems})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if (groups[i].Category == uncategorised) != (groups[j].Category == uncategorised) {
+			return groups[j].Category == uncategorised
+		}
+		return groups[i].Category < groups[j].Category
+	})
+	return groups
+}
+
+// Write a shopping list as JSON, plain text or Markdown depending on ?format=
+func writeShoppingList(w http.ResponseWriter, r *http.Request, status int, list shoppingListResponse) {
+	switch r.URL.Query().Get("format") {
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(list)
+	case "text", "txt":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(status)
+		renderShoppingListText(w, list)
+	case "markdown", "md":
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.WriteHeader(status)
+		renderShoppingListMarkdown(w, list)
+	default:
+		http.Error(w, "format must be json, text or markdown", http.StatusBadRequest)
+	}
+}
+
+// Quantity and unit for display, e.g. "1.5 kg"
+func shoppingItemQuantity(item models.ShoppingListItem) string {
+	var parts []string
+	if item.Amount != nil {
+		parts = append(parts, strconv.FormatFloat(*item.Amount, 'f', -1, 64))
+	}
+	if item.Unit != "" {
+		parts = append(parts, item.Unit)
+	}
+	return strings.Join(parts, " ")
+}
+
+func shoppingListTitle(list shoppingListResponse) string {
+	if list.Name != "" {
+		return list.Name
+	}
+	return "Shopping list"
+}
+
+func renderShoppingListText(w io.Writer, list shoppingListResponse) {
+	fmt.Fprintln(w, shoppingListTitle(list))
+	for _, group := range list.Groups {
+		fmt.Fprintf(w, "\n%s\n", strings.ToUpper(group.Category))
+		for _, item := range group.Items {
+			box := "[ ]"
+			if item.Checked {
+				box = "[x]"
+			}
+			fmt.Fprintf(w, "  %s %s\n", box, strings.TrimSpace(shoppingItemQuantity(item)+" "+item.Label))
+		}
+	}
+}
+
+func renderShoppingListMarkdown(w io.Writer, list shoppingListResponse) {
+	fmt.Fprintf(w, "# %s\n", shoppingListTitle(list))
+	for _, group := range list.Groups {
+		fmt.Fprintf(w, "\n## %s\n\n", group.Category)
+		for _, item := range group.Items {
+			box := "[ ]"
+			if item.Checked {
+				box = "[x]"
+			}
+			line := item.Label
+			if quantity := shoppingItemQuantity(item); quantity != "" {
+				line = fmt.Sprintf("%s (%s)", item.Label, quantity)
+			}
+			fmt.Fprintf(w, "- %s %s\n", box, line)
+		}
+	}
+}
+
+// Build a shopping list without saving it
+func (app *App) generateShoppingList(w http.ResponseWriter, r *http.Request) {
+	var req shoppingListRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	recipes, err := app.shoppingListRecipes(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	list := shoppingListResponse{
+		Name:   req.Name,
+		Groups: groupShoppingList(buildShoppingList(recipes)),
+	}
+	writeShoppingList(w, r, http.StatusOK, list)
+}
+
+// Build a shopping list and save it for the user to tick off
+func (app *App) addShoppingList(w http.ResponseWriter, r *http.Request) {
+	var req shoppingListRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if req.UserID == "" {
+		http.Error(w, "userID is required", http.StatusBadRequest)
+		return
+	}
+
+	recipes, err := app.shoppingListRecipes(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	list := models.ShoppingList{
+		UserID: req.UserID,
+		Name:   req.Name,
+		Items:  buildShoppingList(recipes),
+	}
+	result := app.Repo.DB.Create(&list) // Creates the items with it
+	if result.Error != nil {
+		app.Logger.Println("Shopping list error:", result.Error)
+		http.Error(w, result.Error.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeShoppingList(w, r, http.StatusCreated, shoppingListResponseFor(list))
+}
+
+func shoppingListResponseFor(list models.ShoppingList) shoppingListResponse {
+	return shoppingListResponse{
+		ShoppingListID: list.ShoppingListID,
+		UserID:         list.UserID,
+		Name:           list.Name,
+		Groups:         groupShoppingList(list.Items),
+	}
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+
+	"recipe-api/internal/models"
+)
+
+// Get a saved shopping list
+func (app *App) getShoppingListByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid shopping list ID", http.StatusBadRequest)
+		return
+	}
+
+	var list models.ShoppingList
+	result := app.Repo.DB.Preload("Items").First(&list, id)
+	if result.Error != nil {
+		http.Error(w, "Shopping list not found", http.StatusNotFound)
+		return
+	}
+	writeShoppingList(w, r, http.StatusOK, shoppingListResponseFor(list))
+}
+
+// Get every saved shopping list for a user, newest first
+func (app *App) getShoppingListsByUser(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["userID"]
+
+	var lists []models.ShoppingList
+	result := app.Repo.DB.Preload("Items").Where("user_id = ?", userID).
+		Order("created_at DESC").Find(&lists)
+	if result.Error != nil {
+		http.Error(w, "Error fetching shopping lists.", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]shoppingListResponse, len(lists))
+	for i, list := range lists {
+		response[i] = shoppingListResponseFor(list)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Tick an item on or off
+func (app *App) checkShoppingListItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid item ID", http.StatusBadRequest)
+		return
+	}
+
+	var data struct {
+		Checked bool `json:"checked"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	var item models.ShoppingListItem
+	if result := app.Repo.DB.First(&item, id); result.Error != nil {
+		http.Error(w, "Shopping list item not found", http.StatusNotFound)
+		return
+	}
+
+	result := app.Repo.DB.Model(&item).Update("checked", data.Checked)
+	if result.Error != nil {
+		http.Error(w, "Failed to update item", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+// Delete a saved shopping list
+func (app *App) deleteShoppingListByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid shopping list ID", http.StatusBadRequest)
+		return
+	}
+
+	err = app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		return deleteShoppingListTree(tx, id)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Shopping list not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to delete shopping list", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Delete a shopping list and its items
+func deleteShoppingListTree(tx *gorm.DB, id int) error {
+	if err := tx.Where("shopping_list_id = ?", id).Delete(&models.ShoppingListItem{}).Error; err != nil {
+		return err
+	}
+	result := tx.Delete(&models.ShoppingList{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"recipe-api/internal/models"
+	"strings"
+	"testing"
+)
+
+func createShoppingTestRecipes(t *testing.T) (models.Recipe, models.Recipe) {
+	t.Helper()
+
+	produce := "Produce"
+	soup := createTestRecipe(t, testApp, true)
+	soup.Servings = ToPtr(2)
+	soup.Ingredients = []models.RecipeIngredient{
+		{Amount: ToPtr(float32(500)), Ingredient: &models.Ingredient{Label: "Carrot", Category: &produce}, Unit: createTestUnit("g")},
+		{Amount: ToPtr(float32(2)), Ingredient: createTestIngredient("Water"), Unit: createTestUnit("cup")},
+		{Ingredient: createTestIngredient("Salt"), Unit: createTestUnit("pinch")},
+	}
+
+	salad := createTestRecipe(t, testApp, true)
+	salad.Ingredients = []models.RecipeIngredient{
+		{Amount: ToPtr(float32(1)), Ingredient: &models.Ingredient{Label: "Carrot", Category: &produce}, Unit: createTestUnit("kg")},
+		{Amount: ToPtr(float32(3)), Ingredient: &models.Ingredient{Label: "Carrot", Category: &produce}, Unit: createTestUnit("whole")},
+		{Ingredient: createTestIngredient("Salt"), Unit: createTestUnit("Pinch")},
+	}
+
+	return postTestRecipe(t, testApp, soup), postTestRecipe(t, testApp, salad)
+}
+
+func TestGenerateShoppingListMergesIngredients(t *testing.T) {
+	defer clearDatabase(testApp)
+	soup, salad := createShoppingTestRecipes(t)
+
+	payload := map[string]any{
+		"recipes": []map[string]int{
+			{"recipe_id": soup.RecipeID, "servings": 4}, // doubles the soup
+			{"recipe_id": salad.RecipeID},
+		},
+	}
+	w := serveJSON(t, testRouter(), http.MethodPost, "/shoppinglist/generate", payload)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var list shoppingListResponse
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if len(list.Groups) != 2 || list.Groups[0].Category != "Produce" || list.Groups[1].Category != uncategorised {
+		t.Fatalf("expected Produce then Other groups, got %+v", list.Groups)
+	}
+
+	// 1000 g from the doubled soup plus 1 kg converted, and a separate line for whole carrots
+	carrots := list.Groups[0].Items
+	if len(carrots) != 2 {
+		t.Fatalf("expected 2 carrot lines, got %+v", carrots)
+	}
+	if carrots[0].Unit != "g" || *carrots[0].Amount != 2000 {
+		t.Errorf("expected 2000 g of carrots, got %v %s", *carrots[0].Amount, carrots[0].Unit)
+	}
+	if carrots[1].Unit != "whole" || *carrots[1].Amount != 3 {
+		t.Errorf("expected 3 whole carrots, got %v %s", *carrots[1].Amount, carrots[1].Unit)
+	}
+
+	other := list.Groups[1].Items
+	if len(other) != 2 {
+		t.Fatalf("expected salt and water, got %+v", other)
+	}
+	if other[0].Label != "Salt" || other[0].Amount != nil {
+		t.Errorf("expected a single unmeasured salt line, got %+v", other[0])
+	}
+	if other[1].Label != "Water" || *other[1].Amount != 4 {
+		t.Errorf("expected 4 cups of water, got %+v", other[1])
+	}
+}
+
+func TestShoppingListExportAndTickOff(t *testing.T) {
+	defer clearDatabase(testApp)
+	soup, _ := createShoppingTestRecipes(t)
+	router := testRouter()
+
+	payload := map[string]any{
+		"userID":  "cook",
+		"name":    "Soup night",
+		"recipes": []map[string]int{{"recipe_id": soup.RecipeID}},
+	}
+	w := serveJSON(t, router, http.MethodPost, "/shoppinglist/add", payload)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	var saved shoppingListResponse
+	json.NewDecoder(w.Body).Decode(&saved)
+	carrot := saved.Groups[0].Items[0]
+	if carrot.ShoppingListItemID == 0 {
+		t.Fatal("expected saved items to have IDs")
+	}
+
+	w = serveJSON(t, router, http.MethodPut, fmt.Sprintf("/shoppinglist/item/%d", carrot.ShoppingListItemID), map[string]bool{"checked": true})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	w = serveJSON(t, router, http.MethodGet, fmt.Sprintf("/shoppinglist/id/%d?format=markdown", saved.ShoppingListID), nil)
+	markdown := w.Body.String()
+	if !strings.HasPrefix(markdown, "# Soup night\n") {
+		t.Fatalf("unexpected markdown title:\n%s", markdown)
+	}
+	if !strings.Contains(markdown, "## Produce\n\n- [x] Carrot (500 g)\n") {
+		t.Fatalf("expected ticked carrots in markdown:\n%s", markdown)
+	}
+
+	w = serveJSON(t, router, http.MethodGet, fmt.Sprintf("/shoppinglist/id/%d?format=text", saved.ShoppingListID), nil)
+	// Unit labels match case-insensitively, so the unit keeps whichever spelling was created first
+	if !strings.Contains(strings.ToLower(w.Body.String()), "  [ ] 2 cup water\n") {
+		t.Fatalf("expected unticked water in text export:\n%s", w.Body.String())
+	}
+}
+
+func TestShoppingListKeepsUnlinkedIngredientsApart(t *testing.T) {
+	row := func(label string, amount float64) models.RecipeIngredient {
+		return models.RecipeIngredient{Amount: ToPtr(float32(amount)), Ingredient: &models.Ingredient{Label: label}, Unit: &models.Unit{Label: "g"}}
+	}
+	recipe := models.Recipe{Ingredients: []models.RecipeIngredient{row("Flour", 100), row("Sugar", 50), row(" flour ", 200)}}
+
+	items := buildShoppingList([]scaledRecipe{{recipe, 1}})
+	if len(items) != 2 {
+		t.Fatalf("expected flour and sugar lines, got %+v", items)
+	}
+	if items[0].Label != "Flour" || *items[0].Amount != 300 || items[1].Label != "Sugar" || *items[1].Amount != 50 {
+		t.Errorf("expected 300 g flour and 50 g sugar, got %+v %+v", items[0], items[1])
+	}
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// How often idle event streams send a comment so proxies keep them open
+var sseHeartbeat = 15 * time.Second
+
+// Event stream opened with startSSE
+type sseStream struct {
+	w          http.ResponseWriter
+	controller *http.ResponseController
+}
+
+// Send the headers for a Server-Sent Events response. The controller finds
+// the flusher through any middleware wrapping the writer.
+func startSSE(w http.ResponseWriter) (*sseStream, error) {
+	stream := &sseStream{w: w, controller: http.NewResponseController(w)}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // stop nginx buffering the stream
+	w.WriteHeader(http.StatusOK)
+	return stream, stream.controller.Flush()
+}
+
+// Send an event with a JSON payload. id may be empty.
+func (stream *sseStream) send(id, event string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	var message strings.Builder
+	if id != "" {
+		fmt.Fprintf(&message, "id: %s\n", id)
+	}
+	fmt.Fprintf(&message, "event: %s\ndata: %s\n\n", event, data)
+	if _, err := stream.w.Write([]byte(message.String())); err != nil {
+		return err
+	}
+	return stream.controller.Flush()
+}
+
+// Send a comment line, ignored by clients
+func (stream *sseStream) heartbeat() error {
+	if _, err := stream.w.Write([]byte(": heartbeat\n\n")); err != nil {
+		return err
+	}
+	return stream.controller.Flush()
+}
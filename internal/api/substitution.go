@@ -0,0 +1,289 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+
+	"recipe-api/internal/diet"
+	"recipe-api/internal/models"
+	"recipe-api/internal/repository"
+)
+
+// Load substitutions with their ingredients and replacements
+func preloadSubstitution(db *gorm.DB) *gorm.DB {
+	return db.Preload("Ingredient").
+		Preload("Replacements", func(db *gorm.DB) *gorm.DB {
+			return db.Order("substitution_replacement_id ASC")
+		}).
+		Preload("Replacements.Ingredient").
+		Preload("Replacements.Unit")
+}
+
+// Check a substitution's ratios and diet before anything is written
+func validateSubstitution(sub *models.Substitution) error {
+	if sub.IngredientID == 0 && sub.Ingredient == nil {
+		return errors.New("an ingredient to replace is required")
+	}
+	if len(sub.Replacements) == 0 {
+		return errors.New("at least one replacement is required")
+	}
+	for i := range sub.Replacements {
+		replacement := &sub.Replacements[i]
+		if replacement.IngredientID == 0 && replacement.Ingredient == nil {
+			return errors.New("every replacement needs an ingredient")
+		}
+		if replacement.Ratio < 0 {
+			return errors.New("ratio must not be negative")
+		}
+		if replacement.Ratio == 0 {
+			replacement.Ratio = 1
+		}
+	}
+	if sub.Diet != nil {
+		d, ok := diet.Lookup(*sub.Diet)
+		if !ok {
+			return fmt.Errorf("unknown diet %q", *sub.Diet)
+		}
+		sub.Diet = &d.Name
+	}
+	return nil
+}
+
+// Substitution the rules reject once its labels are resolved, as opposed to
+// a storage failure
+type invalidSubstitution struct{ error }
+
+// Resolve the ingredient and unit labels sent with a substitution
+func resolveSubstitution(tx *gorm.DB, sub *models.Substitution) error {
+	if sub.Ingredient != nil {
+		ingredient, err := repository.FirstOrCreateIngredient(tx, sub.Ingredient)
+		if err != nil {
+			return err
+		}
+		sub.IngredientID = ingredient.IngredientID
+	}
+	sub.Ingredient = nil
+
+	for i := range sub.Replacements {
+		replacement := &sub.Replacements[i]
+		if replacement.Ingredient != nil {
+			ingredient, err := repository.FirstOrCreateIngredient(tx, replacement.Ingredient)
+			if err != nil {
+				return err
+			}
+			replacement.IngredientID = ingredient.IngredientID
+		}
+		if replacement.IngredientID == sub.IngredientID {
+			return invalidSubstitution{errors.New("an ingredient can't replace itself")}
+		}
+		if replacement.Unit != nil {
+			unit, err := repository.FirstOrCreateUnit(tx, replacement.Unit)
+			if err != nil {
+				return err
+			}
+			replacement.UnitID = &unit.UnitID
+		}
+		replacement.SubstitutionReplacementID = 0
+		replacement.Ingredient = nil
+		replacement.Unit = nil
+	}
+	return nil
+}
+
+// Answer a failed save, 400 when the substitution was invalid and 500 when
+// storing it failed, and report whether it succeeded
+func (app *App) substitutionSaved(w http.ResponseWriter, err error) bool {
+	var invalid invalidSubstitution
+	if errors.As(err, &invalid) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	if err != nil {
+		app.Logger.Println("Transaction Failed:", err)
+		http.Error(w, "Failed to save substitution", http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
+
+// Insert a substitution's replacements
+func createReplacements(tx *gorm.DB, id int, replacements []models.SubstitutionReplacement) error {
+	for _, replacement := range replacements {
+		replacement.SubstitutionID = id
+		if err := tx.Create(&replacement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Add a substitution for an ingredient
+func (app *App) addSubstitution(w http.ResponseWriter, r *http.Request) {
+	var data models.Substitution
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if err := validateSubstitution(&data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sub := data
+	sub.SubstitutionID = 0
+	result := app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		if err := resolveSubstitution(tx, &sub); err != nil {
+			return err
+		}
+		replacements := sub.Replacements
+		sub.Replacements = nil
+		if err := tx.Create(&sub).Error; err != nil {
+			return err
+		}
+		return createReplacements(tx, sub.SubstitutionID, replacements)
+	})
+	if !app.substitutionSaved(w, result) {
+		return
+	}
+
+	preloadSubstitution(app.Repo.DB).First(&sub, sub.SubstitutionID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(sub)
+}
+
+// Get a single substitution
+func (app *App) getSubstitutionByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid substitution ID", http.StatusBadRequest)
+		return
+	}
+
+	var sub models.Substitution
+	if result := preloadSubstitution(app.Repo.DB).First(&sub, id); result.Error != nil {
+		http.Error(w, "Substitution not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sub)
+}
+
+// Get the substitutions for an ingredient, optionally only those for ?diet=
+func (app *App) getIngredientSubstitutions(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid ingredient ID", http.StatusBadRequest)
+		return
+	}
+
+	query := preloadSubstitution(app.Repo.DB).Where("ingredient_id = ?", id)
+	if name := r.URL.Query().Get("diet"); name != "" {
+		d, ok := diet.Lookup(name)
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown diet %q", name), http.StatusBadRequest)
+			return
+		}
+		query = query.Where("diet = ?", d.Name)
+	}
+
+	subs := []models.Substitution{}
+	if result := query.Order("substitution_id ASC").Find(&subs); result.Error != nil {
+		http.Error(w, "Error fetching substitutions.", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(subs)
+}
+
+// Replace a substitution's diet, notes and replacements
+func (app *App) updateSubstitutionByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid substitution ID", http.StatusBadRequest)
+		return
+	}
+
+	var data models.Substitution
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	var sub models.Substitution
+	if result := app.Repo.DB.First(&sub, id); result.Error != nil {
+		http.Error(w, "Substitution not found", http.StatusNotFound)
+		return
+	}
+	if data.IngredientID == 0 && data.Ingredient == nil {
+		data.IngredientID = sub.IngredientID
+	}
+	if err := validateSubstitution(&data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result := app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		if err := resolveSubstitution(tx, &data); err != nil {
+			return err
+		}
+		err := tx.Model(&sub).Updates(map[string]any{
+			"ingredient_id": data.IngredientID,
+			"diet":          data.Diet,
+			"notes":         data.Notes,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("substitution_id = ?", id).Delete(&models.SubstitutionReplacement{}).Error; err != nil {
+			return err
+		}
+		return createReplacements(tx, id, data.Replacements)
+	})
+	if !app.substitutionSaved(w, result) {
+		return
+	}
+
+	preloadSubstitution(app.Repo.DB).First(&sub, id)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sub)
+}
+
+// Delete a substitution and its replacements
+func (app *App) deleteSubstitutionByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid substitution ID", http.StatusBadRequest)
+		return
+	}
+
+	err = app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("substitution_id = ?", id).Delete(&models.SubstitutionReplacement{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.Substitution{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Substitution not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to delete substitution", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"recipe-api/internal/models"
+	"strings"
+	"testing"
+)
+
+func addTestSubstitution(t *testing.T, router http.Handler, sub models.Substitution) models.Substitution {
+	t.Helper()
+	w := serveJSON(t, router, http.MethodPost, "/substitution/add", sub)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var created models.Substitution
+	json.NewDecoder(w.Body).Decode(&created)
+	return created
+}
+
+func ingredientsByLabel(recipe models.Recipe) map[string]models.RecipeIngredient {
+	byLabel := make(map[string]models.RecipeIngredient)
+	for _, ri := range recipe.Ingredients {
+		if ri.Ingredient != nil {
+			byLabel[ri.Ingredient.Label] = ri
+		}
+	}
+	return byLabel
+}
+
+func TestSubstituteRecipe(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+
+	dairy := "dairy"
+	vegan := "vegan"
+	recipe := postTestRecipe(t, testApp, models.Recipe{
+		Name:       "Scones",
+		Difficulty: 2,
+		UserID:     "baker",
+		Ingredients: []models.RecipeIngredient{
+			{Amount: ToPtr(float32(1)), Unit: createTestUnit("Cup"),
+				Ingredient: &models.Ingredient{Label: "Buttermilk", Animal: &dairy, Allergens: models.StringList{"milk"}}},
+			{Amount: ToPtr(float32(2)), Unit: createTestUnit("Cup"), Ingredient: createTestIngredient("Flour")},
+		},
+	})
+
+	general := addTestSubstitution(t, router, models.Substitution{
+		Ingredient: createTestIngredient("Buttermilk"),
+		Replacements: []models.SubstitutionReplacement{
+			{Ingredient: &models.Ingredient{Label: "Milk", Animal: &dairy, Allergens: models.StringList{"milk"}}, Ratio: 0.9},
+			{Ingredient: createTestIngredient("Lemon juice"), Ratio: 0.1},
+		},
+	})
+	buttermilk := general.IngredientID
+	addTestSubstitution(t, router, models.Substitution{
+		IngredientID: buttermilk,
+		Diet:         &vegan,
+		Replacements: []models.SubstitutionReplacement{
+			{Ingredient: &models.Ingredient{Label: "Soy milk", Allergens: models.StringList{"soy"}}},
+			{Ingredient: &models.Ingredient{Label: "Vinegar", DietChecked: true}, Unit: createTestUnit("tbsp")},
+		},
+	})
+
+	var response substitutedRecipe
+	w := serveJSON(t, router, http.MethodGet, fmt.Sprintf("/recipe/id/%d/substitute?ingredient=%d", recipe.RecipeID, buttermilk), nil)
+	json.NewDecoder(w.Body).Decode(&response)
+	if len(response.Applied) != 1 || response.Applied[0].SubstitutionID != general.SubstitutionID {
+		t.Fatalf("expected the general swap to be applied, got %+v", response.Applied)
+	}
+	ingredients := ingredientsByLabel(response.Recipe)
+	if len(ingredients) != 3 || *ingredients["Milk"].Amount != 0.9 || ingredients["Lemon juice"].Unit.Label != "Cup" {
+		t.Fatalf("unexpected substituted ingredients %v", ingredients)
+	}
+
+	// Asking for a vegan version swaps whatever the diet rules out
+	w = serveJSON(t, router, http.MethodGet, fmt.Sprintf("/recipe/id/%d/substitute?diet=vegan", recipe.RecipeID), nil)
+	response = substitutedRecipe{}
+	json.NewDecoder(w.Body).Decode(&response)
+	ingredients = ingredientsByLabel(response.Recipe)
+	if _, ok := ingredients["Soy milk"]; !ok || len(ingredients) != 3 || ingredients["Vinegar"].Unit.Label != "tbsp" {
+		t.Fatalf("unexpected vegan ingredients %v", ingredients)
+	}
+	if len(response.Recipe.Diets) == 0 || response.Recipe.Diets[0] != "vegan" {
+		t.Fatalf("expected the variant to be vegan, got %v", response.Recipe.Diets)
+	}
+
+	// Nothing to swap flour for
+	var flour models.Ingredient
+	testApp.Repo.DB.First(&flour, "label = ?", "Flour")
+	w = serveJSON(t, router, http.MethodGet, fmt.Sprintf("/recipe/id/%d/substitute?ingredient=%d", recipe.RecipeID, flour.IngredientID), nil)
+	response = substitutedRecipe{}
+	json.NewDecoder(w.Body).Decode(&response)
+	if len(response.Applied) != 0 || len(response.Unresolved) != 1 || response.Unresolved[0].Label != "Flour" {
+		t.Fatalf("expected flour to be unresolved, got %+v", response)
+	}
+
+	var subs []models.Substitution
+	w = serveJSON(t, router, http.MethodGet, fmt.Sprintf("/ingredient/id/%d/substitutions?diet=vegan", buttermilk), nil)
+	json.NewDecoder(w.Body).Decode(&subs)
+	if len(subs) != 1 || len(subs[0].Replacements) != 2 || subs[0].Replacements[0].Ingredient.Label != "Soy milk" {
+		t.Fatalf("expected the vegan swap, got %+v", subs)
+	}
+}
+
+func TestSubstitutionManagement(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+
+	w := serveJSON(t, router, http.MethodPost, "/substitution/add", models.Substitution{Ingredient: createTestIngredient("Egg")})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d without replacements, got %d", http.StatusBadRequest, w.Code)
+	}
+	w = serveJSON(t, router, http.MethodPost, "/substitution/add", models.Substitution{
+		Ingredient:   createTestIngredient("Egg"),
+		Replacements: []models.SubstitutionReplacement{{Ingredient: createTestIngredient("egg"), Ratio: 1}},
+	})
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "replace itself") {
+		t.Fatalf("expected status %d for an ingredient replacing itself, got %d: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+
+	sub := addTestSubstitution(t, router, models.Substitution{
+		Ingredient:   createTestIngredient("Egg"),
+		Replacements: []models.SubstitutionReplacement{{Ingredient: createTestIngredient("Banana"), Ratio: 0.5}},
+	})
+	path := fmt.Sprintf("/substitution/id/%d", sub.SubstitutionID)
+
+	notes := "best in cakes"
+	w = serveJSON(t, router, http.MethodPut, path, models.Substitution{
+		Notes:        &notes,
+		Replacements: []models.SubstitutionReplacement{{Ingredient: createTestIngredient("Apple sauce"), Ratio: 0.25}},
+	})
+	var updated models.Substitution
+	json.NewDecoder(w.Body).Decode(&updated)
+	if updated.IngredientID != sub.IngredientID || updated.Notes == nil || len(updated.Replacements) != 1 ||
+		updated.Replacements[0].Ingredient.Label != "Apple sauce" || updated.Replacements[0].Ratio != 0.25 {
+		t.Fatalf("unexpected updated substitution %+v", updated)
+	}
+
+	w = serveJSON(t, router, http.MethodDelete, path, nil)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, w.Code)
+	}
+	w = serveJSON(t, router, http.MethodGet, path, nil)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d after delete, got %d", http.StatusNotFound, w.Code)
+	}
+}
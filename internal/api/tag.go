@@ -0,0 +1,271 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"recipe-api/internal/models"
+	"recipe-api/internal/repository"
+)
+
+// Tags of one kind with how many recipes carry each
+type tagFacet struct {
+	Kind string          `json:"kind"`
+	Tags []tagFacetCount `json:"tags"`
+}
+
+type tagFacetCount struct {
+	models.Tag
+	Count int `json:"count"`
+}
+
+// Normalise a tag's name and kind, defaulting to a free tag
+func normaliseTag(tag *models.Tag) error {
+	tag.Name = strings.ToLower(repository.CanonicalLabel(tag.Name))
+	tag.Kind = strings.ToLower(strings.TrimSpace(tag.Kind))
+	if tag.Kind == "" {
+		tag.Kind = models.TagFree
+	}
+	if tag.Name == "" {
+		return errors.New("tag name is required")
+	}
+	if !slices.Contains(models.TagKinds, tag.Kind) {
+		return fmt.Errorf("unknown tag kind %q", tag.Kind)
+	}
+	return nil
+}
+
+// Find a tag by kind and name
+func findTag(db *gorm.DB, tag models.Tag) (models.Tag, bool, error) {
+	var found models.Tag
+	result := db.Where("kind = ? AND name = ?", tag.Kind, tag.Name).Limit(1).Find(&found)
+	return found, result.RowsAffected > 0, result.Error
+}
+
+// Normalise tags sent with a recipe and check controlled ones exist, before
+// anything is written
+func validateTags(db *gorm.DB, tags []models.Tag) error {
+	for i := range tags {
+		if tags[i].TagID != 0 && tags[i].Name == "" {
+			continue
+		}
+		if err := normaliseTag(&tags[i]); err != nil {
+			return err
+		}
+		if tags[i].Kind == models.TagFree {
+			continue
+		}
+		if _, found, err := findTag(db, tags[i]); err != nil {
+			return err
+		} else if !found {
+			return fmt.Errorf("unknown %s %q", tags[i].Kind, tags[i].Name)
+		}
+	}
+	return nil
+}
+
+// Resolve validated tags to stored ones, creating free tags as needed
+func resolveTags(tx *gorm.DB, tags []models.Tag) ([]models.Tag, error) {
+	resolved := make([]models.Tag, 0, len(tags))
+	for _, tag := range tags {
+		if tag.TagID != 0 && tag.Name == "" {
+			if err := tx.First(&tag, tag.TagID).Error; err != nil {
+				return nil, fmt.Errorf("tag %d not found", tag.TagID)
+			}
+		} else {
+			stored, found, err := findTag(tx, tag)
+			if err != nil {
+				return nil, err
+			}
+			if !found {
+				stored = models.Tag{Name: tag.Name, Kind: tag.Kind}
+				if err := tx.Create(&stored).Error; err != nil {
+					return nil, err
+				}
+			}
+			tag = stored
+		}
+		if !slices.ContainsFunc(resolved, func(t models.Tag) bool { return t.TagID == tag.TagID }) {
+			resolved = append(resolved, tag)
+		}
+	}
+	return resolved, nil
+}
+
+// Create a tag, usually a course, cuisine or occasion for the vocabulary
+func (app *App) addTag(w http.ResponseWriter, r *http.Request) {
+	var data models.Tag
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if err := normaliseTag(&data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, found, err := findTag(app.Repo.DB, data); err != nil {
+		http.Error(w, "Error checking tags.", http.StatusInternalServerError)
+		return
+	} else if found {
+		http.Error(w, fmt.Sprintf("%s %q already exists", data.Kind, data.Name), http.StatusConflict)
+		return
+	}
+
+	tag := models.Tag{Name: data.Name, Kind: data.Kind}
+	if result := app.Repo.DB.Create(&tag); result.Error != nil {
+		http.Error(w, "Failed to create tag", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(tag)
+}
+
+// List tags, optionally of one ?kind=
+func (app *App) getAllTags(w http.ResponseWriter, r *http.Request) {
+	query := app.Repo.DB.Order("kind ASC").Order("name ASC")
+	if kind := r.URL.Query().Get("kind"); kind != "" {
+		query = query.Where("kind = ?", strings.ToLower(kind))
+	}
+
+	tags := []models.Tag{}
+	if result := query.Find(&tags); result.Error != nil {
+		http.Error(w, "Error fetching tags.", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tags)
+}
+
+// Rename a tag
+func (app *App) updateTagByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := catalogueID(w, r)
+	if !ok {
+		return
+	}
+
+	var data models.Tag
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	var tag models.Tag
+	if result := app.Repo.DB.First(&tag, id); result.Error != nil {
+		http.Error(w, "Tag not found", http.StatusNotFound)
+		return
+	}
+	data.Kind = tag.Kind
+	if err := normaliseTag(&data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if existing, found, err := findTag(app.Repo.DB, data); err != nil {
+		http.Error(w, "Error checking tags.", http.StatusInternalServerError)
+		return
+	} else if found && existing.TagID != id {
+		http.Error(w, fmt.Sprintf("%s %q already exists", data.Kind, data.Name), http.StatusConflict)
+		return
+	}
+
+	err := app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&tag).Update("name", data.Name).Error; err != nil {
+			return err
+		}
+		return touchRecipes(tx, taggedRecipes(tx, id))
+	})
+	if err != nil {
+		app.Logger.Println("Transaction Failed:", err)
+		http.Error(w, "Failed to update tag", http.StatusInternalServerError)
+		return
+	}
+	app.forgetAllRecipes(r.Context())
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tag)
+}
+
+// IDs of the recipes carrying a tag, as a subquery
+func taggedRecipes(tx *gorm.DB, id int) *gorm.DB {
+	return tx.Session(&gorm.Session{NewDB: true}).Table("recipe_tags").Select("recipe_id").Where("tag_id = ?", id)
+}
+
+// Delete a tag and take it off every recipe
+func (app *App) deleteTagByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := catalogueID(w, r)
+	if !ok {
+		return
+	}
+
+	err := app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		if err := touchRecipes(tx, taggedRecipes(tx, id)); err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM recipe_tags WHERE tag_id = ?", id).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.Tag{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Tag not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to delete tag", http.StatusInternalServerError)
+		return
+	}
+	app.forgetAllRecipes(r.Context())
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Count recipes per tag among those matching the listing filters, grouped by
+// kind for the filter sidebar
+func (app *App) getTagFacets(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseRecipeFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	matching := filter.apply(app.Repo.DB.Model(&models.Recipe{})).Select("recipes.recipe_id")
+	var counts []tagFacetCount
+	result := app.Repo.DB.Table("tags").
+		Select("tags.tag_id, tags.name, tags.kind, COUNT(DISTINCT recipe_tags.recipe_id) AS count").
+		Joins("JOIN recipe_tags ON recipe_tags.tag_id = tags.tag_id").
+		Where("recipe_tags.recipe_id IN (?)", matching).
+		Group("tags.tag_id, tags.name, tags.kind").
+		Order("tags.kind ASC").Order("count DESC").Order("tags.name ASC").
+		Scan(&counts)
+	if result.Error != nil {
+		app.Logger.Println("Facet error:", result.Error)
+		http.Error(w, "Error counting tags.", http.StatusInternalServerError)
+		return
+	}
+
+	facets := []tagFacet{}
+	for _, kind := range models.TagKinds {
+		facet := tagFacet{Kind: kind, Tags: []tagFacetCount{}}
+		for _, count := range counts {
+			if count.Kind == kind {
+				facet.Tags = append(facet.Tags, count)
+			}
+		}
+		facets = append(facets, facet)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(facets)
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"recipe-api/internal/models"
+	"testing"
+)
+
+func postTaggedRecipe(t *testing.T, name string, tags ...models.Tag) models.Recipe {
+	t.Helper()
+	return postTestRecipe(t, testApp, models.Recipe{Name: name, Difficulty: 1, UserID: "cook", Tags: tags})
+}
+
+func TestRecipeTags(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+
+	for _, tag := range []models.Tag{{Kind: "cuisine", Name: "Italian"}, {Kind: "course", Name: "main"}} {
+		w := serveJSON(t, router, http.MethodPost, "/tag/add", tag)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+	}
+	w := serveJSON(t, router, http.MethodPost, "/tag/add", models.Tag{Kind: "cuisine", Name: " italian "})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected status %d for a duplicate tag, got %d", http.StatusConflict, w.Code)
+	}
+
+	italian := models.Tag{Kind: "cuisine", Name: "italian"}
+	pasta := postTaggedRecipe(t, "Pasta", italian, models.Tag{Name: "Quick"}, models.Tag{Kind: "course", Name: "Main"})
+	postTaggedRecipe(t, "Pizza", italian, models.Tag{Name: "party"})
+	postTaggedRecipe(t, "Curry", models.Tag{Name: "quick"})
+
+	// Controlled vocabularies only take known values
+	w = serveJSON(t, router, http.MethodPost, "/recipe/add", models.Recipe{
+		Name: "Moon cheese", UserID: "cook", Tags: []models.Tag{{Kind: "cuisine", Name: "martian"}},
+	})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d for an unknown cuisine, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/recipe/all?tag=quick", "Curry,Pasta"},
+		{"/recipe/all?tag=quick,cuisine:italian", "Pasta"},
+		{"/recipe/all?tag=quick&tag=party&tag_mode=or", "Curry,Pasta,Pizza"},
+		{"/recipe/all?tag=course:quick", ""},
+	}
+	for _, test := range tests {
+		if got := recipeNames(t, router, test.path); got != test.expected {
+			t.Errorf("GET %s: expected %q, got %q", test.path, test.expected, got)
+		}
+	}
+
+	var facets []tagFacet
+	w = serveJSON(t, router, http.MethodGet, "/tag/facets?tag=cuisine:italian", nil)
+	json.NewDecoder(w.Body).Decode(&facets)
+	counts := make(map[string]int)
+	for _, facet := range facets {
+		for _, tag := range facet.Tags {
+			counts[facet.Kind+":"+tag.Name] = tag.Count
+		}
+	}
+	expected := map[string]int{"cuisine:italian": 2, "course:main": 1, "tag:quick": 1, "tag:party": 1}
+	if fmt.Sprint(counts) != fmt.Sprint(expected) {
+		t.Fatalf("expected facets %v, got %v", expected, counts)
+	}
+
+	// Updates without tags keep them, updates with tags replace them
+	path := fmt.Sprintf("/recipe/id/%d", pasta.RecipeID)
+	serveJSON(t, router, http.MethodPut, path, models.Recipe{Name: "Pasta", Difficulty: 2})
+	var got models.Recipe
+	json.NewDecoder(serveJSON(t, router, http.MethodGet, path, nil).Body).Decode(&got)
+	if len(got.Tags) != 3 {
+		t.Fatalf("expected tags to be kept, got %+v", got.Tags)
+	}
+	serveJSON(t, router, http.MethodPut, path, models.Recipe{Name: "Pasta", Tags: []models.Tag{{Name: "weeknight"}}})
+	got = models.Recipe{}
+	json.NewDecoder(serveJSON(t, router, http.MethodGet, path, nil).Body).Decode(&got)
+	if len(got.Tags) != 1 || got.Tags[0].Name != "weeknight" {
+		t.Fatalf("expected tags to be replaced, got %+v", got.Tags)
+	}
+
+	// Deleting a tag takes it off recipes
+	w = serveJSON(t, router, http.MethodDelete, fmt.Sprintf("/tag/id/%d", got.Tags[0].TagID), nil)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, w.Code)
+	}
+	if names := recipeNames(t, router, "/recipe/all?tag=weeknight"); names != "" {
+		t.Fatalf("expected no recipes tagged weeknight, got %s", names)
+	}
+}
@@ -1,7 +1,12 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"recipe-api/internal/models"
 	"recipe-api/internal/repository"
 	"testing"
@@ -68,6 +73,32 @@ func createTestRecipe(t *testing.T, app *App, uniqueID ...bool) models.Recipe {
 	return recipe
 }
 
+// Create a recipe through the add handler and return the stored copy
+func postTestRecipe(t *testing.T, app *App, recipe models.Recipe) models.Recipe {
+	t.Helper()
+
+	body, err := json.Marshal(recipe)
+	if err != nil {
+		t.Fatalf("failed to marshal recipe: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/recipe/add", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	app.addRecipe(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	var created models.Recipe
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return created
+}
+
 func createRepository(db *gorm.DB) *repository.App {
 	return &repository.App{
 		DB: db,
@@ -75,6 +106,29 @@ func createRepository(db *gorm.DB) *repository.App {
 }
 
 func clearDatabase(app *App) {
+	app.Repo.DB.Exec("DELETE FROM webhook_attempts")
+	app.Repo.DB.Exec("DELETE FROM webhook_deliveries")
+	app.Repo.DB.Exec("DELETE FROM webhooks")
+	app.Repo.DB.Exec("DELETE FROM events")
+	app.Repo.DB.Exec("DELETE FROM cook_timers")
+	app.Repo.DB.Exec("DELETE FROM cook_sessions")
+	app.Repo.DB.Exec("DELETE FROM images")
+	app.Repo.DB.Exec("DELETE FROM ratings")
+	app.Repo.DB.Exec("DELETE FROM favourites")
+	app.Repo.DB.Exec("DELETE FROM collection_entries")
+	app.Repo.DB.Exec("DELETE FROM collections")
+	app.Repo.DB.Exec("DELETE FROM substitution_replacements")
+	app.Repo.DB.Exec("DELETE FROM substitutions")
+	app.Repo.DB.Exec("DELETE FROM nutritions")
+	app.Repo.DB.Exec("DELETE FROM pantry_items")
+	app.Repo.DB.Exec("DELETE FROM shopping_list_items")
+	app.Repo.DB.Exec("DELETE FROM shopping_lists")
+	app.Repo.DB.Exec("DELETE FROM meal_plan_entries")
+	app.Repo.DB.Exec("DELETE FROM meal_plans")
+	app.Repo.DB.Exec("DELETE FROM recipe_tags")
+	app.Repo.DB.Exec("DELETE FROM tags")
 	app.Repo.DB.Exec("DELETE FROM recipe_ingredients")
+	app.Repo.DB.Exec("DELETE FROM instructions")
 	app.Repo.DB.Exec("DELETE FROM recipes")
+	app.forgetAllRecipes(context.Background())
 }
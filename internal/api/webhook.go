@@ -0,0 +1,297 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+
+	"recipe-api/internal/events"
+	"recipe-api/internal/models"
+)
+
+// Longest signing secret a webhook can store
+const maxWebhookSecret = 64
+
+// Check a webhook's URL, event types and secret
+func checkWebhook(hook *models.Webhook) error {
+	target, err := url.Parse(hook.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return errors.New("url must be an absolute http or https URL")
+	}
+	for _, eventType := range hook.Events {
+		if !slices.Contains(events.Types, eventType) {
+			return fmt.Errorf("unknown event type %q", eventType)
+		}
+	}
+	if utf8.RuneCountInString(hook.Secret) > maxWebhookSecret {
+		return fmt.Errorf("secret must be at most %d characters", maxWebhookSecret)
+	}
+	return nil
+}
+
+// Whether a webhook wants an event type
+func webhookWants(hook models.Webhook, eventType string) bool {
+	return len(hook.Events) == 0 || slices.Contains(hook.Events, eventType)
+}
+
+// Queue an event for every active webhook of the recipe's owner that wants
+// it, in the caller's transaction so the event and its deliveries commit
+// together
+func enqueueWebhooks(tx *gorm.DB, event models.Event) error {
+	var hooks []models.Webhook
+	if err := tx.Where("active = ? AND user_id = ?", true, event.UserID).Find(&hooks).Error; err != nil {
+		return err
+	}
+	for _, hook := range hooks {
+		if !webhookWants(hook, event.Type) {
+			continue
+		}
+		delivery := models.WebhookDelivery{
+			WebhookID:     hook.WebhookID,
+			EventID:       event.EventID,
+			EventType:     event.Type,
+			Status:        models.DeliveryPending,
+			NextAttemptAt: time.Now(),
+		}
+		if err := tx.Create(&delivery).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Webhook named in the route, writing the error response when missing
+func (app *App) webhookFromRoute(w http.ResponseWriter, r *http.Request) (models.Webhook, bool) {
+	var hook models.Webhook
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid webhook ID", http.StatusBadRequest)
+		return hook, false
+	}
+	if result := app.Repo.DB.First(&hook, id); result.Error != nil {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return hook, false
+	}
+	return hook, true
+}
+
+// Subscribe a URL to recipe events. The signing secret is generated unless
+// given, and only returned here.
+func (app *App) addWebhook(w http.ResponseWriter, r *http.Request) {
+	var data models.Webhook
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if data.UserID == "" {
+		http.Error(w, "userID is required", http.StatusBadRequest)
+		return
+	}
+	if err := checkWebhook(&data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	hook := models.Webhook{UserID: data.UserID, URL: data.URL, Events: data.Events, Secret: data.Secret, Active: true}
+	if hook.Secret == "" {
+		secret, err := newShareToken()
+		if err != nil {
+			http.Error(w, "Failed to save webhook", http.StatusInternalServerError)
+			return
+		}
+		hook.Secret = secret
+	}
+	if err := app.Repo.DB.Create(&hook).Error; err != nil {
+		app.Logger.Println("Webhook error:", err)
+		http.Error(w, "Failed to save webhook", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(hook)
+}
+
+// Get a user's webhooks
+func (app *App) getWebhooksByUser(w http.ResponseWriter, r *http.Request) {
+	hooks := []models.Webhook{}
+	result := app.Repo.DB.Omit("Secret").Where("user_id = ?", mux.Vars(r)["userID"]).Order("webhook_id ASC").Find(&hooks)
+	if result.Error != nil {
+		http.Error(w, "Error fetching webhooks.", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hooks)
+}
+
+// Get a webhook
+func (app *App) getWebhook(w http.ResponseWriter, r *http.Request) {
+	hook, ok := app.webhookFromRoute(w, r)
+	if !ok {
+		return
+	}
+	hook.Secret = ""
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hook)
+}
+
+// Change a webhook's URL, event types or whether it is active
+func (app *App) updateWebhook(w http.ResponseWriter, r *http.Request) {
+	hook, ok := app.webhookFromRoute(w, r)
+	if !ok {
+		return
+	}
+	var data struct {
+		URL    *string   `json:"url"`
+		Events *[]string `json:"events"`
+		Active *bool     `json:"active"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if data.URL != nil {
+		hook.URL = *data.URL
+	}
+	if data.Events != nil {
+		hook.Events = *data.Events
+	}
+	if data.Active != nil {
+		hook.Active = *data.Active
+	}
+	if err := checkWebhook(&hook); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result := app.Repo.DB.Model(&hook).Select("URL", "Events", "Active").Updates(&hook)
+	if result.Error != nil {
+		http.Error(w, "Failed to update webhook", http.StatusInternalServerError)
+		return
+	}
+	hook.Secret = ""
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hook)
+}
+
+// Delete a webhook with its delivery history
+func (app *App) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	hook, ok := app.webhookFromRoute(w, r)
+	if !ok {
+		return
+	}
+	err := app.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		deliveries := tx.Model(&models.WebhookDelivery{}).Select("delivery_id").Where("webhook_id = ?", hook.WebhookID)
+		if err := tx.Where("delivery_id IN (?)", deliveries).Delete(&models.WebhookAttempt{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("webhook_id = ?", hook.WebhookID).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&hook).Error
+	})
+	if err != nil {
+		app.Logger.Println("Transaction Failed:", err)
+		http.Error(w, "Failed to delete webhook", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// List a webhook's deliveries, newest first, with their attempts.
+// ?status= narrows to pending, succeeded or failed; ?limit= defaults to 50.
+func (app *App) getWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	hook, ok := app.webhookFromRoute(w, r)
+	if !ok {
+		return
+	}
+	query := app.Repo.DB.Preload("History", func(db *gorm.DB) *gorm.DB {
+		return db.Order("attempt_id ASC")
+	}).Where("webhook_id = ?", hook.WebhookID)
+
+	switch status := r.URL.Query().Get("status"); status {
+	case "":
+	case models.DeliveryPending, models.DeliverySucceeded, models.DeliveryFailed:
+		query = query.Where("status = ?", status)
+	default:
+		http.Error(w, "status must be pending, succeeded or failed", http.StatusBadRequest)
+		return
+	}
+	limit := 50
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(parsed, 500)
+	}
+
+	deliveries := []models.WebhookDelivery{}
+	if err := query.Order("delivery_id DESC").Limit(limit).Find(&deliveries).Error; err != nil {
+		http.Error(w, "Error fetching deliveries.", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// Queue deliveries to be sent again from scratch
+func (app *App) replayDeliveries(w http.ResponseWriter, query *gorm.DB) {
+	result := query.Model(&models.WebhookDelivery{}).Updates(map[string]any{
+		"status":          models.DeliveryPending,
+		"attempts":        0,
+		"next_attempt_at": time.Now(),
+		"delivered_at":    nil,
+	})
+	if result.Error != nil {
+		app.Logger.Println("Webhook replay error:", result.Error)
+		http.Error(w, "Failed to replay deliveries", http.StatusInternalServerError)
+		return
+	}
+	app.wakeWebhookWorker()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int64{"replayed": result.RowsAffected})
+}
+
+// Replay one delivery, unless a worker holds it or a retry is already due.
+// Pending deliveries not yet due are being sent or waiting out a backoff,
+// so resetting them could send the event twice.
+func (app *App) replayDelivery(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid delivery ID", http.StatusBadRequest)
+		return
+	}
+	var delivery models.WebhookDelivery
+	if result := app.Repo.DB.First(&delivery, id); result.Error != nil {
+		http.Error(w, "Delivery not found", http.StatusNotFound)
+		return
+	}
+	now := time.Now()
+	if delivery.Status == models.DeliveryPending && delivery.NextAttemptAt.After(now) {
+		http.Error(w, "Delivery is being sent or retried", http.StatusConflict)
+		return
+	}
+	app.replayDeliveries(w, app.Repo.DB.Where("delivery_id = ? AND (status <> ? OR next_attempt_at <= ?)",
+		delivery.DeliveryID, models.DeliveryPending, now))
+}
+
+// Replay every failed delivery of a webhook
+func (app *App) replayFailedDeliveries(w http.ResponseWriter, r *http.Request) {
+	hook, ok := app.webhookFromRoute(w, r)
+	if !ok {
+		return
+	}
+	app.replayDeliveries(w, app.Repo.DB.Where("webhook_id = ? AND status = ?", hook.WebhookID, models.DeliveryFailed))
+}
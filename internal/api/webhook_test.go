@@ -0,0 +1,265 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"recipe-api/internal/events"
+	"recipe-api/internal/models"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func addTestWebhook(t *testing.T, router http.Handler, hook models.Webhook) models.Webhook {
+	t.Helper()
+	w := serveJSON(t, router, http.MethodPost, "/webhook/add", hook)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var created models.Webhook
+	json.NewDecoder(w.Body).Decode(&created)
+	return created
+}
+
+func webhookDeliveries(t *testing.T, router http.Handler, hookID int, query string) []models.WebhookDelivery {
+	t.Helper()
+	w := serveJSON(t, router, http.MethodGet, fmt.Sprintf("/webhook/id/%d/deliveries%s", hookID, query), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var deliveries []models.WebhookDelivery
+	json.NewDecoder(w.Body).Decode(&deliveries)
+	return deliveries
+}
+
+func TestWebhookSubscriptions(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+
+	for _, hook := range []models.Webhook{
+		{URL: "https://example.com/hook"},
+		{UserID: "cook", URL: "ftp://example.com/hook"},
+		{UserID: "cook", URL: "https://example.com/hook", Events: models.StringList{"recipe.eaten"}},
+		{UserID: "cook", URL: "https://example.com/hook", Secret: strings.Repeat("s", maxWebhookSecret+1)},
+	} {
+		if w := serveJSON(t, router, http.MethodPost, "/webhook/add", hook); w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d for %+v, got %d", http.StatusBadRequest, hook, w.Code)
+		}
+	}
+
+	hook := addTestWebhook(t, router, models.Webhook{UserID: "cook", URL: "https://example.com/hook", Events: models.StringList{events.RecipeDeleted}})
+	if hook.Secret == "" || !hook.Active {
+		t.Fatalf("expected an active webhook with a generated secret, got %+v", hook)
+	}
+
+	var listed []models.Webhook
+	json.NewDecoder(serveJSON(t, router, http.MethodGet, "/webhook/user/cook", nil).Body).Decode(&listed)
+	if len(listed) != 1 || listed[0].Secret != "" {
+		t.Fatalf("expected the webhook listed without its secret, got %+v", listed)
+	}
+
+	// Only subscribed event types on the owner's recipes are queued, in the
+	// transaction that wrote the event
+	recipe := postTestRecipe(t, testApp, models.Recipe{Name: "Soup", Difficulty: 1, UserID: "cook"})
+	other := postTestRecipe(t, testApp, models.Recipe{Name: "Broth", Difficulty: 1, UserID: "baker"})
+	serveJSON(t, router, http.MethodDelete, fmt.Sprintf("/recipe/id/%d", other.RecipeID), nil)
+	if deliveries := webhookDeliveries(t, router, hook.WebhookID, ""); len(deliveries) != 0 {
+		t.Fatalf("expected no delivery for an unsubscribed event or another user's recipe, got %d", len(deliveries))
+	}
+	serveJSON(t, router, http.MethodDelete, fmt.Sprintf("/recipe/id/%d", recipe.RecipeID), nil)
+	deliveries := webhookDeliveries(t, router, hook.WebhookID, "?status=pending")
+	if len(deliveries) != 1 || deliveries[0].EventType != events.RecipeDeleted {
+		t.Fatalf("expected one pending delete delivery, got %+v", deliveries)
+	}
+
+	// Inactive webhooks get nothing new
+	w := serveJSON(t, router, http.MethodPut, fmt.Sprintf("/webhook/id/%d", hook.WebhookID), map[string]any{"active": false, "events": []string{}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	postTestRecipe(t, testApp, models.Recipe{Name: "Stew", Difficulty: 1, UserID: "cook"})
+	if deliveries := webhookDeliveries(t, router, hook.WebhookID, ""); len(deliveries) != 1 {
+		t.Fatalf("expected no delivery for an inactive webhook, got %d", len(deliveries))
+	}
+
+	if w := serveJSON(t, router, http.MethodDelete, fmt.Sprintf("/webhook/id/%d", hook.WebhookID), nil); w.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, w.Code)
+	}
+	var remaining int64
+	testApp.Repo.DB.Model(&models.WebhookDelivery{}).Count(&remaining)
+	if remaining != 0 {
+		t.Fatalf("expected deliveries deleted with the webhook, got %d", remaining)
+	}
+}
+
+// Let deliveries reach test receivers on loopback until the returned
+// function is called
+func allowLocalWebhooks() func() {
+	client := webhookClient
+	webhookClient = &http.Client{Timeout: 10 * time.Second, CheckRedirect: noRedirects}
+	return func() { webhookClient = client }
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	defer clearDatabase(testApp)
+	defer allowLocalWebhooks()()
+	router := testRouter()
+
+	var mu sync.Mutex
+	failing := true
+	var received []*http.Request
+	var bodies [][]byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, r)
+		bodies = append(bodies, body)
+		if failing {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+		}
+	}))
+	defer receiver.Close()
+
+	hook := addTestWebhook(t, router, models.Webhook{UserID: "cook", URL: receiver.URL, Secret: "s3cret"})
+	recipe := postTestRecipe(t, testApp, models.Recipe{Name: "Soup", Difficulty: 1, UserID: "cook"})
+
+	// A failure is recorded and retried after a backoff
+	ctx := context.Background()
+	now := time.Now()
+	if sent := testApp.deliverDueWebhooks(ctx, now); sent != 1 {
+		t.Fatalf("expected one delivery sent, got %d", sent)
+	}
+	if sent := testApp.deliverDueWebhooks(ctx, now.Add(10*time.Second)); sent != 0 {
+		t.Fatalf("expected the retry to wait for its backoff, sent %d", sent)
+	}
+	deliveries := webhookDeliveries(t, router, hook.WebhookID, "")
+	if len(deliveries) != 1 || deliveries[0].Status != models.DeliveryPending || deliveries[0].Attempts != 1 ||
+		len(deliveries[0].History) != 1 || *deliveries[0].History[0].StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected one failed attempt recorded, got %+v", deliveries)
+	}
+	if due := deliveries[0].NextAttemptAt.Sub(now); due < webhookBaseBackoff-time.Second {
+		t.Fatalf("expected the retry at least %s away, got %s", webhookBaseBackoff, due)
+	}
+	replay := fmt.Sprintf("/webhook/delivery/%d/replay", deliveries[0].DeliveryID)
+	if w := serveJSON(t, router, http.MethodPost, replay, nil); w.Code != http.StatusConflict {
+		t.Fatalf("expected a delivery awaiting its retry not to be replayed, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Running out of attempts marks it failed; replaying sends it again
+	testApp.Repo.DB.Model(&models.WebhookDelivery{}).Where("delivery_id = ?", deliveries[0].DeliveryID).
+		Update("attempts", webhookMaxAttempts-1)
+	testApp.deliverDueWebhooks(ctx, now.Add(time.Hour))
+	if deliveries := webhookDeliveries(t, router, hook.WebhookID, "?status=failed"); len(deliveries) != 1 {
+		t.Fatalf("expected the delivery to be marked failed, got %+v", deliveries)
+	}
+
+	mu.Lock()
+	failing = false
+	mu.Unlock()
+	w := serveJSON(t, router, http.MethodPost, fmt.Sprintf("/webhook/id/%d/replay", hook.WebhookID), nil)
+	if w.Code != http.StatusOK || w.Body.String() != "{\"replayed\":1}\n" {
+		t.Fatalf("expected one delivery replayed, got %d: %s", w.Code, w.Body.String())
+	}
+	if sent := testApp.deliverDueWebhooks(ctx, time.Now()); sent != 1 {
+		t.Fatalf("expected the replay sent, got %d", sent)
+	}
+	delivered := webhookDeliveries(t, router, hook.WebhookID, "?status=succeeded")
+	if len(delivered) != 1 || delivered[0].DeliveredAt == nil || delivered[0].LastError != nil {
+		t.Fatalf("expected the delivery to succeed, got %+v", delivered)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	last, body := received[len(received)-1], bodies[len(bodies)-1]
+	if last.Header.Get("X-Recipe-Event") != events.RecipeCreated ||
+		last.Header.Get("X-Recipe-Delivery") != fmt.Sprint(delivered[0].DeliveryID) {
+		t.Fatalf("unexpected headers %v", last.Header)
+	}
+	if want := signWebhook("s3cret", last.Header.Get("X-Recipe-Timestamp"), body); last.Header.Get("X-Recipe-Signature") != want {
+		t.Fatalf("expected signature %s, got %s", want, last.Header.Get("X-Recipe-Signature"))
+	}
+	var event models.Event
+	json.Unmarshal(body, &event)
+	if event.RecipeID != recipe.RecipeID || event.Type != events.RecipeCreated {
+		t.Fatalf("unexpected payload %s", body)
+	}
+}
+
+func TestWebhookTargets(t *testing.T) {
+	defer clearDatabase(testApp)
+	router := testRouter()
+
+	for address, public := range map[string]bool{
+		"93.184.216.34":   true,
+		"2606:4700::1111": true,
+		"127.0.0.1":       false,
+		"10.1.2.3":        false,
+		"172.16.0.1":      false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"100.64.0.1":      false,
+		"0.0.0.0":         false,
+		"::1":             false,
+		"fd00::1":         false,
+		"fe80::1":         false,
+		"::ffff:10.0.0.1": false,
+	} {
+		if got := publicAddress(netip.MustParseAddr(address)); got != public {
+			t.Errorf("%s: expected public %v, got %v", address, public, got)
+		}
+	}
+
+	var mu sync.Mutex
+	hits := 0
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits++
+		mu.Unlock()
+		http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+	}))
+	defer receiver.Close()
+
+	// The delivery client refuses loopback receivers
+	hook := addTestWebhook(t, router, models.Webhook{UserID: "cook", URL: receiver.URL})
+	postTestRecipe(t, testApp, models.Recipe{Name: "Soup", Difficulty: 1, UserID: "cook"})
+	ctx := context.Background()
+	testApp.deliverDueWebhooks(ctx, time.Now())
+	deliveries := webhookDeliveries(t, router, hook.WebhookID, "")
+	if len(deliveries) != 1 || deliveries[0].LastError == nil || hits != 0 {
+		t.Fatalf("expected the loopback delivery refused, got %+v after %d requests", deliveries, hits)
+	}
+
+	// Redirects are the answer, not followed
+	defer allowLocalWebhooks()()
+	testApp.deliverDueWebhooks(ctx, time.Now().Add(time.Hour))
+	deliveries = webhookDeliveries(t, router, hook.WebhookID, "")
+	history := deliveries[0].History
+	if len(history) != 2 || history[1].StatusCode == nil || *history[1].StatusCode != http.StatusFound || hits != 1 {
+		t.Fatalf("expected the redirect recorded as a failed attempt, got %+v after %d requests", history, hits)
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{4, 4 * time.Minute},
+		{7, 32 * time.Minute},
+		{20, time.Hour},
+	}
+	for _, test := range tests {
+		if got := webhookBackoff(test.attempts); got != test.expected {
+			t.Errorf("attempt %d: expected %s, got %s", test.attempts, test.expected, got)
+		}
+	}
+}
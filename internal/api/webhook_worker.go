@@ -0,0 +1,246 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+
+	"recipe-api/internal/models"
+)
+
+const (
+	webhookMaxAttempts = 8                // attempts before a delivery is marked failed
+	webhookBaseBackoff = 30 * time.Second // delay after the first failure, doubled each time
+	webhookMaxBackoff  = time.Hour
+	webhookLease       = time.Minute // how long a claimed delivery is hidden from other workers
+	webhookBatch       = 20          // deliveries claimed per pass
+)
+
+// Client for webhook deliveries. It only connects to public addresses,
+// checked after DNS resolution so a hostname can't point it back inside the
+// network, and hands redirects back as the answer instead of following
+// them. Replaced in tests, whose receivers listen on loopback.
+var webhookClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext:         (&net.Dialer{Timeout: 5 * time.Second, Control: dialPublicOnly}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+		MaxIdleConnsPerHost: 2,
+	},
+	CheckRedirect: noRedirects,
+}
+
+// Ranges outside the global unicast space in practice that netip doesn't
+// classify: "this network", carrier-grade NAT, benchmarking and reserved
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+}
+
+// Whether webhooks may reach an address: not loopback, private, link-local
+// (cloud metadata at 169.254.169.254 included), multicast or unspecified
+func publicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// Dialer control refusing connections to addresses that aren't public
+func dialPublicOnly(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !publicAddress(addrPort.Addr()) {
+		return fmt.Errorf("webhook target %s is not a public address", addrPort.Addr())
+	}
+	return nil
+}
+
+func noRedirects(*http.Request, []*http.Request) error {
+	return http.ErrUseLastResponse
+}
+
+// How often the worker looks for due deliveries without being woken
+var webhookPoll = time.Second
+
+func (app *App) webhookWake() chan struct{} {
+	app.webhookOnce.Do(func() {
+		app.webhookSignal = make(chan struct{}, 1)
+	})
+	return app.webhookSignal
+}
+
+// Nudge the worker to look for due deliveries now
+func (app *App) wakeWebhookWorker() {
+	select {
+	case app.webhookWake() <- struct{}{}:
+	default:
+	}
+}
+
+// Delay before the next attempt after the given number of failed attempts
+func webhookBackoff(attempts int) time.Duration {
+	if attempts < 1 {
+		return 0
+	}
+	backoff := webhookBaseBackoff
+	for i := 1; i < attempts && backoff < webhookMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, webhookMaxBackoff)
+}
+
+// Signature header value for a delivery body: HMAC-SHA256 over
+// "<timestamp>.<body>" keyed with the webhook's secret
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Send deliveries until the context is cancelled, woken by new events and
+// polling for retries that have come due
+func (app *App) RunWebhookWorker(ctx context.Context) {
+	ticker := time.NewTicker(webhookPoll)
+	defer ticker.Stop()
+	for {
+		for app.deliverDueWebhooks(ctx, time.Now()) == webhookBatch {
+			// A full batch means more may be due
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-app.webhookWake():
+		}
+	}
+}
+
+// Claim and send one batch of due deliveries, returning how many were sent
+func (app *App) deliverDueWebhooks(ctx context.Context, now time.Time) int {
+	var due []models.WebhookDelivery
+	result := app.Repo.DB.Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, now).
+		Order("next_attempt_at ASC").Limit(webhookBatch).Find(&due)
+	if result.Error != nil {
+		app.Logger.Println("Webhook error:", result.Error)
+		return 0
+	}
+
+	sent := 0
+	for _, delivery := range due {
+		// Only one worker wins the conditional update
+		claim := app.Repo.DB.Model(&models.WebhookDelivery{}).
+			Where("delivery_id = ? AND status = ? AND next_attempt_at <= ?", delivery.DeliveryID, models.DeliveryPending, now).
+			Update("next_attempt_at", now.Add(webhookLease))
+		if claim.Error != nil || claim.RowsAffected == 0 {
+			continue
+		}
+		app.sendWebhook(ctx, delivery)
+		sent++
+	}
+	return sent
+}
+
+// Make one delivery attempt and record its outcome
+func (app *App) sendWebhook(ctx context.Context, delivery models.WebhookDelivery) {
+	var hook models.Webhook
+	var event models.Event
+	if err := app.Repo.DB.First(&hook, delivery.WebhookID).Error; err != nil {
+		app.finishWebhook(delivery, nil, err, 0)
+		return
+	}
+	if !hook.Active {
+		app.finishWebhook(delivery, nil, fmt.Errorf("webhook is inactive"), 0)
+		return
+	}
+	if err := app.Repo.DB.First(&event, delivery.EventID).Error; err != nil {
+		app.finishWebhook(delivery, nil, err, 0)
+		return
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		app.finishWebhook(delivery, nil, err, 0)
+		return
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		app.finishWebhook(delivery, nil, err, 0)
+		return
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "recipe-api-webhooks")
+	request.Header.Set("X-Recipe-Event", event.Type)
+	request.Header.Set("X-Recipe-Delivery", strconv.Itoa(delivery.DeliveryID))
+	request.Header.Set("X-Recipe-Timestamp", timestamp)
+	request.Header.Set("X-Recipe-Signature", signWebhook(hook.Secret, timestamp, body))
+
+	start := time.Now()
+	response, err := webhookClient.Do(request)
+	elapsed := time.Since(start)
+	if err != nil {
+		app.finishWebhook(delivery, nil, err, elapsed)
+		return
+	}
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+	response.Body.Close()
+
+	status := response.StatusCode
+	if status < 200 || status > 299 {
+		err = fmt.Errorf("receiver answered %s", response.Status)
+	}
+	app.finishWebhook(delivery, &status, err, elapsed)
+}
+
+// Record an attempt, then mark the delivery succeeded, failed for good or
+// due again after a backoff
+func (app *App) finishWebhook(delivery models.WebhookDelivery, statusCode *int, failure error, elapsed time.Duration) {
+	attempt := models.WebhookAttempt{DeliveryID: delivery.DeliveryID, StatusCode: statusCode, DurationMS: elapsed.Milliseconds()}
+	changes := map[string]any{"attempts": delivery.Attempts + 1}
+	if failure == nil {
+		now := time.Now()
+		changes["status"] = models.DeliverySucceeded
+		changes["delivered_at"] = &now
+		changes["last_error"] = nil
+	} else {
+		message := failure.Error()
+		attempt.Error = &message
+		changes["last_error"] = message
+		if delivery.Attempts+1 >= webhookMaxAttempts {
+			changes["status"] = models.DeliveryFailed
+		} else {
+			changes["next_attempt_at"] = time.Now().Add(webhookBackoff(delivery.Attempts + 1))
+		}
+	}
+
+	if err := app.Repo.DB.Create(&attempt).Error; err != nil {
+		app.Logger.Println("Webhook error:", err)
+	}
+	if err := app.Repo.DB.Model(&delivery).Updates(changes).Error; err != nil {
+		app.Logger.Println("Webhook error:", err)
+	}
+}
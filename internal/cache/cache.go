@@ -0,0 +1,42 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Returned by Get when a key has no value
+var ErrMiss = errors.New("cache miss")
+
+// Byte store with expiring keys. Implementations are safe for concurrent use.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error // ttl 0 keeps the value until evicted
+	Delete(ctx context.Context, keys ...string) error                           // deleting a missing key is not an error
+	Clear(ctx context.Context) error                                            // drop every key this cache owns
+}
+
+// Options for New, mirroring the RECIPE_CACHE_* and REDIS_URL settings
+type Options struct {
+	Backend    string // none, memory or redis
+	MaxEntries int    // memory only
+	MaxBytes   int64  // memory only
+	RedisURL   string // redis://[:password@]host:port[/db], or rediss:// for TLS
+	Prefix     string // redis key prefix, so several apps can share a database
+}
+
+// Open the configured backend, nil for none
+func New(opts Options) (Cache, error) {
+	switch opts.Backend {
+	case "", "none":
+		return nil, nil
+	case "memory":
+		return NewMemory(opts.MaxEntries, opts.MaxBytes), nil
+	case "redis":
+		return NewRedis(RedisOptions{URL: opts.RedisURL, Prefix: opts.Prefix})
+	default:
+		return nil, fmt.Errorf("unknown cache backend %q", opts.Backend)
+	}
+}
@@ -0,0 +1,339 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// Exercise a cache's get, set, expiry, delete and clear, letting time pass
+// with wait
+func checkCache(t *testing.T, cache Cache, wait func(time.Duration)) {
+	t.Helper()
+	ctx := context.Background()
+
+	if _, err := cache.Get(ctx, "recipe:id:1"); !errors.Is(err, ErrMiss) {
+		t.Fatalf("expected ErrMiss for a missing key, got %v", err)
+	}
+	if err := cache.Set(ctx, "recipe:id:1", []byte(`{"id":1}`), 0); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	value, err := cache.Get(ctx, "recipe:id:1")
+	if err != nil || string(value) != `{"id":1}` {
+		t.Fatalf("get: %q %v", value, err)
+	}
+
+	// Binary values survive the round trip
+	binary := []byte("a\r\nb\x00c")
+	cache.Set(ctx, "binary", binary, 0)
+	if value, _ := cache.Get(ctx, "binary"); string(value) != string(binary) {
+		t.Fatalf("expected %q, got %q", binary, value)
+	}
+
+	cache.Set(ctx, "short", []byte("x"), 20*time.Millisecond)
+	wait(40 * time.Millisecond)
+	if _, err := cache.Get(ctx, "short"); !errors.Is(err, ErrMiss) {
+		t.Fatalf("expected the key to expire, got %v", err)
+	}
+
+	cache.Set(ctx, "recipe:id:2", []byte("2"), 0)
+	if err := cache.Delete(ctx, "recipe:id:1", "missing"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := cache.Get(ctx, "recipe:id:1"); !errors.Is(err, ErrMiss) {
+		t.Fatalf("expected ErrMiss after delete, got %v", err)
+	}
+	if _, err := cache.Get(ctx, "recipe:id:2"); err != nil {
+		t.Fatalf("expected other keys to survive a delete, got %v", err)
+	}
+
+	if err := cache.Clear(ctx); err != nil {
+		t.Fatalf("clear: %v", err)
+	}
+	for _, key := range []string{"recipe:id:2", "binary"} {
+		if _, err := cache.Get(ctx, key); !errors.Is(err, ErrMiss) {
+			t.Fatalf("expected %s to be cleared, got %v", key, err)
+		}
+	}
+}
+
+func TestMemory(t *testing.T) {
+	checkCache(t, NewMemory(100, 1<<20), time.Sleep)
+
+	// The least recently used value makes way
+	cache := NewMemory(2, 0)
+	ctx := context.Background()
+	cache.Set(ctx, "a", []byte("1"), 0)
+	cache.Set(ctx, "b", []byte("2"), 0)
+	cache.Get(ctx, "a")
+	cache.Set(ctx, "c", []byte("3"), 0)
+	if _, err := cache.Get(ctx, "b"); !errors.Is(err, ErrMiss) {
+		t.Errorf("expected b to be evicted, got %v", err)
+	}
+	if cache.Len() != 2 {
+		t.Errorf("expected 2 values, got %d", cache.Len())
+	}
+
+	// Callers can't change what is stored
+	value, _ := cache.Get(ctx, "a")
+	value[0] = 'x'
+	if again, _ := cache.Get(ctx, "a"); string(again) != "1" {
+		t.Errorf("expected the stored value to be unchanged, got %q", again)
+	}
+}
+
+func TestRedis(t *testing.T) {
+	server := miniredis.RunT(t)
+	server.RequireAuth("hunter2")
+	url := fmt.Sprintf("redis://:hunter2@%s/3", server.Addr())
+	ctx := context.Background()
+
+	cache, err := NewRedis(RedisOptions{URL: url, Prefix: "recipes:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cache.Close()
+	if err := cache.Ping(ctx); err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+
+	// Keys from another app sharing the database survive Clear
+	other, _ := NewRedis(RedisOptions{URL: url, Prefix: "other:"})
+	defer other.Close()
+	other.Set(ctx, "keep", []byte("1"), 0)
+	other.Set(ctx, "keep2", []byte("2"), 0)
+
+	// The server's clock only moves when told to
+	checkCache(t, cache, server.FastForward)
+	if keys := server.DB(3).Keys(); !slices.Equal(keys, []string{"other:keep", "other:keep2"}) {
+		t.Errorf("expected only the other prefix to remain, got %v", keys)
+	}
+	if keys := server.DB(0).Keys(); len(keys) != 0 {
+		t.Errorf("expected database 0 to be untouched, got %v", keys)
+	}
+
+	// Glob characters in the prefix match only themselves
+	starred, _ := NewRedis(RedisOptions{URL: url, Prefix: "other*"})
+	defer starred.Close()
+	if err := starred.Clear(ctx); err != nil || len(server.DB(3).Keys()) != 2 {
+		t.Errorf("expected a starred prefix to clear nothing else, got %v %v", err, server.DB(3).Keys())
+	}
+
+	// Without a prefix Clear leaves other apps' keys alone
+	whole, _ := NewRedis(RedisOptions{URL: url})
+	defer whole.Close()
+	whole.Set(ctx, "mine", []byte("1"), 0)
+	if err := whole.Clear(ctx); err == nil || len(server.DB(3).Keys()) != 3 {
+		t.Errorf("expected Clear without a prefix to fail and keep every key, got %v %v", err, server.DB(3).Keys())
+	}
+}
+
+func TestRedisErrors(t *testing.T) {
+	server := miniredis.RunT(t)
+	server.RequireAuth("hunter2")
+	ctx := context.Background()
+
+	for _, url := range []string{"", "http://localhost", "redis://localhost/x"} {
+		if _, err := NewRedis(RedisOptions{URL: url}); err == nil {
+			t.Errorf("expected %q to be rejected", url)
+		}
+	}
+
+	wrong, _ := NewRedis(RedisOptions{URL: "redis://:nope@" + server.Addr()})
+	defer wrong.Close()
+	if err := wrong.Ping(ctx); err == nil || !strings.HasPrefix(err.Error(), "WRONGPASS") {
+		t.Errorf("expected WRONGPASS, got %v", err)
+	}
+
+	addr := server.Addr()
+	server.Close()
+	down, _ := NewRedis(RedisOptions{URL: "redis://" + addr, DialTimeout: 100 * time.Millisecond})
+	defer down.Close()
+	if _, err := down.Get(ctx, "a"); err == nil || errors.Is(err, ErrMiss) {
+		t.Errorf("expected a connection error, got %v", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	if cache, err := New(Options{Backend: "none"}); cache != nil || err != nil {
+		t.Errorf("expected no cache, got %v %v", cache, err)
+	}
+	if cache, err := New(Options{Backend: "memory", MaxEntries: 10}); err != nil {
+		t.Errorf("memory: %v", err)
+	} else if _, ok := cache.(*Memory); !ok {
+		t.Errorf("expected *Memory, got %T", cache)
+	}
+	if _, err := New(Options{Backend: "memcached"}); err == nil {
+		t.Error("expected an unknown backend to be rejected")
+	}
+}
+
+// Cache that fails every call
+type brokenCache struct{}
+
+func (brokenCache) Get(context.Context, string) ([]byte, error) {
+	return nil, errors.New("down")
+}
+func (brokenCache) Set(context.Context, string, []byte, time.Duration) error {
+	return errors.New("down")
+}
+func (brokenCache) Delete(context.Context, ...string) error { return errors.New("down") }
+func (brokenCache) Clear(context.Context) error             { return errors.New("down") }
+
+func TestReadThrough(t *testing.T) {
+	ctx := context.Background()
+	rt := NewReadThrough(NewMemory(10, 0), time.Minute)
+
+	var loads atomic.Int64
+	load := func(context.Context) ([]byte, error) {
+		loads.Add(1)
+		return []byte("pancakes"), nil
+	}
+	for i := 0; i < 3; i++ {
+		value, err := rt.Fetch(ctx, "recipe:id:1", load)
+		if err != nil || string(value) != "pancakes" {
+			t.Fatalf("fetch: %q %v", value, err)
+		}
+	}
+	if loads.Load() != 1 {
+		t.Fatalf("expected one load, got %d", loads.Load())
+	}
+
+	rt.Delete(ctx, "recipe:id:1")
+	rt.Fetch(ctx, "recipe:id:1", load)
+	if loads.Load() != 2 {
+		t.Errorf("expected a delete to force a load, got %d", loads.Load())
+	}
+
+	// Load errors are returned and not cached
+	missing := errors.New("not found")
+	for i := 0; i < 2; i++ {
+		if _, err := rt.Fetch(ctx, "recipe:id:2", func(context.Context) ([]byte, error) {
+			loads.Add(1)
+			return nil, missing
+		}); !errors.Is(err, missing) {
+			t.Fatalf("expected the load error, got %v", err)
+		}
+	}
+	if loads.Load() != 4 {
+		t.Errorf("expected failed loads to be retried, got %d", loads.Load())
+	}
+
+	stats := rt.Stats()
+	if stats.Hits != 2 || stats.Misses != 4 || stats.Loads != 4 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	// A nil ReadThrough just loads
+	var none *ReadThrough
+	if value, err := none.Fetch(ctx, "k", load); err != nil || string(value) != "pancakes" {
+		t.Errorf("expected a nil ReadThrough to load, got %q %v", value, err)
+	}
+	none.Delete(ctx, "k")
+	none.Clear(ctx)
+}
+
+func TestReadThroughSingleFlight(t *testing.T) {
+	ctx := context.Background()
+	rt := NewReadThrough(NewMemory(10, 0), time.Minute)
+
+	release := make(chan struct{})
+	var loads atomic.Int64
+	load := func(context.Context) ([]byte, error) {
+		loads.Add(1)
+		<-release
+		return []byte("pancakes"), nil
+	}
+
+	const callers = 20
+	var started, done sync.WaitGroup
+	started.Add(callers)
+	done.Add(callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			defer done.Done()
+			started.Done()
+			if value, err := rt.Fetch(ctx, "recipe:id:1", load); err != nil || string(value) != "pancakes" {
+				t.Errorf("fetch: %q %v", value, err)
+			}
+		}()
+	}
+	started.Wait()
+	// Give every caller time to join the flight before it lands
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	done.Wait()
+
+	if loads.Load() != 1 {
+		t.Errorf("expected concurrent misses to share one load, got %d", loads.Load())
+	}
+	if stats := rt.Stats(); stats.Shared != callers {
+		t.Errorf("expected %d shared results, got %+v", callers, stats)
+	}
+
+	// A caller that gives up doesn't cancel the load for the others
+	slow := make(chan struct{})
+	cancelled, cancel := context.WithCancel(ctx)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	_, err := rt.Fetch(cancelled, "recipe:id:2", func(ctx context.Context) ([]byte, error) {
+		<-slow
+		return []byte("waffles"), ctx.Err()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the caller to stop waiting, got %v", err)
+	}
+	close(slow)
+	value, err := rt.Fetch(ctx, "recipe:id:2", func(context.Context) ([]byte, error) {
+		return nil, errors.New("should have joined the running load")
+	})
+	if err != nil || string(value) != "waffles" {
+		t.Errorf("expected the running load to finish, got %q %v", value, err)
+	}
+}
+
+func TestReadThroughWriteDuringLoad(t *testing.T) {
+	ctx := context.Background()
+	rt := NewReadThrough(NewMemory(10, 0), time.Minute)
+
+	// The write lands after the load read the old value
+	rt.Fetch(ctx, "recipe:id:1", func(context.Context) ([]byte, error) {
+		rt.Delete(ctx, "recipe:id:1")
+		return []byte("old"), nil
+	})
+	value, _ := rt.Fetch(ctx, "recipe:id:1", func(context.Context) ([]byte, error) {
+		return []byte("new"), nil
+	})
+	if string(value) != "new" {
+		t.Errorf("expected the stale load not to be cached, got %q", value)
+	}
+}
+
+func TestReadThroughBrokenCache(t *testing.T) {
+	ctx := context.Background()
+	rt := NewReadThrough(brokenCache{}, time.Minute)
+	var failures []error
+	rt.OnError = func(err error) { failures = append(failures, err) }
+
+	value, err := rt.Fetch(ctx, "recipe:id:1", func(context.Context) ([]byte, error) {
+		return []byte("pancakes"), nil
+	})
+	if err != nil || string(value) != "pancakes" {
+		t.Fatalf("expected to fall back to loading, got %q %v", value, err)
+	}
+	rt.Delete(ctx, "recipe:id:1")
+	rt.Clear(ctx)
+	if len(failures) != 4 || rt.Stats().Errors != 4 {
+		t.Errorf("expected get, set, delete and clear failures, got %v", failures)
+	}
+}
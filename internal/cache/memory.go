@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"recipe-api/internal/lru"
+)
+
+// Cache in process memory, evicting the least recently used values
+type Memory struct {
+	entries *lru.Cache[string, []byte]
+}
+
+// Cache of up to maxEntries values totalling maxBytes, 0 for no limit
+func NewMemory(maxEntries int, maxBytes int64) *Memory {
+	return &Memory{entries: lru.New[string, []byte](maxEntries, maxBytes)}
+}
+
+func (cache *Memory) Get(ctx context.Context, key string) ([]byte, error) {
+	value, ok := cache.entries.Get(key)
+	if !ok {
+		return nil, ErrMiss
+	}
+	return slices.Clone(value), nil
+}
+
+func (cache *Memory) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	cache.entries.Add(key, slices.Clone(value), int64(len(key)+len(value)), ttl)
+	return nil
+}
+
+func (cache *Memory) Delete(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		cache.entries.Remove(key)
+	}
+	return nil
+}
+
+func (cache *Memory) Clear(ctx context.Context) error {
+	cache.entries.Purge()
+	return nil
+}
+
+// Number of values held
+func (cache *Memory) Len() int {
+	return cache.entries.Len()
+}
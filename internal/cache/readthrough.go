@@ -0,0 +1,143 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Loads values through a Cache, calling the loader on a miss and storing
+// what it returns. Concurrent misses for a key share one load, so a popular
+// key expiring doesn't send every reader to the database at once.
+//
+// The cache is an optimisation: when it fails, values are loaded directly
+// and the error is passed to OnError.
+//
+// The epoch that stops a load storing a value older than a write is kept in
+// memory, so it only covers writes made through this ReadThrough. Processes
+// sharing a Redis cache can still store a stale value from a load that
+// overlaps another process's write; the TTL bounds how long it lives.
+type ReadThrough struct {
+	Cache   Cache
+	TTL     time.Duration
+	OnError func(error) // cache failures, may be nil
+
+	group singleflight.Group
+	epoch atomic.Uint64 // bumped by every invalidation
+
+	hits, misses, loads, shared, errors atomic.Int64
+}
+
+// Counts of read-through use, published as expvars
+type Stats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+	Loads  int64 `json:"loads"`  // loader calls
+	Shared int64 `json:"shared"` // misses answered by another caller's load
+	Errors int64 `json:"errors"` // cache failures
+}
+
+// Constructor
+func NewReadThrough(cache Cache, ttl time.Duration) *ReadThrough {
+	return &ReadThrough{Cache: cache, TTL: ttl}
+}
+
+// Value for key from the cache, or from load when it is missing. Errors from
+// load are returned as they are and never cached. A nil ReadThrough just
+// calls load.
+func (rt *ReadThrough) Fetch(ctx context.Context, key string, load func(context.Context) ([]byte, error)) ([]byte, error) {
+	if rt == nil || rt.Cache == nil {
+		return load(ctx)
+	}
+
+	value, err := rt.Cache.Get(ctx, key)
+	if err == nil {
+		rt.hits.Add(1)
+		return value, nil
+	}
+	rt.misses.Add(1)
+	if !errors.Is(err, ErrMiss) {
+		rt.fail(err)
+	}
+
+	// The shared load outlives any one caller, so it runs without their
+	// cancellation; each caller still stops waiting when its context ends
+	result := rt.group.DoChan(key, func() (any, error) {
+		rt.loads.Add(1)
+		epoch := rt.epoch.Load()
+		loadCtx := context.WithoutCancel(ctx)
+		value, err := load(loadCtx)
+		if err != nil {
+			return nil, err
+		}
+		// A write landed while loading, so the value may predate it
+		if rt.epoch.Load() != epoch {
+			return value, nil
+		}
+		if err := rt.Cache.Set(loadCtx, key, value, rt.TTL); err != nil {
+			rt.fail(err)
+		}
+		return value, nil
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-result:
+		if res.Shared {
+			rt.shared.Add(1)
+		}
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.([]byte), nil
+	}
+}
+
+// Drop keys, so the next Fetch loads them again. Loads already running are
+// forgotten and won't store what they read before the write.
+func (rt *ReadThrough) Delete(ctx context.Context, keys ...string) {
+	if rt == nil || rt.Cache == nil {
+		return
+	}
+	rt.epoch.Add(1)
+	for _, key := range keys {
+		rt.group.Forget(key)
+	}
+	if err := rt.Cache.Delete(ctx, keys...); err != nil {
+		rt.fail(err)
+	}
+}
+
+// Drop every key
+func (rt *ReadThrough) Clear(ctx context.Context) {
+	if rt == nil || rt.Cache == nil {
+		return
+	}
+	rt.epoch.Add(1)
+	if err := rt.Cache.Clear(ctx); err != nil {
+		rt.fail(err)
+	}
+}
+
+func (rt *ReadThrough) Stats() Stats {
+	if rt == nil {
+		return Stats{}
+	}
+	return Stats{
+		Hits:   rt.hits.Load(),
+		Misses: rt.misses.Load(),
+		Loads:  rt.loads.Load(),
+		Shared: rt.shared.Load(),
+		Errors: rt.errors.Load(),
+	}
+}
+
+func (rt *ReadThrough) fail(err error) {
+	rt.errors.Add(1)
+	if rt.OnError != nil {
+		rt.OnError(err)
+	}
+}
@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Connection details for a Redis-protocol server (Redis, Valkey, KeyDB, ...)
+type RedisOptions struct {
+	URL         string        // redis://[[user]:password@]host[:port][/db], or rediss:// for TLS
+	Prefix      string        // prepended to every key; Clear refuses to run without one
+	PoolSize    int           // connections in the pool, go-redis's default when 0
+	Timeout     time.Duration // per command read and write, default 2s
+	DialTimeout time.Duration // default 2s
+}
+
+// Cache on a Redis server through go-redis, which pools, times out and
+// reconnects
+type Redis struct {
+	client *redis.Client
+	prefix string
+}
+
+// Constructor. Connections are made on first use.
+func NewRedis(opts RedisOptions) (*Redis, error) {
+	options, err := redis.ParseURL(opts.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis URL %q: %w", opts.URL, err)
+	}
+	if opts.PoolSize > 0 {
+		options.PoolSize = opts.PoolSize
+	}
+	options.ReadTimeout, options.WriteTimeout, options.DialTimeout = 2*time.Second, 2*time.Second, 2*time.Second
+	if opts.Timeout > 0 {
+		options.ReadTimeout, options.WriteTimeout = opts.Timeout, opts.Timeout
+	}
+	if opts.DialTimeout > 0 {
+		options.DialTimeout = opts.DialTimeout
+	}
+	// A deadline on the context wins over the timeouts
+	options.ContextTimeoutEnabled = true
+	return &Redis{client: redis.NewClient(options), prefix: opts.Prefix}, nil
+}
+
+func (cache *Redis) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := cache.client.Get(ctx, cache.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrMiss
+	}
+	return value, err
+}
+
+func (cache *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if ttl > 0 {
+		ttl = max(ttl, time.Millisecond) // finest expiry Redis keeps
+	}
+	return cache.client.Set(ctx, cache.prefix+key, value, ttl).Err()
+}
+
+func (cache *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = cache.prefix + key
+	}
+	return cache.client.Del(ctx, prefixed...).Err()
+}
+
+// Delete every key under the prefix, scanning so the server isn't blocked.
+// Without a prefix the cache can't tell its keys from anyone else's in a
+// shared database, so Clear fails rather than deleting them all.
+func (cache *Redis) Clear(ctx context.Context) error {
+	if cache.prefix == "" {
+		return errors.New("redis: Clear needs a key prefix")
+	}
+	pattern := escapeGlob(cache.prefix) + "*"
+	var cursor uint64
+	for {
+		keys, next, err := cache.client.Scan(ctx, cursor, pattern, 500).Result()
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			if err := cache.client.Del(ctx, keys...).Err(); err != nil {
+				return err
+			}
+		}
+		if cursor = next; cursor == 0 {
+			return nil
+		}
+	}
+}
+
+// Check the server answers
+func (cache *Redis) Ping(ctx context.Context) error {
+	return cache.client.Ping(ctx).Err()
+}
+
+// Close the pool
+func (cache *Redis) Close() error {
+	return cache.client.Close()
+}
+
+// Escape glob characters so a prefix matches only itself in SCAN MATCH
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`*?[]\`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -1,54 +1,336 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"log"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
-	"runtime"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
 	Port        string
+	GRPCPort    string
+	AdminAddr   string // loopback host:port for runtime counters, "" for none
 	DatabaseURL string
+	DBDriver    string // used when DatabaseURL has no scheme
 	FrontendURL string
 	Debug       bool
+
+	// Connection pool
+	DBMaxOpenConns    int
+	DBMaxIdleConns    int
+	DBConnMaxLifetime time.Duration
+
+	// CORS policy
+	CorsAllowedOrigins   []string
+	CorsAllowedHeaders   []string
+	CorsExposedHeaders   []string
+	CorsAllowCredentials bool
+	CorsMaxAge           int // seconds
+
+	// Media storage
+	StorageBackend string // local or s3
+	StoragePath    string // root directory for local storage
+	S3Endpoint     string
+	S3Bucket       string
+	S3Region       string
+	S3AccessKey    string
+	S3SecretKey    string
+	ImageMaxBytes  int
+
+	// How long the change feed's event log is kept, 0 for ever
+	EventRetention time.Duration
+
+	// GraphQL limits
+	GraphQLMaxDepth      int
+	GraphQLMaxComplexity int
+
+	// Response cache for recipe reads
+	ResponseCacheEntries int // 0 disables the cache
+	ResponseCacheBytes   int
+	ResponseCacheTTL     time.Duration
+
+	// Read-through cache for recipe lookups
+	RecipeCache        string // none, memory or redis
+	RecipeCacheEntries int    // memory only
+	RecipeCacheBytes   int    // memory only
+	RecipeCacheTTL     time.Duration
+	RedisURL           string
+	RedisPrefix        string
+
+	// Where each setting's value came from, keyed by env name
+	sources map[string]string
 }
 
-func Load() *Config {
-	LoadEnvFromRoot()
+// Value sources, lowest precedence first
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// Default configuration before any source is applied
+func Default() *Config {
+	return &Config{
+		Port:               "8080",
+		GRPCPort:           "9090",
+		AdminAddr:          "127.0.0.1:6060",
+		DBDriver:           "postgres",
+		DBMaxOpenConns:     10,
+		DBMaxIdleConns:     5,
+		DBConnMaxLifetime:  30 * time.Minute,
+		CorsAllowedHeaders: []string{"Content-Type", "Authorization"},
+		CorsExposedHeaders: []string{"Location", "Deprecation", "Sunset", "Link"},
+		CorsMaxAge:         600,
+		StorageBackend:     "local",
+		StoragePath:        "uploads",
+		S3Region:           "us-east-1",
+		ImageMaxBytes:      5 << 20,
+
+		EventRetention: 30 * 24 * time.Hour,
+
+		GraphQLMaxDepth:      8,
+		GraphQLMaxComplexity: 1000,
 
-	debug, _ := strconv.ParseBool(os.Getenv("DEBUG"))
+		ResponseCacheEntries: 512,
+		ResponseCacheBytes:   32 << 20,
+		ResponseCacheTTL:     5 * time.Minute,
 
-	cfg := &Config{
-		Port:        loadEnv("PORT", "8080"),
-		DatabaseURL: loadEnv("DATABASE_URL", ""),
-		FrontendURL: loadEnv("FRONTEND_URL", ""),
-		Debug:       debug,
+		RecipeCache:        "memory",
+		RecipeCacheEntries: 1000,
+		RecipeCacheBytes:   64 << 20,
+		RecipeCacheTTL:     10 * time.Minute,
+		RedisPrefix:        "recipe-api:",
 	}
-	return cfg
 }
 
-// Load variable from Env file, or default values if they don't exist
-func loadEnv(key, defaultValue string) string {
-	if value, ok := os.LookupEnv(key); ok {
-		return value
+// Load configuration by layering defaults, a YAML/TOML config file,
+// environment variables (including .env) and command-line flags, in that
+// order of precedence, then validate the result.
+//
+// The config file is given by --config or CONFIG_FILE, the .env file by
+// --env-file or ENV_FILE (default ".env" in the working directory).
+func Load(args []string) (*Config, error) {
+	cfg := Default()
+	cfg.sources = make(map[string]string)
+
+	flags, err := parseFlags(cfg, args)
+	if err != nil {
+		return nil, err
 	}
-	return defaultValue
+
+	LoadEnvFile(firstNonEmpty(flags.envFile, os.Getenv("ENV_FILE"), ".env"))
+
+	if path := firstNonEmpty(flags.configFile, os.Getenv("CONFIG_FILE")); path != "" {
+		if err := cfg.applyFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.applyFlags(flags); err != nil {
+		return nil, err
+	}
+
+	// Fall back to the single frontend origin when no allow-list is given
+	if len(cfg.CorsAllowedOrigins) == 0 && cfg.FrontendURL != "" {
+		cfg.CorsAllowedOrigins = []string{cfg.FrontendURL}
+	}
+
+	return cfg, cfg.Validate()
 }
 
-func LoadEnvFromRoot() {
-	_, file, _, _ := runtime.Caller(0)
-	dir := filepath.Dir(file)
+// Check required fields and value ranges
+func (cfg *Config) Validate() error {
+	var errs []error
+
+	port, err := strconv.Atoi(cfg.Port)
+	if err != nil || port < 1 || port > 65535 {
+		errs = append(errs, fmt.Errorf("PORT must be between 1 and 65535, got %q", cfg.Port))
+	}
+	grpcPort, err := strconv.Atoi(cfg.GRPCPort)
+	if err != nil || grpcPort < 1 || grpcPort > 65535 {
+		errs = append(errs, fmt.Errorf("GRPC_PORT must be between 1 and 65535, got %q", cfg.GRPCPort))
+	} else if grpcPort == port {
+		errs = append(errs, errors.New("GRPC_PORT must differ from PORT"))
+	}
+
+	if cfg.AdminAddr != "" && !isLoopback(cfg.AdminAddr) {
+		errs = append(errs, fmt.Errorf("ADMIN_ADDR must be a loopback address such as 127.0.0.1:6060, got %q", cfg.AdminAddr))
+	}
+
+	if cfg.DBDriver != "postgres" && cfg.DBDriver != "sqlite" {
+		errs = append(errs, fmt.Errorf("DB_DRIVER must be postgres or sqlite, got %q", cfg.DBDriver))
+	}
+	if cfg.DatabaseURL == "" && !cfg.IsSQLite() {
+		errs = append(errs, errors.New("DATABASE_URL is required unless using SQLite"))
+	}
+
+	if cfg.DBMaxOpenConns < 0 || cfg.DBMaxIdleConns < 0 || cfg.DBConnMaxLifetime < 0 {
+		errs = append(errs, errors.New("database pool settings must not be negative"))
+	}
+
+	if cfg.CorsAllowCredentials && slices.Contains(cfg.CorsAllowedOrigins, "*") {
+		errs = append(errs, errors.New(`CORS_ALLOW_CREDENTIALS can't be used with the "*" origin; list the origins instead`))
+	}
+	if cfg.CorsMaxAge < 0 {
+		errs = append(errs, fmt.Errorf("CORS_MAX_AGE must not be negative, got %d", cfg.CorsMaxAge))
+	}
 
-	root := filepath.Join(dir, "../../")
+	switch cfg.StorageBackend {
+	case "local":
+		if cfg.StoragePath == "" {
+			errs = append(errs, errors.New("STORAGE_PATH is required for local storage"))
+		}
+	case "s3":
+		if cfg.S3Endpoint == "" || cfg.S3Bucket == "" {
+			errs = append(errs, errors.New("S3_ENDPOINT and S3_BUCKET are required for s3 storage"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("STORAGE_BACKEND must be local or s3, got %q", cfg.StorageBackend))
+	}
+	if cfg.ImageMaxBytes <= 0 {
+		errs = append(errs, fmt.Errorf("IMAGE_MAX_BYTES must be positive, got %d", cfg.ImageMaxBytes))
+	}
+	if cfg.EventRetention < 0 {
+		errs = append(errs, fmt.Errorf("EVENT_RETENTION must not be negative, got %s", cfg.EventRetention))
+	}
+	if cfg.GraphQLMaxDepth <= 0 || cfg.GraphQLMaxComplexity <= 0 {
+		errs = append(errs, errors.New("GRAPHQL_MAX_DEPTH and GRAPHQL_MAX_COMPLEXITY must be positive"))
+	}
+	if cfg.ResponseCacheEntries < 0 || cfg.ResponseCacheBytes < 0 || cfg.ResponseCacheTTL < 0 {
+		errs = append(errs, errors.New("response cache settings must not be negative"))
+	}
+	switch cfg.RecipeCache {
+	case "none", "memory":
+	case "redis":
+		if cfg.RedisURL == "" {
+			errs = append(errs, errors.New("REDIS_URL is required for the redis recipe cache"))
+		}
+		if cfg.RedisPrefix == "" {
+			errs = append(errs, errors.New("REDIS_PREFIX must not be empty, clearing the cache deletes every key under it"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("RECIPE_CACHE must be none, memory or redis, got %q", cfg.RecipeCache))
+	}
+	if cfg.RecipeCacheEntries < 0 || cfg.RecipeCacheBytes < 0 || cfg.RecipeCacheTTL < 0 {
+		errs = append(errs, errors.New("recipe cache settings must not be negative"))
+	}
 
-	envPath := filepath.Join(root, ".env")
+	return errors.Join(errs...)
+}
 
-	err := godotenv.Load(envPath)
+// Whether host:port names this machine only, so admin endpoints stay private
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
 	if err != nil {
-		log.Printf("Warning: .env file not found at: %v. Will refer to default values instead. \n", envPath)
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Whether the database is a SQLite file, from the DSN scheme or DB_DRIVER
+func (cfg *Config) IsSQLite() bool {
+	dsn := strings.ToLower(cfg.DatabaseURL)
+	if strings.HasPrefix(dsn, "sqlite:") || strings.HasPrefix(dsn, "file:") {
+		return true
+	}
+	return cfg.DBDriver == "sqlite" && !strings.Contains(dsn, "://")
+}
+
+// Source of a setting's effective value
+func (cfg *Config) Source(key string) string {
+	if source, ok := cfg.sources[key]; ok {
+		return source
+	}
+	return SourceDefault
+}
+
+// Effective configuration as KEY=value lines with secrets redacted
+func (cfg *Config) Print(w io.Writer) {
+	lines := make([]string, 0)
+	for _, s := range settings(cfg) {
+		value := s.get()
+		if s.secret && value != "" {
+			value = redact(value)
+		}
+		lines = append(lines, fmt.Sprintf("%s=%s\t# %s", s.key, value, cfg.Source(s.key)))
+	}
+	sort.Strings(lines)
+	for _, line := range lines {
+		fmt.Fprintln(w, line)
+	}
+}
+
+// Hide a secret, keeping the non-sensitive parts of connection URLs
+func redact(value string) string {
+	if u, err := url.Parse(value); err == nil && u.User != nil {
+		if _, hasPassword := u.User.Password(); hasPassword {
+			return u.Redacted()
+		}
+	}
+	return "********"
+}
+
+// Read environment variables, including KEY_FILE secrets
+func (cfg *Config) applyEnv() error {
+	for _, s := range settings(cfg) {
+		value, fromEnv := os.LookupEnv(s.key)
+
+		if path, ok := os.LookupEnv(s.key + "_FILE"); ok {
+			if fromEnv {
+				return fmt.Errorf("both %s and %s_FILE are set", s.key, s.key)
+			}
+			contents, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("reading %s_FILE: %w", s.key, err)
+			}
+			value, fromEnv = strings.TrimSpace(string(contents)), true
+		}
+
+		if !fromEnv {
+			continue
+		}
+		if err := s.set(value); err != nil {
+			return fmt.Errorf("env %s: %w", s.key, err)
+		}
+		cfg.sources[s.key] = SourceEnv
+	}
+	return nil
+}
+
+// Load variables from a .env file without overriding the real environment
+func LoadEnvFile(path string) {
+	err := godotenv.Load(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("Warning: could not read env file %v: %v \n", filepath.Clean(path), err)
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
 	}
+	return ""
 }
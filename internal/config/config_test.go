@@ -0,0 +1,189 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, name, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := writeFile(t, "config.yaml", `
+port: 9000
+database_url: postgres://file@localhost/recipes
+frontend_url: http://file.local
+cors_allowed_origins:
+  - https://a.example.com
+  - https://*.example.org
+debug: true
+`)
+	t.Setenv("CONFIG_FILE", file)
+	t.Setenv("FRONTEND_URL", "http://env.local")
+	t.Setenv("PORT", "9100")
+
+	cfg, err := Load([]string{"--port", "9200", "--debug=false"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.Port != "9200" || cfg.Source("PORT") != SourceFlag {
+		t.Errorf("expected flag port 9200, got %s from %s", cfg.Port, cfg.Source("PORT"))
+	}
+	if cfg.FrontendURL != "http://env.local" || cfg.Source("FRONTEND_URL") != SourceEnv {
+		t.Errorf("expected env frontend, got %s from %s", cfg.FrontendURL, cfg.Source("FRONTEND_URL"))
+	}
+	if cfg.DatabaseURL != "postgres://file@localhost/recipes" || cfg.Source("DATABASE_URL") != SourceFile {
+		t.Errorf("expected file database url, got %s from %s", cfg.DatabaseURL, cfg.Source("DATABASE_URL"))
+	}
+	if cfg.Debug {
+		t.Errorf("expected flag to switch debug off")
+	}
+	if len(cfg.CorsAllowedOrigins) != 2 || cfg.CorsAllowedOrigins[1] != "https://*.example.org" {
+		t.Errorf("unexpected origins %v", cfg.CorsAllowedOrigins)
+	}
+	if cfg.CorsMaxAge != 600 || cfg.Source("CORS_MAX_AGE") != SourceDefault {
+		t.Errorf("expected default max age, got %d", cfg.CorsMaxAge)
+	}
+}
+
+func TestLoadTomlFile(t *testing.T) {
+	file := writeFile(t, "config.toml", `
+database_url = "postgres://localhost/recipes"
+cors_max_age = 60
+cors_allow_credentials = true
+`)
+	cfg, err := Load([]string{"--config", file})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.CorsMaxAge != 60 || !cfg.CorsAllowCredentials {
+		t.Errorf("toml values not applied: %+v", cfg)
+	}
+}
+
+func TestLoadRejectsUnknownFileKey(t *testing.T) {
+	file := writeFile(t, "config.yaml", "databse_url: postgres://localhost/recipes\n")
+	if _, err := Load([]string{"--config", file}); err == nil {
+		t.Fatal("expected unknown key to be rejected")
+	}
+}
+
+func TestSecretFromFile(t *testing.T) {
+	secret := writeFile(t, "db", "postgres://user:hunter2@db/recipes\n")
+	t.Setenv("DATABASE_URL_FILE", secret)
+
+	cfg, err := Load(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.DatabaseURL != "postgres://user:hunter2@db/recipes" {
+		t.Fatalf("expected secret from file, got %q", cfg.DatabaseURL)
+	}
+
+	t.Setenv("DATABASE_URL", "postgres://other")
+	if _, err := Load(nil); err == nil {
+		t.Fatal("expected error when both DATABASE_URL and DATABASE_URL_FILE are set")
+	}
+}
+
+func TestValidation(t *testing.T) {
+	if _, err := Load(nil); err == nil || !strings.Contains(err.Error(), "DATABASE_URL") {
+		t.Fatalf("expected missing DATABASE_URL error, got %v", err)
+	}
+
+	if _, err := Load([]string{"--db-driver", "sqlite"}); err != nil {
+		t.Fatalf("expected sqlite without DATABASE_URL to be valid, got %v", err)
+	}
+
+	t.Setenv("DATABASE_URL", "sqlite://recipes.db")
+	if _, err := Load(nil); err != nil {
+		t.Fatalf("expected sqlite DSN to be valid, got %v", err)
+	}
+	t.Setenv("DATABASE_URL", "postgres://db")
+
+	_, err := Load([]string{"--port", "99999"})
+	if err == nil || !strings.Contains(err.Error(), "PORT") {
+		t.Fatalf("expected invalid port error, got %v", err)
+	}
+
+	_, err = Load([]string{"--port", "9090"})
+	if err == nil || !strings.Contains(err.Error(), "GRPC_PORT") {
+		t.Fatalf("expected clashing gRPC port error, got %v", err)
+	}
+
+	_, err = Load([]string{"--admin-addr", "0.0.0.0:6060"})
+	if err == nil || !strings.Contains(err.Error(), "ADMIN_ADDR") {
+		t.Fatalf("expected a public admin address to be rejected, got %v", err)
+	}
+	for _, addr := range []string{"localhost:6060", "[::1]:6060", ""} {
+		if _, err := Load([]string{"--admin-addr", addr}); err != nil {
+			t.Fatalf("expected admin address %q to be valid, got %v", addr, err)
+		}
+	}
+
+	_, err = Load([]string{"--cors-allowed-origins", "*", "--cors-allow-credentials"})
+	if err == nil || !strings.Contains(err.Error(), "CORS_ALLOW_CREDENTIALS") {
+		t.Fatalf("expected credentials with any origin to be rejected, got %v", err)
+	}
+
+	_, err = Load([]string{"--event-retention", "-1h"})
+	if err == nil || !strings.Contains(err.Error(), "EVENT_RETENTION") {
+		t.Fatalf("expected negative event retention error, got %v", err)
+	}
+
+	_, err = Load([]string{"--recipe-cache", "redis"})
+	if err == nil || !strings.Contains(err.Error(), "REDIS_URL") {
+		t.Fatalf("expected missing REDIS_URL error, got %v", err)
+	}
+	t.Setenv("REDIS_URL", "redis://localhost:6379/0")
+	_, err = Load([]string{"--recipe-cache", "redis", "--redis-prefix", ""})
+	if err == nil || !strings.Contains(err.Error(), "REDIS_PREFIX") {
+		t.Fatalf("expected empty REDIS_PREFIX error, got %v", err)
+	}
+	_, err = Load([]string{"--recipe-cache-bytes", "-1"})
+	if err == nil || !strings.Contains(err.Error(), "recipe cache") {
+		t.Fatalf("expected negative recipe cache size error, got %v", err)
+	}
+	_, err = Load([]string{"--recipe-cache", "memcached"})
+	if err == nil || !strings.Contains(err.Error(), "RECIPE_CACHE") {
+		t.Fatalf("expected unknown recipe cache error, got %v", err)
+	}
+}
+
+func TestPrintRedactsSecrets(t *testing.T) {
+	t.Setenv("DATABASE_URL", "postgres://user:hunter2@db/recipes")
+	t.Setenv("S3_ACCESS_KEY", "AKIAEXAMPLE")
+	cfg, err := Load(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var out bytes.Buffer
+	cfg.Print(&out)
+
+	if strings.Contains(out.String(), "hunter2") || strings.Contains(out.String(), "AKIAEXAMPLE") {
+		t.Fatalf("secret leaked in output:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "DATABASE_URL=postgres://user:xxxxx@db/recipes") {
+		t.Fatalf("expected redacted database url in output:\n%s", out.String())
+	}
+}
+
+func TestSecretFlagsRejected(t *testing.T) {
+	for _, flag := range []string{"--database-url", "--s3-secret-key", "--s3-access-key", "--redis-url"} {
+		_, err := Load([]string{flag, "postgres://user:hunter2@db/recipes"})
+		if err == nil || !strings.Contains(err.Error(), "_FILE") {
+			t.Errorf("%s: expected secrets to be refused as flags, got %v", flag, err)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// A single configurable value, addressable from every source.
+// Env key is the canonical name; file keys are its lowercase form
+// (database_url) and flags its kebab form (--debug). Secrets have no flag,
+// since the command line is visible to every process on the machine.
+type setting struct {
+	key    string
+	usage  string
+	secret bool
+	target any // *string, *bool, *int, *time.Duration or *[]string inside Config
+}
+
+// Every setting bound to the fields of cfg
+func settings(cfg *Config) []setting {
+	return []setting{
+		{key: "PORT", usage: "HTTP port to listen on", target: &cfg.Port},
+		{key: "GRPC_PORT", usage: "gRPC port to listen on", target: &cfg.GRPCPort},
+		{key: "ADMIN_ADDR", usage: "loopback address for /debug/vars, empty to disable", target: &cfg.AdminAddr},
+		{key: "DATABASE_URL", usage: "database DSN (postgres://, sqlite:// or file:)", secret: true, target: &cfg.DatabaseURL},
+		{key: "DB_DRIVER", usage: "driver for DSNs without a scheme (postgres or sqlite)", target: &cfg.DBDriver},
+		{key: "DB_MAX_OPEN_CONNS", usage: "maximum open database connections (0 for unlimited)", target: &cfg.DBMaxOpenConns},
+		{key: "DB_MAX_IDLE_CONNS", usage: "maximum idle database connections", target: &cfg.DBMaxIdleConns},
+		{key: "DB_CONN_MAX_LIFETIME", usage: "maximum lifetime of a database connection, e.g. 30m", target: &cfg.DBConnMaxLifetime},
+		{key: "FRONTEND_URL", usage: "frontend origin, used when no CORS origins are set", target: &cfg.FrontendURL},
+		{key: "DEBUG", usage: "enable debug logging", target: &cfg.Debug},
+		{key: "CORS_ALLOWED_ORIGINS", usage: "comma separated origins, may use https://*.example.com", target: &cfg.CorsAllowedOrigins},
+		{key: "CORS_ALLOWED_HEADERS", usage: "comma separated request headers allowed by CORS", target: &cfg.CorsAllowedHeaders},
+		{key: "CORS_EXPOSED_HEADERS", usage: "comma separated response headers exposed by CORS", target: &cfg.CorsExposedHeaders},
+		{key: "CORS_ALLOW_CREDENTIALS", usage: "allow credentialed CORS requests", target: &cfg.CorsAllowCredentials},
+		{key: "CORS_MAX_AGE", usage: "seconds browsers may cache preflight responses", target: &cfg.CorsMaxAge},
+		{key: "STORAGE_BACKEND", usage: "where uploaded images are kept (local or s3)", target: &cfg.StorageBackend},
+		{key: "STORAGE_PATH", usage: "directory for local image storage", target: &cfg.StoragePath},
+		{key: "S3_ENDPOINT", usage: "S3-compatible endpoint, e.g. http://localhost:9000", target: &cfg.S3Endpoint},
+		{key: "S3_BUCKET", usage: "bucket for s3 image storage", target: &cfg.S3Bucket},
+		{key: "S3_REGION", usage: "region used to sign s3 requests", target: &cfg.S3Region},
+		{key: "S3_ACCESS_KEY", usage: "access key for s3 image storage", secret: true, target: &cfg.S3AccessKey},
+		{key: "S3_SECRET_KEY", usage: "secret key for s3 image storage", secret: true, target: &cfg.S3SecretKey},
+		{key: "IMAGE_MAX_BYTES", usage: "largest image upload accepted, in bytes", target: &cfg.ImageMaxBytes},
+		{key: "EVENT_RETENTION", usage: "how long change feed events are kept, e.g. 720h (0 keeps them)", target: &cfg.EventRetention},
+		{key: "GRAPHQL_MAX_DEPTH", usage: "deepest nesting a GraphQL query may have", target: &cfg.GraphQLMaxDepth},
+		{key: "GRAPHQL_MAX_COMPLEXITY", usage: "highest estimated cost a GraphQL query may have", target: &cfg.GraphQLMaxComplexity},
+		{key: "RESPONSE_CACHE_ENTRIES", usage: "recipe responses kept in the response cache (0 to disable)", target: &cfg.ResponseCacheEntries},
+		{key: "RESPONSE_CACHE_BYTES", usage: "total size of the response cache, in bytes", target: &cfg.ResponseCacheBytes},
+		{key: "RESPONSE_CACHE_TTL", usage: "how long a cached response is served, e.g. 5m", target: &cfg.ResponseCacheTTL},
+		{key: "RECIPE_CACHE", usage: "cache for recipe lookups by ID and name (none, memory or redis)", target: &cfg.RecipeCache},
+		{key: "RECIPE_CACHE_ENTRIES", usage: "recipes kept by the memory recipe cache", target: &cfg.RecipeCacheEntries},
+		{key: "RECIPE_CACHE_BYTES", usage: "total size of the memory recipe cache, in bytes", target: &cfg.RecipeCacheBytes},
+		{key: "RECIPE_CACHE_TTL", usage: "how long a cached recipe is kept, e.g. 10m", target: &cfg.RecipeCacheTTL},
+		{key: "REDIS_URL", usage: "redis server for the recipe cache, e.g. redis://localhost:6379/0", secret: true, target: &cfg.RedisURL},
+		{key: "REDIS_PREFIX", usage: "prefix for recipe cache keys in redis", target: &cfg.RedisPrefix},
+	}
+}
+
+func (s setting) fileKey() string {
+	return strings.ToLower(s.key)
+}
+
+func (s setting) flagName() string {
+	return strings.ReplaceAll(strings.ToLower(s.key), "_", "-")
+}
+
+func (s setting) isBool() bool {
+	_, ok := s.target.(*bool)
+	return ok
+}
+
+// Parse a raw string into the bound field
+func (s setting) set(raw string) error {
+	switch target := s.target.(type) {
+	case *string:
+		*target = raw
+	case *bool:
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		*target = value
+	case *int:
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		*target = value
+	case *time.Duration:
+		value, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+		*target = value
+	case *[]string:
+		*target = splitList(raw)
+	}
+	return nil
+}
+
+// Format the bound field as a string
+func (s setting) get() string {
+	switch target := s.target.(type) {
+	case *string:
+		return *target
+	case *bool:
+		return strconv.FormatBool(*target)
+	case *int:
+		return strconv.Itoa(*target)
+	case *time.Duration:
+		return target.String()
+	case *[]string:
+		return strings.Join(*target, ",")
+	}
+	return ""
+}
+
+// Split a comma separated value, dropping empty entries
+func splitList(raw string) []string {
+	var list []string
+	for _, value := range strings.Split(raw, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+// Apply a YAML or TOML config file, chosen by extension
+func (cfg *Config) applyFile(path string) error {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	values := make(map[string]any)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(contents, &values)
+	case ".toml":
+		err = toml.Unmarshal(contents, &values)
+	default:
+		return fmt.Errorf("config file %s must be .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+
+	known := make(map[string]bool)
+	for _, s := range settings(cfg) {
+		known[s.fileKey()] = true
+
+		value, ok := values[s.fileKey()]
+		if !ok {
+			continue
+		}
+		if err := s.set(fileValue(value)); err != nil {
+			return fmt.Errorf("config file %s: %w", s.fileKey(), err)
+		}
+		cfg.sources[s.key] = SourceFile
+	}
+
+	for key := range values {
+		if !known[key] {
+			return fmt.Errorf("config file %s: unknown key %q", path, key)
+		}
+	}
+	return nil
+}
+
+// Normalise decoded file values, joining lists with commas
+func fileValue(value any) string {
+	if list, ok := value.([]any); ok {
+		items := make([]string, len(list))
+		for i, item := range list {
+			items[i] = fmt.Sprint(item)
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(value)
+}
+
+// Raw flag values, applied after the file and environment
+type parsedFlags struct {
+	configFile string
+	envFile    string
+	values     map[string]string
+}
+
+// Flag value that records the raw string so it can be applied last
+type rawFlag struct {
+	key    string
+	isBool bool
+	values map[string]string
+}
+
+func (f *rawFlag) String() string {
+	if f == nil || f.values == nil {
+		return ""
+	}
+	return f.values[f.key]
+}
+
+func (f *rawFlag) Set(value string) error {
+	f.values[f.key] = value
+	return nil
+}
+
+func (f *rawFlag) IsBoolFlag() bool {
+	return f.isBool
+}
+
+// Flag for a secret setting, which refuses any value so the secret never
+// ends up in argv
+type secretFlag struct {
+	key string
+}
+
+func (f *secretFlag) String() string {
+	return ""
+}
+
+func (f *secretFlag) Set(string) error {
+	return fmt.Errorf("secrets can't be passed as flags, set %s or %s_FILE instead", f.key, f.key)
+}
+
+func parseFlags(cfg *Config, args []string) (*parsedFlags, error) {
+	parsed := &parsedFlags{values: make(map[string]string)}
+
+	fs := flag.NewFlagSet("recipe-api", flag.ContinueOnError)
+	fs.StringVar(&parsed.configFile, "config", "", "path to a YAML or TOML config file")
+	fs.StringVar(&parsed.envFile, "env-file", "", "path to a .env file (default .env)")
+	for _, s := range settings(cfg) {
+		if s.secret {
+			fs.Var(&secretFlag{key: s.key}, s.flagName(), fmt.Sprintf("not accepted, set %s or %s_FILE", s.key, s.key))
+			continue
+		}
+		fs.Var(&rawFlag{key: s.key, isBool: s.isBool(), values: parsed.values}, s.flagName(), s.usage)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+	return parsed, nil
+}
+
+func (cfg *Config) applyFlags(flags *parsedFlags) error {
+	for _, s := range settings(cfg) {
+		value, ok := flags.values[s.key]
+		if !ok {
+			continue
+		}
+		if err := s.set(value); err != nil {
+			return fmt.Errorf("flag --%s: %w", s.flagName(), err)
+		}
+		cfg.sources[s.key] = SourceFlag
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// Supported drivers
+const (
+	Postgres = "postgres"
+	SQLite   = "sqlite"
+)
+
+// Connection settings
+type Options struct {
+	DSN             string
+	Driver          string // used when the DSN has no scheme
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	Logger          gormlogger.Interface
+}
+
+// Open a connection, choosing the driver from the DSN scheme:
+// postgres:// and postgresql:// use Postgres, sqlite:// and file: use SQLite
+func Open(opts Options) (*gorm.DB, error) {
+	driver, dsn, err := Resolve(opts.DSN, opts.Driver)
+	if err != nil {
+		return nil, err
+	}
+
+	var dialector gorm.Dialector
+	switch driver {
+	case Postgres:
+		dialector = postgres.Open(dsn)
+	case SQLite:
+		dialector = sqlite.Open(sqliteDSN(dsn))
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: opts.Logger})
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	// Every connection to an in-memory database gets its own empty database
+	if driver == SQLite && isMemory(dsn) {
+		opts.MaxOpenConns = 1
+	}
+
+	if opts.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(opts.MaxOpenConns)
+	}
+	if opts.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(opts.MaxIdleConns)
+	}
+	if opts.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	}
+	return db, nil
+}
+
+// Work out the driver for a DSN and strip any sqlite:// scheme
+func Resolve(dsn, fallback string) (string, string, error) {
+	lower := strings.ToLower(dsn)
+	switch {
+	case strings.HasPrefix(lower, "postgres://"), strings.HasPrefix(lower, "postgresql://"):
+		return Postgres, dsn, nil
+	case strings.HasPrefix(lower, "sqlite://"):
+		return SQLite, dsn[len("sqlite://"):], nil
+	case strings.HasPrefix(lower, "sqlite:"):
+		return SQLite, dsn[len("sqlite:"):], nil
+	case strings.HasPrefix(lower, "file:"):
+		return SQLite, dsn, nil
+	case strings.Contains(lower, "://"):
+		return "", "", fmt.Errorf("unsupported database scheme in %q", strings.SplitN(dsn, "://", 2)[0]+"://")
+	}
+
+	switch fallback {
+	case Postgres:
+		return Postgres, dsn, nil
+	case SQLite:
+		if dsn == "" {
+			dsn = "recipes.db"
+		}
+		return SQLite, dsn, nil
+	}
+	return "", "", fmt.Errorf("unknown database driver %q", fallback)
+}
+
+func isMemory(dsn string) bool {
+	return strings.Contains(dsn, ":memory:") || strings.Contains(dsn, "mode=memory")
+}
+
+// Enable WAL and a busy timeout for file databases so readers don't block writers
+func sqliteDSN(dsn string) string {
+	if isMemory(dsn) {
+		return dsn
+	}
+
+	path, query, _ := strings.Cut(dsn, "?")
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return dsn
+	}
+	if params.Get("_journal_mode") == "" {
+		params.Set("_journal_mode", "WAL")
+	}
+	if params.Get("_busy_timeout") == "" {
+		params.Set("_busy_timeout", "5000")
+	}
+	return path + "?" + params.Encode()
+}
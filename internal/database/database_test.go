@@ -0,0 +1,56 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	cases := []struct {
+		dsn, fallback   string
+		driver, resolve string
+	}{
+		{"postgres://user@localhost/recipes", SQLite, Postgres, "postgres://user@localhost/recipes"},
+		{"postgresql://localhost/recipes", SQLite, Postgres, "postgresql://localhost/recipes"},
+		{"sqlite://data/recipes.db", Postgres, SQLite, "data/recipes.db"},
+		{"sqlite::memory:", Postgres, SQLite, ":memory:"},
+		{"file:recipes.db?cache=shared", Postgres, SQLite, "file:recipes.db?cache=shared"},
+		{"host=localhost dbname=recipes", Postgres, Postgres, "host=localhost dbname=recipes"},
+		{"", SQLite, SQLite, "recipes.db"},
+	}
+	for _, c := range cases {
+		driver, dsn, err := Resolve(c.dsn, c.fallback)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", c.dsn, err)
+		}
+		if driver != c.driver || dsn != c.resolve {
+			t.Errorf("%q: expected %s %q, got %s %q", c.dsn, c.driver, c.resolve, driver, dsn)
+		}
+	}
+
+	if _, _, err := Resolve("mysql://localhost/recipes", Postgres); err == nil {
+		t.Error("expected unsupported scheme to fail")
+	}
+}
+
+func TestOpenSQLiteUsesWAL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recipes.db")
+
+	db, err := Open(Options{DSN: "sqlite://" + path, MaxOpenConns: 4})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+
+	var mode string
+	if err := db.Raw("PRAGMA journal_mode").Scan(&mode).Error; err != nil {
+		t.Fatalf("failed to read journal mode: %v", err)
+	}
+	if mode != "wal" {
+		t.Fatalf("expected WAL journal mode, got %q", mode)
+	}
+
+	sqlDB, _ := db.DB()
+	if max := sqlDB.Stats().MaxOpenConnections; max != 4 {
+		t.Fatalf("expected 4 max open connections, got %d", max)
+	}
+}
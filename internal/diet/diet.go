@@ -0,0 +1,196 @@
+package diet
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"recipe-api/internal/models"
+)
+
+// Allergens ingredients can be tagged with
+var Allergens = []string{
+	"celery", "egg", "fish", "gluten", "lupin", "milk", "mustard",
+	"nuts", "peanuts", "sesame", "shellfish", "soy", "sulphites",
+}
+
+// Animal products an ingredient can be
+var Animals = []string{"meat", "fish", "dairy", "egg", "honey"}
+
+// Diet and the ingredients it rules out
+type Diet struct {
+	Name      string
+	Animals   []string // animal products it excludes
+	Allergens []string // allergens it excludes
+}
+
+// Diets recipes are flagged with, in the order they are reported
+var Diets = []Diet{
+	{"vegan", []string{"meat", "fish", "dairy", "egg", "honey"}, []string{"egg", "fish", "milk", "shellfish"}},
+	{"vegetarian", []string{"meat", "fish"}, []string{"fish", "shellfish"}},
+	{"pescatarian", []string{"meat"}, nil},
+	{"gluten-free", nil, []string{"gluten"}},
+	{"dairy-free", []string{"dairy"}, []string{"milk"}},
+	{"egg-free", []string{"egg"}, []string{"egg"}},
+	{"nut-free", nil, []string{"nuts", "peanuts"}},
+}
+
+// Normalise a diet or allergen name: trimmed, lower case, spaces as dashes
+func Normalise(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), "-")
+}
+
+// Find a diet by name
+func Lookup(name string) (Diet, bool) {
+	name = Normalise(name)
+	for _, diet := range Diets {
+		if diet.Name == name {
+			return diet, true
+		}
+	}
+	return Diet{}, false
+}
+
+// Whether name is a known allergen
+func IsAllergen(name string) bool {
+	for _, allergen := range Allergens {
+		if allergen == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Whether name is a known animal product
+func IsAnimal(name string) bool {
+	for _, animal := range Animals {
+		if animal == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Whether the diet allows an ingredient. Unchecked ingredients are unknown,
+// so they aren't allowed.
+func (diet Diet) Allows(ingredient models.Ingredient) bool {
+	return ingredient.DietChecked && !diet.Excludes(ingredient)
+}
+
+// Whether an ingredient's tags rule it out of the diet
+func (diet Diet) Excludes(ingredient models.Ingredient) bool {
+	if ingredient.Animal != nil {
+		for _, animal := range diet.Animals {
+			if *ingredient.Animal == animal {
+				return true
+			}
+		}
+	}
+	for _, allergen := range diet.Allergens {
+		for _, contained := range ingredient.Allergens {
+			if contained == allergen {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Normalise and check an ingredient's allergens and animal product. Giving
+// either marks the ingredient checked.
+func CheckIngredient(ingredient *models.Ingredient) error {
+	var allergens models.StringList
+	seen := make(map[string]bool)
+	for _, allergen := range ingredient.Allergens {
+		allergen = Normalise(allergen)
+		if !IsAllergen(allergen) {
+			return fmt.Errorf("unknown allergen %q", allergen)
+		}
+		if !seen[allergen] {
+			seen[allergen] = true
+			allergens = append(allergens, allergen)
+		}
+	}
+	sort.Strings(allergens)
+	ingredient.Allergens = allergens
+
+	if ingredient.Animal != nil {
+		animal := Normalise(*ingredient.Animal)
+		switch {
+		case animal == "":
+			ingredient.Animal = nil
+		case IsAnimal(animal):
+			ingredient.Animal = &animal
+		default:
+			return fmt.Errorf("unknown animal product %q", animal)
+		}
+	}
+	ingredient.DietChecked = ingredient.DietChecked || len(ingredient.Allergens) > 0 || ingredient.Animal != nil
+	return nil
+}
+
+// Normalise and check a recipe's diet overrides. An empty, non-nil map is
+// kept so updates can clear overrides.
+func CheckOverrides(overrides models.DietOverrides) (models.DietOverrides, error) {
+	if overrides == nil {
+		return nil, nil
+	}
+	checked := make(models.DietOverrides, len(overrides))
+	for name, allowed := range overrides {
+		diet, ok := Lookup(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown diet %q", name)
+		}
+		checked[diet.Name] = allowed
+	}
+	return checked, nil
+}
+
+// Diets a recipe suits and allergens it contains, from its ingredients with
+// the recipe's manual overrides applied. A recipe with unchecked ingredients
+// suits no diet unless overridden, and may contain more allergens than listed.
+func Derive(recipe models.Recipe) (diets []string, allergens []string) {
+	for _, diet := range Diets {
+		suits := true
+		for _, ri := range recipe.Ingredients {
+			if ri.Ingredient != nil && !diet.Allows(*ri.Ingredient) {
+				suits = false
+				break
+			}
+		}
+		if override, ok := recipe.DietOverrides[diet.Name]; ok {
+			suits = override
+		}
+		if suits {
+			diets = append(diets, diet.Name)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, ri := range recipe.Ingredients {
+		if ri.Ingredient == nil {
+			continue
+		}
+		for _, allergen := range ri.Ingredient.Allergens {
+			if !seen[allergen] {
+				seen[allergen] = true
+				allergens = append(allergens, allergen)
+			}
+		}
+	}
+	sort.Strings(allergens)
+	return diets, allergens
+}
+
+// Labels of a recipe's ingredients whose allergens and animal product aren't
+// known
+func Unverified(recipe models.Recipe) []string {
+	var labels []string
+	for _, ri := range recipe.Ingredients {
+		if ri.Ingredient != nil && !ri.Ingredient.DietChecked && !slices.Contains(labels, ri.Ingredient.Label) {
+			labels = append(labels, ri.Ingredient.Label)
+		}
+	}
+	return labels
+}
@@ -0,0 +1,103 @@
+package diet
+
+import (
+	"reflect"
+	"testing"
+
+	"recipe-api/internal/models"
+)
+
+func ingredient(label, animal string, allergens ...string) *models.Ingredient {
+	ingredient := &models.Ingredient{Label: label, Allergens: allergens, DietChecked: true}
+	if animal != "" {
+		ingredient.Animal = &animal
+	}
+	return ingredient
+}
+
+func TestDerive(t *testing.T) {
+	recipe := models.Recipe{
+		Ingredients: []models.RecipeIngredient{
+			{Ingredient: ingredient("Flour", "", "gluten")},
+			{Ingredient: ingredient("Butter", "dairy", "milk")},
+			{Ingredient: ingredient("Sugar", "")},
+		},
+	}
+
+	diets, allergens := Derive(recipe)
+	if expected := []string{"vegetarian", "pescatarian", "egg-free", "nut-free"}; !reflect.DeepEqual(diets, expected) {
+		t.Errorf("expected diets %v, got %v", expected, diets)
+	}
+	if expected := []string{"gluten", "milk"}; !reflect.DeepEqual(allergens, expected) {
+		t.Errorf("expected allergens %v, got %v", expected, allergens)
+	}
+
+	// Overrides win over what the ingredients say
+	recipe.DietOverrides = models.DietOverrides{"gluten-free": true, "nut-free": false}
+	diets, _ = Derive(recipe)
+	if expected := []string{"vegetarian", "pescatarian", "gluten-free", "egg-free"}; !reflect.DeepEqual(diets, expected) {
+		t.Errorf("expected diets %v with overrides, got %v", expected, diets)
+	}
+
+	// An unchecked ingredient could be anything, so only overrides stand
+	recipe.Ingredients = append(recipe.Ingredients, models.RecipeIngredient{Ingredient: &models.Ingredient{Label: "Stock cube"}})
+	diets, _ = Derive(recipe)
+	if expected := []string{"gluten-free"}; !reflect.DeepEqual(diets, expected) {
+		t.Errorf("expected diets %v with an unchecked ingredient, got %v", expected, diets)
+	}
+	if unverified := Unverified(recipe); !reflect.DeepEqual(unverified, []string{"Stock cube"}) {
+		t.Errorf("expected the stock cube unverified, got %v", unverified)
+	}
+}
+
+func TestCheckIngredient(t *testing.T) {
+	checked := ingredient("Pesto", " Dairy ", "Nuts", "milk", "nuts")
+	if err := CheckIngredient(checked); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual([]string(checked.Allergens), []string{"milk", "nuts"}) || *checked.Animal != "dairy" {
+		t.Errorf("unexpected normalised ingredient %+v", checked)
+	}
+
+	tagged := &models.Ingredient{Label: "Almonds", Allergens: models.StringList{"nuts"}}
+	untagged := &models.Ingredient{Label: "Cardamom"}
+	CheckIngredient(tagged)
+	CheckIngredient(untagged)
+	if !tagged.DietChecked || untagged.DietChecked {
+		t.Errorf("expected only the tagged ingredient checked, got %v and %v", tagged.DietChecked, untagged.DietChecked)
+	}
+
+	if err := CheckIngredient(ingredient("Mystery", "", "kryptonite")); err == nil {
+		t.Error("expected an unknown allergen to fail")
+	}
+	if err := CheckIngredient(ingredient("Mystery", "unicorn")); err == nil {
+		t.Error("expected an unknown animal product to fail")
+	}
+}
+
+func TestCheckOverrides(t *testing.T) {
+	overrides, err := CheckOverrides(models.DietOverrides{"Gluten Free": true})
+	if err != nil || !reflect.DeepEqual(overrides, models.DietOverrides{"gluten-free": true}) {
+		t.Errorf("unexpected overrides %v, %v", overrides, err)
+	}
+	if _, err := CheckOverrides(models.DietOverrides{"carnivore": true}); err == nil {
+		t.Error("expected an unknown diet to fail")
+	}
+}
+
+func TestStoredLists(t *testing.T) {
+	overrides := models.DietOverrides{"vegan": true, "nut-free": false}
+	value, _ := overrides.Value()
+	if value != ",+vegan,-nut-free," {
+		t.Fatalf("unexpected stored overrides %q", value)
+	}
+	var scanned models.DietOverrides
+	if err := scanned.Scan(value); err != nil || !reflect.DeepEqual(scanned, overrides) {
+		t.Fatalf("expected %v after scanning, got %v (%v)", overrides, scanned, err)
+	}
+
+	var list models.StringList
+	if err := list.Scan([]byte(",gluten,milk,")); err != nil || !reflect.DeepEqual([]string(list), []string{"gluten", "milk"}) {
+		t.Fatalf("unexpected scanned list %v (%v)", list, err)
+	}
+}
@@ -0,0 +1,69 @@
+package events
+
+import (
+	"sync"
+
+	"recipe-api/internal/models"
+)
+
+// Recipe lifecycle event types
+const (
+	RecipeCreated  = "recipe.created"
+	RecipeUpdated  = "recipe.updated"
+	RecipeDeleted  = "recipe.deleted"
+	RecipeRestored = "recipe.restored"
+)
+
+var Types = []string{RecipeCreated, RecipeUpdated, RecipeDeleted, RecipeRestored}
+
+// Events a subscriber may fall behind by before it is dropped
+const subscriberBuffer = 64
+
+// In-process fan-out of committed events to live subscribers. A subscriber
+// that can't keep up has its channel closed; it should resume from the
+// persisted log instead of silently missing events.
+type Bus struct {
+	mu          sync.Mutex
+	subscribers map[chan models.Event]bool
+}
+
+// Constructor
+func NewBus() *Bus {
+	return &Bus{subscribers: make(map[chan models.Event]bool)}
+}
+
+func (bus *Bus) Subscribe() chan models.Event {
+	events := make(chan models.Event, subscriberBuffer)
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	bus.subscribers[events] = true
+	return events
+}
+
+func (bus *Bus) Unsubscribe(events chan models.Event) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	if bus.subscribers[events] {
+		delete(bus.subscribers, events)
+		close(events)
+	}
+}
+
+// Send events to every subscriber, in order
+func (bus *Bus) Publish(events ...models.Event) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	for subscriber := range bus.subscribers {
+		for _, event := range events {
+			select {
+			case subscriber <- event:
+			default:
+				delete(bus.subscribers, subscriber)
+				close(subscriber)
+			}
+			if !bus.subscribers[subscriber] {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package events
+
+import (
+	"testing"
+
+	"recipe-api/internal/models"
+)
+
+func TestBus(t *testing.T) {
+	bus := NewBus()
+	fast := bus.Subscribe()
+	slow := bus.Subscribe()
+
+	for i := 1; i <= subscriberBuffer; i++ {
+		bus.Publish(models.Event{EventID: int64(i)})
+		<-fast
+	}
+	if event := <-slow; event.EventID != 1 {
+		t.Fatalf("expected events in order, got %d first", event.EventID)
+	}
+
+	// One more than the buffer holds drops the slow subscriber
+	bus.Publish(models.Event{EventID: subscriberBuffer + 1}, models.Event{EventID: subscriberBuffer + 2})
+	for range slow {
+	}
+	if event := <-fast; event.EventID != subscriberBuffer+1 {
+		t.Fatalf("expected the fast subscriber to keep receiving, got %d", event.EventID)
+	}
+
+	<-fast
+
+	bus.Unsubscribe(fast)
+	if _, open := <-fast; open {
+		t.Fatal("expected the channel to be closed after unsubscribing")
+	}
+	bus.Unsubscribe(slow) // already dropped, must not panic
+}
@@ -0,0 +1,142 @@
+// Package lru is a size-bounded least-recently-used cache with optional
+// expiry, safe for concurrent use.
+package lru
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Cache of at most MaxEntries values whose costs add up to at most MaxCost.
+// A zero limit is no limit.
+type Cache[K comparable, V any] struct {
+	MaxEntries int
+	MaxCost    int64
+
+	// Called without the lock held for every value pushed out to make room
+	OnEvict func(key K, value V)
+
+	mu    sync.Mutex
+	ll    *list.List // front is most recently used
+	items map[K]*list.Element
+	cost  int64
+	now   func() time.Time
+}
+
+type entry[K comparable, V any] struct {
+	key     K
+	value   V
+	cost    int64
+	expires time.Time // zero for never
+}
+
+func New[K comparable, V any](maxEntries int, maxCost int64) *Cache[K, V] {
+	return &Cache[K, V]{
+		MaxEntries: maxEntries,
+		MaxCost:    maxCost,
+		ll:         list.New(),
+		items:      make(map[K]*list.Element),
+		now:        time.Now,
+	}
+}
+
+// Value for key, unless it is missing or expired
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	item := element.Value.(*entry[K, V])
+	if !item.expires.IsZero() && !c.now().Before(item.expires) {
+		c.remove(element)
+		var zero V
+		return zero, false
+	}
+	c.ll.MoveToFront(element)
+	return item.value, true
+}
+
+// Store a value costing cost, expiring after ttl unless ttl is 0. Values
+// costing more than MaxCost are not stored. Reports whether it was stored.
+func (c *Cache[K, V]) Add(key K, value V, cost int64, ttl time.Duration) bool {
+	if c.MaxCost > 0 && cost > c.MaxCost {
+		c.Remove(key)
+		return false
+	}
+	var expires time.Time
+	if ttl > 0 {
+		expires = c.now().Add(ttl)
+	}
+
+	c.mu.Lock()
+	if element, ok := c.items[key]; ok {
+		item := element.Value.(*entry[K, V])
+		c.cost += cost - item.cost
+		item.value, item.cost, item.expires = value, cost, expires
+		c.ll.MoveToFront(element)
+	} else {
+		c.items[key] = c.ll.PushFront(&entry[K, V]{key, value, cost, expires})
+		c.cost += cost
+	}
+
+	var evicted []*entry[K, V]
+	for c.ll.Len() > 1 && ((c.MaxEntries > 0 && c.ll.Len() > c.MaxEntries) || (c.MaxCost > 0 && c.cost > c.MaxCost)) {
+		oldest := c.ll.Back()
+		evicted = append(evicted, oldest.Value.(*entry[K, V]))
+		c.remove(oldest)
+	}
+	c.mu.Unlock()
+
+	if c.OnEvict != nil {
+		for _, item := range evicted {
+			c.OnEvict(item.key, item.value)
+		}
+	}
+	return true
+}
+
+// Drop a key; reports whether it was there
+func (c *Cache[K, V]) Remove(key K) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.items[key]
+	if ok {
+		c.remove(element)
+	}
+	return ok
+}
+
+// Drop every value, returning how many there were
+func (c *Cache[K, V]) Purge() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := c.ll.Len()
+	c.ll.Init()
+	clear(c.items)
+	c.cost = 0
+	return n
+}
+
+// Number of values held, including expired ones not yet dropped
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// Total cost of the values held
+func (c *Cache[K, V]) Cost() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cost
+}
+
+func (c *Cache[K, V]) remove(element *list.Element) {
+	item := c.ll.Remove(element).(*entry[K, V])
+	delete(c.items, item.key)
+	c.cost -= item.cost
+}
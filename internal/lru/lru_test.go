@@ -0,0 +1,71 @@
+package lru
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := New[string, int](3, 0)
+	var evicted []string
+	cache.OnEvict = func(key string, _ int) { evicted = append(evicted, key) }
+
+	cache.Add("a", 1, 1, 0)
+	cache.Add("b", 2, 1, 0)
+	cache.Add("c", 3, 1, 0)
+	cache.Get("a") // b is now the oldest
+	cache.Add("d", 4, 1, 0)
+
+	if _, ok := cache.Get("b"); ok {
+		t.Errorf("expected b to be evicted")
+	}
+	if value, ok := cache.Get("a"); !ok || value != 1 {
+		t.Errorf("expected a to survive, got %d %v", value, ok)
+	}
+	if fmt.Sprint(evicted) != "[b]" || cache.Len() != 3 {
+		t.Errorf("unexpected evictions %v, len %d", evicted, cache.Len())
+	}
+}
+
+func TestCostLimit(t *testing.T) {
+	cache := New[string, string](0, 10)
+	cache.Add("a", "", 4, 0)
+	cache.Add("b", "", 4, 0)
+	cache.Add("c", "", 4, 0) // pushes a out
+
+	if _, ok := cache.Get("a"); ok || cache.Cost() != 8 {
+		t.Errorf("expected a evicted and cost 8, got cost %d", cache.Cost())
+	}
+	if cache.Add("huge", "", 11, 0) {
+		t.Errorf("expected a value over the limit to be refused")
+	}
+	// Replacing a value updates the cost
+	cache.Add("b", "", 1, 0)
+	if cache.Cost() != 5 {
+		t.Errorf("expected cost 5, got %d", cache.Cost())
+	}
+	if n := cache.Purge(); n != 2 || cache.Len() != 0 || cache.Cost() != 0 {
+		t.Errorf("expected purge to empty the cache, got %d", n)
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache := New[int, string](0, 0)
+	cache.now = func() time.Time { return now }
+
+	cache.Add(1, "short", 1, time.Minute)
+	cache.Add(2, "forever", 1, 0)
+	now = now.Add(time.Minute)
+
+	if _, ok := cache.Get(1); ok {
+		t.Errorf("expected the value to expire")
+	}
+	if value, ok := cache.Get(2); !ok || value != "forever" {
+		t.Errorf("expected values without a ttl to stay")
+	}
+	if cache.Len() != 1 {
+		t.Errorf("expected the expired value dropped, len %d", cache.Len())
+	}
+}
@@ -0,0 +1,89 @@
+package measure
+
+import (
+	"strings"
+)
+
+// What a unit measures; only units of the same dimension convert
+type Dimension string
+
+const (
+	Mass   Dimension = "mass"
+	Volume Dimension = "volume"
+)
+
+// Known unit with its size in the dimension's base unit (grams or millilitres)
+type Unit struct {
+	Name      string
+	Dimension Dimension
+	Factor    float64
+}
+
+var units = []struct {
+	unit    Unit
+	aliases []string
+}{
+	{Unit{"mg", Mass, 0.001}, []string{"milligram", "milligrams"}},
+	{Unit{"g", Mass, 1}, []string{"gram", "grams", "gr"}},
+	{Unit{"kg", Mass, 1000}, []string{"kilogram", "kilograms", "kilo", "kilos"}},
+	{Unit{"oz", Mass, 28.349523125}, []string{"ounce", "ounces"}},
+	{Unit{"lb", Mass, 453.59237}, []string{"lbs", "pound", "pounds"}},
+	{Unit{"ml", Volume, 1}, []string{"millilitre", "millilitres", "milliliter", "milliliters"}},
+	{Unit{"cl", Volume, 10}, []string{"centilitre", "centilitres", "centiliter", "centiliters"}},
+	{Unit{"l", Volume, 1000}, []string{"litre", "litres", "liter", "liters"}},
+	{Unit{"tsp", Volume, 5}, []string{"teaspoon", "teaspoons"}},
+	{Unit{"tbsp", Volume, 15}, []string{"tablespoon", "tablespoons"}},
+	// A plain cup is the metric 250 ml; recipes in US cups say so
+	{Unit{"cup", Volume, 250}, []string{"cups", "metric cup", "metric cups"}},
+	{Unit{"us cup", Volume, 236.5882365}, []string{"us cups", "cup (us)", "cups (us)"}},
+	{Unit{"fl oz", Volume, 29.5735295625}, []string{"floz", "fluid ounce", "fluid ounces"}},
+	{Unit{"pint", Volume, 568.26125}, []string{"pints", "pt"}},
+}
+
+var byLabel = func() map[string]Unit {
+	lookup := make(map[string]Unit)
+	for _, entry := range units {
+		lookup[entry.unit.Name] = entry.unit
+		for _, alias := range entry.aliases {
+			lookup[alias] = entry.unit
+		}
+	}
+	return lookup
+}()
+
+// Normalise a unit label for comparison: trimmed, lower case, single spaces
+func Normalise(label string) string {
+	return strings.Join(strings.Fields(strings.ToLower(label)), " ")
+}
+
+// Find a known unit by label or alias, ignoring case and spacing
+func Lookup(label string) (Unit, bool) {
+	unit, ok := byLabel[strings.TrimSuffix(Normalise(label), ".")]
+	return unit, ok
+}
+
+// Whether two unit labels name the same unit
+func Same(a, b string) bool {
+	if Normalise(a) == Normalise(b) {
+		return true
+	}
+	unitA, okA := Lookup(a)
+	unitB, okB := Lookup(b)
+	return okA && okB && unitA.Name == unitB.Name
+}
+
+// Convert an amount between two unit labels of the same dimension
+func Convert(amount float64, from, to string) (float64, bool) {
+	if Same(from, to) {
+		return amount, true
+	}
+	fromUnit, ok := Lookup(from)
+	if !ok {
+		return 0, false
+	}
+	toUnit, ok := Lookup(to)
+	if !ok || fromUnit.Dimension != toUnit.Dimension {
+		return 0, false
+	}
+	return amount * fromUnit.Factor / toUnit.Factor, true
+}
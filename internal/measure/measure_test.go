@@ -0,0 +1,40 @@
+package measure
+
+import (
+	"math"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	cases := []struct {
+		amount   float64
+		from, to string
+		expected float64
+		ok       bool
+	}{
+		{1, "kg", "g", 1000, true},
+		{500, "Grams", "kilogram", 0.5, true},
+		{3, "tsp", "Tbsp", 1, true},
+		{2, "cup", "ml", 500, true},
+		{1, "US cup", "ml", 236.5882365, true},
+		{1, "metric cup", "cup", 1, true},
+		{1, "Pinch", "pinch", 1, true},
+		{1, "cup", "g", 0, false},
+		{1, "clove", "g", 0, false},
+	}
+	for _, c := range cases {
+		got, ok := Convert(c.amount, c.from, c.to)
+		if ok != c.ok || math.Abs(got-c.expected) > 1e-9 {
+			t.Errorf("Convert(%v, %q, %q) = %v, %v; expected %v, %v", c.amount, c.from, c.to, got, ok, c.expected, c.ok)
+		}
+	}
+}
+
+func TestSame(t *testing.T) {
+	if !Same(" Gram ", "g") {
+		t.Error("expected gram and g to be the same unit")
+	}
+	if Same("g", "kg") {
+		t.Error("expected g and kg to differ")
+	}
+}
@@ -0,0 +1,172 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // register the GIF decoder
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+// Largest image accepted, in pixels, so a small file can't decode into a huge bitmap
+const MaxPixels = 40_000_000
+
+// Thumbnail size, scaled to a width keeping the aspect ratio
+type Size struct {
+	Name  string
+	Width int
+}
+
+// Thumbnails generated for every upload, smallest first. Sizes at least as
+// wide as the original are skipped rather than upscaled.
+var Sizes = []Size{
+	{"small", 160},
+	{"medium", 480},
+	{"large", 1024},
+}
+
+// Name of the untouched upload among an image's sizes
+const Original = "original"
+
+// Content types accepted for upload, detected from the data itself
+var Accepted = map[string]string{
+	"image/jpeg": "jpeg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+}
+
+var ErrUnsupported = errors.New("image must be a JPEG, PNG or GIF")
+
+// Upload checked and resized
+type Processed struct {
+	ContentType string
+	Width       int
+	Height      int
+	Original    []byte
+	Thumbnails  map[string][]byte // keyed by size name
+	ThumbType   string            // content type of the thumbnails
+}
+
+// File extension for a content type, including the dot
+func Ext(contentType string) string {
+	switch contentType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	}
+	return ""
+}
+
+// Content type of the thumbnails made from an upload: JPEGs get JPEG
+// thumbnails, everything else PNG to keep transparency
+func ThumbnailType(contentType string) string {
+	if contentType == "image/jpeg" {
+		return "image/jpeg"
+	}
+	return "image/png"
+}
+
+// Validate an upload by its content and build its thumbnails
+func Process(data []byte) (*Processed, error) {
+	contentType := http.DetectContentType(data)
+	if _, ok := Accepted[contentType]; !ok {
+		return nil, ErrUnsupported
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid image: %w", err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > MaxPixels {
+		return nil, fmt.Errorf("image must be at most %d pixels", MaxPixels)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid image: %w", err)
+	}
+
+	processed := &Processed{
+		ContentType: contentType,
+		Width:       config.Width,
+		Height:      config.Height,
+		Original:    data,
+		Thumbnails:  make(map[string][]byte),
+		ThumbType:   ThumbnailType(contentType),
+	}
+
+	// Converted once and shared by every size, as it is as big as the source
+	var rgba *image.RGBA
+	for _, size := range Sizes {
+		if size.Width >= config.Width {
+			continue
+		}
+		if rgba == nil {
+			rgba = ToRGBA(src)
+		}
+		var out bytes.Buffer
+		thumb := Resize(rgba, size.Width)
+		if processed.ThumbType == "image/jpeg" {
+			err = jpeg.Encode(&out, thumb, &jpeg.Options{Quality: 85})
+		} else {
+			err = png.Encode(&out, thumb)
+		}
+		if err != nil {
+			return nil, err
+		}
+		processed.Thumbnails[size.Name] = out.Bytes()
+	}
+	return processed, nil
+}
+
+// Image as RGBA, converting it unless it already is one
+func ToRGBA(src image.Image) *image.RGBA {
+	if rgba, ok := src.(*image.RGBA); ok {
+		return rgba
+	}
+	bounds := src.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+	return rgba
+}
+
+// Scale an image down to a width, averaging the source pixels each output
+// pixel covers
+func Resize(src *image.RGBA, width int) *image.RGBA {
+	bounds := src.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	height := max(1, (srcH*width+srcW/2)/srcW)
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*srcH/height, max((y+1)*srcH/height, y*srcH/height+1)
+		for x := 0; x < width; x++ {
+			x0, x1 := x*srcW/width, max((x+1)*srcW/width, x*srcW/width+1)
+
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				start := src.PixOffset(bounds.Min.X+x0, bounds.Min.Y+sy)
+				row := src.Pix[start : start+(x1-x0)*4]
+				for i := 0; i < len(row); i += 4 {
+					sum[0] += int(row[i])
+					sum[1] += int(row[i+1])
+					sum[2] += int(row[i+2])
+					sum[3] += int(row[i+3])
+				}
+			}
+			count := (y1 - y0) * (x1 - x0)
+			offset := y*dst.Stride + x*4
+			for c := range sum {
+				dst.Pix[offset+c] = uint8(sum[c] / count)
+			}
+		}
+	}
+	return dst
+}
@@ -0,0 +1,97 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: 200, G: 100, B: 50, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestProcessThumbnails(t *testing.T) {
+	processed, err := Process(testPNG(t, 600, 300))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if processed.ContentType != "image/png" || processed.Width != 600 || processed.Height != 300 {
+		t.Fatalf("unexpected metadata %+v", processed)
+	}
+	if _, ok := processed.Thumbnails["large"]; ok {
+		t.Fatal("large thumbnail should be skipped for a narrower original")
+	}
+
+	small, err := png.Decode(bytes.NewReader(processed.Thumbnails["small"]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if small.Bounds().Dx() != 160 || small.Bounds().Dy() != 80 {
+		t.Fatalf("expected a 160x80 thumbnail, got %v", small.Bounds())
+	}
+	if r, g, b, _ := small.At(10, 10).RGBA(); r>>8 != 200 || g>>8 != 100 || b>>8 != 50 {
+		t.Fatalf("expected the colour to survive averaging, got %d %d %d", r>>8, g>>8, b>>8)
+	}
+}
+
+func TestProcessJPEG(t *testing.T) {
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 500, 500)), nil)
+
+	processed, err := Process(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if processed.ThumbType != "image/jpeg" || len(processed.Thumbnails) != 2 {
+		t.Fatalf("expected small and medium JPEG thumbnails, got %s %d", processed.ThumbType, len(processed.Thumbnails))
+	}
+}
+
+func TestProcessRejects(t *testing.T) {
+	if _, err := Process([]byte("<html>not an image</html>")); err != ErrUnsupported {
+		t.Fatalf("expected ErrUnsupported, got %v", err)
+	}
+	truncated := testPNG(t, 10, 10)[:40]
+	if _, err := Process(truncated); err == nil {
+		t.Fatal("expected a truncated image to be rejected")
+	}
+}
+
+func TestResizeSubImage(t *testing.T) {
+	// Left half red, right half blue; resizing just the right half sees blue
+	img := image.NewRGBA(image.Rect(0, 0, 40, 10))
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 40; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= 20 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	right := img.SubImage(image.Rect(20, 0, 40, 10)).(*image.RGBA)
+	thumb := Resize(right, 4)
+	if thumb.Bounds().Dx() != 4 || thumb.Bounds().Dy() != 2 {
+		t.Fatalf("expected a 4x2 thumbnail, got %v", thumb.Bounds())
+	}
+	if got := thumb.RGBAAt(0, 0); got != (color.RGBA{B: 255, A: 255}) {
+		t.Errorf("expected blue from the sub-image, got %v", got)
+	}
+	if ToRGBA(img) != img {
+		t.Errorf("expected an RGBA image to be used as it is")
+	}
+}
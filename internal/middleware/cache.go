@@ -0,0 +1,276 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"recipe-api/internal/lru"
+)
+
+// Cache-Control for GET responses that don't set their own: clients may
+// keep them but must check back before reusing them
+const defaultCacheControl = "private, no-cache"
+
+// Cache-Control for cached responses, which are the same for everyone
+const sharedCacheControl = "public, no-cache"
+
+// Responses of cacheable reads kept in memory, dropped whenever a write
+// succeeds. Answers conditional requests from the cached ETag and
+// Last-Modified, which is the handler's own when it sets one and otherwise
+// the last purge, since nothing the response shows changed after that.
+type ResponseCache struct {
+	TTL time.Duration // how long a response may be served, 0 for until the next write
+
+	entries    *lru.Cache[string, *cachedResponse]
+	generation atomic.Uint64 // bumped by every purge
+	purged     atomic.Int64  // unix nanoseconds of the last purge
+
+	hits, misses, stores, evictions, purges atomic.Int64
+}
+
+type cachedResponse struct {
+	header   http.Header
+	body     []byte
+	etag     string
+	modified time.Time
+}
+
+// Counts of cache use, published as expvars
+type CacheStats struct {
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	Stores    int64 `json:"stores"`
+	Evictions int64 `json:"evictions"`
+	Purges    int64 `json:"purges"`
+	Entries   int   `json:"entries"`
+	Bytes     int64 `json:"bytes"`
+}
+
+// Cache of up to maxEntries responses totalling maxBytes
+func NewResponseCache(maxEntries int, maxBytes int64, ttl time.Duration) *ResponseCache {
+	cache := &ResponseCache{TTL: ttl, entries: lru.New[string, *cachedResponse](maxEntries, maxBytes)}
+	cache.entries.OnEvict = func(string, *cachedResponse) { cache.evictions.Add(1) }
+	cache.purged.Store(time.Now().UnixNano())
+	return cache
+}
+
+func (cache *ResponseCache) Stats() CacheStats {
+	if cache == nil {
+		return CacheStats{}
+	}
+	return CacheStats{
+		Hits:      cache.hits.Load(),
+		Misses:    cache.misses.Load(),
+		Stores:    cache.stores.Load(),
+		Evictions: cache.evictions.Load(),
+		Purges:    cache.purges.Load(),
+		Entries:   cache.entries.Len(),
+		Bytes:     cache.entries.Cost(),
+	}
+}
+
+// Drop every cached response. Reads already running won't store what they
+// read before the write. Safe on a nil cache.
+func (cache *ResponseCache) Purge() {
+	if cache == nil {
+		return
+	}
+	cache.generation.Add(1)
+	cache.purged.Store(time.Now().UnixNano())
+	cache.entries.Purge()
+	cache.purges.Add(1)
+}
+
+// Serve GET requests from the cache, storing successful responses of next.
+// Responses are keyed by URL and Accept header. A nil cache only sets the
+// headers.
+func (cache *ResponseCache) Cached(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Cache-Control", sharedCacheControl)
+		if cache == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := r.URL.RequestURI() + "\n" + r.Header.Get("Accept")
+		response, ok := cache.entries.Get(key)
+		if ok {
+			cache.hits.Add(1)
+			w.Header().Set("X-Cache", "HIT")
+		} else {
+			cache.misses.Add(1)
+			generation := cache.generation.Load()
+			modified := time.Unix(0, cache.purged.Load())
+			recorder := &responseRecorder{header: w.Header().Clone(), status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+			if recorder.status != http.StatusOK {
+				recorder.copyTo(w)
+				return
+			}
+			if own, err := http.ParseTime(recorder.header.Get("Last-Modified")); err == nil {
+				modified = own
+			}
+			sum := sha256.Sum256(recorder.body.Bytes())
+			response = &cachedResponse{
+				header:   recorder.header,
+				body:     recorder.body.Bytes(),
+				etag:     `"` + hex.EncodeToString(sum[:12]) + `"`,
+				modified: modified.UTC(),
+			}
+			// A write landed while reading, so the response may predate it
+			if cache.generation.Load() == generation &&
+				cache.entries.Add(key, response, int64(len(response.body)), cache.TTL) {
+				cache.stores.Add(1)
+			}
+			w.Header().Set("X-Cache", "MISS")
+		}
+		response.serve(w, r)
+	})
+}
+
+// Write a cached response, or 304 when the client's copy is current
+func (response *cachedResponse) serve(w http.ResponseWriter, r *http.Request) {
+	header := w.Header()
+	for name, values := range response.header {
+		// Copied, so later Adds can't reach the cached slices
+		header[name] = slices.Clone(values)
+	}
+	header.Set("ETag", response.etag)
+	header.Set("Last-Modified", response.modified.Format(http.TimeFormat))
+
+	if response.notModified(r) {
+		header.Del("Content-Type")
+		header.Del("Content-Length")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		w.Write(response.body)
+	}
+}
+
+// Whether the request's validators match. If-None-Match wins over
+// If-Modified-Since, which only matches dates after the second the response
+// was made, since another write could land within that second.
+func (response *cachedResponse) notModified(r *http.Request) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, tag := range strings.Split(match, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == response.etag {
+				return true
+			}
+		}
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	return err == nil && response.modified.Truncate(time.Second).Before(since)
+}
+
+type keepCacheKey struct{}
+
+// Mark a request as writing nothing, such as a POST that only reads, so
+// Invalidate keeps the cache after it. A no-op outside Invalidate.
+func KeepCache(r *http.Request) {
+	if keep, ok := r.Context().Value(keepCacheKey{}).(*bool); ok {
+		*keep = true
+	}
+}
+
+// Handler that only reads whatever its method, leaving the cache alone
+func ReadOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		KeepCache(r)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Purge the cache after every successful write request, so reads never
+// outlive the data they show, unless the handler marked it with KeepCache.
+// Also sets the default Cache-Control on reads.
+func (cache *ResponseCache) Invalidate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			w.Header().Set("Cache-Control", defaultCacheControl)
+			next.ServeHTTP(w, r)
+			return
+		case http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+		keep := false
+		r = r.WithContext(context.WithValue(r.Context(), keepCacheKey{}, &keep))
+		status := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(status, r)
+		if status.status < 400 && !keep {
+			cache.Purge()
+		}
+	})
+}
+
+// Response held in memory to be cached
+type responseRecorder struct {
+	header      http.Header
+	body        bytes.Buffer
+	status      int
+	wroteHeader bool
+}
+
+func (rec *responseRecorder) Header() http.Header { return rec.header }
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status, rec.wroteHeader = status, true
+	}
+}
+
+func (rec *responseRecorder) Write(p []byte) (int, error) {
+	rec.wroteHeader = true
+	return rec.body.Write(p)
+}
+
+// Pass an uncached response on as it was written
+func (rec *responseRecorder) copyTo(w http.ResponseWriter) {
+	header := w.Header()
+	for name, values := range rec.header {
+		header[name] = values
+	}
+	header.Set("Cache-Control", defaultCacheControl)
+	w.WriteHeader(rec.status)
+	w.Write(rec.body.Bytes())
+}
+
+// Response writer that remembers the status
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (sw *statusWriter) WriteHeader(status int) {
+	if !sw.wroteHeader {
+		sw.status, sw.wroteHeader = status, true
+	}
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+func (sw *statusWriter) Write(p []byte) (int, error) {
+	sw.wroteHeader = true
+	return sw.ResponseWriter.Write(p)
+}
+
+func (sw *statusWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
@@ -0,0 +1,250 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Handler counting its calls, answering with the count
+func countingHandler(calls *int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		if r.URL.Query().Get("missing") != "" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"calls":%d,"accept":%q}`, *calls, r.Header.Get("Accept"))
+	})
+}
+
+func serveCached(handler http.Handler, method, target string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestResponseCacheHitsAndMisses(t *testing.T) {
+	cache := NewResponseCache(10, 1<<20, 0)
+	calls := 0
+	handler := cache.Cached(countingHandler(&calls))
+
+	first := serveCached(handler, "GET", "/recipes?page=1", nil)
+	second := serveCached(handler, "GET", "/recipes?page=1", nil)
+	if first.Header().Get("X-Cache") != "MISS" || second.Header().Get("X-Cache") != "HIT" {
+		t.Fatalf("expected MISS then HIT, got %q then %q", first.Header().Get("X-Cache"), second.Header().Get("X-Cache"))
+	}
+	if calls != 1 || first.Body.String() != second.Body.String() {
+		t.Fatalf("expected one handler call and identical bodies, got %d calls", calls)
+	}
+	if second.Header().Get("Content-Type") != "application/json" || second.Header().Get("Cache-Control") != "public, no-cache" {
+		t.Errorf("unexpected headers on hit: %v", second.Header())
+	}
+	if first.Header().Get("ETag") == "" || first.Header().Get("ETag") != second.Header().Get("ETag") {
+		t.Errorf("expected a stable ETag, got %q and %q", first.Header().Get("ETag"), second.Header().Get("ETag"))
+	}
+
+	// Query and Accept are part of the key
+	serveCached(handler, "GET", "/recipes?page=2", nil)
+	yaml := serveCached(handler, "GET", "/recipes?page=1", map[string]string{"Accept": "application/yaml"})
+	if calls != 3 || yaml.Header().Get("X-Cache") != "MISS" {
+		t.Errorf("expected separate entries per URL and Accept, got %d calls", calls)
+	}
+
+	// Errors pass through uncached
+	for i := 0; i < 2; i++ {
+		rr := serveCached(handler, "GET", "/recipes?missing=1", nil)
+		if rr.Code != http.StatusNotFound || rr.Header().Get("Cache-Control") != "private, no-cache" {
+			t.Errorf("expected an uncached 404, got %d %q", rr.Code, rr.Header().Get("Cache-Control"))
+		}
+	}
+	if calls != 5 {
+		t.Errorf("expected errors not to be cached, got %d calls", calls)
+	}
+
+	stats := cache.Stats()
+	if stats.Hits != 1 || stats.Misses != 5 || stats.Stores != 3 || stats.Entries != 3 || stats.Bytes == 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestResponseCacheConditional(t *testing.T) {
+	cache := NewResponseCache(10, 1<<20, 0)
+	calls := 0
+	handler := cache.Cached(countingHandler(&calls))
+
+	first := serveCached(handler, "GET", "/recipe/id/1", nil)
+	etag := first.Header().Get("ETag")
+	modified, err := http.ParseTime(first.Header().Get("Last-Modified"))
+	if err != nil {
+		t.Fatalf("bad Last-Modified: %v", err)
+	}
+
+	cases := []struct {
+		headers map[string]string
+		status  int
+	}{
+		{map[string]string{"If-None-Match": etag}, http.StatusNotModified},
+		{map[string]string{"If-None-Match": `"other", W/` + etag}, http.StatusNotModified},
+		{map[string]string{"If-None-Match": `"other"`}, http.StatusOK},
+		{map[string]string{"If-Modified-Since": modified.Add(time.Second).Format(http.TimeFormat)}, http.StatusNotModified},
+		{map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)}, http.StatusOK},
+		// If-None-Match decides when both are sent
+		{map[string]string{"If-None-Match": `"other"`, "If-Modified-Since": modified.Add(time.Hour).Format(http.TimeFormat)}, http.StatusOK},
+	}
+	for _, c := range cases {
+		rr := serveCached(handler, "GET", "/recipe/id/1", c.headers)
+		if rr.Code != c.status {
+			t.Errorf("%v: expected %d, got %d", c.headers, c.status, rr.Code)
+		}
+		if c.status == http.StatusNotModified && rr.Body.Len() != 0 {
+			t.Errorf("%v: expected an empty 304 body", c.headers)
+		}
+		if rr.Header().Get("ETag") != etag {
+			t.Errorf("%v: expected ETag %s, got %q", c.headers, etag, rr.Header().Get("ETag"))
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected conditional requests to be answered from the cache, got %d calls", calls)
+	}
+}
+
+func TestResponseCacheInvalidate(t *testing.T) {
+	cache := NewResponseCache(10, 1<<20, 0)
+	calls := 0
+	reads := cache.Cached(countingHandler(&calls))
+	handler := cache.Invalidate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fail":
+			http.Error(w, "bad request", http.StatusBadRequest)
+		case "/write":
+			w.WriteHeader(http.StatusCreated)
+		case "/search":
+			KeepCache(r)
+		case "/readonly":
+			ReadOnly(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(w, r)
+		default:
+			reads.ServeHTTP(w, r)
+		}
+	}))
+
+	serveCached(handler, "GET", "/recipes", nil)
+	serveCached(handler, "POST", "/fail", nil)
+	serveCached(handler, "OPTIONS", "/write", nil)
+	if rr := serveCached(handler, "GET", "/recipes", nil); rr.Header().Get("X-Cache") != "HIT" {
+		t.Fatalf("expected failed writes and preflights to keep the cache, got %q", rr.Header().Get("X-Cache"))
+	}
+	serveCached(handler, "POST", "/search", nil)
+	serveCached(handler, "POST", "/readonly", nil)
+	if rr := serveCached(handler, "GET", "/recipes", nil); rr.Header().Get("X-Cache") != "HIT" {
+		t.Fatalf("expected POSTs marked read-only to keep the cache, got %q", rr.Header().Get("X-Cache"))
+	}
+
+	if rr := serveCached(handler, "POST", "/write", nil); rr.Code != http.StatusCreated {
+		t.Fatalf("expected the write to pass through, got %d", rr.Code)
+	}
+	if rr := serveCached(handler, "GET", "/recipes", nil); rr.Header().Get("X-Cache") != "MISS" {
+		t.Errorf("expected a write to purge the cache, got %q", rr.Header().Get("X-Cache"))
+	}
+	if cache.Stats().Purges != 1 {
+		t.Errorf("expected one purge, got %d", cache.Stats().Purges)
+	}
+
+	// Uncached reads get the private default
+	rr := serveCached(handler, "GET", "/other", nil)
+	if rr.Header().Get("Cache-Control") != "public, no-cache" {
+		t.Errorf("expected cached reads to be public, got %q", rr.Header().Get("Cache-Control"))
+	}
+	plain := cache.Invalidate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	if rr := serveCached(plain, "GET", "/", nil); rr.Header().Get("Cache-Control") != "private, no-cache" {
+		t.Errorf("expected private, no-cache by default, got %q", rr.Header().Get("Cache-Control"))
+	}
+}
+
+func TestResponseCacheNil(t *testing.T) {
+	var cache *ResponseCache
+	calls := 0
+	handler := cache.Invalidate(cache.Cached(countingHandler(&calls)))
+
+	serveCached(handler, "GET", "/recipes", nil)
+	rr := serveCached(handler, "GET", "/recipes", nil)
+	if calls != 2 || rr.Header().Get("X-Cache") != "" {
+		t.Errorf("expected a nil cache to pass every request through, got %d calls", calls)
+	}
+	serveCached(handler, "DELETE", "/recipes", nil)
+	cache.Purge()
+	if cache.Stats() != (CacheStats{}) {
+		t.Errorf("expected empty stats from a nil cache")
+	}
+}
+
+func TestResponseCacheExpiry(t *testing.T) {
+	cache := NewResponseCache(10, 1<<20, 20*time.Millisecond)
+	calls := 0
+	handler := cache.Cached(countingHandler(&calls))
+
+	serveCached(handler, "GET", "/recipes", nil)
+	time.Sleep(30 * time.Millisecond)
+	if rr := serveCached(handler, "GET", "/recipes", nil); rr.Header().Get("X-Cache") != "MISS" {
+		t.Errorf("expected the entry to expire after the TTL, got %q", rr.Header().Get("X-Cache"))
+	}
+}
+
+func TestResponseCachePurgeDuringRead(t *testing.T) {
+	cache := NewResponseCache(10, 1<<20, 0)
+	calls := 0
+	counting := countingHandler(&calls)
+	handler := cache.Cached(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		counting.ServeHTTP(w, r)
+		// A write commits and purges after this read saw the old data
+		if calls == 1 {
+			cache.Purge()
+		}
+	}))
+
+	if rr := serveCached(handler, "GET", "/recipes", nil); rr.Code != http.StatusOK {
+		t.Fatalf("expected the read to be answered, got %d", rr.Code)
+	}
+	if rr := serveCached(handler, "GET", "/recipes", nil); rr.Header().Get("X-Cache") != "MISS" || calls != 2 {
+		t.Errorf("expected a read overtaken by a purge not to be stored, got %q after %d calls", rr.Header().Get("X-Cache"), calls)
+	}
+	if cache.Stats().Stores != 1 {
+		t.Errorf("expected only the second read stored, got %d", cache.Stats().Stores)
+	}
+}
+
+func TestResponseCacheLastModified(t *testing.T) {
+	cache := NewResponseCache(10, 1<<20, 0)
+	updated := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	handler := cache.Cached(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/recipe/id/1" {
+			w.Header().Set("Last-Modified", updated.Format(http.TimeFormat))
+		}
+		w.Write([]byte("{}"))
+	}))
+
+	own := serveCached(handler, "GET", "/recipe/id/1", nil)
+	if own.Header().Get("Last-Modified") != updated.Format(http.TimeFormat) {
+		t.Errorf("expected the handler's Last-Modified, got %q", own.Header().Get("Last-Modified"))
+	}
+	since := map[string]string{"If-Modified-Since": updated.Add(time.Hour).Format(http.TimeFormat)}
+	if rr := serveCached(handler, "GET", "/recipe/id/1", since); rr.Code != http.StatusNotModified {
+		t.Errorf("expected 304 after the handler's date, got %d", rr.Code)
+	}
+
+	// Without one it is the last purge, which a later fill keeps
+	cache.Purge()
+	purged := serveCached(handler, "GET", "/recipes", nil).Header().Get("Last-Modified")
+	time.Sleep(1100 * time.Millisecond)
+	cache.entries.Purge()
+	if refilled := serveCached(handler, "GET", "/recipes", nil).Header().Get("Last-Modified"); refilled != purged {
+		t.Errorf("expected Last-Modified to stay at the purge, got %q then %q", purged, refilled)
+	}
+}
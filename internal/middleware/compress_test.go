@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	cases := map[string]string{
+		"":                          "",
+		"identity":                  "",
+		"gzip":                      "gzip",
+		"gzip, br":                  "br",
+		"gzip, deflate, br, zstd":   "zstd",
+		"br;q=0.5, gzip":            "gzip",
+		"zstd;q=0, br;q=0.1":        "br",
+		"*":                         "zstd",
+		"*;q=0.2, gzip;q=0.8":       "gzip",
+		"gzip;q=0":                  "",
+		"GZIP":                      "gzip",
+		"gzip;q=oops, br;q=0.3":     "br",
+		"*;q=0, identity":           "",
+		" br ; q=1 , gzip ; q=0.9 ": "br",
+	}
+	for header, expected := range cases {
+		if got := negotiateEncoding(header); got != expected {
+			t.Errorf("%q: expected %q, got %q", header, expected, got)
+		}
+	}
+}
+
+func decode(t *testing.T, encoding string, body []byte) string {
+	t.Helper()
+	var reader io.Reader
+	switch encoding {
+	case "gzip":
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = zr
+	case "br":
+		reader = brotli.NewReader(bytes.NewReader(body))
+	case "zstd":
+		zr, err := zstd.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer zr.Close()
+		reader = zr
+	default:
+		return string(body)
+	}
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("decoding %s: %v", encoding, err)
+	}
+	return string(decoded)
+}
+
+func TestCompressRoundTrip(t *testing.T) {
+	payload := `{"recipes":[` + strings.Repeat(`{"name":"Pancakes"},`, 200) + `{}]}`
+	handler := Compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"abc"`)
+		// Written in pieces to cross the buffering threshold midway
+		for i := 0; i < len(payload); i += 100 {
+			w.Write([]byte(payload[i:min(i+100, len(payload))]))
+		}
+	}))
+
+	for _, encoding := range []string{"gzip", "br", "zstd"} {
+		req := httptest.NewRequest("GET", "/recipes", nil)
+		req.Header.Set("Accept-Encoding", encoding)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if got := rr.Header().Get("Content-Encoding"); got != encoding {
+			t.Fatalf("expected Content-Encoding %s, got %q", encoding, got)
+		}
+		if rr.Header().Get("Vary") != "Accept-Encoding" {
+			t.Errorf("%s: expected Vary: Accept-Encoding, got %q", encoding, rr.Header().Get("Vary"))
+		}
+		if rr.Header().Get("ETag") != `W/"abc"` {
+			t.Errorf("%s: expected a weak ETag, got %q", encoding, rr.Header().Get("ETag"))
+		}
+		if rr.Body.Len() >= len(payload) {
+			t.Errorf("%s: body not smaller (%d >= %d)", encoding, rr.Body.Len(), len(payload))
+		}
+		if got := decode(t, encoding, rr.Body.Bytes()); got != payload {
+			t.Errorf("%s: round trip mismatch", encoding)
+		}
+	}
+}
+
+func TestCompressSkips(t *testing.T) {
+	large := strings.Repeat("a", 4096)
+	cases := []struct {
+		name        string
+		contentType string
+		status      int
+		body        string
+		encoded     bool
+	}{
+		{"small body", "application/json", http.StatusOK, `{"ok":true}`, false},
+		{"binary", "image/png", http.StatusOK, large, false},
+		{"event stream", "text/event-stream", http.StatusOK, large, false},
+		{"no content", "application/json", http.StatusNoContent, "", false},
+		{"error", "text/plain; charset=utf-8", http.StatusNotFound, large, true},
+		{"sniffed html", "", http.StatusOK, "<!DOCTYPE html>" + large, true},
+	}
+	for _, c := range cases {
+		handler := Compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if c.contentType != "" {
+				w.Header().Set("Content-Type", c.contentType)
+			}
+			w.WriteHeader(c.status)
+			io.WriteString(w, c.body)
+		}))
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != c.status {
+			t.Errorf("%s: expected status %d, got %d", c.name, c.status, rr.Code)
+		}
+		encoded := rr.Header().Get("Content-Encoding") == "gzip"
+		if encoded != c.encoded {
+			t.Errorf("%s: expected encoded %v, got %v", c.name, c.encoded, encoded)
+		}
+		if got := decode(t, rr.Header().Get("Content-Encoding"), rr.Body.Bytes()); got != c.body {
+			t.Errorf("%s: body changed", c.name)
+		}
+	}
+
+	// Without Accept-Encoding nothing changes
+	handler := Compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, large)
+	}))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if rr.Header().Get("Content-Encoding") != "" || rr.Body.String() != large {
+		t.Errorf("expected an identity response without Accept-Encoding")
+	}
+}
+
+func TestCompressFlush(t *testing.T) {
+	handler := Compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, "first")
+		w.(http.Flusher).Flush()
+		io.WriteString(w, " second")
+	}))
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if !rr.Flushed {
+		t.Error("expected the flush to reach the client")
+	}
+	if rr.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected a flushed response to be compressed, got %q", rr.Header().Get("Content-Encoding"))
+	}
+	if got := decode(t, "gzip", rr.Body.Bytes()); got != "first second" {
+		t.Errorf("expected %q, got %q", "first second", got)
+	}
+}
@@ -2,19 +2,152 @@ package middleware
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
 )
 
-// Middleware for setting CORs flags
-func CorsMiddleware(next http.Handler, FrontendURL string) http.Handler {
+// Methods checked against the router when answering a preflight request
+var corsCandidateMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+// CORS policy loaded from config
+type CorsPolicy struct {
+	AllowedOrigins   []string // exact origins, "*" or wildcard subdomains e.g. https://*.example.com
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration // how long browsers may cache a preflight response
+}
+
+// Constructor with the headers the frontend currently sends
+func NewCorsPolicy(allowedOrigins ...string) *CorsPolicy {
+	return &CorsPolicy{
+		AllowedOrigins: allowedOrigins,
+		AllowedHeaders: []string{"Content-Type", "Authorization"},
+	}
+}
+
+// Check an Origin header against the allow-list
+func (policy *CorsPolicy) AllowsOrigin(origin string) bool {
+	_, ok := policy.allowOrigin(origin)
+	return ok
+}
+
+// Access-Control-Allow-Origin for an Origin header. Listed origins are
+// echoed back; "*" only ever answers with a literal "*", which browsers
+// refuse for credentialed requests.
+func (policy *CorsPolicy) allowOrigin(origin string) (string, bool) {
+	if origin == "" {
+		return "", false
+	}
+	anyOrigin := false
+	for _, allowed := range policy.AllowedOrigins {
+		if allowed == "*" {
+			anyOrigin = true
+		} else if matchOrigin(allowed, origin) {
+			return origin, true
+		}
+	}
+	if anyOrigin {
+		return "*", true
+	}
+	return "", false
+}
+
+// Match a single allow-list entry, supporting "scheme://*.domain" patterns
+func matchOrigin(pattern, origin string) bool {
+	if strings.EqualFold(pattern, origin) {
+		return true
+	}
+
+	prefix, suffix, found := strings.Cut(pattern, "*")
+	if !found || !strings.HasPrefix(suffix, ".") {
+		return false
+	}
+	origin = strings.ToLower(origin)
+	prefix = strings.ToLower(prefix)
+	suffix = strings.ToLower(suffix)
+	if !strings.HasPrefix(origin, prefix) || !strings.HasSuffix(origin, suffix) {
+		return false
+	}
+	// Wildcard must cover at least one subdomain label
+	subdomain := origin[len(prefix) : len(origin)-len(suffix)]
+	return subdomain != "" && !strings.ContainsAny(subdomain, "/:")
+}
+
+// Methods the router will accept for the requested path
+func allowedMethods(router *mux.Router, r *http.Request) []string {
+	var methods []string
+	for _, method := range corsCandidateMethods {
+		probe := r.Clone(r.Context())
+		probe.Method = method
+
+		var match mux.RouteMatch
+		if router.Match(probe, &match) && match.MatchErr == nil {
+			methods = append(methods, method)
+		}
+	}
+	return methods
+}
+
+// Middleware for setting CORs flags, wrapping the router so preflights can
+// report the methods registered for each route
+func (policy *CorsPolicy) CorsMiddleware(router *mux.Router) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// TODO: Edit CORs settings in future
-		w.Header().Set("Access-Control-Allow-Origin", FrontendURL)
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
+		w.Header().Add("Vary", "Origin")
+
+		origin := r.Header.Get("Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+		allowOrigin, ok := policy.allowOrigin(origin)
+		if !ok {
+			if preflight {
+				http.Error(w, "Origin not allowed", http.StatusForbidden)
+				return
+			}
+			router.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", allowOrigin)
+		if policy.AllowCredentials && allowOrigin != "*" {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if len(policy.ExposedHeaders) > 0 {
+				w.Header().Set("Access-Control-Expose-Headers", strings.Join(policy.ExposedHeaders, ", "))
+			}
+			router.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Access-Control-Request-Method")
+		w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+		methods := allowedMethods(router, r)
+		if len(methods) == 0 {
+			http.NotFound(w, r)
 			return
 		}
-		next.ServeHTTP(w, r)
+		methods = append(methods, http.MethodOptions)
+
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+		if len(policy.AllowedHeaders) > 0 {
+			w.Header().Set("Access-Control-Allow-Headers", strings.Join(policy.AllowedHeaders, ", "))
+		}
+		if policy.MaxAge > 0 {
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(policy.MaxAge.Seconds())))
+		}
+		w.WriteHeader(http.StatusNoContent)
 	})
 }
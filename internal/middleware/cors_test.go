@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func newCorsTestRouter() *mux.Router {
+	router := mux.NewRouter()
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+	router.HandleFunc("/recipe/id/{id}", ok).Methods("GET", "PUT", "DELETE")
+	router.HandleFunc("/recipe/add", ok).Methods("POST")
+	return router
+}
+
+func TestCorsOriginPatterns(t *testing.T) {
+	policy := NewCorsPolicy("https://app.example.com", "https://*.recipes.dev")
+
+	cases := map[string]bool{
+		"https://app.example.com":     true,
+		"https://APP.example.com":     true,
+		"https://evil.example.com":    false,
+		"https://a.recipes.dev":       true,
+		"https://a.b.recipes.dev":     true,
+		"https://recipes.dev":         false,
+		"http://a.recipes.dev":        false,
+		"https://a.recipes.dev.evil":  false,
+		"https://x.evil/.recipes.dev": false,
+		"":                            false,
+	}
+	for origin, expected := range cases {
+		if got := policy.AllowsOrigin(origin); got != expected {
+			t.Errorf("origin %q: expected %v, got %v", origin, expected, got)
+		}
+	}
+}
+
+func TestCorsEchoesMatchedOrigin(t *testing.T) {
+	policy := NewCorsPolicy("https://*.example.com")
+	policy.AllowCredentials = true
+	policy.ExposedHeaders = []string{"Location"}
+	handler := policy.CorsMiddleware(newCorsTestRouter())
+
+	req := httptest.NewRequest(http.MethodGet, "/recipe/id/1", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Fatalf("expected origin to be echoed, got %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Fatalf("expected credentials header, got %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Expose-Headers"); got != "Location" {
+		t.Fatalf("expected exposed headers, got %q", got)
+	}
+	if got := w.Header().Get("Vary"); got != "Origin" {
+		t.Fatalf("expected Vary: Origin, got %q", got)
+	}
+}
+
+func TestCorsAnyOriginIsLiteral(t *testing.T) {
+	policy := NewCorsPolicy("https://app.example.com", "*")
+	policy.AllowCredentials = true
+	handler := policy.CorsMiddleware(newCorsTestRouter())
+
+	cases := map[string]string{
+		"https://app.example.com": "https://app.example.com",
+		"https://evil.example":    "*",
+	}
+	for origin, expected := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/recipe/id/1", nil)
+		req.Header.Set("Origin", origin)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != expected {
+			t.Errorf("%s: expected allow origin %q, got %q", origin, expected, got)
+		}
+		credentials := w.Header().Get("Access-Control-Allow-Credentials") == "true"
+		if credentials != (expected != "*") {
+			t.Errorf("%s: expected credentials only for listed origins, got %v", origin, credentials)
+		}
+	}
+}
+
+func TestCorsRejectsUnknownOrigin(t *testing.T) {
+	handler := NewCorsPolicy("https://app.example.com").CorsMiddleware(newCorsTestRouter())
+
+	// Simple requests pass through without CORS headers
+	req := httptest.NewRequest(http.MethodGet, "/recipe/id/1", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Fatalf("expected no allow origin header, got %q", got)
+	}
+
+	// Preflights are refused
+	req = httptest.NewRequest(http.MethodOptions, "/recipe/id/1", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	req.Header.Set("Access-Control-Request-Method", "DELETE")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", w.Code)
+	}
+}
+
+func TestCorsPreflightRouteMethods(t *testing.T) {
+	policy := NewCorsPolicy("https://app.example.com")
+	policy.MaxAge = 10 * time.Minute
+	handler := policy.CorsMiddleware(newCorsTestRouter())
+
+	req := httptest.NewRequest(http.MethodOptions, "/recipe/id/1", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "PUT")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", w.Code)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Methods"); got != "GET, PUT, DELETE, OPTIONS" {
+		t.Fatalf("unexpected allowed methods %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Max-Age"); got != "600" {
+		t.Fatalf("expected max age 600, got %q", got)
+	}
+
+	// Methods differ per route
+	req = httptest.NewRequest(http.MethodOptions, "/recipe/add", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	w = httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Access-Control-Allow-Methods"); got != "POST, OPTIONS" {
+		t.Fatalf("unexpected allowed methods %q", got)
+	}
+
+	// Unknown routes are not found
+	req = httptest.NewRequest(http.MethodOptions, "/nothing", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	w = httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+}
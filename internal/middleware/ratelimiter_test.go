@@ -88,3 +88,34 @@ func TestRateLimiterBlockOnLimitSet(t *testing.T) {
 		t.Fatalf("expected rate limit response, got %d", w.Code)
 	}
 }
+
+func TestRateLimiterLimitsPatch(t *testing.T) {
+	limiter := &RateLimiter{
+		Put: rate.NewLimiter(rate.Limit(1), 1),
+	}
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	handler := limiter.RateLimitMiddleware(next)
+
+	// PATCH shares the PUT limit
+	req := httptest.NewRequest(http.MethodPut, "/", nil)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected first request to succeed, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPatch, "/", nil)
+	w = httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected PATCH to be rate limited, got %d", w.Code)
+	}
+}
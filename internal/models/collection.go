@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Who can see a collection
+const (
+	VisibilityPrivate = "private" // only the owner
+	VisibilityPublic  = "public"  // anyone, and listed publicly
+	VisibilityLink    = "link"    // anyone with the share token
+)
+
+var Visibilities = []string{VisibilityPrivate, VisibilityPublic, VisibilityLink}
+
+// Named, ordered group of recipes owned by a user
+type Collection struct {
+	CollectionID int               `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID       string            `gorm:"type:varchar(32);not null;index" json:"userID"`
+	Name         string            `gorm:"not null" json:"name"`
+	Description  *string           `json:"description,omitempty"` //optional
+	Visibility   string            `gorm:"type:varchar(8);not null;default:private" json:"visibility"`
+	ShareToken   *string           `gorm:"type:varchar(64);uniqueIndex" json:"shareToken,omitempty"` // set while shared by link
+	CreatedAt    time.Time         `json:"createdAt"`
+	Entries      []CollectionEntry `gorm:"foreignKey:CollectionID" json:"entries,omitempty"`
+}
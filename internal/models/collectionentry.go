@@ -0,0 +1,10 @@
+package models
+
+// Recipe in a collection at a position
+type CollectionEntry struct {
+	CollectionEntryID int     `gorm:"primaryKey;autoIncrement" json:"id"`
+	CollectionID      int     `gorm:"not null;uniqueIndex:idx_collection_recipe" json:"collection_id"`
+	RecipeID          int     `gorm:"not null;uniqueIndex:idx_collection_recipe;index" json:"recipe_id"`
+	Position          int     `gorm:"not null" json:"position"`
+	Recipe            *Recipe `gorm:"foreignKey:RecipeID;references:RecipeID" json:"recipe,omitempty"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Guided cooking of a recipe, one step at a time
+type CookSession struct {
+	CookSessionID int          `gorm:"primaryKey;autoIncrement" json:"id"`
+	RecipeID      int          `gorm:"not null;index" json:"recipe_id"`
+	UserID        string       `gorm:"type:varchar(32);not null;index" json:"userID"`
+	CurrentStep   int          `gorm:"not null" json:"currentStep"` // an Instruction.StepNumber
+	CreatedAt     time.Time    `json:"createdAt"`
+	UpdatedAt     time.Time    `json:"updatedAt"`
+	Timers        []CookTimer  `gorm:"foreignKey:CookSessionID" json:"timers,omitempty"`
+	Step          *Instruction `gorm:"-" json:"step,omitempty"` // the current step, attached on read
+	TotalSteps    int          `gorm:"-" json:"totalSteps"`     // attached on read
+}
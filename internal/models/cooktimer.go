@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Countdown started from a step's duration during a cook session
+type CookTimer struct {
+	CookTimerID   int        `gorm:"primaryKey;autoIncrement" json:"id"`
+	CookSessionID int        `gorm:"not null;index" json:"session_id"`
+	StepNumber    int        `gorm:"not null" json:"stepNumber"`
+	Seconds       int        `gorm:"not null" json:"seconds"`
+	StartedAt     time.Time  `json:"startedAt"`
+	EndsAt        time.Time  `gorm:"index" json:"endsAt"`
+	FiredAt       *time.Time `json:"firedAt,omitempty"`     // set when the timer ran out
+	CancelledAt   *time.Time `json:"cancelledAt,omitempty"` // set when the cook stopped it
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Manual diet flags on a recipe, true to always claim a diet and false to
+// never claim it. Stored as ",+vegan,-gluten-free," so flags can be matched with LIKE.
+type DietOverrides map[string]bool
+
+func (overrides DietOverrides) Value() (driver.Value, error) {
+	if len(overrides) == 0 {
+		return "", nil
+	}
+	entries := make([]string, 0, len(overrides))
+	for diet, allowed := range overrides {
+		sign := "-"
+		if allowed {
+			sign = "+"
+		}
+		entries = append(entries, sign+diet)
+	}
+	sort.Strings(entries)
+	return "," + strings.Join(entries, ",") + ",", nil
+}
+
+func (overrides *DietOverrides) Scan(value any) error {
+	var list StringList
+	if err := list.Scan(value); err != nil {
+		return fmt.Errorf("cannot scan %T into DietOverrides", value)
+	}
+
+	*overrides = nil
+	for _, entry := range list {
+		if *overrides == nil {
+			*overrides = make(DietOverrides)
+		}
+		(*overrides)[entry[1:]] = entry[0] == '+'
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Persisted domain event, replayed to clients resuming the change feed
+type Event struct {
+	EventID   int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	Type      string    `gorm:"type:varchar(32);not null;index" json:"type"`
+	RecipeID  int       `gorm:"not null;index" json:"recipe_id"`
+	UserID    string    `gorm:"type:varchar(32);not null;index" json:"userID"` // owner of the recipe
+	Data      string    `gorm:"type:text;not null" json:"-"`                   // JSON payload
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Encode and decode with the payload inlined as JSON rather than a string
+func (event Event) MarshalJSON() ([]byte, error) {
+	type plain Event
+	data := json.RawMessage(event.Data)
+	if len(data) == 0 {
+		data = json.RawMessage("null")
+	}
+	return json.Marshal(struct {
+		plain
+		Data json.RawMessage `json:"data"`
+	}{plain(event), data})
+}
+
+func (event *Event) UnmarshalJSON(data []byte) error {
+	type plain Event
+	var decoded struct {
+		plain
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*event = Event(decoded.plain)
+	event.Data = string(decoded.Data)
+	return nil
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Recipe a user has marked as a favourite
+type Favourite struct {
+	FavouriteID int       `gorm:"primaryKey;autoIncrement" json:"id"`
+	RecipeID    int       `gorm:"not null;uniqueIndex:idx_favourite_recipe_user" json:"recipe_id"`
+	UserID      string    `gorm:"type:varchar(32);not null;uniqueIndex:idx_favourite_recipe_user;index" json:"userID"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Uploaded picture of a recipe, or of one of its steps when StepNumber is set.
+// Step photos follow the step number so they survive instructions being rebuilt.
+type Image struct {
+	ImageID     int               `gorm:"primaryKey;autoIncrement" json:"id"`
+	RecipeID    int               `gorm:"not null;index" json:"recipe_id"`
+	StepNumber  *int              `json:"stepNumber,omitempty"` // nil for the recipe's hero image
+	Key         string            `gorm:"type:varchar(255);not null;uniqueIndex" json:"-"`
+	ContentType string            `gorm:"type:varchar(32);not null" json:"contentType"`
+	Width       int               `json:"width"`
+	Height      int               `json:"height"`
+	Size        int64             `json:"size"`
+	Thumbnails  StringList        `gorm:"type:varchar(255)" json:"-"` // thumbnail sizes stored with the original
+	URLs        map[string]string `gorm:"-" json:"urls,omitempty"`    // computed on read
+	CreatedAt   time.Time         `json:"createdAt"`
+}
@@ -2,6 +2,10 @@ package models
 
 // Single Ingredient
 type Ingredient struct {
-	IngredientID int    `gorm:"primaryKey;autoIncrement" json:"id"`
-	Label        string `gorm:"type:varchar(32);not null" json:"label"`
+	IngredientID int        `gorm:"primaryKey;autoIncrement" json:"id"`
+	Label        string     `gorm:"type:varchar(32);not null" json:"label"`
+	Category     *string    `gorm:"type:varchar(32)" json:"category,omitempty"`   // shop aisle, optional
+	Allergens    StringList `gorm:"type:varchar(255)" json:"allergens,omitempty"` // e.g. gluten, milk, nuts
+	Animal       *string    `gorm:"type:varchar(16)" json:"animal,omitempty"`     // animal product it is, if any: meat, fish, dairy, egg, honey
+	DietChecked  bool       `gorm:"not null;default:false" json:"dietChecked"`    // allergens and animal product are known; unchecked ingredients block diet flags
 }
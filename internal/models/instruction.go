@@ -6,6 +6,8 @@ type Instruction struct {
 	RecipeID      int     `gorm:"not null;index" json:"recipe_id"`
 	StepNumber    int     `gorm:"not null;check:step_number>0" json:"stepNumber"`
 	StepText      string  `json:"stepText"`
-	Duration      *int    `json:"stepTime,omitempty"` //optional
-	Notes         *string `json:"notes,omitempty"`    //optional
+	Duration      *int    `json:"stepTime,omitempty"`                     //optional, minutes
+	Phase         *string `gorm:"type:varchar(8)" json:"phase,omitempty"` // prep, cook or rest
+	Notes         *string `json:"notes,omitempty"`                        //optional
+	Photo         *Image  `gorm:"-" json:"photo,omitempty"`               // attached on read
 }
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// Weekly meal plan owned by a user
+type MealPlan struct {
+	MealPlanID int             `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID     string          `gorm:"type:varchar(32);not null;index" json:"userID"`
+	Name       string          `json:"name"`
+	WeekStart  time.Time       `gorm:"not null" json:"weekStart"` // Monday of the planned week
+	Entries    []MealPlanEntry `gorm:"foreignKey:MealPlanID" json:"entries,omitempty"`
+}
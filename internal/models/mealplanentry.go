@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Meal slots within a day
+const (
+	SlotBreakfast = "breakfast"
+	SlotLunch     = "lunch"
+	SlotDinner    = "dinner"
+	SlotSnack     = "snack"
+)
+
+var MealSlots = []string{SlotBreakfast, SlotLunch, SlotDinner, SlotSnack}
+
+// Single planned meal
+type MealPlanEntry struct {
+	MealPlanEntryID int       `gorm:"primaryKey;autoIncrement" json:"id"`
+	MealPlanID      int       `gorm:"not null;index" json:"mealPlan_id"`
+	Date            time.Time `gorm:"not null" json:"date"`
+	Slot            string    `gorm:"type:varchar(16);not null" json:"slot"`
+	RecipeID        int       `gorm:"not null;index" json:"recipe_id"`
+	Servings        int       `gorm:"not null;default:1" json:"servings"`
+	Recipe          *Recipe   `gorm:"foreignKey:RecipeID;references:RecipeID" json:"recipe,omitempty"`
+}
@@ -0,0 +1,44 @@
+package models
+
+// Bases nutrition facts can be given on
+const (
+	NutritionPer100g = "100g"
+	NutritionPerUnit = "unit"
+)
+
+// Nutrient amounts: energy in kcal, sodium in mg, the rest in grams
+type NutritionFacts struct {
+	Calories float64 `json:"calories"`
+	Protein  float64 `json:"protein"`
+	Fat      float64 `json:"fat"`
+	Carbs    float64 `json:"carbs"`
+	Fibre    float64 `json:"fibre"`
+	Sugar    float64 `json:"sugar"`
+	Sodium   float64 `json:"sodium"`
+}
+
+// Nutrition facts for one ingredient, per 100 g or per unit (one egg, one clove)
+type Nutrition struct {
+	IngredientID int            `gorm:"primaryKey;autoIncrement:false" json:"ingredient_id"`
+	Basis        string         `gorm:"type:varchar(8);not null;default:100g" json:"basis"`
+	GramsPerUnit *float64       `json:"gramsPerUnit,omitempty"` // optional, weight of one unit
+	Density      *float64       `json:"density,omitempty"`      // optional, grams per millilitre
+	Facts        NutritionFacts `gorm:"embedded" json:"facts"`
+	Source       string         `gorm:"type:varchar(64)" json:"source,omitempty"`
+}
+
+// Ingredient left out of a recipe's nutrition and why
+type UnresolvedIngredient struct {
+	IngredientID int    `json:"ingredient_id"`
+	Label        string `json:"label"`
+	Reason       string `json:"reason"`
+}
+
+// Computed nutrition for a recipe, not stored
+type NutritionSummary struct {
+	Servings   int                    `json:"servings"`
+	PerServing NutritionFacts         `json:"perServing"`
+	Total      NutritionFacts         `json:"total"`
+	Complete   bool                   `json:"complete"` // every ingredient was resolved
+	Unresolved []UnresolvedIngredient `json:"unresolved,omitempty"`
+}
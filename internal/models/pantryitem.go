@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Ingredient a user has in stock
+type PantryItem struct {
+	PantryItemID int         `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID       string      `gorm:"type:varchar(32);not null;index" json:"userID"`
+	IngredientID int         `gorm:"not null;index" json:"ingredient_id"`
+	Quantity     float64     `gorm:"not null" json:"quantity"`
+	UnitID       *int        `gorm:"index" json:"unit_id,omitempty"`   //optional
+	ExpiresAt    *time.Time  `gorm:"index" json:"expiresAt,omitempty"` //optional
+	Ingredient   *Ingredient `gorm:"foreignKey:IngredientID;references:IngredientID" json:"ingredient"`
+	Unit         *Unit       `gorm:"foreignKey:UnitID;references:UnitID" json:"unit,omitempty"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Star rating of a recipe with an optional review, one per user per recipe
+type Rating struct {
+	RatingID  int       `gorm:"primaryKey;autoIncrement" json:"id"`
+	RecipeID  int       `gorm:"not null;uniqueIndex:idx_rating_recipe_user" json:"recipe_id"`
+	UserID    string    `gorm:"type:varchar(32);not null;uniqueIndex:idx_rating_recipe_user;index" json:"userID"`
+	Stars     int       `gorm:"not null;check:stars BETWEEN 1 AND 5" json:"stars"`
+	Review    *string   `json:"review,omitempty"` //optional
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
@@ -1,12 +1,26 @@
 package models
 
+import "time"
+
 // Main recipe model
 type Recipe struct {
-	RecipeID     int                `gorm:"primaryKey;autoIncrement" json:"id"`
-	Name         string             `gorm:"unique" json:"name"`
-	Difficulty   int                `json:"difficulty"`
-	Description  *string            `json:"description,omitempty"` //optional
-	Ingredients  []RecipeIngredient `gorm:"foreignKey:RecipeID" json:"ingredients,omitempty"`
-	Instructions []Instruction      `gorm:"foreignKey:RecipeID" json:"instructions,omitempty"`
-	UserID       string             `gorm:"type:varchar(32);not null" json:"userID"`
+	RecipeID      int                `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name          string             `gorm:"unique" json:"name"`
+	Difficulty    int                `json:"difficulty"`
+	Description   *string            `json:"description,omitempty"` //optional
+	Servings      *int               `json:"servings,omitempty"`    //optional
+	Ingredients   []RecipeIngredient `gorm:"foreignKey:RecipeID" json:"ingredients,omitempty"`
+	Instructions  []Instruction      `gorm:"foreignKey:RecipeID" json:"instructions,omitempty"`
+	Tags          []Tag              `gorm:"many2many:recipe_tags;joinForeignKey:RecipeID;joinReferences:TagID" json:"tags,omitempty"`
+	UserID        string             `gorm:"type:varchar(32);not null" json:"userID"`
+	UpdatedAt     time.Time          `json:"updatedAt"`                                        // moved on by any change to what the recipe shows
+	RatingAverage float64            `gorm:"not null;default:0" json:"ratingAverage"`          // maintained from ratings
+	RatingCount   int                `gorm:"not null;default:0" json:"ratingCount"`            // maintained from ratings
+	DietOverrides DietOverrides      `gorm:"type:varchar(255)" json:"dietOverrides,omitempty"` // manual flags, win over derived diets
+	Diets         []string           `gorm:"-" json:"diets,omitempty"`                         // computed on read
+	Allergens     []string           `gorm:"-" json:"allergens,omitempty"`                     // computed on read
+	Unverified    []string           `gorm:"-" json:"unverified,omitempty"`                    // computed on read, ingredients with unknown allergens
+	Nutrition     *NutritionSummary  `gorm:"-" json:"nutrition,omitempty"`                     // computed on read
+	Image         *Image             `gorm:"-" json:"image,omitempty"`                         // hero image, attached on read
+	Timing        *Timing            `gorm:"-" json:"timing,omitempty"`                        // computed on read from step times
 }
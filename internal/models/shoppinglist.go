@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// Saved shopping list owned by a user
+type ShoppingList struct {
+	ShoppingListID int                `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID         string             `gorm:"type:varchar(32);not null;index" json:"userID"`
+	Name           string             `json:"name"`
+	CreatedAt      time.Time          `json:"createdAt"`
+	Items          []ShoppingListItem `gorm:"foreignKey:ShoppingListID" json:"items,omitempty"`
+}
@@ -0,0 +1,14 @@
+package models
+
+// Single line of a shopping list. Labels are copied so a saved list reads the
+// same after the ingredient catalogue changes.
+type ShoppingListItem struct {
+	ShoppingListItemID int      `gorm:"primaryKey;autoIncrement" json:"id"`
+	ShoppingListID     int      `gorm:"not null;index" json:"shoppingList_id"`
+	IngredientID       int      `gorm:"index" json:"ingredient_id"`
+	Label              string   `json:"label"`
+	Category           string   `json:"category"`
+	Amount             *float64 `json:"amount,omitempty"` //optional
+	Unit               string   `json:"unit,omitempty"`   //optional
+	Checked            bool     `json:"checked"`
+}
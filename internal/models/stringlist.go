@@ -0,0 +1,39 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+)
+
+// List of short labels stored in one column as ",a,b," so a single entry can
+// be matched with LIKE '%,a,%' on any database
+type StringList []string
+
+func (list StringList) Value() (driver.Value, error) {
+	if len(list) == 0 {
+		return "", nil
+	}
+	return "," + strings.Join(list, ",") + ",", nil
+}
+
+func (list *StringList) Scan(value any) error {
+	var stored string
+	switch v := value.(type) {
+	case nil:
+	case string:
+		stored = v
+	case []byte:
+		stored = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into StringList", value)
+	}
+
+	*list = nil
+	for _, entry := range strings.Split(strings.Trim(stored, ","), ",") {
+		if entry != "" {
+			*list = append(*list, entry)
+		}
+	}
+	return nil
+}
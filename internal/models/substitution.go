@@ -0,0 +1,11 @@
+package models
+
+// Swap for an ingredient, made of one or more replacement ingredients
+type Substitution struct {
+	SubstitutionID int                       `gorm:"primaryKey;autoIncrement" json:"id"`
+	IngredientID   int                       `gorm:"not null;index" json:"ingredient_id"`    // ingredient being replaced
+	Diet           *string                   `gorm:"type:varchar(16)" json:"diet,omitempty"` // optional, diet the swap is for
+	Notes          *string                   `json:"notes,omitempty"`                        //optional
+	Ingredient     *Ingredient               `gorm:"foreignKey:IngredientID;references:IngredientID" json:"ingredient,omitempty"`
+	Replacements   []SubstitutionReplacement `gorm:"foreignKey:SubstitutionID" json:"replacements"`
+}
@@ -0,0 +1,13 @@
+package models
+
+// Ingredient used in place of another, scaled by Ratio.
+// Without a unit the replacement is measured in the original's unit.
+type SubstitutionReplacement struct {
+	SubstitutionReplacementID int         `gorm:"primaryKey;autoIncrement" json:"id"`
+	SubstitutionID            int         `gorm:"not null;index" json:"substitution_id"`
+	IngredientID              int         `gorm:"not null;index" json:"ingredient_id"`
+	Ratio                     float64     `gorm:"not null;default:1" json:"ratio"` // amount per unit of the original
+	UnitID                    *int        `json:"unit_id,omitempty"`               //optional
+	Ingredient                *Ingredient `gorm:"foreignKey:IngredientID;references:IngredientID" json:"ingredient,omitempty"`
+	Unit                      *Unit       `gorm:"foreignKey:UnitID;references:UnitID" json:"unit,omitempty"`
+}
@@ -0,0 +1,19 @@
+package models
+
+// Kinds of tag. Free tags are created as they are used; the others come from
+// a controlled vocabulary managed through the tag endpoints.
+const (
+	TagFree     = "tag"
+	TagCourse   = "course"
+	TagCuisine  = "cuisine"
+	TagOccasion = "occasion"
+)
+
+var TagKinds = []string{TagFree, TagCourse, TagCuisine, TagOccasion}
+
+// Label attached to recipes
+type Tag struct {
+	TagID int    `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name  string `gorm:"type:varchar(32);not null;uniqueIndex:idx_tag_kind_name" json:"name"`
+	Kind  string `gorm:"type:varchar(16);not null;default:tag;uniqueIndex:idx_tag_kind_name" json:"kind"`
+}
@@ -0,0 +1,17 @@
+package models
+
+// Recipe times in minutes, each with its ISO 8601 duration (PT1H30M) as used
+// by schema.org
+type Timing struct {
+	PrepMinutes   int    `json:"prepMinutes"`
+	PrepTime      string `json:"prepTime"`
+	CookMinutes   int    `json:"cookMinutes"`
+	CookTime      string `json:"cookTime"`
+	RestMinutes   int    `json:"restMinutes"`
+	RestTime      string `json:"restTime"`
+	ActiveMinutes int    `json:"activeMinutes"` // prep and cook, the time someone is busy
+	ActiveTime    string `json:"activeTime"`
+	TotalMinutes  int    `json:"totalMinutes"`
+	TotalTime     string `json:"totalTime"`
+	Complete      bool   `json:"complete"` // every step has a duration
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Partner endpoint notified of recipe events
+type Webhook struct {
+	WebhookID int        `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    string     `gorm:"type:varchar(32);not null;index" json:"userID"` // owner
+	URL       string     `gorm:"not null" json:"url"`
+	Events    StringList `gorm:"type:varchar(255)" json:"events"`                   // event types, all when empty
+	Secret    string     `gorm:"type:varchar(64);not null" json:"secret,omitempty"` // only returned on creation
+	Active    bool       `gorm:"not null;default:true" json:"active"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Delivery states
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed" // gave up after the last retry
+)
+
+// Event queued for a webhook, written in the same transaction as the change
+// it reports so none are lost
+type WebhookDelivery struct {
+	DeliveryID    int              `gorm:"primaryKey;autoIncrement" json:"id"`
+	WebhookID     int              `gorm:"not null;index" json:"webhook_id"`
+	EventID       int64            `gorm:"not null;index" json:"event_id"`
+	EventType     string           `gorm:"type:varchar(32);not null" json:"eventType"`
+	Status        string           `gorm:"type:varchar(16);not null;index:idx_delivery_due" json:"status"`
+	Attempts      int              `gorm:"not null" json:"attempts"`
+	NextAttemptAt time.Time        `gorm:"index:idx_delivery_due" json:"nextAttemptAt"`
+	LastError     *string          `json:"lastError,omitempty"`
+	DeliveredAt   *time.Time       `json:"deliveredAt,omitempty"`
+	CreatedAt     time.Time        `json:"createdAt"`
+	History       []WebhookAttempt `gorm:"foreignKey:DeliveryID" json:"history,omitempty"`
+}
+
+// One try at sending a delivery
+type WebhookAttempt struct {
+	AttemptID  int       `gorm:"primaryKey;autoIncrement" json:"id"`
+	DeliveryID int       `gorm:"not null;index" json:"delivery_id"`
+	StatusCode *int      `json:"statusCode,omitempty"` // nil when no response came back
+	Error      *string   `json:"error,omitempty"`
+	DurationMS int64     `json:"durationMs"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
@@ -0,0 +1,122 @@
+package nutrition
+
+import (
+	"math"
+
+	"recipe-api/internal/measure"
+	"recipe-api/internal/models"
+)
+
+// Add facts scaled by factor
+func add(total *models.NutritionFacts, facts models.NutritionFacts, factor float64) {
+	total.Calories += facts.Calories * factor
+	total.Protein += facts.Protein * factor
+	total.Fat += facts.Fat * factor
+	total.Carbs += facts.Carbs * factor
+	total.Fibre += facts.Fibre * factor
+	total.Sugar += facts.Sugar * factor
+	total.Sodium += facts.Sodium * factor
+}
+
+// Round every nutrient to one decimal place
+func round(facts models.NutritionFacts) models.NutritionFacts {
+	r := func(value float64) float64 { return math.Round(value*10) / 10 }
+	return models.NutritionFacts{
+		Calories: r(facts.Calories),
+		Protein:  r(facts.Protein),
+		Fat:      r(facts.Fat),
+		Carbs:    r(facts.Carbs),
+		Fibre:    r(facts.Fibre),
+		Sugar:    r(facts.Sugar),
+		Sodium:   r(facts.Sodium),
+	}
+}
+
+// How many multiples of an ingredient's nutrition basis an amount is.
+// Returns a reason when the amount can't be converted.
+func Multiplier(amount float64, unit string, facts models.Nutrition) (float64, string) {
+	known, isKnown := measure.Lookup(unit)
+
+	// Grams of the amount, when it can be weighed
+	grams, weighed := 0.0, false
+	switch {
+	case isKnown && known.Dimension == measure.Mass:
+		grams, weighed = amount*known.Factor, true
+	case isKnown && known.Dimension == measure.Volume && facts.Density != nil:
+		grams, weighed = amount*known.Factor*(*facts.Density), true
+	case !isKnown && facts.GramsPerUnit != nil:
+		grams, weighed = amount*(*facts.GramsPerUnit), true
+	}
+
+	switch facts.Basis {
+	case models.NutritionPerUnit:
+		if !isKnown {
+			return amount, ""
+		}
+		if weighed && facts.GramsPerUnit != nil && *facts.GramsPerUnit > 0 {
+			return grams / *facts.GramsPerUnit, ""
+		}
+		return 0, "cannot convert " + unit + " to a count without grams per unit"
+	default:
+		if weighed {
+			return grams / 100, ""
+		}
+		if isKnown && known.Dimension == measure.Volume {
+			return 0, "cannot weigh " + unit + " without a density"
+		}
+		if unit == "" {
+			return 0, "no unit and no grams per unit"
+		}
+		return 0, "unknown unit " + unit
+	}
+}
+
+// Nutrition for a recipe from its ingredients' facts, keyed by ingredient ID.
+// Ingredients that can't be resolved are listed rather than silently dropped.
+func Compute(recipe models.Recipe, facts map[int]models.Nutrition) *models.NutritionSummary {
+	summary := &models.NutritionSummary{Servings: 1}
+	if recipe.Servings != nil && *recipe.Servings > 0 {
+		summary.Servings = *recipe.Servings
+	}
+
+	for _, ri := range recipe.Ingredients {
+		label := ""
+		if ri.Ingredient != nil {
+			label = ri.Ingredient.Label
+		}
+		unresolved := func(reason string) {
+			summary.Unresolved = append(summary.Unresolved, models.UnresolvedIngredient{
+				IngredientID: ri.IngredientID,
+				Label:        label,
+				Reason:       reason,
+			})
+		}
+
+		ingredientFacts, ok := facts[ri.IngredientID]
+		if !ok {
+			unresolved("no nutrition data")
+			continue
+		}
+		if ri.Amount == nil {
+			unresolved("no amount")
+			continue
+		}
+
+		unit := ""
+		if ri.Unit != nil {
+			unit = ri.Unit.Label
+		}
+		multiplier, reason := Multiplier(float64(*ri.Amount), unit, ingredientFacts)
+		if reason != "" {
+			unresolved(reason)
+			continue
+		}
+		add(&summary.Total, ingredientFacts.Facts, multiplier)
+	}
+
+	add(&summary.PerServing, summary.Total, 1/float64(summary.Servings))
+	summary.Total = round(summary.Total)
+	summary.PerServing = round(summary.PerServing)
+	summary.Complete = len(summary.Unresolved) == 0
+	return summary
+}
@@ -0,0 +1,251 @@
+package nutrition
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"recipe-api/internal/models"
+	"recipe-api/internal/repository"
+)
+
+// Header names accepted for each column, so USDA FoodData Central exports
+// and hand-written files both load
+var columnAliases = map[string][]string{
+	"label":          {"description", "name", "label", "food", "ingredient"},
+	"basis":          {"basis"},
+	"grams_per_unit": {"grams_per_unit", "portion_g", "gram_weight"},
+	"density":        {"density", "density_g_per_ml"},
+	"calories":       {"calories", "energy", "energy_kcal", "kcal"},
+	"protein":        {"protein", "protein_g"},
+	"fat":            {"fat", "fat_g", "total_fat", "total_lipid_fat"},
+	"carbs":          {"carbs", "carbohydrate", "carbohydrate_g", "carbohydrate_by_difference"},
+	"fibre":          {"fibre", "fiber", "fiber_g", "fibre_g", "fiber_total_dietary"},
+	"sugar":          {"sugar", "sugars", "sugars_g", "sugars_total"},
+	"sodium":         {"sodium", "sodium_mg", "sodium_na"},
+}
+
+// Nutrition facts read for a named food
+type Record struct {
+	Label     string
+	Nutrition models.Nutrition
+}
+
+// Outcome of an import
+type Report struct {
+	Matched   int
+	Created   int
+	Unmatched []string
+}
+
+func normaliseHeader(header string) string {
+	header = strings.ToLower(strings.TrimSpace(header))
+	header = strings.NewReplacer(" ", "_", "-", "_", "(", "", ")", "", ",", "").Replace(header)
+	return header
+}
+
+// Read nutrition records from CSV with a header row. Values are per 100 g
+// unless a basis column says "unit".
+func ParseCSV(r io.Reader) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = normaliseHeader(name)
+		for field, aliases := range columnAliases {
+			for _, alias := range aliases {
+				if _, taken := columns[field]; !taken && name == alias {
+					columns[field] = i
+				}
+			}
+		}
+	}
+	if _, ok := columns["label"]; !ok {
+		return nil, errors.New("CSV needs a description or name column")
+	}
+
+	var records []Record
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		get := func(field string) string {
+			if i, ok := columns[field]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+		number := func(field string) (float64, error) {
+			value := get(field)
+			if value == "" {
+				return 0, nil
+			}
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return 0, fmt.Errorf("line %d: invalid %s %q", line, field, value)
+			}
+			return parsed, nil
+		}
+		optional := func(field string) (*float64, error) {
+			if get(field) == "" {
+				return nil, nil
+			}
+			value, err := number(field)
+			return &value, err
+		}
+
+		record := Record{Label: get("label")}
+		if record.Label == "" {
+			continue
+		}
+
+		facts := &record.Nutrition.Facts
+		for field, target := range map[string]*float64{
+			"calories": &facts.Calories,
+			"protein":  &facts.Protein,
+			"fat":      &facts.Fat,
+			"carbs":    &facts.Carbs,
+			"fibre":    &facts.Fibre,
+			"sugar":    &facts.Sugar,
+			"sodium":   &facts.Sodium,
+		} {
+			if *target, err = number(field); err != nil {
+				return nil, err
+			}
+		}
+
+		record.Nutrition.Basis = models.NutritionPer100g
+		if strings.EqualFold(get("basis"), models.NutritionPerUnit) {
+			record.Nutrition.Basis = models.NutritionPerUnit
+		}
+		if record.Nutrition.GramsPerUnit, err = optional("grams_per_unit"); err != nil {
+			return nil, err
+		}
+		if record.Nutrition.Density, err = optional("density"); err != nil {
+			return nil, err
+		}
+
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// Candidate ingredient labels for a food description: "Carrots, raw" also
+// tries "carrots" and "carrot"
+func candidateLabels(description string) []string {
+	full := strings.ToLower(strings.Join(strings.Fields(description), " "))
+	first := strings.TrimSpace(strings.SplitN(full, ",", 2)[0])
+	candidates := []string{full, first}
+	if singular, found := strings.CutSuffix(first, "s"); found && singular != "" {
+		candidates = append(candidates, singular)
+	}
+	return candidates
+}
+
+// Store records against matching ingredients, replacing existing facts.
+// Unmatched foods create new ingredients when create is set.
+func Import(db *gorm.DB, records []Record, source string, create bool) (Report, error) {
+	var report Report
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var ingredients []models.Ingredient
+		if err := tx.Find(&ingredients).Error; err != nil {
+			return err
+		}
+		byLabel := make(map[string]int, len(ingredients))
+		for _, ingredient := range ingredients {
+			byLabel[strings.ToLower(repository.CanonicalLabel(ingredient.Label))] = ingredient.IngredientID
+		}
+
+		var imported []int
+		for _, record := range records {
+			ingredientID := 0
+			for _, candidate := range candidateLabels(record.Label) {
+				if id, ok := byLabel[candidate]; ok {
+					ingredientID = id
+					break
+				}
+			}
+
+			if ingredientID == 0 {
+				if !create {
+					report.Unmatched = append(report.Unmatched, record.Label)
+					continue
+				}
+				// Shortened labels can name an ingredient created for an
+				// earlier record, so go through the usual lookup
+				label := truncate(repository.CanonicalLabel(strings.SplitN(record.Label, ",", 2)[0]), 32)
+				ingredient, err := repository.FirstOrCreateIngredient(tx, &models.Ingredient{Label: label})
+				if err != nil {
+					return err
+				}
+				ingredientID = ingredient.IngredientID
+				if _, seen := byLabel[strings.ToLower(ingredient.Label)]; seen {
+					report.Matched++
+				} else {
+					byLabel[strings.ToLower(ingredient.Label)] = ingredientID
+					report.Created++
+				}
+			} else {
+				report.Matched++
+			}
+
+			imported = append(imported, ingredientID)
+			nutrition := record.Nutrition
+			nutrition.IngredientID = ingredientID
+			nutrition.Source = truncate(source, 64)
+			result := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&nutrition)
+			if result.Error != nil {
+				return result.Error
+			}
+		}
+
+		// Recipes show computed nutrition, so move their UpdatedAt on
+		for ids := range slices.Chunk(imported, 500) {
+			recipes := tx.Session(&gorm.Session{NewDB: true}).Table("recipe_ingredients").
+				Select("recipe_id").Where("ingredient_id IN ?", ids)
+			if err := tx.Model(&models.Recipe{}).Where("recipe_id IN (?)", recipes).UpdateColumn("updated_at", time.Now()).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return report, err
+}
+
+// Trim value to at most length characters, the unit varchar columns count
+// in, cutting between runes so the result stays valid UTF-8
+func truncate(value string, length int) string {
+	value = strings.TrimSpace(value)
+	if utf8.RuneCountInString(value) <= length {
+		return value
+	}
+	runes := 0
+	for i := range value {
+		if runes == length {
+			return strings.TrimSpace(value[:i])
+		}
+		runes++
+	}
+	return value
+}
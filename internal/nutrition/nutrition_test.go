@@ -0,0 +1,104 @@
+package nutrition
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"recipe-api/internal/models"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestParseCSV(t *testing.T) {
+	csv := `Description,Energy (kcal),Protein (g),Total lipid (fat),Carbohydrate,Fiber,Sugars,Sodium (mg),basis,grams_per_unit
+"Carrots, raw",41,0.93,0.24,9.58,2.8,4.74,69,,
+"Egg, whole",72,6.3,4.8,0.4,0,0.2,71,unit,50
+`
+	records, err := ParseCSV(strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+
+	carrot := records[0]
+	if carrot.Label != "Carrots, raw" || carrot.Nutrition.Basis != models.NutritionPer100g {
+		t.Errorf("unexpected carrot record %+v", carrot)
+	}
+	if carrot.Nutrition.Facts.Calories != 41 || carrot.Nutrition.Facts.Sodium != 69 || carrot.Nutrition.Facts.Fibre != 2.8 {
+		t.Errorf("unexpected carrot facts %+v", carrot.Nutrition.Facts)
+	}
+
+	egg := records[1]
+	if egg.Nutrition.Basis != models.NutritionPerUnit || egg.Nutrition.GramsPerUnit == nil || *egg.Nutrition.GramsPerUnit != 50 {
+		t.Errorf("unexpected egg record %+v", egg.Nutrition)
+	}
+
+	if _, err := ParseCSV(strings.NewReader("calories\n10\n")); err == nil {
+		t.Error("expected CSV without a name column to fail")
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	cases := map[string]string{
+		"  Carrots  ":         "Carrots",
+		"Crème fraîche, full": "Crème fraîch",
+		"Käse ohne Rinde":     "Käse ohne Ri",
+		"Sour cream ":         "Sour cream",
+	}
+	for value, expected := range cases {
+		got := truncate(value, 12)
+		if got != expected || !utf8.ValidString(got) {
+			t.Errorf("truncate(%q, 12) = %q; expected %q", value, got, expected)
+		}
+	}
+}
+
+func TestCandidateLabels(t *testing.T) {
+	got := candidateLabels("Carrots,  raw")
+	expected := []string{"carrots, raw", "carrots", "carrot"}
+	if strings.Join(got, "|") != strings.Join(expected, "|") {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestCompute(t *testing.T) {
+	facts := map[int]models.Nutrition{
+		1: {IngredientID: 1, Basis: models.NutritionPer100g, Facts: models.NutritionFacts{Calories: 40, Protein: 1}},
+		2: {IngredientID: 2, Basis: models.NutritionPerUnit, GramsPerUnit: ptr(50.0), Facts: models.NutritionFacts{Calories: 70, Fat: 5}},
+		3: {IngredientID: 3, Basis: models.NutritionPer100g, Density: ptr(1.0), Facts: models.NutritionFacts{Calories: 60}},
+		4: {IngredientID: 4, Basis: models.NutritionPer100g, Facts: models.NutritionFacts{Calories: 900}},
+	}
+	recipe := models.Recipe{
+		Servings: ptr(2),
+		Ingredients: []models.RecipeIngredient{
+			{IngredientID: 1, Amount: ptr(float32(0.5)), Unit: &models.Unit{Label: "kg"}},            // 200 kcal
+			{IngredientID: 2, Amount: ptr(float32(2)), Ingredient: &models.Ingredient{Label: "Egg"}}, // 140 kcal
+			{IngredientID: 3, Amount: ptr(float32(1)), Unit: &models.Unit{Label: "cup"}},             // 150 kcal
+			{IngredientID: 4, Amount: ptr(float32(1)), Unit: &models.Unit{Label: "tbsp"}, Ingredient: &models.Ingredient{Label: "Oil"}},
+			{IngredientID: 5, Amount: ptr(float32(1)), Ingredient: &models.Ingredient{Label: "Saffron"}},
+		},
+	}
+
+	summary := Compute(recipe, facts)
+
+	if summary.Total.Calories != 490 {
+		t.Errorf("expected 490 kcal in total, got %v", summary.Total.Calories)
+	}
+	if summary.PerServing.Calories != 245 || summary.PerServing.Fat != 5 {
+		t.Errorf("unexpected per serving facts %+v", summary.PerServing)
+	}
+	if summary.Complete || len(summary.Unresolved) != 2 {
+		t.Fatalf("expected oil and saffron to be unresolved, got %+v", summary.Unresolved)
+	}
+	if summary.Unresolved[0].Label != "Oil" || !strings.Contains(summary.Unresolved[0].Reason, "density") {
+		t.Errorf("unexpected unresolved oil %+v", summary.Unresolved[0])
+	}
+	if summary.Unresolved[1].Reason != "no nutrition data" {
+		t.Errorf("unexpected unresolved saffron %+v", summary.Unresolved[1])
+	}
+}
@@ -0,0 +1,168 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// One of the standard Helvetica faces every PDF viewer has, so nothing is
+// embedded
+type Font int
+
+const (
+	Regular Font = iota
+	Bold
+	Italic
+)
+
+var fonts = []struct {
+	name   string
+	widths *[95]int
+}{
+	{"Helvetica", &helveticaWidths},
+	{"Helvetica-Bold", &helveticaBoldWidths},
+	{"Helvetica-Oblique", &helveticaWidths},
+}
+
+// Advance widths of printable ASCII (32 to 126) in thousandths of the font
+// size, from the Adobe font metrics
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // space to /
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556, // 0 to ?
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778, // @ to O
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556, // P to _
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556, // ` to o
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584, // p to ~
+}
+
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
+
+// Widths of the WinAnsi characters above ASCII that differ from a typical
+// letter. Accented letters are close enough to their base letters' 556.
+var extraWidths = map[byte]int{
+	0x85: 1000, // ellipsis
+	0x91: 222,  // single quotes
+	0x92: 222,
+	0x93: 333, // double quotes
+	0x94: 333,
+	0x95: 350,  // bullet
+	0x97: 1000, // em dash
+	0xA0: 278,  // no-break space
+	0xB0: 400,  // degree
+	0xBC: 834,  // fractions
+	0xBD: 834,
+	0xBE: 834,
+	0xD7: 584, // multiplication
+}
+
+// Characters of Windows-1252 between 0x80 and 0x9F
+var winAnsiExtras = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8A, '‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E,
+	'‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+	'˜': 0x98, '™': 0x99, 'š': 0x9A, '›': 0x9B, 'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+func (font Font) resource() string {
+	return fmt.Sprintf("F%d", int(font)+1)
+}
+
+// Encode text for the standard fonts, which only cover Western European
+// text. Accented letters they lack lose the accent (ő prints as o); other
+// characters, such as Greek, Cyrillic or CJK, become '?'.
+func winAnsi(s string) []byte {
+	encoded := make([]byte, 0, len(s))
+	for _, r := range s {
+		if b, ok := winAnsiByte(r); ok {
+			encoded = append(encoded, b)
+		} else if b, ok := winAnsiByte([]rune(norm.NFD.String(string(r)))[0]); ok {
+			encoded = append(encoded, b)
+		} else {
+			encoded = append(encoded, '?')
+		}
+	}
+	return encoded
+}
+
+func winAnsiByte(r rune) (byte, bool) {
+	switch {
+	case r == '\t' || r == '\n' || r == '\r':
+		return ' ', true
+	case r >= 0x20 && r < 0x7F, r >= 0xA0 && r <= 0xFF:
+		return byte(r), true
+	case winAnsiExtras[r] != 0:
+		return winAnsiExtras[r], true
+	}
+	return 0, false
+}
+
+func glyphWidth(font Font, b byte) int {
+	if b >= 32 && b <= 126 {
+		return fonts[font].widths[b-32]
+	}
+	if width, ok := extraWidths[b]; ok {
+		return width
+	}
+	return 556
+}
+
+// Width of text in points at the given size
+func TextWidth(font Font, size float64, s string) float64 {
+	total := 0
+	for _, b := range winAnsi(s) {
+		total += glyphWidth(font, b)
+	}
+	return float64(total) * size / 1000
+}
+
+// Break text into lines no wider than width, between words where possible
+func Wrap(font Font, size float64, s string, width float64) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(s) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if TextWidth(font, size, candidate) <= width {
+			line = candidate
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+		// Split words too long for a line of their own
+		for TextWidth(font, size, word) > width {
+			cut := fit(font, size, word, width)
+			lines = append(lines, word[:cut])
+			word = word[cut:]
+		}
+		line = word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Length in bytes of the longest prefix of word that fits, at least one rune
+func fit(font Font, size float64, word string, width float64) int {
+	cut := 0
+	for i, r := range word {
+		end := i + len(string(r))
+		if cut > 0 && TextWidth(font, size, word[:end]) > width {
+			break
+		}
+		cut = end
+	}
+	return cut
+}
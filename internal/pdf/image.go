@@ -0,0 +1,65 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // register the GIF decoder
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// Picture written to the document, to be drawn on any number of pages
+type Image struct {
+	Width  int // in pixels
+	Height int
+
+	id   int
+	name string
+}
+
+// Write a JPEG, PNG or GIF to the document. JPEGs are embedded as they are;
+// other images are flattened onto white and compressed.
+func (pw *Writer) AddImage(data []byte) (*Image, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("pdf: %w", err)
+	}
+	pw.images++
+	img := &Image{Width: config.Width, Height: config.Height, id: pw.Reserve(), name: fmt.Sprintf("Im%d", pw.images)}
+
+	if format == "jpeg" && (config.ColorModel == color.YCbCrModel || config.ColorModel == color.GrayModel) {
+		space := "/DeviceRGB"
+		if config.ColorModel == color.GrayModel {
+			space = "/DeviceGray"
+		}
+		pw.stream(img.id, fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s /BitsPerComponent 8 /Filter /DCTDecode",
+			img.Width, img.Height, space), data)
+		return img, pw.err
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("pdf: %w", err)
+	}
+	var pixels bytes.Buffer
+	zw := zlib.NewWriter(&pixels)
+	bounds := decoded.Bounds()
+	row := make([]byte, 0, bounds.Dx()*3)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		row = row[:0]
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := decoded.At(x, y).RGBA()
+			// Premultiplied, so adding the uncovered share of white flattens it
+			white := 0xffff - a
+			row = append(row, byte((r+white)>>8), byte((g+white)>>8), byte((b+white)>>8))
+		}
+		zw.Write(row)
+	}
+	zw.Close()
+	pw.stream(img.id, fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode",
+		bounds.Dx(), bounds.Dy()), pixels.Bytes())
+	return img, pw.err
+}
@@ -0,0 +1,61 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Page being drawn. Coordinates are in points from the top left corner,
+// with y growing down the page.
+type Page struct {
+	ID int // object number; reserved when the page is added unless set
+
+	content bytes.Buffer
+	images  []*Image
+	links   []link
+}
+
+// Area of a page that jumps to another page when clicked
+type link struct {
+	x1, y1, x2, y2 float64 // in PDF space, from the bottom left
+	target         int
+}
+
+// Draw text with its baseline at y
+func (p *Page) Text(font Font, size, x, y float64, s string) {
+	fmt.Fprintf(&p.content, "BT /%s %.2f Tf %.2f %.2f Td (", font.resource(), size, x, PageHeight-y)
+	for _, b := range winAnsi(s) {
+		if b == '(' || b == ')' || b == '\\' {
+			p.content.WriteByte('\\')
+		}
+		p.content.WriteByte(b)
+	}
+	p.content.WriteString(") Tj ET\n")
+}
+
+// Set the gray level of text and lines drawn next, 0 for black to 1 for white
+func (p *Page) Gray(level float64) {
+	fmt.Fprintf(&p.content, "%.3f g %.3f G\n", level, level)
+}
+
+// Draw a straight line of the given width
+func (p *Page) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&p.content, "%.2f w %.2f %.2f m %.2f %.2f l S\n", width, x1, PageHeight-y1, x2, PageHeight-y2)
+}
+
+// Draw an image scaled into the box whose top left corner is at x, y
+func (p *Page) Image(image *Image, x, y, width, height float64) {
+	fmt.Fprintf(&p.content, "q %.2f 0 0 %.2f %.2f %.2f cm /%s Do Q\n", width, height, x, PageHeight-y-height, image.name)
+	for _, used := range p.images {
+		if used == image {
+			return
+		}
+	}
+	p.images = append(p.images, image)
+}
+
+// Make the box whose top left corner is at x, y link to the page with the
+// given object number
+func (p *Page) Link(x, y, width, height float64, target int) {
+	p.links = append(p.links, link{x, PageHeight - y - height, x + width, PageHeight - y, target})
+}
@@ -0,0 +1,199 @@
+// Package pdf writes PDF documents page by page straight to an io.Writer.
+// Fonts, images and pages are written as soon as they are added, so only
+// the cross-reference offsets are held until Close.
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf16"
+)
+
+// A4 in points, the unit of every coordinate
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+// Object numbers fixed in every document
+const (
+	catalogObject = 1
+	pagesObject   = 2
+	fontObject    = 3 // first of the standard fonts, one object each
+)
+
+// Document being streamed to an io.Writer
+type Writer struct {
+	out     *bufio.Writer
+	written int64
+	offsets []int64 // by object number, 0 until the object is written
+	pages   []int
+	images  int
+	closed  bool
+	err     error
+}
+
+// Start a document, writing its header and fonts
+func NewWriter(w io.Writer) *Writer {
+	pw := &Writer{
+		out:     bufio.NewWriterSize(w, 32<<10),
+		offsets: make([]int64, fontObject+len(fonts)),
+	}
+	// The comment of high bytes marks the file as binary
+	pw.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+	for i, font := range fonts {
+		pw.object(fontObject+i, func() {
+			pw.printf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", font.name)
+		})
+	}
+	return pw
+}
+
+// Reserve an object number, e.g. for a page that links point to before it
+// is written
+func (pw *Writer) Reserve() int {
+	pw.offsets = append(pw.offsets, 0)
+	return len(pw.offsets) - 1
+}
+
+// Write a finished page. Pages appear in the order they are added.
+func (pw *Writer) AddPage(page *Page) error {
+	return pw.AddPageAt(len(pw.pages), page)
+}
+
+// Write a finished page, placing it at index among the pages added so far,
+// e.g. for contents drawn once the pages they list are written
+func (pw *Writer) AddPageAt(index int, page *Page) error {
+	if index < 0 || index > len(pw.pages) {
+		return fmt.Errorf("pdf: page index %d out of range", index)
+	}
+	if page.ID == 0 {
+		page.ID = pw.Reserve()
+	}
+
+	var content bytes.Buffer
+	zw := zlib.NewWriter(&content)
+	zw.Write(page.content.Bytes())
+	zw.Close()
+	contentID := pw.Reserve()
+	pw.stream(contentID, "/Filter /FlateDecode", content.Bytes())
+
+	pw.object(page.ID, func() {
+		pw.printf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.2f %.2f]", pagesObject, PageWidth, PageHeight)
+		pw.printf(" /Resources << /Font <<")
+		for i := range fonts {
+			pw.printf(" /%s %d 0 R", Font(i).resource(), fontObject+i)
+		}
+		pw.printf(" >>")
+		if len(page.images) > 0 {
+			pw.printf(" /XObject <<")
+			for _, image := range page.images {
+				pw.printf(" /%s %d 0 R", image.name, image.id)
+			}
+			pw.printf(" >>")
+		}
+		pw.printf(" >> /Contents %d 0 R", contentID)
+		if len(page.links) > 0 {
+			pw.printf(" /Annots [")
+			for _, link := range page.links {
+				pw.printf(" << /Type /Annot /Subtype /Link /Rect [%.2f %.2f %.2f %.2f] /Border [0 0 0] /Dest [%d 0 R /XYZ null null null] >>",
+					link.x1, link.y1, link.x2, link.y2, link.target)
+			}
+			pw.printf(" ]")
+		}
+		pw.printf(" >>")
+	})
+	pw.pages = slices.Insert(pw.pages, index, page.ID)
+	return pw.err
+}
+
+// Finish the document with its page tree, catalog and cross-reference
+// table, and flush it. The title is shown by viewers.
+func (pw *Writer) Close(title string) error {
+	if pw.closed {
+		return errors.New("pdf: writer already closed")
+	}
+	pw.closed = true
+
+	pw.object(pagesObject, func() {
+		kids := make([]string, len(pw.pages))
+		for i, id := range pw.pages {
+			kids[i] = fmt.Sprintf("%d 0 R", id)
+		}
+		pw.printf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pw.pages))
+	})
+	pw.object(catalogObject, func() {
+		pw.printf("<< /Type /Catalog /Pages %d 0 R >>", pagesObject)
+	})
+	infoID := pw.Reserve()
+	pw.object(infoID, func() {
+		pw.printf("<< /Title %s /Producer %s >>", textString(title), textString("recipe-api"))
+	})
+
+	for id := 1; id < len(pw.offsets); id++ {
+		if pw.offsets[id] == 0 && pw.err == nil {
+			pw.err = fmt.Errorf("pdf: object %d was reserved but never written", id)
+		}
+	}
+
+	xref := pw.written
+	pw.printf("xref\n0 %d\n0000000000 65535 f \n", len(pw.offsets))
+	for _, offset := range pw.offsets[1:] {
+		pw.printf("%010d 00000 n \n", offset)
+	}
+	pw.printf("trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(pw.offsets), catalogObject, infoID, xref)
+
+	if err := pw.out.Flush(); err != nil && pw.err == nil {
+		pw.err = err
+	}
+	return pw.err
+}
+
+// Write an indirect object whose body is written by body
+func (pw *Writer) object(id int, body func()) {
+	pw.offsets[id] = pw.written
+	pw.printf("%d 0 obj\n", id)
+	body()
+	pw.printf("\nendobj\n")
+}
+
+// Write a stream object with extra dictionary entries
+func (pw *Writer) stream(id int, dict string, data []byte) {
+	pw.object(id, func() {
+		pw.printf("<< %s /Length %d >>\nstream\n", dict, len(data))
+		pw.write(data)
+		pw.printf("\nendstream")
+	})
+}
+
+func (pw *Writer) printf(format string, args ...any) {
+	pw.write(fmt.Appendf(nil, format, args...))
+}
+
+// Write to the output, keeping the first error
+func (pw *Writer) write(data []byte) {
+	if pw.err != nil {
+		return
+	}
+	n, err := pw.out.Write(data)
+	pw.written += int64(n)
+	pw.err = err
+}
+
+// Text string for the document information, as UTF-16 so any title works
+func textString(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, unit := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", unit)
+	}
+	b.WriteString(">")
+	return b.String()
+}
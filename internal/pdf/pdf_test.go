@@ -0,0 +1,180 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func testImage(t *testing.T, encode func(io.Writer, image.Image) error) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	img.Set(0, 0, color.NRGBA{R: 255, A: 255})
+	img.Set(1, 0, color.NRGBA{B: 255, A: 0}) // transparent, so white
+	var buf bytes.Buffer
+	if err := encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// Decompressed contents of every Flate stream in a document
+func inflateStreams(t *testing.T, doc []byte) []string {
+	t.Helper()
+	var streams []string
+	for _, match := range regexp.MustCompile(`(?s)/FlateDecode /Length (\d+) >>\nstream\n`).FindAllSubmatchIndex(doc, -1) {
+		length, _ := strconv.Atoi(string(doc[match[2]:match[3]]))
+		r, err := zlib.NewReader(bytes.NewReader(doc[match[1] : match[1]+length]))
+		if err != nil {
+			t.Fatalf("bad stream: %v", err)
+		}
+		data, _ := io.ReadAll(r)
+		streams = append(streams, string(data))
+	}
+	return streams
+}
+
+func TestWriterStructure(t *testing.T) {
+	var out bytes.Buffer
+	doc := NewWriter(&out)
+
+	second := doc.Reserve()
+	first := &Page{}
+	first.Text(Bold, 12, 50, 60, "Hello (world) \\ café ½ Győr Ωμέγα")
+	first.Line(50, 70, 200, 70, 1)
+	first.Link(50, 48, 100, 14, second)
+
+	png, err := doc.AddImage(testImage(t, png.Encode))
+	if err != nil {
+		t.Fatal(err)
+	}
+	jpg, err := doc.AddImage(testImage(t, func(w io.Writer, img image.Image) error { return jpeg.Encode(w, img, nil) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.Image(png, 50, 100, 40, 20)
+	first.Image(png, 100, 100, 40, 20)
+	if err := doc.AddPage(first); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.AddPage(&Page{ID: second}); err != nil {
+		t.Fatal(err)
+	}
+	second2 := &Page{}
+	second2.Image(jpg, 0, 0, 10, 10)
+	doc.AddPage(second2)
+	if err := doc.Close("Soups & Stews — vol. 1"); err != nil {
+		t.Fatal(err)
+	}
+	data := out.Bytes()
+
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatalf("missing header or trailer")
+	}
+
+	// Every cross-reference entry points at its object
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(data)
+	xrefAt, _ := strconv.Atoi(string(startxref[1]))
+	if !bytes.HasPrefix(data[xrefAt:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point at the table", xrefAt)
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(data[xrefAt:], -1)
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !bytes.HasPrefix(data[offset:], []byte(want)) {
+			t.Errorf("object %d: offset %d points at %q", i+1, offset, data[offset:offset+12])
+		}
+	}
+	if size := fmt.Sprintf("/Size %d ", len(entries)+1); !bytes.Contains(data, []byte(size)) {
+		t.Errorf("expected trailer %s", size)
+	}
+
+	for _, expected := range []string{
+		"/Type /Pages /Kids [",
+		"/Count 3 >>",
+		"/BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding",
+		fmt.Sprintf("/Dest [%d 0 R /XYZ null null null]", second),
+		"/ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /DCTDecode",
+		"/XObject << /Im1 ",
+		"/Title <FEFF0053006F",
+	} {
+		if !bytes.Contains(data, []byte(expected)) {
+			t.Errorf("expected document to contain %q", expected)
+		}
+	}
+
+	streams := inflateStreams(t, data)
+	// The PNG is flattened onto white: a red pixel, then transparent ones
+	if streams[0] != "\xff\x00\x00"+strings.Repeat("\xff", 7*3) {
+		t.Errorf("unexpected pixels %q", streams[0])
+	}
+	content := streams[1]
+	for _, expected := range []string{
+		"/F2 12.00 Tf 50.00 781.89 Td (Hello \\(world\\) \\\\ caf\xe9 \xbd Gyor ?????) Tj",
+		"1.00 w 50.00 771.89 m 200.00 771.89 l S",
+		"q 40.00 0 0 20.00 50.00 721.89 cm /Im1 Do Q",
+	} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("expected content to contain %q, got:\n%s", expected, content)
+		}
+	}
+}
+
+func TestWriterUnwrittenPage(t *testing.T) {
+	doc := NewWriter(io.Discard)
+	doc.Reserve()
+	if err := doc.Close("Missing"); err == nil || !strings.Contains(err.Error(), "never written") {
+		t.Fatalf("expected an error for a reserved page, got %v", err)
+	}
+}
+
+func TestWriterPageOrder(t *testing.T) {
+	var out bytes.Buffer
+	doc := NewWriter(&out)
+	first, second, third := &Page{ID: doc.Reserve()}, &Page{ID: doc.Reserve()}, &Page{ID: doc.Reserve()}
+	doc.AddPage(first)
+	doc.AddPage(third)
+	if err := doc.AddPageAt(1, second); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.AddPageAt(5, &Page{}); err == nil {
+		t.Errorf("expected an error for an index past the pages")
+	}
+	if err := doc.Close("Order"); err != nil {
+		t.Fatal(err)
+	}
+	if kids := fmt.Sprintf("/Kids [%d 0 R %d 0 R %d 0 R]", first.ID, second.ID, third.ID); !bytes.Contains(out.Bytes(), []byte(kids)) {
+		t.Errorf("expected pages in order %s", kids)
+	}
+}
+
+func TestTextWidthAndWrap(t *testing.T) {
+	if width := TextWidth(Regular, 10, "Hello"); width != 22.78 {
+		t.Errorf("expected Hello to be 22.78pt wide, got %v", width)
+	}
+	if TextWidth(Bold, 10, "Hello") <= TextWidth(Regular, 10, "Hello") {
+		t.Errorf("expected bold to be wider")
+	}
+
+	lines := Wrap(Regular, 10, "Chop the onions finely and fry them", 80)
+	if strings.Join(lines, "|") != "Chop the onions|finely and fry|them" {
+		t.Errorf("unexpected wrap %q", lines)
+	}
+	for _, line := range Wrap(Regular, 10, "Supercalifragilisticexpialidocious", 40) {
+		if TextWidth(Regular, 10, line) > 40 {
+			t.Errorf("line %q is wider than 40pt", line)
+		}
+	}
+	if lines := Wrap(Regular, 10, "   ", 40); len(lines) != 0 {
+		t.Errorf("expected no lines for blank text, got %q", lines)
+	}
+}
@@ -0,0 +1,5 @@
+// Package recipepb is the protobuf and gRPC code generated from
+// proto/recipe/v1/recipe.proto.
+package recipepb
+
+//go:generate protoc -I ../../proto --go_out=. --go_opt=module=recipe-api/internal/recipepb --go-grpc_out=. --go-grpc_opt=module=recipe-api/internal/recipepb recipe/v1/recipe.proto
@@ -0,0 +1,1120 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: recipe/v1/recipe.proto
+
+package recipepb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Unit struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Label         string                 `protobuf:"bytes,2,opt,name=label,proto3" json:"label,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Unit) Reset() {
+	*x = Unit{}
+	mi := &file_recipe_v1_recipe_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Unit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Unit) ProtoMessage() {}
+
+func (x *Unit) ProtoReflect() protoreflect.Message {
+	mi := &file_recipe_v1_recipe_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Unit.ProtoReflect.Descriptor instead.
+func (*Unit) Descriptor() ([]byte, []int) {
+	return file_recipe_v1_recipe_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Unit) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Unit) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+type Ingredient struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Label         string                 `protobuf:"bytes,2,opt,name=label,proto3" json:"label,omitempty"`
+	Category      *string                `protobuf:"bytes,3,opt,name=category,proto3,oneof" json:"category,omitempty"`
+	Allergens     []string               `protobuf:"bytes,4,rep,name=allergens,proto3" json:"allergens,omitempty"`
+	Animal        *string                `protobuf:"bytes,5,opt,name=animal,proto3,oneof" json:"animal,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Ingredient) Reset() {
+	*x = Ingredient{}
+	mi := &file_recipe_v1_recipe_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Ingredient) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Ingredient) ProtoMessage() {}
+
+func (x *Ingredient) ProtoReflect() protoreflect.Message {
+	mi := &file_recipe_v1_recipe_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Ingredient.ProtoReflect.Descriptor instead.
+func (*Ingredient) Descriptor() ([]byte, []int) {
+	return file_recipe_v1_recipe_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Ingredient) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Ingredient) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *Ingredient) GetCategory() string {
+	if x != nil && x.Category != nil {
+		return *x.Category
+	}
+	return ""
+}
+
+func (x *Ingredient) GetAllergens() []string {
+	if x != nil {
+		return x.Allergens
+	}
+	return nil
+}
+
+func (x *Ingredient) GetAnimal() string {
+	if x != nil && x.Animal != nil {
+		return *x.Animal
+	}
+	return ""
+}
+
+type RecipeIngredient struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Id     int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Amount *float32               `protobuf:"fixed32,2,opt,name=amount,proto3,oneof" json:"amount,omitempty"`
+	// Found or created by label when writing
+	Ingredient    *Ingredient `protobuf:"bytes,3,opt,name=ingredient,proto3" json:"ingredient,omitempty"`
+	Unit          *Unit       `protobuf:"bytes,4,opt,name=unit,proto3" json:"unit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecipeIngredient) Reset() {
+	*x = RecipeIngredient{}
+	mi := &file_recipe_v1_recipe_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecipeIngredient) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecipeIngredient) ProtoMessage() {}
+
+func (x *RecipeIngredient) ProtoReflect() protoreflect.Message {
+	mi := &file_recipe_v1_recipe_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecipeIngredient.ProtoReflect.Descriptor instead.
+func (*RecipeIngredient) Descriptor() ([]byte, []int) {
+	return file_recipe_v1_recipe_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *RecipeIngredient) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *RecipeIngredient) GetAmount() float32 {
+	if x != nil && x.Amount != nil {
+		return *x.Amount
+	}
+	return 0
+}
+
+func (x *RecipeIngredient) GetIngredient() *Ingredient {
+	if x != nil {
+		return x.Ingredient
+	}
+	return nil
+}
+
+func (x *RecipeIngredient) GetUnit() *Unit {
+	if x != nil {
+		return x.Unit
+	}
+	return nil
+}
+
+type Instruction struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Id         int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	StepNumber int32                  `protobuf:"varint,2,opt,name=step_number,json=stepNumber,proto3" json:"step_number,omitempty"`
+	StepText   string                 `protobuf:"bytes,3,opt,name=step_text,json=stepText,proto3" json:"step_text,omitempty"`
+	// Minutes
+	StepTime *int32 `protobuf:"varint,4,opt,name=step_time,json=stepTime,proto3,oneof" json:"step_time,omitempty"`
+	// prep, cook or rest
+	Phase         *string `protobuf:"bytes,5,opt,name=phase,proto3,oneof" json:"phase,omitempty"`
+	Notes         *string `protobuf:"bytes,6,opt,name=notes,proto3,oneof" json:"notes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Instruction) Reset() {
+	*x = Instruction{}
+	mi := &file_recipe_v1_recipe_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Instruction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Instruction) ProtoMessage() {}
+
+func (x *Instruction) ProtoReflect() protoreflect.Message {
+	mi := &file_recipe_v1_recipe_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Instruction.ProtoReflect.Descriptor instead.
+func (*Instruction) Descriptor() ([]byte, []int) {
+	return file_recipe_v1_recipe_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Instruction) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Instruction) GetStepNumber() int32 {
+	if x != nil {
+		return x.StepNumber
+	}
+	return 0
+}
+
+func (x *Instruction) GetStepText() string {
+	if x != nil {
+		return x.StepText
+	}
+	return ""
+}
+
+func (x *Instruction) GetStepTime() int32 {
+	if x != nil && x.StepTime != nil {
+		return *x.StepTime
+	}
+	return 0
+}
+
+func (x *Instruction) GetPhase() string {
+	if x != nil && x.Phase != nil {
+		return *x.Phase
+	}
+	return ""
+}
+
+func (x *Instruction) GetNotes() string {
+	if x != nil && x.Notes != nil {
+		return *x.Notes
+	}
+	return ""
+}
+
+type Recipe struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description   *string                `protobuf:"bytes,3,opt,name=description,proto3,oneof" json:"description,omitempty"`
+	Difficulty    int32                  `protobuf:"varint,4,opt,name=difficulty,proto3" json:"difficulty,omitempty"`
+	Servings      *int32                 `protobuf:"varint,5,opt,name=servings,proto3,oneof" json:"servings,omitempty"`
+	UserId        string                 `protobuf:"bytes,6,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Ingredients   []*RecipeIngredient    `protobuf:"bytes,7,rep,name=ingredients,proto3" json:"ingredients,omitempty"`
+	Instructions  []*Instruction         `protobuf:"bytes,8,rep,name=instructions,proto3" json:"instructions,omitempty"`
+	RatingAverage float64                `protobuf:"fixed64,9,opt,name=rating_average,json=ratingAverage,proto3" json:"rating_average,omitempty"`
+	RatingCount   int32                  `protobuf:"varint,10,opt,name=rating_count,json=ratingCount,proto3" json:"rating_count,omitempty"`
+	Diets         []string               `protobuf:"bytes,11,rep,name=diets,proto3" json:"diets,omitempty"`
+	Allergens     []string               `protobuf:"bytes,12,rep,name=allergens,proto3" json:"allergens,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Recipe) Reset() {
+	*x = Recipe{}
+	mi := &file_recipe_v1_recipe_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Recipe) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Recipe) ProtoMessage() {}
+
+func (x *Recipe) ProtoReflect() protoreflect.Message {
+	mi := &file_recipe_v1_recipe_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Recipe.ProtoReflect.Descriptor instead.
+func (*Recipe) Descriptor() ([]byte, []int) {
+	return file_recipe_v1_recipe_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Recipe) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Recipe) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Recipe) GetDescription() string {
+	if x != nil && x.Description != nil {
+		return *x.Description
+	}
+	return ""
+}
+
+func (x *Recipe) GetDifficulty() int32 {
+	if x != nil {
+		return x.Difficulty
+	}
+	return 0
+}
+
+func (x *Recipe) GetServings() int32 {
+	if x != nil && x.Servings != nil {
+		return *x.Servings
+	}
+	return 0
+}
+
+func (x *Recipe) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *Recipe) GetIngredients() []*RecipeIngredient {
+	if x != nil {
+		return x.Ingredients
+	}
+	return nil
+}
+
+func (x *Recipe) GetInstructions() []*Instruction {
+	if x != nil {
+		return x.Instructions
+	}
+	return nil
+}
+
+func (x *Recipe) GetRatingAverage() float64 {
+	if x != nil {
+		return x.RatingAverage
+	}
+	return 0
+}
+
+func (x *Recipe) GetRatingCount() int32 {
+	if x != nil {
+		return x.RatingCount
+	}
+	return 0
+}
+
+func (x *Recipe) GetDiets() []string {
+	if x != nil {
+		return x.Diets
+	}
+	return nil
+}
+
+func (x *Recipe) GetAllergens() []string {
+	if x != nil {
+		return x.Allergens
+	}
+	return nil
+}
+
+// Same filters as the HTTP listing
+type RecipeFilter struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Diets            []string               `protobuf:"bytes,1,rep,name=diets,proto3" json:"diets,omitempty"`
+	ExcludeAllergens []string               `protobuf:"bytes,2,rep,name=exclude_allergens,json=excludeAllergens,proto3" json:"exclude_allergens,omitempty"`
+	// "name" or "kind:name"
+	Tags         []string `protobuf:"bytes,3,rep,name=tags,proto3" json:"tags,omitempty"`
+	MatchAnyTag  bool     `protobuf:"varint,4,opt,name=match_any_tag,json=matchAnyTag,proto3" json:"match_any_tag,omitempty"`
+	MaxTotalTime *int32   `protobuf:"varint,5,opt,name=max_total_time,json=maxTotalTime,proto3,oneof" json:"max_total_time,omitempty"`
+	// name or rating
+	Sort          string `protobuf:"bytes,6,opt,name=sort,proto3" json:"sort,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecipeFilter) Reset() {
+	*x = RecipeFilter{}
+	mi := &file_recipe_v1_recipe_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecipeFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecipeFilter) ProtoMessage() {}
+
+func (x *RecipeFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_recipe_v1_recipe_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecipeFilter.ProtoReflect.Descriptor instead.
+func (*RecipeFilter) Descriptor() ([]byte, []int) {
+	return file_recipe_v1_recipe_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *RecipeFilter) GetDiets() []string {
+	if x != nil {
+		return x.Diets
+	}
+	return nil
+}
+
+func (x *RecipeFilter) GetExcludeAllergens() []string {
+	if x != nil {
+		return x.ExcludeAllergens
+	}
+	return nil
+}
+
+func (x *RecipeFilter) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *RecipeFilter) GetMatchAnyTag() bool {
+	if x != nil {
+		return x.MatchAnyTag
+	}
+	return false
+}
+
+func (x *RecipeFilter) GetMaxTotalTime() int32 {
+	if x != nil && x.MaxTotalTime != nil {
+		return *x.MaxTotalTime
+	}
+	return 0
+}
+
+func (x *RecipeFilter) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+type GetRecipeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRecipeRequest) Reset() {
+	*x = GetRecipeRequest{}
+	mi := &file_recipe_v1_recipe_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRecipeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRecipeRequest) ProtoMessage() {}
+
+func (x *GetRecipeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_recipe_v1_recipe_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRecipeRequest.ProtoReflect.Descriptor instead.
+func (*GetRecipeRequest) Descriptor() ([]byte, []int) {
+	return file_recipe_v1_recipe_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetRecipeRequest) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type ListRecipesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Filter        *RecipeFilter          `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRecipesRequest) Reset() {
+	*x = ListRecipesRequest{}
+	mi := &file_recipe_v1_recipe_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRecipesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRecipesRequest) ProtoMessage() {}
+
+func (x *ListRecipesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_recipe_v1_recipe_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRecipesRequest.ProtoReflect.Descriptor instead.
+func (*ListRecipesRequest) Descriptor() ([]byte, []int) {
+	return file_recipe_v1_recipe_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListRecipesRequest) GetFilter() *RecipeFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+type CreateRecipeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Recipe        *Recipe                `protobuf:"bytes,1,opt,name=recipe,proto3" json:"recipe,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateRecipeRequest) Reset() {
+	*x = CreateRecipeRequest{}
+	mi := &file_recipe_v1_recipe_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateRecipeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateRecipeRequest) ProtoMessage() {}
+
+func (x *CreateRecipeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_recipe_v1_recipe_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateRecipeRequest.ProtoReflect.Descriptor instead.
+func (*CreateRecipeRequest) Descriptor() ([]byte, []int) {
+	return file_recipe_v1_recipe_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *CreateRecipeRequest) GetRecipe() *Recipe {
+	if x != nil {
+		return x.Recipe
+	}
+	return nil
+}
+
+type UpdateRecipeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Recipe        *Recipe                `protobuf:"bytes,2,opt,name=recipe,proto3" json:"recipe,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateRecipeRequest) Reset() {
+	*x = UpdateRecipeRequest{}
+	mi := &file_recipe_v1_recipe_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateRecipeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateRecipeRequest) ProtoMessage() {}
+
+func (x *UpdateRecipeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_recipe_v1_recipe_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateRecipeRequest.ProtoReflect.Descriptor instead.
+func (*UpdateRecipeRequest) Descriptor() ([]byte, []int) {
+	return file_recipe_v1_recipe_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *UpdateRecipeRequest) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdateRecipeRequest) GetRecipe() *Recipe {
+	if x != nil {
+		return x.Recipe
+	}
+	return nil
+}
+
+type DeleteRecipeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteRecipeRequest) Reset() {
+	*x = DeleteRecipeRequest{}
+	mi := &file_recipe_v1_recipe_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRecipeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRecipeRequest) ProtoMessage() {}
+
+func (x *DeleteRecipeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_recipe_v1_recipe_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRecipeRequest.ProtoReflect.Descriptor instead.
+func (*DeleteRecipeRequest) Descriptor() ([]byte, []int) {
+	return file_recipe_v1_recipe_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *DeleteRecipeRequest) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type DeleteRecipeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteRecipeResponse) Reset() {
+	*x = DeleteRecipeResponse{}
+	mi := &file_recipe_v1_recipe_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRecipeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRecipeResponse) ProtoMessage() {}
+
+func (x *DeleteRecipeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_recipe_v1_recipe_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRecipeResponse.ProtoReflect.Descriptor instead.
+func (*DeleteRecipeResponse) Descriptor() ([]byte, []int) {
+	return file_recipe_v1_recipe_proto_rawDescGZIP(), []int{11}
+}
+
+type RandomRecipeRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Highest difficulty, any when 0
+	MaxDifficulty int32         `protobuf:"varint,1,opt,name=max_difficulty,json=maxDifficulty,proto3" json:"max_difficulty,omitempty"`
+	Filter        *RecipeFilter `protobuf:"bytes,2,opt,name=filter,proto3" json:"filter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RandomRecipeRequest) Reset() {
+	*x = RandomRecipeRequest{}
+	mi := &file_recipe_v1_recipe_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RandomRecipeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RandomRecipeRequest) ProtoMessage() {}
+
+func (x *RandomRecipeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_recipe_v1_recipe_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RandomRecipeRequest.ProtoReflect.Descriptor instead.
+func (*RandomRecipeRequest) Descriptor() ([]byte, []int) {
+	return file_recipe_v1_recipe_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *RandomRecipeRequest) GetMaxDifficulty() int32 {
+	if x != nil {
+		return x.MaxDifficulty
+	}
+	return 0
+}
+
+func (x *RandomRecipeRequest) GetFilter() *RecipeFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+type SearchRecipesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	Filter        *RecipeFilter          `protobuf:"bytes,2,opt,name=filter,proto3" json:"filter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchRecipesRequest) Reset() {
+	*x = SearchRecipesRequest{}
+	mi := &file_recipe_v1_recipe_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchRecipesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchRecipesRequest) ProtoMessage() {}
+
+func (x *SearchRecipesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_recipe_v1_recipe_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchRecipesRequest.ProtoReflect.Descriptor instead.
+func (*SearchRecipesRequest) Descriptor() ([]byte, []int) {
+	return file_recipe_v1_recipe_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *SearchRecipesRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchRecipesRequest) GetFilter() *RecipeFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+type SearchRecipesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Recipes       []*Recipe              `protobuf:"bytes,1,rep,name=recipes,proto3" json:"recipes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchRecipesResponse) Reset() {
+	*x = SearchRecipesResponse{}
+	mi := &file_recipe_v1_recipe_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchRecipesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchRecipesResponse) ProtoMessage() {}
+
+func (x *SearchRecipesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_recipe_v1_recipe_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchRecipesResponse.ProtoReflect.Descriptor instead.
+func (*SearchRecipesResponse) Descriptor() ([]byte, []int) {
+	return file_recipe_v1_recipe_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *SearchRecipesResponse) GetRecipes() []*Recipe {
+	if x != nil {
+		return x.Recipes
+	}
+	return nil
+}
+
+var File_recipe_v1_recipe_proto protoreflect.FileDescriptor
+
+const file_recipe_v1_recipe_proto_rawDesc = "" +
+	"\n" +
+	"\x16recipe/v1/recipe.proto\x12\trecipe.v1\",\n" +
+	"\x04Unit\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x14\n" +
+	"\x05label\x18\x02 \x01(\tR\x05label\"\xa6\x01\n" +
+	"\n" +
+	"Ingredient\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x14\n" +
+	"\x05label\x18\x02 \x01(\tR\x05label\x12\x1f\n" +
+	"\bcategory\x18\x03 \x01(\tH\x00R\bcategory\x88\x01\x01\x12\x1c\n" +
+	"\tallergens\x18\x04 \x03(\tR\tallergens\x12\x1b\n" +
+	"\x06animal\x18\x05 \x01(\tH\x01R\x06animal\x88\x01\x01B\v\n" +
+	"\t_categoryB\t\n" +
+	"\a_animal\"\xa6\x01\n" +
+	"\x10RecipeIngredient\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x1b\n" +
+	"\x06amount\x18\x02 \x01(\x02H\x00R\x06amount\x88\x01\x01\x125\n" +
+	"\n" +
+	"ingredient\x18\x03 \x01(\v2\x15.recipe.v1.IngredientR\n" +
+	"ingredient\x12#\n" +
+	"\x04unit\x18\x04 \x01(\v2\x0f.recipe.v1.UnitR\x04unitB\t\n" +
+	"\a_amount\"\xd5\x01\n" +
+	"\vInstruction\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x1f\n" +
+	"\vstep_number\x18\x02 \x01(\x05R\n" +
+	"stepNumber\x12\x1b\n" +
+	"\tstep_text\x18\x03 \x01(\tR\bstepText\x12 \n" +
+	"\tstep_time\x18\x04 \x01(\x05H\x00R\bstepTime\x88\x01\x01\x12\x19\n" +
+	"\x05phase\x18\x05 \x01(\tH\x01R\x05phase\x88\x01\x01\x12\x19\n" +
+	"\x05notes\x18\x06 \x01(\tH\x02R\x05notes\x88\x01\x01B\f\n" +
+	"\n" +
+	"_step_timeB\b\n" +
+	"\x06_phaseB\b\n" +
+	"\x06_notes\"\xc3\x03\n" +
+	"\x06Recipe\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12%\n" +
+	"\vdescription\x18\x03 \x01(\tH\x00R\vdescription\x88\x01\x01\x12\x1e\n" +
+	"\n" +
+	"difficulty\x18\x04 \x01(\x05R\n" +
+	"difficulty\x12\x1f\n" +
+	"\bservings\x18\x05 \x01(\x05H\x01R\bservings\x88\x01\x01\x12\x17\n" +
+	"\auser_id\x18\x06 \x01(\tR\x06userId\x12=\n" +
+	"\vingredients\x18\a \x03(\v2\x1b.recipe.v1.RecipeIngredientR\vingredients\x12:\n" +
+	"\finstructions\x18\b \x03(\v2\x16.recipe.v1.InstructionR\finstructions\x12%\n" +
+	"\x0erating_average\x18\t \x01(\x01R\rratingAverage\x12!\n" +
+	"\frating_count\x18\n" +
+	" \x01(\x05R\vratingCount\x12\x14\n" +
+	"\x05diets\x18\v \x03(\tR\x05diets\x12\x1c\n" +
+	"\tallergens\x18\f \x03(\tR\tallergensB\x0e\n" +
+	"\f_descriptionB\v\n" +
+	"\t_servings\"\xdb\x01\n" +
+	"\fRecipeFilter\x12\x14\n" +
+	"\x05diets\x18\x01 \x03(\tR\x05diets\x12+\n" +
+	"\x11exclude_allergens\x18\x02 \x03(\tR\x10excludeAllergens\x12\x12\n" +
+	"\x04tags\x18\x03 \x03(\tR\x04tags\x12\"\n" +
+	"\rmatch_any_tag\x18\x04 \x01(\bR\vmatchAnyTag\x12)\n" +
+	"\x0emax_total_time\x18\x05 \x01(\x05H\x00R\fmaxTotalTime\x88\x01\x01\x12\x12\n" +
+	"\x04sort\x18\x06 \x01(\tR\x04sortB\x11\n" +
+	"\x0f_max_total_time\"\"\n" +
+	"\x10GetRecipeRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\"E\n" +
+	"\x12ListRecipesRequest\x12/\n" +
+	"\x06filter\x18\x01 \x01(\v2\x17.recipe.v1.RecipeFilterR\x06filter\"@\n" +
+	"\x13CreateRecipeRequest\x12)\n" +
+	"\x06recipe\x18\x01 \x01(\v2\x11.recipe.v1.RecipeR\x06recipe\"P\n" +
+	"\x13UpdateRecipeRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12)\n" +
+	"\x06recipe\x18\x02 \x01(\v2\x11.recipe.v1.RecipeR\x06recipe\"%\n" +
+	"\x13DeleteRecipeRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\"\x16\n" +
+	"\x14DeleteRecipeResponse\"m\n" +
+	"\x13RandomRecipeRequest\x12%\n" +
+	"\x0emax_difficulty\x18\x01 \x01(\x05R\rmaxDifficulty\x12/\n" +
+	"\x06filter\x18\x02 \x01(\v2\x17.recipe.v1.RecipeFilterR\x06filter\"]\n" +
+	"\x14SearchRecipesRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12/\n" +
+	"\x06filter\x18\x02 \x01(\v2\x17.recipe.v1.RecipeFilterR\x06filter\"D\n" +
+	"\x15SearchRecipesResponse\x12+\n" +
+	"\arecipes\x18\x01 \x03(\v2\x11.recipe.v1.RecipeR\arecipes2\xfd\x03\n" +
+	"\rRecipeService\x12;\n" +
+	"\tGetRecipe\x12\x1b.recipe.v1.GetRecipeRequest\x1a\x11.recipe.v1.Recipe\x12A\n" +
+	"\vListRecipes\x12\x1d.recipe.v1.ListRecipesRequest\x1a\x11.recipe.v1.Recipe0\x01\x12A\n" +
+	"\fCreateRecipe\x12\x1e.recipe.v1.CreateRecipeRequest\x1a\x11.recipe.v1.Recipe\x12A\n" +
+	"\fUpdateRecipe\x12\x1e.recipe.v1.UpdateRecipeRequest\x1a\x11.recipe.v1.Recipe\x12O\n" +
+	"\fDeleteRecipe\x12\x1e.recipe.v1.DeleteRecipeRequest\x1a\x1f.recipe.v1.DeleteRecipeResponse\x12A\n" +
+	"\fRandomRecipe\x12\x1e.recipe.v1.RandomRecipeRequest\x1a\x11.recipe.v1.Recipe\x12R\n" +
+	"\rSearchRecipes\x12\x1f.recipe.v1.SearchRecipesRequest\x1a .recipe.v1.SearchRecipesResponseB\x1eZ\x1crecipe-api/internal/recipepbb\x06proto3"
+
+var (
+	file_recipe_v1_recipe_proto_rawDescOnce sync.Once
+	file_recipe_v1_recipe_proto_rawDescData []byte
+)
+
+func file_recipe_v1_recipe_proto_rawDescGZIP() []byte {
+	file_recipe_v1_recipe_proto_rawDescOnce.Do(func() {
+		file_recipe_v1_recipe_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_recipe_v1_recipe_proto_rawDesc), len(file_recipe_v1_recipe_proto_rawDesc)))
+	})
+	return file_recipe_v1_recipe_proto_rawDescData
+}
+
+var file_recipe_v1_recipe_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_recipe_v1_recipe_proto_goTypes = []any{
+	(*Unit)(nil),                  // 0: recipe.v1.Unit
+	(*Ingredient)(nil),            // 1: recipe.v1.Ingredient
+	(*RecipeIngredient)(nil),      // 2: recipe.v1.RecipeIngredient
+	(*Instruction)(nil),           // 3: recipe.v1.Instruction
+	(*Recipe)(nil),                // 4: recipe.v1.Recipe
+	(*RecipeFilter)(nil),          // 5: recipe.v1.RecipeFilter
+	(*GetRecipeRequest)(nil),      // 6: recipe.v1.GetRecipeRequest
+	(*ListRecipesRequest)(nil),    // 7: recipe.v1.ListRecipesRequest
+	(*CreateRecipeRequest)(nil),   // 8: recipe.v1.CreateRecipeRequest
+	(*UpdateRecipeRequest)(nil),   // 9: recipe.v1.UpdateRecipeRequest
+	(*DeleteRecipeRequest)(nil),   // 10: recipe.v1.DeleteRecipeRequest
+	(*DeleteRecipeResponse)(nil),  // 11: recipe.v1.DeleteRecipeResponse
+	(*RandomRecipeRequest)(nil),   // 12: recipe.v1.RandomRecipeRequest
+	(*SearchRecipesRequest)(nil),  // 13: recipe.v1.SearchRecipesRequest
+	(*SearchRecipesResponse)(nil), // 14: recipe.v1.SearchRecipesResponse
+}
+var file_recipe_v1_recipe_proto_depIdxs = []int32{
+	1,  // 0: recipe.v1.RecipeIngredient.ingredient:type_name -> recipe.v1.Ingredient
+	0,  // 1: recipe.v1.RecipeIngredient.unit:type_name -> recipe.v1.Unit
+	2,  // 2: recipe.v1.Recipe.ingredients:type_name -> recipe.v1.RecipeIngredient
+	3,  // 3: recipe.v1.Recipe.instructions:type_name -> recipe.v1.Instruction
+	5,  // 4: recipe.v1.ListRecipesRequest.filter:type_name -> recipe.v1.RecipeFilter
+	4,  // 5: recipe.v1.CreateRecipeRequest.recipe:type_name -> recipe.v1.Recipe
+	4,  // 6: recipe.v1.UpdateRecipeRequest.recipe:type_name -> recipe.v1.Recipe
+	5,  // 7: recipe.v1.RandomRecipeRequest.filter:type_name -> recipe.v1.RecipeFilter
+	5,  // 8: recipe.v1.SearchRecipesRequest.filter:type_name -> recipe.v1.RecipeFilter
+	4,  // 9: recipe.v1.SearchRecipesResponse.recipes:type_name -> recipe.v1.Recipe
+	6,  // 10: recipe.v1.RecipeService.GetRecipe:input_type -> recipe.v1.GetRecipeRequest
+	7,  // 11: recipe.v1.RecipeService.ListRecipes:input_type -> recipe.v1.ListRecipesRequest
+	8,  // 12: recipe.v1.RecipeService.CreateRecipe:input_type -> recipe.v1.CreateRecipeRequest
+	9,  // 13: recipe.v1.RecipeService.UpdateRecipe:input_type -> recipe.v1.UpdateRecipeRequest
+	10, // 14: recipe.v1.RecipeService.DeleteRecipe:input_type -> recipe.v1.DeleteRecipeRequest
+	12, // 15: recipe.v1.RecipeService.RandomRecipe:input_type -> recipe.v1.RandomRecipeRequest
+	13, // 16: recipe.v1.RecipeService.SearchRecipes:input_type -> recipe.v1.SearchRecipesRequest
+	4,  // 17: recipe.v1.RecipeService.GetRecipe:output_type -> recipe.v1.Recipe
+	4,  // 18: recipe.v1.RecipeService.ListRecipes:output_type -> recipe.v1.Recipe
+	4,  // 19: recipe.v1.RecipeService.CreateRecipe:output_type -> recipe.v1.Recipe
+	4,  // 20: recipe.v1.RecipeService.UpdateRecipe:output_type -> recipe.v1.Recipe
+	11, // 21: recipe.v1.RecipeService.DeleteRecipe:output_type -> recipe.v1.DeleteRecipeResponse
+	4,  // 22: recipe.v1.RecipeService.RandomRecipe:output_type -> recipe.v1.Recipe
+	14, // 23: recipe.v1.RecipeService.SearchRecipes:output_type -> recipe.v1.SearchRecipesResponse
+	17, // [17:24] is the sub-list for method output_type
+	10, // [10:17] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
+}
+
+func init() { file_recipe_v1_recipe_proto_init() }
+func file_recipe_v1_recipe_proto_init() {
+	if File_recipe_v1_recipe_proto != nil {
+		return
+	}
+	file_recipe_v1_recipe_proto_msgTypes[1].OneofWrappers = []any{}
+	file_recipe_v1_recipe_proto_msgTypes[2].OneofWrappers = []any{}
+	file_recipe_v1_recipe_proto_msgTypes[3].OneofWrappers = []any{}
+	file_recipe_v1_recipe_proto_msgTypes[4].OneofWrappers = []any{}
+	file_recipe_v1_recipe_proto_msgTypes[5].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_recipe_v1_recipe_proto_rawDesc), len(file_recipe_v1_recipe_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   15,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_recipe_v1_recipe_proto_goTypes,
+		DependencyIndexes: file_recipe_v1_recipe_proto_depIdxs,
+		MessageInfos:      file_recipe_v1_recipe_proto_msgTypes,
+	}.Build()
+	File_recipe_v1_recipe_proto = out.File
+	file_recipe_v1_recipe_proto_goTypes = nil
+	file_recipe_v1_recipe_proto_depIdxs = nil
+}
@@ -0,0 +1,337 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"recipe-api/internal/models"
+	"recipe-api/internal/pdf"
+)
+
+// Recipes to print as a booklet, with what goes on its cover
+type Book struct {
+	Title    string
+	Subtitle string
+	Recipes  []models.Recipe
+
+	// For books too long to hold in memory, Count recipes are passed to add
+	// in order, a batch at a time, in place of Recipes
+	Count   int
+	Batches func(add func([]models.Recipe) error) error
+}
+
+// Stored bytes of a recipe's image, read just before the page it goes on
+type ImageLoader func(models.Image) ([]byte, error)
+
+// Page layout in points
+const (
+	pageMargin     = 56.0
+	pageBottom     = pdf.PageHeight - pageMargin
+	contentWidth   = pdf.PageWidth - 2*pageMargin
+	maxImageHeight = 240.0
+)
+
+// Write a PDF cookbook: a cover, a table of contents, then each recipe from
+// a new page with its ingredients and numbered method. Hero images are
+// included when images is set; one that fails to load leaves a gap.
+// Pages are streamed to w as they are finished, so only one batch of
+// recipes is held at a time.
+func Cookbook(w io.Writer, book Book, images ImageLoader) error {
+	if book.Batches == nil {
+		recipes := book.Recipes
+		book.Count = len(recipes)
+		book.Batches = func(add func([]models.Recipe) error) error { return add(recipes) }
+	}
+
+	// The contents come second but are drawn last, once every recipe's
+	// first page is known. Each recipe takes one line, so laying them out
+	// blank tells how many pages to leave.
+	dry := &cookbook{book: book, entries: make([]contentsEntry, book.Count)}
+	dry.contents()
+	dry.finishPage()
+
+	doc := pdf.NewWriter(w)
+	c := &cookbook{book: book, withImages: images != nil, doc: doc, images: images}
+	c.cover()
+	c.finishPage()
+	contentsIDs := make([]int, dry.pages)
+	for i := range contentsIDs {
+		contentsIDs[i] = doc.Reserve()
+	}
+	c.pages += len(contentsIDs)
+
+	err := book.Batches(func(recipes []models.Recipe) error {
+		for _, recipe := range recipes {
+			if len(c.entries) == book.Count {
+				break
+			}
+			c.newPage()
+			c.entries = append(c.entries, contentsEntry{name: recipe.Name, page: c.pages, id: c.page.ID})
+			c.recipe(recipe)
+		}
+		return c.err
+	})
+	c.finishPage()
+	if err != nil {
+		return err
+	}
+
+	// Back after the cover for the contents, filling every page left for
+	// them even if fewer recipes came than counted
+	c.pages, c.reserved, c.inserting = 1, contentsIDs, true
+	c.contents()
+	for len(c.reserved) > 0 {
+		c.newPage()
+	}
+	c.finishPage()
+	if c.err != nil {
+		return c.err
+	}
+	return doc.Close(book.Title)
+}
+
+// Layout of a cookbook, drawing onto doc unless it is nil
+type cookbook struct {
+	book       Book
+	withImages bool
+
+	doc       *pdf.Writer
+	images    ImageLoader
+	reserved  []int // objects for the next pages, reserved before they are drawn
+	inserting bool  // pages go in at their number rather than at the end
+	err       error
+
+	page    *pdf.Page
+	pages   int     // number of the current page
+	y       float64 // top of the next line on the page
+	entries []contentsEntry
+}
+
+// Line of the table of contents
+type contentsEntry struct {
+	name string
+	page int // number of the recipe's first page
+	id   int // object of that page, for the link
+}
+
+func (c *cookbook) cover() {
+	c.newPage()
+	c.y = pdf.PageHeight / 3
+	for _, line := range pdf.Wrap(pdf.Bold, 32, c.book.Title, contentWidth) {
+		c.centred(pdf.Bold, 32, line)
+		c.y += 40
+	}
+	if c.book.Subtitle != "" {
+		c.y += 8
+		c.page.Gray(0.3)
+		for _, line := range pdf.Wrap(pdf.Regular, 14, c.book.Subtitle, contentWidth) {
+			c.centred(pdf.Regular, 14, line)
+			c.y += 20
+		}
+	}
+	c.y += 12
+	c.page.Gray(0.3)
+	c.centred(pdf.Italic, 12, plural(c.book.Count, "recipe"))
+	c.page.Gray(0)
+}
+
+// Table of contents, one line per recipe linking to its first page
+func (c *cookbook) contents() {
+	c.newPage()
+	c.heading(pdf.Bold, 22, "Contents")
+	c.y += 10
+	for _, entry := range c.entries {
+		c.ensure(18)
+		number := strconv.Itoa(entry.page)
+		numberWidth := pdf.TextWidth(pdf.Regular, 11, number)
+		name := clip(pdf.Regular, 11, entry.name, contentWidth-numberWidth-30)
+		nameWidth := pdf.TextWidth(pdf.Regular, 11, name)
+
+		baseline := c.y + 11
+		c.page.Text(pdf.Regular, 11, pageMargin, baseline, name)
+		c.page.Gray(0.6)
+		dots := strings.Repeat(".", int((contentWidth-nameWidth-numberWidth-12)/pdf.TextWidth(pdf.Regular, 11, ".")))
+		c.page.Text(pdf.Regular, 11, pageMargin+nameWidth+6, baseline, dots)
+		c.page.Gray(0)
+		c.page.Text(pdf.Regular, 11, pageMargin+contentWidth-numberWidth, baseline, number)
+		if c.doc != nil {
+			c.page.Link(pageMargin, c.y, contentWidth, 16, entry.id)
+		}
+		c.y += 18
+	}
+}
+
+// Recipe from the top of a new page
+func (c *cookbook) recipe(recipe models.Recipe) {
+	c.heading(pdf.Bold, 22, recipe.Name)
+
+	var meta []string
+	if recipe.Servings != nil && *recipe.Servings > 0 {
+		meta = append(meta, fmt.Sprintf("Serves %d", *recipe.Servings))
+	}
+	if recipe.Difficulty > 0 {
+		meta = append(meta, fmt.Sprintf("Difficulty %d/5", recipe.Difficulty))
+	}
+	if recipe.Timing != nil && recipe.Timing.TotalMinutes > 0 {
+		total := minutes(recipe.Timing.TotalMinutes)
+		if !recipe.Timing.Complete {
+			total += "+"
+		}
+		meta = append(meta, total)
+	}
+	if len(meta) > 0 {
+		c.page.Gray(0.35)
+		c.paragraph(pdf.Italic, 10, 14, 0, strings.Join(meta, " · "))
+		c.page.Gray(0)
+	}
+	c.y += 6
+
+	if recipe.Description != nil && *recipe.Description != "" {
+		c.paragraph(pdf.Regular, 11, 15, 0, *recipe.Description)
+		c.y += 6
+	}
+	if c.withImages && recipe.Image != nil {
+		c.image(*recipe.Image)
+	}
+
+	if len(recipe.Ingredients) > 0 {
+		c.section("Ingredients")
+		for _, ri := range recipe.Ingredients {
+			c.ensure(15)
+			c.page.Text(pdf.Regular, 11, pageMargin+2, c.y+11, "•")
+			c.paragraph(pdf.Regular, 11, 15, 14, IngredientLine(ri))
+		}
+	}
+
+	if len(recipe.Instructions) > 0 {
+		c.section("Method")
+		for i, instruction := range recipe.Instructions {
+			text := instruction.StepText
+			if instruction.Duration != nil && *instruction.Duration > 0 {
+				text += " (" + minutes(*instruction.Duration) + ")"
+			}
+			c.ensure(15)
+			c.page.Text(pdf.Bold, 11, pageMargin, c.y+11, fmt.Sprintf("%d.", i+1))
+			c.paragraph(pdf.Regular, 11, 15, 22, text)
+			if instruction.Notes != nil && *instruction.Notes != "" {
+				c.page.Gray(0.35)
+				c.paragraph(pdf.Italic, 9.5, 13, 22, *instruction.Notes)
+				c.page.Gray(0)
+			}
+			c.y += 4
+		}
+	}
+}
+
+// Hero image scaled to the page width, at most maxImageHeight tall
+func (c *cookbook) image(image models.Image) {
+	if image.Width <= 0 || image.Height <= 0 {
+		return
+	}
+	width := contentWidth
+	height := width * float64(image.Height) / float64(image.Width)
+	if height > maxImageHeight {
+		height = maxImageHeight
+		width = height * float64(image.Width) / float64(image.Height)
+	}
+	c.ensure(height)
+	if c.doc != nil {
+		if data, err := c.images(image); err == nil {
+			if embedded, err := c.doc.AddImage(data); err == nil {
+				c.page.Image(embedded, pageMargin+(contentWidth-width)/2, c.y, width, height)
+			}
+		}
+	}
+	c.y += height + 12
+}
+
+// Section heading kept on the same page as its first line
+func (c *cookbook) section(title string) {
+	c.ensure(22 + 15)
+	c.y += 6
+	c.heading(pdf.Bold, 13, title)
+	c.y += 2
+}
+
+func (c *cookbook) heading(font pdf.Font, size float64, text string) {
+	c.paragraph(font, size, size*1.25, 0, text)
+}
+
+// Wrapped text indented from the margin, moving to a new page as needed
+func (c *cookbook) paragraph(font pdf.Font, size, leading, indent float64, text string) {
+	lines := pdf.Wrap(font, size, text, contentWidth-indent)
+	if len(lines) == 0 {
+		lines = []string{""}
+	}
+	for _, line := range lines {
+		c.ensure(leading)
+		c.page.Text(font, size, pageMargin+indent, c.y+size, line)
+		c.y += leading
+	}
+}
+
+func (c *cookbook) centred(font pdf.Font, size float64, text string) {
+	c.page.Text(font, size, (pdf.PageWidth-pdf.TextWidth(font, size, text))/2, c.y+size, text)
+}
+
+// Start a new page unless height more fits on this one
+func (c *cookbook) ensure(height float64) {
+	if c.y+height > pageBottom {
+		c.newPage()
+	}
+}
+
+func (c *cookbook) newPage() {
+	c.finishPage()
+	c.page = &pdf.Page{}
+	if c.doc != nil {
+		if len(c.reserved) > 0 {
+			c.page.ID, c.reserved = c.reserved[0], c.reserved[1:]
+		} else {
+			c.page.ID = c.doc.Reserve()
+		}
+	}
+	c.pages++
+	c.y = pageMargin
+}
+
+// Number the current page and write it out
+func (c *cookbook) finishPage() {
+	if c.page == nil {
+		return
+	}
+	if c.pages > 1 {
+		number := strconv.Itoa(c.pages)
+		c.page.Gray(0.4)
+		c.page.Text(pdf.Regular, 9, (pdf.PageWidth-pdf.TextWidth(pdf.Regular, 9, number))/2, pdf.PageHeight-pageMargin/2, number)
+	}
+	if c.doc != nil && c.err == nil {
+		if c.inserting {
+			c.err = c.doc.AddPageAt(c.pages-1, c.page)
+		} else {
+			c.err = c.doc.AddPage(c.page)
+		}
+	}
+	c.page = nil
+}
+
+// Text cut to fit width, ending in an ellipsis when shortened
+func clip(font pdf.Font, size float64, text string, width float64) string {
+	if pdf.TextWidth(font, size, text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && pdf.TextWidth(font, size, string(runes)+"…") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimSpace(string(runes)) + "…"
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,178 @@
+package render
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"recipe-api/internal/models"
+)
+
+// Decompressed content streams of a PDF's pages, in page order
+func pageContents(t *testing.T, doc []byte) []string {
+	t.Helper()
+	object := func(id string) []byte {
+		start := bytes.Index(doc, []byte("\n"+id+" 0 obj\n"))
+		if start < 0 {
+			t.Fatalf("object %s missing", id)
+		}
+		return doc[start+len(id)+8:]
+	}
+
+	var pages []string
+	kids := regexp.MustCompile(`/Type /Pages /Kids \[([^\]]*)\]`).FindSubmatch(doc)
+	for _, kid := range regexp.MustCompile(`(\d+) 0 R`).FindAllSubmatch(kids[1], -1) {
+		contents := regexp.MustCompile(`^<< /Type /Page .*?/Contents (\d+) 0 R`).FindSubmatch(object(string(kid[1])))
+		if contents == nil {
+			t.Fatalf("page %s has no contents", kid[1])
+		}
+		stream := regexp.MustCompile(`^<< /Filter /FlateDecode /Length (\d+) >>\nstream\n`)
+		data := object(string(contents[1]))
+		match := stream.FindSubmatchIndex(data)
+		length, _ := strconv.Atoi(string(data[match[2]:match[3]]))
+		r, err := zlib.NewReader(bytes.NewReader(data[match[1] : match[1]+length]))
+		if err != nil {
+			t.Fatalf("bad stream: %v", err)
+		}
+		page, _ := io.ReadAll(r)
+		pages = append(pages, string(page))
+	}
+	return pages
+}
+
+func TestCookbook(t *testing.T) {
+	long := models.Recipe{Name: "Cassoulet", Difficulty: 5}
+	for i := 1; i <= 60; i++ {
+		long.Instructions = append(long.Instructions, models.Instruction{
+			StepNumber: i,
+			StepText:   fmt.Sprintf("Step %d: stir the beans gently, season and leave to simmer while the duck confits slowly.", i),
+		})
+	}
+	pictured := testRecipe()
+	pictured.Image = &models.Image{ImageID: 1, Width: 200, Height: 100}
+	missing := models.Recipe{Name: "Blinis", Image: &models.Image{ImageID: 2, Width: 100, Height: 100}}
+
+	var pictures bytes.Buffer
+	png.Encode(&pictures, image.NewRGBA(image.Rect(0, 0, 200, 100)))
+	var loaded []int
+	loader := func(image models.Image) ([]byte, error) {
+		loaded = append(loaded, image.ImageID)
+		if image.ImageID == 2 {
+			return nil, errors.New("gone")
+		}
+		return pictures.Bytes(), nil
+	}
+
+	var out bytes.Buffer
+	book := Book{Title: "Winter suppers", Subtitle: "Slow food", Recipes: []models.Recipe{long, pictured, missing}}
+	if err := Cookbook(&out, book, loader); err != nil {
+		t.Fatal(err)
+	}
+	doc := out.Bytes()
+	pages := pageContents(t, doc)
+
+	if !bytes.Contains(doc, []byte(fmt.Sprintf("/Count %d >>", len(pages)))) || len(pages) < 6 {
+		t.Fatalf("expected the long recipe to span pages, got %d pages", len(pages))
+	}
+	if !strings.Contains(pages[0], "(Winter suppers) Tj") || !strings.Contains(pages[0], "(3 recipes) Tj") {
+		t.Errorf("unexpected cover:\n%s", pages[0])
+	}
+
+	// Each contents entry names the page its recipe starts on, and links to it
+	contents := pages[1]
+	for _, name := range []string{"Cassoulet", "Tomato <soup>", "Blinis"} {
+		match := regexp.MustCompile(`\(` + regexp.QuoteMeta(name) + `\) Tj.*?\((\d+)\) Tj`).FindStringSubmatch(strings.ReplaceAll(contents, "\n", " "))
+		if match == nil {
+			t.Fatalf("%s missing from contents:\n%s", name, contents)
+		}
+		number, _ := strconv.Atoi(match[1])
+		if number < 3 || number > len(pages) || !strings.Contains(pages[number-1], "/F2 22.00 Tf") || !strings.Contains(pages[number-1], "("+name+") Tj") {
+			t.Errorf("contents lists %s on page %d, which doesn't start it", name, number)
+		}
+	}
+	if links := bytes.Count(doc, []byte("/Subtype /Link")); links != 3 {
+		t.Errorf("expected 3 contents links, got %d", links)
+	}
+
+	// Steps carry on over pages, numbered throughout
+	if !strings.Contains(strings.Join(pages, ""), "(60.) Tj") || !strings.Contains(pages[3], "(4) Tj") {
+		t.Errorf("expected the method to continue onto numbered pages")
+	}
+
+	// The picture is drawn once; the missing one only leaves a gap
+	if fmt.Sprint(loaded) != "[1 2]" || bytes.Count(doc, []byte("/Subtype /Image")) != 1 {
+		t.Errorf("expected one image embedded after loading %v", loaded)
+	}
+	if !strings.Contains(strings.Join(pages, ""), " cm /Im1 Do Q") {
+		t.Errorf("expected the picture drawn")
+	}
+}
+
+func TestCookbookWithoutImages(t *testing.T) {
+	pictured := testRecipe()
+	pictured.Image = &models.Image{ImageID: 1, Width: 200, Height: 100}
+
+	var out bytes.Buffer
+	if err := Cookbook(&out, Book{Title: "One"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := Cookbook(&out, Book{Title: "Two", Recipes: []models.Recipe{pictured}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(out.Bytes(), []byte("/Subtype /Image")) {
+		t.Errorf("expected no images")
+	}
+}
+
+func TestCookbookBatches(t *testing.T) {
+	batches := 0
+	book := Book{Title: "Everything", Count: 70, Batches: func(add func([]models.Recipe) error) error {
+		for start := 1; start <= 70; start += 20 {
+			batches++
+			var recipes []models.Recipe
+			for i := start; i < start+20 && i <= 70; i++ {
+				recipes = append(recipes, models.Recipe{Name: fmt.Sprintf("Recipe %d", i)})
+			}
+			if err := add(recipes); err != nil {
+				return err
+			}
+		}
+		return nil
+	}}
+
+	var out bytes.Buffer
+	if err := Cookbook(&out, book, nil); err != nil {
+		t.Fatal(err)
+	}
+	pages := pageContents(t, out.Bytes())
+	if batches != 4 || len(pages) != 1+2+70 {
+		t.Fatalf("expected a cover, two contents pages and a page per recipe from 4 batches, got %d pages from %d", len(pages), batches)
+	}
+	// The contents run over both pages left for them, in order
+	if !strings.Contains(pages[1], "(Recipe 1) Tj") || !strings.Contains(pages[2], "(Recipe 70) Tj") || !strings.Contains(pages[2], "(73) Tj") {
+		t.Errorf("unexpected contents:\n%s\n%s", pages[1], pages[2])
+	}
+	if !strings.Contains(pages[72], "(Recipe 70) Tj") {
+		t.Errorf("expected the last recipe on the last page")
+	}
+
+	// Fewer recipes than counted still make a whole document
+	out.Reset()
+	short := Book{Title: "Short", Count: 70, Batches: func(add func([]models.Recipe) error) error {
+		return add([]models.Recipe{{Name: "Only"}})
+	}}
+	if err := Cookbook(&out, short, nil); err != nil {
+		t.Fatal(err)
+	}
+	if pages := pageContents(t, out.Bytes()); len(pages) != 4 || !strings.Contains(pages[1], "(4) Tj") {
+		t.Errorf("expected the contents pages kept with the one recipe after them, got %d pages", len(pages))
+	}
+}
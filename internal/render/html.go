@@ -0,0 +1,26 @@
+package render
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+)
+
+//go:embed templates/*.html
+var templateFiles embed.FS
+
+var templates = template.Must(template.New("").Funcs(template.FuncMap{
+	"ingredient": IngredientLine,
+	"minutes":    minutes,
+}).ParseFS(templateFiles, "templates/*.html"))
+
+// Minutes for display, e.g. "1 h 15 min"
+func minutes(total int) string {
+	switch {
+	case total < 60:
+		return fmt.Sprintf("%d min", total)
+	case total%60 == 0:
+		return fmt.Sprintf("%d h", total/60)
+	}
+	return fmt.Sprintf("%d h %d min", total/60, total%60)
+}
@@ -0,0 +1,63 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"recipe-api/internal/models"
+)
+
+// Amount and unit of a recipe ingredient for display, e.g. "2 cup"
+func IngredientQuantity(ri models.RecipeIngredient) string {
+	var parts []string
+	if ri.Amount != nil {
+		parts = append(parts, strconv.FormatFloat(float64(*ri.Amount), 'f', -1, 32))
+	}
+	if ri.Unit != nil && ri.Unit.Label != "" {
+		parts = append(parts, ri.Unit.Label)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Quantity and label of a recipe ingredient, e.g. "2 cup flour"
+func IngredientLine(ri models.RecipeIngredient) string {
+	label := ""
+	if ri.Ingredient != nil {
+		label = ri.Ingredient.Label
+	}
+	return strings.TrimSpace(IngredientQuantity(ri) + " " + label)
+}
+
+// Write a recipe as Markdown under a heading of the given level ("#", "##")
+func RecipeMarkdown(w io.Writer, recipe models.Recipe, heading string) {
+	fmt.Fprintf(w, "%s %s\n", heading, recipe.Name)
+	if recipe.Description != nil && *recipe.Description != "" {
+		fmt.Fprintf(w, "\n%s\n", *recipe.Description)
+	}
+
+	if len(recipe.Ingredients) > 0 {
+		fmt.Fprintf(w, "\n%s# Ingredients\n\n", heading)
+		for _, ri := range recipe.Ingredients {
+			fmt.Fprintf(w, "- %s\n", IngredientLine(ri))
+		}
+	}
+
+	if len(recipe.Instructions) > 0 {
+		fmt.Fprintf(w, "\n%s# Method\n\n", heading)
+		for i, instruction := range recipe.Instructions {
+			fmt.Fprintf(w, "%d. %s\n", i+1, instruction.StepText)
+		}
+	}
+}
+
+// Write recipes as one Markdown document, separated by rules
+func RecipesMarkdown(w io.Writer, recipes []models.Recipe) {
+	for i, recipe := range recipes {
+		if i > 0 {
+			fmt.Fprint(w, "\n---\n\n")
+		}
+		RecipeMarkdown(w, recipe, "#")
+	}
+}
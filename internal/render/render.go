@@ -0,0 +1,149 @@
+// Package render writes recipes as JSON, YAML, Markdown or printable HTML,
+// picked from the request's ?format= or Accept header.
+package render
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"recipe-api/internal/models"
+)
+
+type Format string
+
+const (
+	JSON     Format = "json"
+	YAML     Format = "yaml"
+	Markdown Format = "markdown"
+	HTML     Format = "html"
+)
+
+// Returned by Negotiate when no supported format is acceptable
+var ErrNotAcceptable = errors.New("format must be json, yaml, markdown or html")
+
+// Names accepted by ?format=
+var formatNames = map[string]Format{
+	"json":     JSON,
+	"yaml":     YAML,
+	"yml":      YAML,
+	"markdown": Markdown,
+	"md":       Markdown,
+	"html":     HTML,
+}
+
+// Media types accepted in Accept headers
+var mediaTypes = map[string]Format{
+	"application/json":   JSON,
+	"application/yaml":   YAML,
+	"application/x-yaml": YAML,
+	"text/yaml":          YAML,
+	"text/markdown":      Markdown,
+	"text/x-markdown":    Markdown,
+	"text/html":          HTML,
+	"application/*":      JSON,
+	"text/*":             HTML,
+	"*/*":                JSON,
+}
+
+func (format Format) ContentType() string {
+	switch format {
+	case YAML:
+		return "application/yaml; charset=utf-8"
+	case Markdown:
+		return "text/markdown; charset=utf-8"
+	case HTML:
+		return "text/html; charset=utf-8"
+	}
+	return "application/json"
+}
+
+// Format for a response: ?format= wins, then the Accept entry with the
+// highest quality, then JSON
+func Negotiate(r *http.Request) (Format, error) {
+	if name := r.URL.Query().Get("format"); name != "" {
+		if format, ok := formatNames[strings.ToLower(name)]; ok {
+			return format, nil
+		}
+		return "", ErrNotAcceptable
+	}
+
+	accept := r.Header.Get("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return JSON, nil
+	}
+	best, bestQuality := Format(""), 0.0
+	for _, entry := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(entry))
+		if err != nil {
+			continue
+		}
+		format, ok := mediaTypes[mediaType]
+		if !ok {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		// Earlier entries win ties
+		if quality > bestQuality {
+			best, bestQuality = format, quality
+		}
+	}
+	if best == "" {
+		return "", ErrNotAcceptable
+	}
+	return best, nil
+}
+
+// Write one recipe in the given format
+func Recipe(w http.ResponseWriter, format Format, recipe models.Recipe) error {
+	w.Header().Set("Content-Type", format.ContentType())
+	return write(w, format, recipe, func(w io.Writer) error {
+		switch format {
+		case Markdown:
+			RecipeMarkdown(w, recipe, "#")
+			return nil
+		case HTML:
+			return templates.ExecuteTemplate(w, "recipe.html", recipe)
+		}
+		return fmt.Errorf("unknown format %q", format)
+	})
+}
+
+// Write a list of recipes in the given format
+func Recipes(w http.ResponseWriter, format Format, recipes []models.Recipe) error {
+	if recipes == nil {
+		recipes = []models.Recipe{}
+	}
+	w.Header().Set("Content-Type", format.ContentType())
+	return write(w, format, recipes, func(w io.Writer) error {
+		switch format {
+		case Markdown:
+			RecipesMarkdown(w, recipes)
+			return nil
+		case HTML:
+			return templates.ExecuteTemplate(w, "recipes.html", recipes)
+		}
+		return fmt.Errorf("unknown format %q", format)
+	})
+}
+
+// Write value as JSON or YAML, leaving the text formats to text
+func write(w io.Writer, format Format, value any, text func(io.Writer) error) error {
+	switch format {
+	case JSON:
+		return json.NewEncoder(w).Encode(value)
+	case YAML:
+		return writeYAML(w, value)
+	}
+	return text(w)
+}
@@ -0,0 +1,119 @@
+package render
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"recipe-api/internal/models"
+)
+
+func toPtr[T any](v T) *T {
+	return &v
+}
+
+func testRecipe() models.Recipe {
+	return models.Recipe{
+		RecipeID:    7,
+		Name:        "Tomato <soup>",
+		Difficulty:  2,
+		Description: toPtr("Quick and red"),
+		Servings:    toPtr(4),
+		Ingredients: []models.RecipeIngredient{
+			{Amount: toPtr(float32(1.5)), Unit: &models.Unit{Label: "kg"}, Ingredient: &models.Ingredient{Label: "tomatoes"}},
+			{Ingredient: &models.Ingredient{Label: "salt"}},
+		},
+		Instructions: []models.Instruction{
+			{StepNumber: 1, StepText: "Chop", Duration: toPtr(10)},
+			{StepNumber: 2, StepText: "Simmer", Duration: toPtr(65), Notes: toPtr("stir now and then")},
+		},
+		UserID: "42",
+		Timing: &models.Timing{TotalMinutes: 75, Complete: true},
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		path, accept string
+		expected     Format
+		err          error
+	}{
+		{"/", "", JSON, nil},
+		{"/", "application/yaml", YAML, nil},
+		{"/", "text/markdown;q=0.5, text/html", HTML, nil},
+		{"/", "text/html;q=0.2, text/markdown;q=0.9", Markdown, nil},
+		{"/", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", HTML, nil},
+		{"/", "*/*", JSON, nil},
+		{"/", "image/png", "", ErrNotAcceptable},
+		{"/?format=md", "text/html", Markdown, nil},
+		{"/?format=YML", "", YAML, nil},
+		{"/?format=pdf", "", "", ErrNotAcceptable},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest(http.MethodGet, test.path, nil)
+		r.Header.Set("Accept", test.accept)
+		format, err := Negotiate(r)
+		if format != test.expected || err != test.err {
+			t.Errorf("%s with Accept %q: expected %q, %v, got %q, %v", test.path, test.accept, test.expected, test.err, format, err)
+		}
+	}
+}
+
+func TestRecipeYAML(t *testing.T) {
+	w := httptest.NewRecorder()
+	if err := Recipe(w, YAML, testRecipe()); err != nil {
+		t.Fatal(err)
+	}
+	body := w.Body.String()
+	if w.Header().Get("Content-Type") != "application/yaml; charset=utf-8" {
+		t.Errorf("unexpected content type %s", w.Header().Get("Content-Type"))
+	}
+	// Keys keep their JSON names and order, and strings that look like other types stay quoted
+	for _, expected := range []string{
+		"id: 7\nname: Tomato <soup>\ndifficulty: 2\n",
+		"  - id: 0\n    recipe_id: 0\n    stepNumber: 1\n    stepText: Chop\n    stepTime: 10\n",
+		"userID: \"42\"\n",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected YAML to contain %q, got:\n%s", expected, body)
+		}
+	}
+}
+
+func TestRecipeMarkdown(t *testing.T) {
+	w := httptest.NewRecorder()
+	if err := Recipes(w, Markdown, []models.Recipe{testRecipe(), {Name: "Toast"}}); err != nil {
+		t.Fatal(err)
+	}
+	expected := "# Tomato <soup>\n\nQuick and red\n\n## Ingredients\n\n- 1.5 kg tomatoes\n- salt\n\n" +
+		"## Method\n\n1. Chop\n2. Simmer\n\n---\n\n# Toast\n"
+	if w.Body.String() != expected {
+		t.Errorf("unexpected Markdown:\n%s", w.Body.String())
+	}
+}
+
+func TestRecipeHTML(t *testing.T) {
+	w := httptest.NewRecorder()
+	if err := Recipe(w, HTML, testRecipe()); err != nil {
+		t.Fatal(err)
+	}
+	body := w.Body.String()
+	for _, expected := range []string{
+		"<title>Tomato &lt;soup&gt;</title>",
+		"<span>Serves 4</span><span>Difficulty 2/5</span><span>1 h 15 min</span>",
+		"<li>1.5 kg tomatoes</li>",
+		"<li>Simmer <span class=\"time\">(1 h 5 min)</span><br><span class=\"note\">stir now and then</span></li>",
+		"@media print",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected HTML to contain %q, got:\n%s", expected, body)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	Recipes(w, HTML, nil)
+	if !strings.Contains(w.Body.String(), "<p>No recipes.</p>") {
+		t.Errorf("expected an empty list message, got:\n%s", w.Body.String())
+	}
+}
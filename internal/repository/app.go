@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"recipe-api/internal/models"
 
 	"gorm.io/gorm"
@@ -17,5 +19,46 @@ func NewApp(db *gorm.DB) *App {
 }
 
 func (app *App) AutoMigrate() error {
-	return app.DB.AutoMigrate(&models.Recipe{})
+	err := app.DB.AutoMigrate(
+		&models.Tag{},
+		&models.Recipe{},
+		&models.Ingredient{},
+		&models.Unit{},
+		&models.RecipeIngredient{},
+		&models.Instruction{},
+		&models.MealPlan{},
+		&models.MealPlanEntry{},
+		&models.ShoppingList{},
+		&models.ShoppingListItem{},
+		&models.PantryItem{},
+		&models.Nutrition{},
+		&models.Substitution{},
+		&models.Collection{},
+		&models.Rating{},
+		&models.Favourite{},
+		&models.Image{},
+		&models.CookSession{},
+		&models.CookTimer{},
+		&models.Event{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.WebhookAttempt{},
+		&models.CollectionEntry{},
+		&models.SubstitutionReplacement{},
+	)
+	if err != nil {
+		return err
+	}
+
+	// Ingredients tagged before diet_checked existed are checked
+	err = app.DB.Model(&models.Ingredient{}).
+		Where("diet_checked = ? AND (COALESCE(allergens, '') <> '' OR animal IS NOT NULL)", false).
+		Update("diet_checked", true).Error
+	if err != nil {
+		return err
+	}
+
+	// Recipes saved before updated_at existed count as changed now
+	return app.DB.Model(&models.Recipe{}).Where("updated_at IS NULL").
+		UpdateColumn("updated_at", time.Now()).Error
 }
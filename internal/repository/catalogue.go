@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"recipe-api/internal/diet"
+	"recipe-api/internal/models"
+)
+
+// Trim and collapse the whitespace in an ingredient or unit label
+func CanonicalLabel(label string) string {
+	return strings.Join(strings.Fields(label), " ")
+}
+
+// Find the oldest catalogue row whose label matches ignoring case and spacing
+func FindByLabel(tx *gorm.DB, dest any, label string) (bool, error) {
+	result := tx.Where("LOWER(label) = ?", strings.ToLower(label)).Limit(1).Find(dest)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Find an ingredient by label, creating it with the given details when missing
+func FirstOrCreateIngredient(tx *gorm.DB, data *models.Ingredient) (models.Ingredient, error) {
+	label := CanonicalLabel(data.Label)
+	if label == "" {
+		return models.Ingredient{}, errors.New("ingredient label is required")
+	}
+
+	var ingredient models.Ingredient
+	found, err := FindByLabel(tx.Order("ingredient_id ASC"), &ingredient, label)
+	if err != nil || found {
+		return ingredient, err
+	}
+
+	// Details are only used when the ingredient is created
+	ingredient = models.Ingredient{Label: label, Category: data.Category, Allergens: data.Allergens, Animal: data.Animal, DietChecked: data.DietChecked}
+	if err := diet.CheckIngredient(&ingredient); err != nil {
+		return models.Ingredient{}, err
+	}
+	return ingredient, tx.Create(&ingredient).Error
+}
+
+// Find a unit by label, creating it when missing
+func FirstOrCreateUnit(tx *gorm.DB, data *models.Unit) (models.Unit, error) {
+	label := CanonicalLabel(data.Label)
+	if label == "" {
+		return models.Unit{}, errors.New("unit label is required")
+	}
+
+	var unit models.Unit
+	found, err := FindByLabel(tx.Order("unit_id ASC"), &unit, label)
+	if err != nil || found {
+		return unit, err
+	}
+
+	unit = models.Unit{Label: label}
+	return unit, tx.Create(&unit).Error
+}
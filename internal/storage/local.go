@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// Store on the local filesystem under a root directory
+type Local struct {
+	Root string
+}
+
+// Constructor, creating the root directory when missing
+func NewLocal(root string) (*Local, error) {
+	if root == "" {
+		return nil, errors.New("local storage needs a path")
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	return &Local{Root: root}, nil
+}
+
+func (store *Local) path(key string) (string, error) {
+	if err := checkKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(store.Root, filepath.FromSlash(key)), nil
+}
+
+// Write atomically through a temporary file so readers never see half an object
+func (store *Local) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	name, err := store.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+// Content type is inferred from the key's extension
+func (store *Local) Get(ctx context.Context, key string) ([]byte, Info, error) {
+	name, err := store.path(key)
+	if err != nil {
+		return nil, Info{}, err
+	}
+	stat, err := os.Stat(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, Info{}, ErrNotFound
+	}
+	if err != nil {
+		return nil, Info{}, err
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, Info{}, err
+	}
+	return data, Info{
+		Size:        stat.Size(),
+		ContentType: mime.TypeByExtension(path.Ext(key)),
+		ModTime:     stat.ModTime(),
+	}, nil
+}
+
+func (store *Local) Delete(ctx context.Context, key string) error {
+	name, err := store.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	// Tidy up directories left empty, stopping at the first one still in use
+	for dir := filepath.Dir(name); dir != filepath.Clean(store.Root); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// Connection details for an S3-compatible object store (AWS, MinIO, R2, ...)
+type S3Options struct {
+	Endpoint  string // e.g. https://s3.eu-west-2.amazonaws.com or http://localhost:9000
+	Bucket    string
+	Region    string
+	AccessKey string
+	SecretKey string
+	Transport http.RoundTripper // defaults to minio-go's
+}
+
+// Store in an S3 bucket through minio-go, using path-style requests
+type S3 struct {
+	client *minio.Client
+	bucket string
+}
+
+// Constructor
+func NewS3(opts S3Options) (*S3, error) {
+	if opts.Endpoint == "" || opts.Bucket == "" {
+		return nil, errors.New("s3 storage needs an endpoint and a bucket")
+	}
+	endpoint, err := url.Parse(opts.Endpoint)
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") ||
+		(endpoint.Path != "" && endpoint.Path != "/") {
+		return nil, fmt.Errorf("invalid s3 endpoint %q, want http(s)://host[:port]", opts.Endpoint)
+	}
+	if opts.Region == "" {
+		opts.Region = "us-east-1"
+	}
+	client, err := minio.New(endpoint.Host, &minio.Options{
+		Creds:        credentials.NewStaticV4(opts.AccessKey, opts.SecretKey, ""),
+		Secure:       endpoint.Scheme == "https",
+		Region:       opts.Region,
+		BucketLookup: minio.BucketLookupPath,
+		Transport:    opts.Transport,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &S3{client: client, bucket: opts.Bucket}, nil
+}
+
+func (store *S3) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	_, err := store.client.PutObject(ctx, store.bucket, key, bytes.NewReader(data), int64(len(data)),
+		minio.PutObjectOptions{ContentType: contentType})
+	return err
+}
+
+func (store *S3) Get(ctx context.Context, key string) ([]byte, Info, error) {
+	if err := checkKey(key); err != nil {
+		return nil, Info{}, err
+	}
+	object, err := store.client.GetObject(ctx, store.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, Info{}, notFound(err)
+	}
+	defer object.Close()
+
+	// The request goes out on the first read or Stat
+	stat, err := object.Stat()
+	if err != nil {
+		return nil, Info{}, notFound(err)
+	}
+	data, err := io.ReadAll(object)
+	if err != nil {
+		return nil, Info{}, notFound(err)
+	}
+	return data, Info{Size: int64(len(data)), ContentType: stat.ContentType, ModTime: stat.LastModified}, nil
+}
+
+func (store *S3) Delete(ctx context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	err := store.client.RemoveObject(ctx, store.bucket, key, minio.RemoveObjectOptions{})
+	if errors.Is(notFound(err), ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// ErrNotFound for a missing key, other errors as they are
+func notFound(err error) error {
+	if err != nil && minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return ErrNotFound
+	}
+	return err
+}
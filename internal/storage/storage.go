@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// Returned when a key has no object
+var ErrNotFound = errors.New("object not found")
+
+// Metadata of a stored object
+type Info struct {
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// Blob store for uploaded media. Keys are slash separated paths such as
+// "recipes/4/abc/small.jpg".
+type Store interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, Info, error)
+	Delete(ctx context.Context, key string) error // deleting a missing key is not an error
+}
+
+// Options for New, mirroring the STORAGE_* and S3_* settings
+type Options struct {
+	Backend string // local or s3
+	Path    string // root directory for local storage
+
+	S3Endpoint  string
+	S3Bucket    string
+	S3Region    string
+	S3AccessKey string
+	S3SecretKey string
+}
+
+// Open the configured backend
+func New(opts Options) (Store, error) {
+	switch opts.Backend {
+	case "", "local":
+		return NewLocal(opts.Path)
+	case "s3":
+		return NewS3(S3Options{
+			Endpoint:  opts.S3Endpoint,
+			Bucket:    opts.S3Bucket,
+			Region:    opts.S3Region,
+			AccessKey: opts.S3AccessKey,
+			SecretKey: opts.S3SecretKey,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", opts.Backend)
+	}
+}
+
+// Reject keys that could escape the store's root
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || strings.HasPrefix(key, "../") || key == ".." {
+		return fmt.Errorf("invalid storage key %q", key)
+	}
+	return nil
+}
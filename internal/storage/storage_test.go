@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+)
+
+// Exercise a store's put, get and delete round trip
+func checkStore(t *testing.T, store Store) {
+	t.Helper()
+	ctx := context.Background()
+
+	if err := store.Put(ctx, "recipes/1/abc/small.png", []byte("png bytes"), "image/png"); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	data, info, err := store.Get(ctx, "recipes/1/abc/small.png")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if string(data) != "png bytes" || info.Size != 9 || info.ContentType != "image/png" {
+		t.Fatalf("unexpected object %q %+v", data, info)
+	}
+
+	if err := store.Delete(ctx, "recipes/1/abc/small.png"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, _, err := store.Get(ctx, "recipes/1/abc/small.png"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound after delete, got %v", err)
+	}
+	if err := store.Delete(ctx, "recipes/1/abc/small.png"); err != nil {
+		t.Fatalf("deleting a missing key should succeed, got %v", err)
+	}
+
+	for _, key := range []string{"../escape", "/absolute", "a/../../b", ""} {
+		if err := store.Put(ctx, key, nil, ""); err == nil {
+			t.Fatalf("expected key %q to be rejected", key)
+		}
+	}
+}
+
+func TestLocal(t *testing.T) {
+	store, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStore(t, store)
+}
+
+func TestS3(t *testing.T) {
+	backend := s3mem.New()
+	if err := backend.CreateBucket("media"); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(gofakes3.New(backend).Server())
+	defer server.Close()
+
+	store, err := NewS3(S3Options{Endpoint: server.URL, Bucket: "media", AccessKey: "key", SecretKey: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStore(t, store)
+
+	bad, _ := NewS3(S3Options{Endpoint: server.URL, Bucket: "other", AccessKey: "key", SecretKey: "secret"})
+	if err := bad.Put(context.Background(), "a.png", []byte("x"), "image/png"); err == nil {
+		t.Fatal("expected an error from a missing bucket")
+	}
+
+	for _, endpoint := range []string{"", "localhost:9000", "ftp://localhost", "http://localhost:9000/prefix"} {
+		if _, err := NewS3(S3Options{Endpoint: endpoint, Bucket: "media"}); err == nil {
+			t.Errorf("expected endpoint %q to be rejected", endpoint)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package timing
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"recipe-api/internal/models"
+)
+
+// Phases a step can be classified as. Steps without one count as cooking.
+const (
+	Prep = "prep"
+	Cook = "cook"
+	Rest = "rest" // hands-off time such as proving, marinating or chilling
+)
+
+var Phases = []string{Prep, Cook, Rest}
+
+// Normalise and check step phases and durations
+func CheckInstructions(instructions []models.Instruction) error {
+	for i := range instructions {
+		step := &instructions[i]
+		if step.Duration != nil && *step.Duration < 0 {
+			return fmt.Errorf("step %d: stepTime must not be negative", step.StepNumber)
+		}
+		if step.Phase == nil {
+			continue
+		}
+		phase := strings.ToLower(strings.TrimSpace(*step.Phase))
+		if phase == "" {
+			step.Phase = nil
+			continue
+		}
+		if !slices.Contains(Phases, phase) {
+			return fmt.Errorf("step %d: phase must be one of %s", step.StepNumber, strings.Join(Phases, ", "))
+		}
+		step.Phase = &phase
+	}
+	return nil
+}
+
+// Phase of a step, defaulting to cooking
+func Phase(step models.Instruction) string {
+	if step.Phase == nil {
+		return Cook
+	}
+	return *step.Phase
+}
+
+// Format minutes as an ISO 8601 duration, e.g. 90 as PT1H30M
+func ISO(minutes int) string {
+	hours, minutes := minutes/60, minutes%60
+	switch {
+	case hours == 0:
+		return fmt.Sprintf("PT%dM", minutes)
+	case minutes == 0:
+		return fmt.Sprintf("PT%dH", hours)
+	}
+	return fmt.Sprintf("PT%dH%dM", hours, minutes)
+}
+
+// Sum a recipe's step durations by phase. Returns nil when no step has a duration.
+func Compute(instructions []models.Instruction) *models.Timing {
+	timing := &models.Timing{Complete: true}
+	timed := false
+	for _, step := range instructions {
+		if step.Duration == nil {
+			timing.Complete = false
+			continue
+		}
+		timed = true
+		switch Phase(step) {
+		case Prep:
+			timing.PrepMinutes += *step.Duration
+		case Rest:
+			timing.RestMinutes += *step.Duration
+		default:
+			timing.CookMinutes += *step.Duration
+		}
+	}
+	if !timed {
+		return nil
+	}
+
+	timing.ActiveMinutes = timing.PrepMinutes + timing.CookMinutes
+	timing.TotalMinutes = timing.ActiveMinutes + timing.RestMinutes
+	timing.PrepTime = ISO(timing.PrepMinutes)
+	timing.CookTime = ISO(timing.CookMinutes)
+	timing.RestTime = ISO(timing.RestMinutes)
+	timing.ActiveTime = ISO(timing.ActiveMinutes)
+	timing.TotalTime = ISO(timing.TotalMinutes)
+	return timing
+}
@@ -0,0 +1,62 @@
+package timing
+
+import (
+	"testing"
+
+	"recipe-api/internal/models"
+)
+
+func step(number, minutes int, phase string) models.Instruction {
+	instruction := models.Instruction{StepNumber: number, Duration: &minutes}
+	if phase != "" {
+		instruction.Phase = &phase
+	}
+	return instruction
+}
+
+func TestISO(t *testing.T) {
+	for minutes, want := range map[int]string{0: "PT0M", 45: "PT45M", 60: "PT1H", 90: "PT1H30M", 1505: "PT25H5M"} {
+		if got := ISO(minutes); got != want {
+			t.Errorf("ISO(%d) = %s, want %s", minutes, got, want)
+		}
+	}
+}
+
+func TestCompute(t *testing.T) {
+	timing := Compute([]models.Instruction{
+		step(1, 15, "prep"),
+		step(2, 60, "rest"),
+		step(3, 25, ""),
+		{StepNumber: 4},
+	})
+	if timing.PrepMinutes != 15 || timing.CookMinutes != 25 || timing.RestMinutes != 60 {
+		t.Fatalf("unexpected phases %+v", timing)
+	}
+	if timing.ActiveMinutes != 40 || timing.TotalMinutes != 100 || timing.TotalTime != "PT1H40M" {
+		t.Fatalf("unexpected totals %+v", timing)
+	}
+	if timing.Complete {
+		t.Fatal("expected a step without a duration to make the timing incomplete")
+	}
+
+	if Compute([]models.Instruction{{StepNumber: 1}}) != nil {
+		t.Fatal("expected no timing without any durations")
+	}
+}
+
+func TestCheckInstructions(t *testing.T) {
+	steps := []models.Instruction{step(1, 5, " Prep "), step(2, 5, "")}
+	if err := CheckInstructions(steps); err != nil {
+		t.Fatal(err)
+	}
+	if *steps[0].Phase != "prep" || steps[1].Phase != nil {
+		t.Fatalf("expected phases to be normalised, got %v %v", *steps[0].Phase, steps[1].Phase)
+	}
+
+	if err := CheckInstructions([]models.Instruction{step(1, 5, "bake")}); err == nil {
+		t.Fatal("expected an unknown phase to be rejected")
+	}
+	if err := CheckInstructions([]models.Instruction{step(1, -5, "")}); err == nil {
+		t.Fatal("expected a negative duration to be rejected")
+	}
+}